@@ -8,12 +8,17 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	// the timezones of the schedules don't depend on the zoneinfo of the host
+	_ "time/tzdata"
 
 	"github.com/go-co-op/gocron"
 	"github.com/pthum/stripcontrol-golang/internal/api"
 	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/database"
 	"github.com/pthum/stripcontrol-golang/internal/database/csv"
+	"github.com/pthum/stripcontrol-golang/internal/database/sqlite"
 	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/messaging/command"
 	messagingimpl "github.com/pthum/stripcontrol-golang/internal/messaging/impl"
 	"github.com/pthum/stripcontrol-golang/internal/model"
 	"github.com/pthum/stripcontrol-golang/internal/service"
@@ -40,20 +45,39 @@ func main() {
 	var enableDebug = cfg.Server.Mode != "release"
 
 	inj := do.New()
-	defer inj.Shutdown()
 	do.ProvideValue(inj, cfg)
 	do.Provide(inj, newScheduler)
-	do.Provide(inj, csv.NewHandlerI[model.ColorProfile])
-	do.Provide(inj, csv.NewHandlerI[model.LedStrip])
+	do.Provide(inj, newDBHandler[model.ColorProfile])
+	do.Provide(inj, newDBHandler[model.LedStrip])
+	do.Provide(inj, newDBHandler[model.OutboxEntry])
+	do.Provide(inj, newDBHandler[model.Scene])
+	do.Provide(inj, newDBHandler[model.Schedule])
+	do.Provide(inj, newDBHandler[model.Timer])
+	do.Provide(inj, newDBHandler[model.Group])
+	do.Provide(inj, messagingimpl.NewStream)
+	do.Provide(inj, messagingimpl.NewEmbeddedBroker)
 	do.Provide(inj, messagingimpl.New)
 	do.Provide(inj, service.NewCPService)
 	do.Provide(inj, service.NewLEDService)
+	do.Provide(inj, service.NewSceneService)
+	do.Provide(inj, service.NewGroupService)
+	do.Provide(inj, service.NewScheduleService)
 	do.Provide(inj, api.NewCPHandler)
 	do.Provide(inj, api.NewLEDHandler)
+	do.Provide(inj, api.NewStatusHandler)
+	do.Provide(inj, api.NewEventsHandler)
+	do.Provide(inj, api.NewLiveHandler)
+	do.Provide(inj, api.NewSceneHandler)
+	do.Provide(inj, api.NewScheduleHandler)
+	do.Provide(inj, api.NewGroupHandler)
 
 	tgH := telegram.NewHandler(inj, cfg.Telegram)
 	go tgH.Handle()
 
+	if err := command.NewHandler(inj).Subscribe(); err != nil {
+		l.Error("error subscribing to the strip commands: %s", err)
+	}
+
 	router := api.NewRouter(inj, enableDebug)
 
 	// Listen and serve on 0.0.0.0:8080
@@ -85,14 +109,43 @@ func main() {
 	} else {
 		l.Info("Server gracefully stopped")
 	}
+	shutdown(inj, l)
 }
 
 func newScheduler(inj *do.Injector) (*gocron.Scheduler, error) {
 	return gocron.NewScheduler(time.UTC), nil
 }
 
+// newDBHandler creates the database handler for the configured database type, csv is used if no type is given
+func newDBHandler[T any](inj *do.Injector) (database.DBHandler[T], error) {
+	cfg := do.MustInvoke[*config.Config](inj)
+	switch cfg.Database.Type {
+	case config.DBTypeSQLite:
+		return sqlite.NewHandlerI[T](inj)
+	case config.DBTypeCSV, "":
+		return csv.NewHandlerI[T](inj)
+	default:
+		return nil, fmt.Errorf("unsupported database type %q", cfg.Database.Type)
+	}
+}
+
+// shutdown stops the scheduler, so no job runs concurrently, and shuts down all services afterwards,
+// which includes flushing the data of the database handlers
+func shutdown(inj *do.Injector, l alog.Logger) {
+	s := do.MustInvoke[*gocron.Scheduler](inj)
+	s.Stop()
+	l.Info("Scheduler stopped")
+	if err := inj.Shutdown(); err != nil {
+		l.Error("error shutting down services: %s", err)
+		return
+	}
+	l.Info("Services gracefully stopped")
+}
+
 func scheduleJobs(inj *do.Injector) {
 	s := do.MustInvoke[*gocron.Scheduler](inj)
+	// register the jobs of the stored schedules
+	do.MustInvoke[service.ScheduleService](inj)
 	// start scheduler
 	s.StartAsync()
 }
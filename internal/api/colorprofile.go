@@ -20,6 +20,7 @@ type CPHandler interface {
 	GetColorProfile(w http.ResponseWriter, r *http.Request)
 	CreateColorProfile(w http.ResponseWriter, r *http.Request)
 	UpdateColorProfile(w http.ResponseWriter, r *http.Request)
+	PatchColorProfile(w http.ResponseWriter, r *http.Request)
 	DeleteColorProfile(w http.ResponseWriter, r *http.Request)
 }
 type cpHandlerImpl struct {
@@ -42,6 +43,7 @@ func (h *cpHandlerImpl) colorProfileRoutes() []Route {
 		{http.MethodPost, profilePath, h.CreateColorProfile},
 		{http.MethodGet, profileIDPath, h.GetColorProfile},
 		{http.MethodPut, profileIDPath, h.UpdateColorProfile},
+		{http.MethodPatch, profileIDPath, h.PatchColorProfile},
 		{http.MethodDelete, profileIDPath, h.DeleteColorProfile},
 	}
 }
@@ -66,6 +68,7 @@ func (h *cpHandlerImpl) GetColorProfile(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	setETag(w, profile)
 	handleJSON(&w, http.StatusOK, profile)
 }
 
@@ -78,9 +81,9 @@ func (h *cpHandlerImpl) CreateColorProfile(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err := h.cps.CreateColorProfile(&input); err != nil {
+	if err := h.cps.WithActor(restActor(r)).CreateColorProfile(&input); err != nil {
 		h.l.Error("Error: %s", err)
-		handleError(&w, http.StatusBadRequest, err.Error())
+		handleErrWithStatus(&w, err, http.StatusBadRequest)
 		return
 	}
 	respondWithCreated(r, w, &input)
@@ -95,17 +98,54 @@ func (h *cpHandlerImpl) UpdateColorProfile(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err := h.cps.UpdateColorProfile(getParam(r, "id"), input); err != nil {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+	updMdl := input
+	updMdl.Version = version
+	profile, err := h.cps.WithActor(restActor(r)).UpdateColorProfile(getParam(r, "id"), updMdl)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+
+	setETag(w, profile)
+	handleJSON(&w, http.StatusOK, profile)
+}
+
+// PatchColorProfile partially update a color profile with a json merge patch
+func (h *cpHandlerImpl) PatchColorProfile(w http.ResponseWriter, r *http.Request) {
+	patch, err := readMergePatch(r)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+
+	profile, err := h.cps.WithActor(restActor(r)).PatchColorProfile(getParam(r, "id"), version, patch)
+	if err != nil {
 		handleErr(&w, err)
 		return
 	}
 
-	handleJSON(&w, http.StatusOK, input)
+	setETag(w, profile)
+	handleJSON(&w, http.StatusOK, profile)
 }
 
 // DeleteColorProfile delete a color profile
 func (h *cpHandlerImpl) DeleteColorProfile(w http.ResponseWriter, r *http.Request) {
-	if err := h.cps.DeleteColorProfile(getParam(r, "id")); err != nil {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+	if err := h.cps.WithActor(restActor(r)).DeleteColorProfile(getParam(r, "id"), version); err != nil {
 		handleErr(&w, err)
 		return
 	}
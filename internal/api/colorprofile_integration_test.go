@@ -1,8 +1,10 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -92,6 +94,7 @@ func TestCreateColorProfileIT(t *testing.T) {
 	mocks := createCPHandlerITMocks(t)
 	inBody := createDummyProfile()
 	var newId int64
+	mocks.expectDBProfileNextID(500)
 	mocks.cpDbh.
 		EXPECT().
 		Create(mock.Anything).
@@ -102,11 +105,17 @@ func TestCreateColorProfileIT(t *testing.T) {
 		}).
 		Return(nil).
 		Once()
+	published := *inBody
+	published.ID = 500
+	mocks.expectPublishProfileEvent(t, model.Save, 500, &published)
 	body := objToReader(t, inBody)
 	req, w := prepareHttpTest(http.MethodPost, profilePath, nil, body)
 
 	mocks.cph.CreateColorProfile(w, req)
 
+	// small sleep to have the async routines run
+	time.Sleep(50 * time.Millisecond)
+
 	res := w.Result()
 	defer res.Body.Close()
 
@@ -121,9 +130,27 @@ func TestCreateColorProfileIT(t *testing.T) {
 	assert.Equal(t, http.StatusCreated, res.StatusCode)
 }
 
+func TestCreateColorProfileIT_Invalid(t *testing.T) {
+	mocks := createCPHandlerITMocks(t)
+	inBody := createProfile(0, -1, 0, 0, 1)
+	body := objToReader(t, inBody)
+	req, w := prepareHttpTest(http.MethodPost, profilePath, nil, body)
+
+	mocks.cph.CreateColorProfile(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	var result model.ValidationError
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	assert.Equal(t, []model.FieldError{{Field: "red", Message: "must be between 0 and 255"}}, result.Errors)
+}
+
 func TestCreateColorProfileIT_SaveError(t *testing.T) {
 	mocks := createCPHandlerITMocks(t)
 	inBody := createDummyProfile()
+	mocks.expectDBProfileNextID(500)
 	mocks.cpDbh.
 		EXPECT().
 		Create(mock.Anything).
@@ -147,6 +174,7 @@ func TestDeleteColorProfileIT(t *testing.T) {
 	mocks := createCPHandlerITMocks(t)
 	getObj := createDummyProfile()
 	mocks.expectDBProfileGet(getObj, nil)
+	mocks.expectDBStripGetAll([]model.LedStrip{}, nil)
 
 	mocks.cpDbh.
 		EXPECT().
@@ -184,10 +212,31 @@ func TestDeleteColorProfileIT_MissingDBProfile(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, res.StatusCode)
 }
 
+func TestDeleteColorProfileIT_Referenced(t *testing.T) {
+	mocks := createCPHandlerITMocks(t)
+	getObj := createDummyProfile()
+	mocks.expectDBProfileGet(getObj, nil)
+	mocks.expectDBStripGetAll([]model.LedStrip{{BaseModel: model.BaseModel{ID: 12}, ProfileID: null.IntFrom(getObj.ID)}}, nil)
+
+	idS := idStringOrDefault(getObj, "9000")
+	req, w := prepareHttpTest(http.MethodDelete, profileIDPath, uv{"id": idS}, nil)
+
+	mocks.cph.DeleteColorProfile(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	var result H
+	bodyToObj(t, res, &result)
+	assert.Contains(t, result["error"], "12")
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+}
+
 func TestDeleteColorProfileIT_DeleteError(t *testing.T) {
 	mocks := createCPHandlerITMocks(t)
 	getObj := createDummyProfile()
 	mocks.expectDBProfileGet(getObj, nil)
+	mocks.expectDBStripGetAll([]model.LedStrip{}, nil)
 
 	mocks.cpDbh.
 		EXPECT().
@@ -210,13 +259,18 @@ func TestUpdateColorProfileIT(t *testing.T) {
 	inBody := createDummyProfile()
 	body := objToReader(t, inBody)
 	dbO := *createProfile(105, 100, 100, 100, 2)
+	// the id of the stored profile is kept
+	inBody.ID = dbO.ID
 	mocks.expectDBProfileGet(&dbO, nil)
 
 	mocks.cpDbh.
 		EXPECT().
 		Update(dbO, *inBody).
 		Return(nil)
-	mocks.expectPublishProfileEvent(t, model.Save, inBody.ID, inBody)
+	// the event contains the version after the update
+	published := *inBody
+	published.Version = dbO.Version + 1
+	mocks.expectPublishProfileEvent(t, model.Save, inBody.ID, &published)
 
 	idS := idStr(dbO.ID)
 	req, w := prepareHttpTest(http.MethodPut, profileIDPath, uv{"id": idS}, body)
@@ -228,8 +282,39 @@ func TestUpdateColorProfileIT(t *testing.T) {
 
 	res := w.Result()
 	defer res.Body.Close()
+	var result model.ColorProfile
+	bodyToObj(t, res, &result)
 
 	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `"1"`, res.Header.Get("ETag"))
+	assert.Equal(t, published, result)
+}
+
+func TestUpdateColorProfileIT_Broker(t *testing.T) {
+	mocks, broker := createCPHandlerBrokerITMocks(t)
+	inBody := createDummyProfile()
+	dbO := *createProfile(105, 100, 100, 100, 2)
+	inBody.ID = dbO.ID
+	mocks.expectDBProfileGet(&dbO, nil)
+	mocks.cpDbh.
+		EXPECT().
+		Update(dbO, *inBody).
+		Return(nil)
+	req, w := prepareHttpTest(http.MethodPut, profileIDPath, uv{"id": idStr(dbO.ID)}, objToReader(t, inBody))
+
+	mocks.cph.UpdateColorProfile(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	// the event contains the version after the update
+	published := *inBody
+	published.Version = dbO.Version + 1
+	var event model.ProfileEvent
+	assert.NoError(t, json.Unmarshal(broker.next(t, "profile"), &event))
+	assert.Equal(t, model.Save, event.Type)
+	assert.Equal(t, inBody.ID, event.ID.Int64)
+	assert.Equal(t, published, event.State.Profile)
 }
 
 func TestUpdateColorProfileIT_MissingDBProfile(t *testing.T) {
@@ -255,6 +340,7 @@ func TestUpdateColorProfileIT_UpdateError(t *testing.T) {
 	inBody := createDummyProfile()
 	body := objToReader(t, inBody)
 	dbO := *createProfile(105, 100, 100, 100, 2)
+	inBody.ID = dbO.ID
 	mocks.expectDBProfileGet(&dbO, nil)
 
 	mocks.cpDbh.
@@ -303,6 +389,55 @@ func createDummyProfile() *model.ColorProfile {
 	return createProfile(185, 123, 234, 12, 1)
 }
 
+func TestPatchColorProfileIT(t *testing.T) {
+	mocks := createCPHandlerITMocks(t)
+	dbO := createProfile(105, 100, 100, 100, 2)
+	expected := *dbO
+	expected.Brightness = null.IntFrom(10)
+	mocks.expectDBProfileGet(dbO, nil)
+	mocks.cpDbh.
+		EXPECT().
+		Update(*dbO, expected).
+		Return(nil)
+	published := expected
+	published.Version = dbO.Version + 1
+	mocks.expectPublishProfileEvent(t, model.Save, dbO.ID, &published)
+	body := strings.NewReader(`{"brightness":10}`)
+	req, w := prepareHttpTest(http.MethodPatch, profileIDPath, uv{"id": idStr(dbO.ID)}, body)
+	req.Header.Set("Content-Type", model.MergePatchContentType)
+
+	mocks.cph.PatchColorProfile(w, req)
+
+	// small sleep to have the async routines run
+	time.Sleep(50 * time.Millisecond)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	var result model.ColorProfile
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, published, result)
+	assert.Equal(t, `"1"`, res.Header.Get("ETag"))
+}
+
+func TestPatchColorProfileIT_Invalid(t *testing.T) {
+	mocks := createCPHandlerITMocks(t)
+	mocks.expectDBProfileGet(createProfile(105, 100, 100, 100, 2), nil)
+	body := strings.NewReader(`{"green":1000}`)
+	req, w := prepareHttpTest(http.MethodPatch, profileIDPath, uv{"id": "105"}, body)
+
+	mocks.cph.PatchColorProfile(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	var result model.ValidationError
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	assert.Equal(t, "green", result.Errors[0].Field)
+}
+
 func createProfile(id, red, green, blue, brightness int64) *model.ColorProfile {
 	return &model.ColorProfile{
 		BaseModel:  model.BaseModel{ID: id},
@@ -313,6 +448,18 @@ func createProfile(id, red, green, blue, brightness int64) *model.ColorProfile {
 	}
 }
 
+// createCPHandlerBrokerITMocks creates the handler publishing the events to an embedded broker
+func createCPHandlerBrokerITMocks(t *testing.T) (*cphITMocks, *brokerIT) {
+	i := do.New()
+	bm, broker := createBrokerBaseMocks(i, t)
+	cph, err := NewCPHandler(i)
+	assert.NoError(t, err)
+	return &cphITMocks{
+		baseMocks: bm,
+		cph:       cph.(*cpHandlerImpl),
+	}, broker
+}
+
 func createCPHandlerITMocks(t *testing.T) *cphITMocks {
 	i := do.New()
 	bm := createBaseMocks(i, t)
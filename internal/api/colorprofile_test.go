@@ -22,7 +22,7 @@ type cphMocks struct {
 func TestCPRoutes(t *testing.T) {
 	mcks := createCPHandlerMocks(t)
 	routes := mcks.cph.colorProfileRoutes()
-	assert.Equal(t, 5, len(routes))
+	assert.Equal(t, 6, len(routes))
 }
 
 func TestGetAllColorProfiles(t *testing.T) {
@@ -161,7 +161,7 @@ func TestDeleteColorProfile(t *testing.T) {
 	getObj := createDummyProfile()
 	mocks.cps.
 		EXPECT().
-		DeleteColorProfile(mock.Anything).
+		DeleteColorProfile(mock.Anything, int64(0)).
 		Return(nil)
 	idS := idStringOrDefault(getObj, "9000")
 	req, w := prepareHttpTest(http.MethodDelete, profileIDPath, uv{"id": idS}, nil)
@@ -179,7 +179,7 @@ func TestDeleteColorProfile_Error(t *testing.T) {
 	getObj := createDummyProfile()
 	mocks.cps.
 		EXPECT().
-		DeleteColorProfile(mock.Anything).
+		DeleteColorProfile(mock.Anything, int64(0)).
 		Return(model.NewAppErr(400, errors.New("delete error")))
 	idS := idStringOrDefault(getObj, "9000")
 	req, w := prepareHttpTest(http.MethodDelete, profileIDPath, uv{"id": idS}, nil)
@@ -197,11 +197,14 @@ func TestUpdateColorProfile(t *testing.T) {
 	inBody := createDummyProfile()
 	body := objToReader(t, inBody)
 	dbO := *createProfile(105, 100, 100, 100, 2)
+	stored := *inBody
+	stored.ID = dbO.ID
+	stored.Version = 4
 
 	mocks.cps.
 		EXPECT().
 		UpdateColorProfile(mock.Anything, mock.Anything).
-		Return(nil)
+		Return(&stored, nil)
 
 	idS := idStr(dbO.ID)
 	req, w := prepareHttpTest(http.MethodPut, profileIDPath, uv{"id": idS}, body)
@@ -209,7 +212,12 @@ func TestUpdateColorProfile(t *testing.T) {
 	mocks.cph.UpdateColorProfile(w, req)
 
 	res := w.Result()
+	defer res.Body.Close()
+	var result model.ColorProfile
+	bodyToObj(t, res, &result)
 	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `"4"`, res.Header.Get("ETag"))
+	assert.Equal(t, stored, result)
 }
 
 func TestUpdateColorProfile_MissingBody(t *testing.T) {
@@ -236,7 +244,7 @@ func TestUpdateColorProfile_Error(t *testing.T) {
 	mocks.cps.
 		EXPECT().
 		UpdateColorProfile(mock.Anything, mock.Anything).
-		Return(model.NewAppErr(400, errors.New("update failed")))
+		Return(nil, model.NewAppErr(400, errors.New("update failed")))
 
 	idS := idStr(dbO.ID)
 	req, w := prepareHttpTest(http.MethodPut, profileIDPath, uv{"id": idS}, body)
@@ -252,6 +260,7 @@ func TestUpdateColorProfile_Error(t *testing.T) {
 func createCPHandlerMocks(t *testing.T) *cphMocks {
 	i := do.New()
 	cps := servicemocks.NewCPService(t)
+	cps.EXPECT().WithActor("rest:192.0.2.1").Return(cps).Maybe()
 	do.ProvideValue[service.CPService](i, cps)
 	cph, err := NewCPHandler(i)
 	assert.NoError(t, err)
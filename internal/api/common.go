@@ -2,12 +2,16 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"mime"
+	"net"
 	"net/http"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
@@ -43,6 +47,49 @@ func bindJSON(r *http.Request, obj interface{}) (err error) {
 	return
 }
 
+// readMergePatch reads the json merge patch from the request body
+func readMergePatch(r *http.Request) ([]byte, error) {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != model.MergePatchContentType && mediaType != "application/json") {
+			return nil, model.NewAppErr(http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content type %q", ct))
+		}
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, model.NewAppErr(http.StatusBadRequest, err)
+	}
+	return patch, nil
+}
+
+// ifMatchVersion returns the version required by the If-Match header, 0 if any version is accepted
+func ifMatchVersion(r *http.Request) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	// only a single strong entity tag can match the version of an object
+	version, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(header, `"`), `"`), 10, 64)
+	if err != nil || version <= 0 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		return 0, model.NewAppErr(http.StatusPreconditionFailed, fmt.Errorf("If-Match %s doesn't match any version", header))
+	}
+	return version, nil
+}
+
+// setETag sets the version of the object as entity tag
+func setETag(w http.ResponseWriter, obj model.Versioned) {
+	w.Header().Set("ETag", `"`+strconv.FormatInt(obj.GetVersion(), 10)+`"`)
+}
+
+// restActor identifies the client of the request as the actor of the caused events
+func restActor(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "rest:" + host
+}
+
 // GetParam get the specified param
 func getParam(r *http.Request, param string) (paramValue string) {
 	vars := mux.Vars(r)
@@ -51,11 +98,22 @@ func getParam(r *http.Request, param string) (paramValue string) {
 }
 
 func handleErr(w *http.ResponseWriter, err error) {
-	if aerr, ok := err.(*model.AppError); ok {
-		handleError(w, aerr.Code, aerr.Error())
+	handleErrWithStatus(w, err, http.StatusInternalServerError)
+}
+
+// handleErrWithStatus renders app errors with their code, all other errors with the given status
+func handleErrWithStatus(w *http.ResponseWriter, err error, status int) {
+	var aerr *model.AppError
+	if !errors.As(err, &aerr) {
+		handleError(w, status, err.Error())
 		return
 	}
-	handleError(w, http.StatusInternalServerError, err.Error())
+	var verr *model.ValidationError
+	if errors.As(aerr.Err, &verr) {
+		handleJSON(w, aerr.Code, verr)
+		return
+	}
+	handleError(w, aerr.Code, aerr.Error())
 }
 
 // HandleError handles an error
@@ -85,6 +143,9 @@ func handleJSON(w *http.ResponseWriter, status int, result interface{}) {
 func respondWithCreated(r *http.Request, w http.ResponseWriter, input model.IDer) {
 	log.Printf("ID after save %d", input.GetID())
 	w.Header().Add("Location", fmt.Sprintf("%s/%d", r.RequestURI, input.GetID()))
+	if versioned, ok := input.(model.Versioned); ok {
+		setETag(w, versioned)
+	}
 	handleJSON(&w, http.StatusCreated, input)
 }
 
@@ -8,11 +8,17 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-co-op/gocron"
 	"github.com/gorilla/mux"
+	"github.com/pthum/stripcontrol-golang/internal/config"
 	"github.com/pthum/stripcontrol-golang/internal/database"
+	"github.com/pthum/stripcontrol-golang/internal/database/csv"
 	dbm "github.com/pthum/stripcontrol-golang/internal/database/mocks"
 	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	messagingimpl "github.com/pthum/stripcontrol-golang/internal/messaging/impl"
 	mhm "github.com/pthum/stripcontrol-golang/internal/messaging/mocks"
 	"github.com/pthum/stripcontrol-golang/internal/model"
 	"github.com/pthum/stripcontrol-golang/internal/service"
@@ -23,6 +29,11 @@ import (
 
 type uv map[string]string
 
+// brokerIT receives the messages published to the embedded broker, like a strip controller would
+type brokerIT struct {
+	messages chan mqtt.Message
+}
+
 type baseMocks struct {
 	cpDbh *dbm.DBHandler[model.ColorProfile]
 	lsDbh *dbm.DBHandler[model.LedStrip]
@@ -35,13 +46,58 @@ func TestRouteHandlerName(t *testing.T) {
 	assert.Equal(t, "GetAllLedStrips", r.HandlerName())
 }
 
+func TestRestActor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/", nil)
+	assert.Equal(t, "rest:192.0.2.1", restActor(req))
+
+	req.RemoteAddr = "[::1]:4711"
+	assert.Equal(t, "rest:::1", restActor(req))
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header  string
+		version int64
+		valid   bool
+	}{
+		{"", 0, true},
+		{"*", 0, true},
+		{`"12"`, 12, true},
+		{`W/"12"`, 0, false},
+		{`12`, 0, false},
+		{`"abc"`, 0, false},
+		{`"1", "2"`, 0, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPut, "/", nil)
+		req.Header.Set("If-Match", tt.header)
+
+		version, err := ifMatchVersion(req)
+
+		assert.Equal(t, tt.version, version, tt.header)
+		if tt.valid {
+			assert.NoError(t, err, tt.header)
+			continue
+		}
+		var aerr *model.AppError
+		if assert.ErrorAs(t, err, &aerr, tt.header) {
+			assert.Equal(t, http.StatusPreconditionFailed, aerr.Code)
+		}
+	}
+}
+
 func createBaseMocks(i *do.Injector, t *testing.T) *baseMocks {
 	cpDbh := dbm.NewDBHandler[model.ColorProfile](t)
 	lsDbh := dbm.NewDBHandler[model.LedStrip](t)
+	do.ProvideValue(i, &config.Config{})
 	do.ProvideValue[database.DBHandler[model.ColorProfile]](i, cpDbh)
 	do.ProvideValue[database.DBHandler[model.LedStrip]](i, lsDbh)
+	provideTimerDeps(i, t)
 	mh := mhm.NewEventHandler(t)
 	do.ProvideValue[messaging.EventHandler](i, mh)
+	ls, err := service.NewLEDService(i)
+	assert.NoError(t, err)
+	do.ProvideValue(i, ls)
 	cps, err := service.NewCPService(i)
 	assert.NoError(t, err)
 	do.ProvideValue(i, cps)
@@ -52,6 +108,108 @@ func createBaseMocks(i *do.Injector, t *testing.T) *baseMocks {
 	}
 }
 
+// provideTimerDeps provides the scheduler and the database of the timers without any stored timer
+func provideTimerDeps(i *do.Injector, t *testing.T) {
+	tDbh := dbm.NewDBHandler[model.Timer](t)
+	tDbh.EXPECT().GetAll().Return([]model.Timer{}, nil).Maybe()
+	do.ProvideValue[database.DBHandler[model.Timer]](i, tDbh)
+	do.ProvideValue(i, gocron.NewScheduler(time.UTC))
+}
+
+// createBrokerBaseMocks creates the base mocks, but with the real event handler publishing to an embedded broker
+func createBrokerBaseMocks(i *do.Injector, t *testing.T) (*baseMocks, *brokerIT) {
+	cfg := &config.Config{
+		Messaging: config.MessagingConfig{
+			StripTopic:   "ledstrip",
+			ProfileTopic: "profile",
+			Broker:       config.BrokerConfig{Enable: true, Address: "127.0.0.1:0"},
+		},
+		CSV: config.CSVConfig{DataDir: t.TempDir()},
+	}
+	cpDbh := dbm.NewDBHandler[model.ColorProfile](t)
+	lsDbh := dbm.NewDBHandler[model.LedStrip](t)
+	// the states are published on every connect and profile change
+	lsDbh.EXPECT().GetAll().Return([]model.LedStrip{}, nil).Maybe()
+	do.ProvideValue(i, cfg)
+	do.ProvideValue[database.DBHandler[model.ColorProfile]](i, cpDbh)
+	do.ProvideValue[database.DBHandler[model.LedStrip]](i, lsDbh)
+	do.ProvideValue[database.DBHandler[model.OutboxEntry]](i, csv.NewHandler[model.OutboxEntry](&cfg.CSV))
+	provideTimerDeps(i, t)
+	do.Provide(i, messagingimpl.NewStream)
+	do.Provide(i, messagingimpl.NewEmbeddedBroker)
+	do.Provide(i, messagingimpl.New)
+	mh := do.MustInvoke[messaging.EventHandler](i)
+	t.Cleanup(func() {
+		assert.NoError(t, i.Shutdown())
+	})
+	assert.Eventually(t, func() bool {
+		return mh.(messaging.ConnectionReporter).ConnectionState() == messaging.Connected
+	}, 5*time.Second, 10*time.Millisecond)
+	ls, err := service.NewLEDService(i)
+	assert.NoError(t, err)
+	do.ProvideValue(i, ls)
+	cps, err := service.NewCPService(i)
+	assert.NoError(t, err)
+	do.ProvideValue(i, cps)
+
+	host, port := do.MustInvoke[*messagingimpl.EmbeddedBroker](i).Address()
+	b := &brokerIT{messages: make(chan mqtt.Message, 100)}
+	client := mqtt.NewClient(mqtt.NewClientOptions().AddBroker("tcp://" + host + ":" + port).SetClientID("controller"))
+	token := client.Connect()
+	assert.True(t, token.WaitTimeout(5*time.Second))
+	assert.NoError(t, token.Error())
+	t.Cleanup(func() { client.Disconnect(100) })
+	token = client.Subscribe("#", 1, func(_ mqtt.Client, msg mqtt.Message) {
+		b.messages <- msg
+	})
+	assert.True(t, token.WaitTimeout(5*time.Second))
+	assert.NoError(t, token.Error())
+	return &baseMocks{
+		cpDbh: cpDbh,
+		lsDbh: lsDbh,
+	}, b
+}
+
+// next returns the payload of the next message on the topic, messages on other topics are skipped
+func (b *brokerIT) next(t *testing.T, topic string) []byte {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-b.messages:
+			if msg.Topic() == topic {
+				return msg.Payload()
+			}
+		case <-timeout:
+			t.Fatalf("no message published to %s", topic)
+			return nil
+		}
+	}
+}
+
+func (bm *baseMocks) expectDBProfileNextID(id int64) {
+	bm.cpDbh.
+		EXPECT().
+		NextID().
+		Return(id, nil).
+		Once()
+}
+
+func (bm *baseMocks) expectDBStripNextID(id int64) {
+	bm.lsDbh.
+		EXPECT().
+		NextID().
+		Return(id, nil).
+		Once()
+}
+
+func (bm *baseMocks) expectDBStripGetAll(strips []model.LedStrip, getError error) {
+	bm.lsDbh.
+		EXPECT().
+		GetAll().
+		Return(strips, getError).
+		Once()
+}
+
 func (bm *baseMocks) expectDBProfileGet(getStrip *model.ColorProfile, getError error) {
 	getStripIdStr := mock.Anything
 	if getStrip != nil {
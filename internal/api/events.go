@@ -0,0 +1,157 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	"github.com/pthum/stripcontrol-golang/internal/service"
+	"github.com/samber/do"
+)
+
+const (
+	eventsPath = "/api/events"
+	// lastEventIDHeader the header sent by reconnecting clients with the id of the last received event
+	lastEventIDHeader = "Last-Event-ID"
+	// eventsPingInterval the interval of the comments keeping idle connections open
+	eventsPingInterval = 30 * time.Second
+)
+
+type EventsHandler interface {
+	GetEvents(w http.ResponseWriter, r *http.Request)
+}
+
+type eventsHandlerImpl struct {
+	stream       messaging.EventStream
+	lsvc         service.LEDService
+	pingInterval time.Duration
+	l            alog.Logger
+}
+
+func NewEventsHandler(i *do.Injector) (EventsHandler, error) {
+	stream := do.MustInvoke[messaging.EventStream](i)
+	lsvc := do.MustInvoke[service.LEDService](i)
+	l := alog.NewLogger("eventshandler")
+	return &eventsHandlerImpl{
+		stream:       stream,
+		lsvc:         lsvc,
+		pingInterval: eventsPingInterval,
+		l:            l,
+	}, nil
+}
+
+func (h *eventsHandlerImpl) eventsRoutes() []Route {
+	return []Route{
+		{http.MethodGet, eventsPath, h.GetEvents},
+	}
+}
+
+// GetEvents streams the strip and profile events as server-sent events. The query parameter strip limits the
+// events to the strip and its current profile, clients resume with the Last-Event-ID header. A resync event tells
+// the client, that the missed events couldn't be replayed and it has to fetch the current state again.
+func (h *eventsHandlerImpl) GetEvents(w http.ResponseWriter, r *http.Request) {
+	lastID, err := lastEventID(r)
+	if err != nil {
+		handleError(&w, http.StatusBadRequest, err.Error())
+		return
+	}
+	stripID := r.URL.Query().Get("strip")
+	if stripID != "" {
+		if _, err := h.lsvc.GetLEDStrip(stripID); err != nil {
+			handleError(&w, http.StatusNotFound, stripNotFoundMsg)
+			return
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	// the stream outlives the write timeout of the server
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.l.Debug("can't disable the write deadline: %s", err)
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	missed, events, cancel := h.stream.Listen(lastID)
+	defer cancel()
+	for _, se := range missed {
+		if err := h.write(w, se, stripID); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ping := time.NewTicker(h.pingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ping.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case se, ok := <-events:
+			if !ok {
+				// the stream was shut down or the client was too slow, it reconnects with the last id
+				return
+			}
+			if err := h.write(w, se, stripID); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// write writes the event, unless it is filtered out
+func (h *eventsHandlerImpl) write(w http.ResponseWriter, se messaging.StreamEvent, stripID string) error {
+	if !h.matches(se, stripID) {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", se.ID, se.Kind, se.Data)
+	return err
+}
+
+// matches checks whether the event belongs to the strip, profile events match the current profile of the strip
+func (h *eventsHandlerImpl) matches(se messaging.StreamEvent, stripID string) bool {
+	if stripID == "" || se.Kind == messaging.StreamKindResync {
+		return true
+	}
+	switch se.Kind {
+	case messaging.StreamKindStrip:
+		return strconv.FormatInt(se.SubjectID, 10) == stripID
+	case messaging.StreamKindProfile:
+		strip, err := h.lsvc.GetLEDStrip(stripID)
+		if err != nil {
+			return false
+		}
+		return strip.ProfileID.Valid && strip.ProfileID.Int64 == se.SubjectID
+	default:
+		return false
+	}
+}
+
+// lastEventID reads the id of the last received event, 0 if the client didn't receive any
+func lastEventID(r *http.Request) (uint64, error) {
+	value := r.Header.Get(lastEventIDHeader)
+	if value == "" {
+		value = r.URL.Query().Get("lastEventId")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid last event id %q", value)
+	}
+	return id, nil
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	mhm "github.com/pthum/stripcontrol-golang/internal/messaging/mocks"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/service"
+	servicemocks "github.com/pthum/stripcontrol-golang/internal/service/mocks"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/assert"
+)
+
+// streamFake an event stream returning the given events
+type streamFake struct {
+	*mhm.EventHandler
+	missed []messaging.StreamEvent
+	events chan messaging.StreamEvent
+	lastID uint64
+}
+
+func (s *streamFake) Listen(lastID uint64) ([]messaging.StreamEvent, <-chan messaging.StreamEvent, func()) {
+	s.lastID = lastID
+	return s.missed, s.events, func() {}
+}
+
+type eventsMocks struct {
+	stream *streamFake
+	lsvc   *servicemocks.LEDService
+	eh     *eventsHandlerImpl
+}
+
+func TestEventsRoutes(t *testing.T) {
+	mocks := createEventsMocks(t)
+	assert.Equal(t, 1, len(mocks.eh.eventsRoutes()))
+}
+
+func TestGetEvents(t *testing.T) {
+	mocks := createEventsMocks(t)
+	mocks.stream.missed = []messaging.StreamEvent{
+		{ID: 4, Kind: messaging.StreamKindStrip, SubjectID: 1, Data: []byte(`{"id":1}`)},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(mocks.eh.GetEvents))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set(lastEventIDHeader, "3")
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	assert.Equal(t, uint64(3), mocks.stream.lastID)
+
+	rd := bufio.NewReader(res.Body)
+	assert.Equal(t, "id: 4\nevent: strip\ndata: {\"id\":1}\n\n", readEvent(t, rd))
+	mocks.stream.events <- messaging.StreamEvent{ID: 5, Kind: messaging.StreamKindProfile, SubjectID: 2, Data: []byte(`{"id":2}`)}
+	assert.Equal(t, "id: 5\nevent: profile\ndata: {\"id\":2}\n\n", readEvent(t, rd))
+	assert.Equal(t, ": ping\n\n", readEvent(t, rd))
+
+	// the stream ends if the listener is dropped
+	close(mocks.stream.events)
+	_, err = rd.ReadString('\n')
+	assert.Error(t, err)
+}
+
+func TestGetEvents_Strip(t *testing.T) {
+	mocks := createEventsMocks(t)
+	strip := &model.LedStrip{BaseModel: model.BaseModel{ID: 1}, ProfileID: null.IntFrom(7)}
+	mocks.lsvc.EXPECT().GetLEDStrip("1").Return(strip, nil)
+	mocks.stream.missed = []messaging.StreamEvent{
+		{ID: 1, Kind: messaging.StreamKindStrip, SubjectID: 2, Data: []byte(`{"id":2}`)},
+		{ID: 2, Kind: messaging.StreamKindProfile, SubjectID: 8, Data: []byte(`{"id":8}`)},
+		{ID: 3, Kind: messaging.StreamKindProfile, SubjectID: 7, Data: []byte(`{"id":7}`)},
+		{ID: 4, Kind: messaging.StreamKindStrip, SubjectID: 1, Data: []byte(`{"id":1}`)},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(mocks.eh.GetEvents))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "?strip=1&lastEventId=0")
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	rd := bufio.NewReader(res.Body)
+	assert.Equal(t, "id: 3\nevent: profile\ndata: {\"id\":7}\n\n", readEvent(t, rd))
+	assert.Equal(t, "id: 4\nevent: strip\ndata: {\"id\":1}\n\n", readEvent(t, rd))
+}
+
+func TestGetEvents_Resync(t *testing.T) {
+	mocks := createEventsMocks(t)
+	strip := &model.LedStrip{BaseModel: model.BaseModel{ID: 1}}
+	mocks.lsvc.EXPECT().GetLEDStrip("1").Return(strip, nil)
+	mocks.stream.missed = []messaging.StreamEvent{{ID: 9, Kind: messaging.StreamKindResync, Data: []byte(`{}`)}}
+	srv := httptest.NewServer(http.HandlerFunc(mocks.eh.GetEvents))
+	defer srv.Close()
+
+	// the resync isn't filtered by the strip
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"?strip=1", nil)
+	req.Header.Set(lastEventIDHeader, "2")
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	rd := bufio.NewReader(res.Body)
+	assert.Equal(t, "id: 9\nevent: resync\ndata: {}\n\n", readEvent(t, rd))
+}
+
+func TestGetEvents_UnknownStrip(t *testing.T) {
+	mocks := createEventsMocks(t)
+	mocks.lsvc.EXPECT().GetLEDStrip("9").Return(nil, errors.New("not found"))
+	req, w := prepareHttpTest(http.MethodGet, eventsPath+"?strip=9", nil, nil)
+
+	mocks.eh.GetEvents(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestGetEvents_InvalidLastEventID(t *testing.T) {
+	mocks := createEventsMocks(t)
+	req, w := prepareHttpTest(http.MethodGet, eventsPath, nil, nil)
+	req.Header.Set(lastEventIDHeader, "abc")
+
+	mocks.eh.GetEvents(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+// readEvent reads the lines up to the next empty line
+func readEvent(t *testing.T, rd *bufio.Reader) string {
+	var sb strings.Builder
+	for {
+		line, err := rd.ReadString('\n')
+		if !assert.NoError(t, err) {
+			return sb.String()
+		}
+		sb.WriteString(line)
+		if line == "\n" {
+			return sb.String()
+		}
+	}
+}
+
+func createEventsMocks(t *testing.T) *eventsMocks {
+	i := do.New()
+	stream := &streamFake{EventHandler: mhm.NewEventHandler(t), events: make(chan messaging.StreamEvent, 1)}
+	do.ProvideValue[messaging.EventStream](i, stream)
+	lsvc := servicemocks.NewLEDService(t)
+	do.ProvideValue[service.LEDService](i, lsvc)
+	eh, err := NewEventsHandler(i)
+	assert.NoError(t, err)
+	impl := eh.(*eventsHandlerImpl)
+	impl.pingInterval = 50 * time.Millisecond
+	return &eventsMocks{
+		stream: stream,
+		lsvc:   lsvc,
+		eh:     impl,
+	}
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"net/http"
+
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/service"
+	"github.com/samber/do"
+)
+
+const (
+	groupNotFoundMsg = "Group not found!"
+	groupPath        = "/api/group"
+	groupIDPath      = groupPath + "/{id}"
+	groupEnablePath  = groupIDPath + "/enable"
+	groupDisablePath = groupIDPath + "/disable"
+	groupProfilePath = groupIDPath + "/profile"
+)
+
+type GroupHandler interface {
+	GetAllGroups(w http.ResponseWriter, r *http.Request)
+	GetGroup(w http.ResponseWriter, r *http.Request)
+	CreateGroup(w http.ResponseWriter, r *http.Request)
+	UpdateGroup(w http.ResponseWriter, r *http.Request)
+	DeleteGroup(w http.ResponseWriter, r *http.Request)
+	EnableGroup(w http.ResponseWriter, r *http.Request)
+	DisableGroup(w http.ResponseWriter, r *http.Request)
+	SetProfileForGroup(w http.ResponseWriter, r *http.Request)
+}
+
+type groupHandlerImpl struct {
+	gs service.GroupService
+	l  alog.Logger
+}
+
+func NewGroupHandler(i *do.Injector) (GroupHandler, error) {
+	return &groupHandlerImpl{
+		gs: do.MustInvoke[service.GroupService](i),
+		l:  alog.NewLogger("grouphandler"),
+	}, nil
+}
+
+func (h *groupHandlerImpl) groupRoutes() []Route {
+	return []Route{
+		{http.MethodGet, groupPath, h.GetAllGroups},
+		{http.MethodPost, groupPath, h.CreateGroup},
+		{http.MethodGet, groupIDPath, h.GetGroup},
+		{http.MethodPut, groupIDPath, h.UpdateGroup},
+		{http.MethodDelete, groupIDPath, h.DeleteGroup},
+		{http.MethodPost, groupEnablePath, h.EnableGroup},
+		{http.MethodPost, groupDisablePath, h.DisableGroup},
+		{http.MethodPut, groupProfilePath, h.SetProfileForGroup},
+	}
+}
+
+// GetAllGroups get all groups
+func (h *groupHandlerImpl) GetAllGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.gs.GetAll()
+	if err != nil {
+		handleError(&w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	handleJSON(&w, http.StatusOK, groups)
+}
+
+// GetGroup get a single group
+func (h *groupHandlerImpl) GetGroup(w http.ResponseWriter, r *http.Request) {
+	group, err := h.gs.GetGroup(getParam(r, "id"))
+	if err != nil {
+		handleError(&w, http.StatusNotFound, groupNotFoundMsg)
+		return
+	}
+
+	setETag(w, group)
+	handleJSON(&w, http.StatusOK, group)
+}
+
+// CreateGroup create a group
+func (h *groupHandlerImpl) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var input model.Group
+	if err := bindJSON(r, &input); err != nil {
+		handleError(&w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.gs.CreateGroup(&input); err != nil {
+		h.l.Error("Error: %s", err)
+		handleErrWithStatus(&w, err, http.StatusBadRequest)
+		return
+	}
+	respondWithCreated(r, w, &input)
+}
+
+// UpdateGroup update a group
+func (h *groupHandlerImpl) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	var input model.Group
+	if err := bindJSON(r, &input); err != nil {
+		handleErr(&w, model.NewAppErr(http.StatusBadRequest, err))
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+	updMdl := input
+	updMdl.Version = version
+	group, err := h.gs.UpdateGroup(getParam(r, "id"), updMdl)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+
+	setETag(w, group)
+	handleJSON(&w, http.StatusOK, group)
+}
+
+// DeleteGroup delete a group
+func (h *groupHandlerImpl) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+	if err := h.gs.DeleteGroup(getParam(r, "id"), version); err != nil {
+		handleErr(&w, err)
+		return
+	}
+
+	handleJSON(&w, http.StatusNoContent, nil)
+}
+
+// EnableGroup enable the strips of the group
+func (h *groupHandlerImpl) EnableGroup(w http.ResponseWriter, r *http.Request) {
+	result, err := h.gs.WithActor(restActor(r)).EnableGroup(getParam(r, "id"))
+	respondWithGroupResult(w, result, err)
+}
+
+// DisableGroup disable the strips of the group
+func (h *groupHandlerImpl) DisableGroup(w http.ResponseWriter, r *http.Request) {
+	result, err := h.gs.WithActor(restActor(r)).DisableGroup(getParam(r, "id"))
+	respondWithGroupResult(w, result, err)
+}
+
+// SetProfileForGroup enable the strips of the group with the referenced profile
+func (h *groupHandlerImpl) SetProfileForGroup(w http.ResponseWriter, r *http.Request) {
+	var input model.ColorProfile
+	if err := bindJSON(r, &input); err != nil {
+		handleErr(&w, model.NewAppErr(http.StatusBadRequest, err))
+		return
+	}
+
+	result, err := h.gs.WithActor(restActor(r)).SetProfileForGroup(getParam(r, "id"), input.ID)
+	respondWithGroupResult(w, result, err)
+}
+
+// respondWithGroupResult responds with the result of a group action, a partially failed action is reported as
+// multi-status
+func respondWithGroupResult(w http.ResponseWriter, result *model.GroupResult, err error) {
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+	status := http.StatusOK
+	if len(result.Failed) > 0 {
+		status = http.StatusMultiStatus
+	}
+	handleJSON(&w, status, result)
+}
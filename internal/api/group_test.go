@@ -0,0 +1,286 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/service"
+	servicemocks "github.com/pthum/stripcontrol-golang/internal/service/mocks"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type groupMocks struct {
+	gs *servicemocks.GroupService
+	gh *groupHandlerImpl
+}
+
+func TestGroupRoutes(t *testing.T) {
+	mcks := createGroupHandlerMocks(t)
+	routes := mcks.gh.groupRoutes()
+	assert.Equal(t, 8, len(routes))
+}
+
+func TestGetAllGroups(t *testing.T) {
+	mocks := createGroupHandlerMocks(t)
+	groups := []model.Group{*createDummyGroup()}
+	mocks.gs.
+		EXPECT().
+		GetAll().
+		Return(groups, nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodGet, groupPath, nil, nil)
+
+	mocks.gh.GetAllGroups(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	var result []model.Group
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, groups, result)
+}
+
+func TestGetGroup(t *testing.T) {
+	mocks := createGroupHandlerMocks(t)
+	group := createDummyGroup()
+	group.Version = 3
+	mocks.gs.
+		EXPECT().
+		GetGroup("4").
+		Return(group, nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodGet, groupIDPath, uv{"id": "4"}, nil)
+
+	mocks.gh.GetGroup(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	var result model.Group
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `"3"`, res.Header.Get("ETag"))
+	assert.Equal(t, *group, result)
+}
+
+func TestGetGroup_Error(t *testing.T) {
+	mocks := createGroupHandlerMocks(t)
+	mocks.gs.
+		EXPECT().
+		GetGroup("4").
+		Return(nil, errors.New("not found")).
+		Once()
+	req, w := prepareHttpTest(http.MethodGet, groupIDPath, uv{"id": "4"}, nil)
+
+	mocks.gh.GetGroup(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+}
+
+func TestCreateGroup(t *testing.T) {
+	mocks := createGroupHandlerMocks(t)
+	group := createDummyGroup()
+	group.ID = 0
+	mocks.gs.
+		EXPECT().
+		CreateGroup(mock.Anything).
+		Run(func(mdl *model.Group) {
+			mdl.ID = 4
+			mdl.Version = 1
+		}).
+		Return(nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodPost, groupPath, nil, objToReader(t, group))
+
+	mocks.gh.CreateGroup(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Contains(t, res.Header.Get("Location"), "/4")
+	assert.Equal(t, `"1"`, res.Header.Get("ETag"))
+}
+
+func TestCreateGroup_Errors(t *testing.T) {
+	mocks := createGroupHandlerMocks(t)
+	req, w := prepareHttpTest(http.MethodPost, groupPath, nil, nil)
+	mocks.gh.CreateGroup(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+
+	mocks.gs.
+		EXPECT().
+		CreateGroup(mock.Anything).
+		Return(model.NewAppErr(http.StatusUnprocessableEntity, &model.ValidationError{})).
+		Once()
+	req, w = prepareHttpTest(http.MethodPost, groupPath, nil, objToReader(t, createDummyGroup()))
+	mocks.gh.CreateGroup(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+}
+
+func TestUpdateGroup(t *testing.T) {
+	mocks := createGroupHandlerMocks(t)
+	stored := *createDummyGroup()
+	stored.Version = 3
+	mocks.gs.
+		EXPECT().
+		UpdateGroup("4", mock.Anything).
+		Run(func(id string, updMdl model.Group) {
+			assert.Equal(t, int64(2), updMdl.Version)
+		}).
+		Return(&stored, nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodPut, groupIDPath, uv{"id": "4"}, objToReader(t, createDummyGroup()))
+	req.Header.Set("If-Match", `"2"`)
+
+	mocks.gh.UpdateGroup(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	var result model.Group
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `"3"`, res.Header.Get("ETag"))
+	assert.Equal(t, stored, result)
+}
+
+func TestUpdateGroup_Error(t *testing.T) {
+	mocks := createGroupHandlerMocks(t)
+	mocks.gs.
+		EXPECT().
+		UpdateGroup("4", mock.Anything).
+		Return(nil, model.NewAppErr(http.StatusUnprocessableEntity, &model.ValidationError{})).
+		Once()
+	req, w := prepareHttpTest(http.MethodPut, groupIDPath, uv{"id": "4"}, objToReader(t, createDummyGroup()))
+
+	mocks.gh.UpdateGroup(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+}
+
+func TestDeleteGroup(t *testing.T) {
+	mocks := createGroupHandlerMocks(t)
+	mocks.gs.
+		EXPECT().
+		DeleteGroup("4", int64(0)).
+		Return(nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodDelete, groupIDPath, uv{"id": "4"}, nil)
+
+	mocks.gh.DeleteGroup(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+}
+
+func TestDeleteGroup_Error(t *testing.T) {
+	mocks := createGroupHandlerMocks(t)
+	mocks.gs.
+		EXPECT().
+		DeleteGroup("4", int64(0)).
+		Return(model.NewAppErr(http.StatusConflict, errors.New("group 4 is nested in group 1"))).
+		Once()
+	req, w := prepareHttpTest(http.MethodDelete, groupIDPath, uv{"id": "4"}, nil)
+
+	mocks.gh.DeleteGroup(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Result().StatusCode)
+}
+
+func TestEnableGroup(t *testing.T) {
+	mocks := createGroupHandlerMocks(t)
+	result := &model.GroupResult{Strips: []model.LedStrip{{BaseModel: model.BaseModel{ID: 185, Version: 2}, Enabled: true}}}
+	mocks.gs.
+		EXPECT().
+		EnableGroup("4").
+		Return(result, nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodPost, groupEnablePath, uv{"id": "4"}, nil)
+
+	mocks.gh.EnableGroup(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	var body model.GroupResult
+	bodyToObj(t, res, &body)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, *result, body)
+}
+
+func TestDisableGroup_PartialFailure(t *testing.T) {
+	mocks := createGroupHandlerMocks(t)
+	result := &model.GroupResult{
+		Strips: []model.LedStrip{{BaseModel: model.BaseModel{ID: 185, Version: 2}}},
+		Failed: []model.StripFailure{{StripID: 186, Error: "strip 186 not found"}},
+	}
+	mocks.gs.
+		EXPECT().
+		DisableGroup("4").
+		Return(result, nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodPost, groupDisablePath, uv{"id": "4"}, nil)
+
+	mocks.gh.DisableGroup(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	var body model.GroupResult
+	bodyToObj(t, res, &body)
+	assert.Equal(t, http.StatusMultiStatus, res.StatusCode)
+	assert.Equal(t, *result, body)
+}
+
+func TestSetProfileForGroup(t *testing.T) {
+	mocks := createGroupHandlerMocks(t)
+	mocks.gs.
+		EXPECT().
+		SetProfileForGroup("4", int64(15)).
+		Return(&model.GroupResult{Strips: []model.LedStrip{}}, nil).
+		Once()
+	profile := model.ColorProfile{BaseModel: model.BaseModel{ID: 15}}
+	req, w := prepareHttpTest(http.MethodPut, groupProfilePath, uv{"id": "4"}, objToReader(t, profile))
+
+	mocks.gh.SetProfileForGroup(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+}
+
+func TestSetProfileForGroup_Errors(t *testing.T) {
+	mocks := createGroupHandlerMocks(t)
+	req, w := prepareHttpTest(http.MethodPut, groupProfilePath, uv{"id": "4"}, nil)
+	mocks.gh.SetProfileForGroup(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+
+	mocks.gs.
+		EXPECT().
+		SetProfileForGroup("4", int64(16)).
+		Return(nil, model.NewAppErr(http.StatusUnprocessableEntity, &model.ValidationError{})).
+		Once()
+	profile := model.ColorProfile{BaseModel: model.BaseModel{ID: 16}}
+	req, w = prepareHttpTest(http.MethodPut, groupProfilePath, uv{"id": "4"}, objToReader(t, profile))
+	mocks.gh.SetProfileForGroup(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+}
+
+func createDummyGroup() *model.Group {
+	return &model.Group{
+		BaseModel: model.BaseModel{ID: 4},
+		Name:      "Ground floor",
+		Strips:    model.IDs{185, 186},
+		Groups:    model.IDs{2},
+	}
+}
+
+func createGroupHandlerMocks(t *testing.T) *groupMocks {
+	i := do.New()
+	gs := servicemocks.NewGroupService(t)
+	gs.EXPECT().WithActor("rest:192.0.2.1").Return(gs).Maybe()
+	do.ProvideValue[service.GroupService](i, gs)
+	gh, err := NewGroupHandler(i)
+	assert.NoError(t, err)
+	return &groupMocks{
+		gs: gs,
+		gh: gh.(*groupHandlerImpl),
+	}
+}
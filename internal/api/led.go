@@ -16,12 +16,14 @@ const (
 	ledstripPath          = "/api/ledstrip"
 	ledstripIDPath        = ledstripPath + "/{id}"
 	ledstripIDProfilePath = ledstripIDPath + "/profile"
+	ledstripIDTimerPath   = ledstripIDPath + "/timer"
 )
 
 type LEDHandler interface {
 	GetAllLedStrips(w http.ResponseWriter, r *http.Request)
 	GetLedStrip(w http.ResponseWriter, r *http.Request)
 	UpdateLedStrip(w http.ResponseWriter, r *http.Request)
+	PatchLedStrip(w http.ResponseWriter, r *http.Request)
 }
 
 type ledHandlerImpl struct {
@@ -53,10 +55,13 @@ func (lh *ledHandlerImpl) ledRoutes() []Route {
 		{http.MethodPost, ledstripPath, lh.CreateLedStrip},
 		{http.MethodGet, ledstripIDPath, lh.GetLedStrip},
 		{http.MethodPut, ledstripIDPath, lh.UpdateLedStrip},
+		{http.MethodPatch, ledstripIDPath, lh.PatchLedStrip},
 		{http.MethodDelete, ledstripIDPath, lh.DeleteLedStrip},
 		{http.MethodGet, ledstripIDProfilePath, lh.GetProfileForStrip},
 		{http.MethodPut, ledstripIDProfilePath, lh.UpdateProfileForStrip},
 		{http.MethodDelete, ledstripIDProfilePath, lh.RemoveProfileForStrip},
+		{http.MethodPost, ledstripIDTimerPath, lh.SetTimerForStrip},
+		{http.MethodDelete, ledstripIDTimerPath, lh.CancelTimerForStrip},
 	}
 }
 
@@ -71,7 +76,7 @@ func (lh *ledHandlerImpl) GetAllLedStrips(w http.ResponseWriter, r *http.Request
 	handleJSON(&w, http.StatusOK, strips)
 }
 
-// GetLedStrip get a single led strip
+// GetLedStrip get a single led strip with its running timer
 func (lh *ledHandlerImpl) GetLedStrip(w http.ResponseWriter, r *http.Request) {
 	// Get model if exist
 	strip, err := lh.lsvc.GetLEDStrip(getParam(r, "id"))
@@ -80,7 +85,8 @@ func (lh *ledHandlerImpl) GetLedStrip(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	handleJSON(&w, http.StatusOK, strip)
+	setETag(w, strip)
+	handleJSON(&w, http.StatusOK, model.StripInfo{LedStrip: *strip, Timer: lh.lsvc.GetTimer(strip.ID)})
 }
 
 // CreateLedStrip create an LED strip
@@ -92,9 +98,9 @@ func (lh *ledHandlerImpl) CreateLedStrip(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := lh.lsvc.CreateLEDStrip(&input); err != nil {
+	if err := lh.lsvc.WithActor(restActor(r)).CreateLEDStrip(&input); err != nil {
 		lh.l.Error("Error: %s", err)
-		handleError(&w, http.StatusBadRequest, err.Error())
+		handleErrWithStatus(&w, err, http.StatusBadRequest)
 		return
 	}
 
@@ -110,17 +116,54 @@ func (lh *ledHandlerImpl) UpdateLedStrip(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := lh.lsvc.UpdateLEDStrip(getParam(r, "id"), input); err != nil {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+	updMdl := input
+	updMdl.Version = version
+	strip, err := lh.lsvc.WithActor(restActor(r)).UpdateLEDStrip(getParam(r, "id"), updMdl)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+
+	setETag(w, strip)
+	handleJSON(&w, http.StatusOK, strip)
+}
+
+// PatchLedStrip partially update an LED strip with a json merge patch
+func (lh *ledHandlerImpl) PatchLedStrip(w http.ResponseWriter, r *http.Request) {
+	patch, err := readMergePatch(r)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+
+	strip, err := lh.lsvc.WithActor(restActor(r)).PatchLEDStrip(getParam(r, "id"), version, patch)
+	if err != nil {
 		handleErr(&w, err)
 		return
 	}
 
-	handleJSON(&w, http.StatusOK, input)
+	setETag(w, strip)
+	handleJSON(&w, http.StatusOK, strip)
 }
 
 // DeleteLedStrip delete an LED strip
 func (lh *ledHandlerImpl) DeleteLedStrip(w http.ResponseWriter, r *http.Request) {
-	if err := lh.lsvc.DeleteLEDStrip(getParam(r, "id")); err != nil {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+	if err := lh.lsvc.WithActor(restActor(r)).DeleteLEDStrip(getParam(r, "id"), version); err != nil {
 		handleErr(&w, err)
 		return
 	}
@@ -136,7 +179,7 @@ func (lh *ledHandlerImpl) UpdateProfileForStrip(w http.ResponseWriter, r *http.R
 		handleError(&w, http.StatusBadRequest, err.Error())
 		return
 	}
-	profile, err := lh.lsvc.UpdateProfileForStrip(getParam(r, "id"), input)
+	profile, err := lh.lsvc.WithActor(restActor(r)).UpdateProfileForStrip(getParam(r, "id"), input)
 	if err != nil {
 		handleErr(&w, err)
 	}
@@ -157,7 +200,34 @@ func (lh *ledHandlerImpl) GetProfileForStrip(w http.ResponseWriter, r *http.Requ
 
 // RemoveProfileForStrip remove the current referenced profile
 func (lh *ledHandlerImpl) RemoveProfileForStrip(w http.ResponseWriter, r *http.Request) {
-	if err := lh.lsvc.RemoveProfileForStrip(getParam(r, "id")); err != nil {
+	if err := lh.lsvc.WithActor(restActor(r)).RemoveProfileForStrip(getParam(r, "id")); err != nil {
+		handleErr(&w, err)
+		return
+	}
+
+	handleJSON(&w, http.StatusNoContent, nil)
+}
+
+// SetTimerForStrip start a timer, which performs its action on the strip once the duration has passed
+func (lh *ledHandlerImpl) SetTimerForStrip(w http.ResponseWriter, r *http.Request) {
+	// Validate input
+	var input model.TimerRequest
+	if err := bindJSON(r, &input); err != nil {
+		handleErr(&w, model.NewAppErr(http.StatusBadRequest, err))
+		return
+	}
+	timer, err := lh.lsvc.WithActor(restActor(r)).SetTimer(getParam(r, "id"), input)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+
+	handleJSON(&w, http.StatusCreated, timer)
+}
+
+// CancelTimerForStrip stop the running timer of the strip without performing its action
+func (lh *ledHandlerImpl) CancelTimerForStrip(w http.ResponseWriter, r *http.Request) {
+	if err := lh.lsvc.WithActor(restActor(r)).CancelTimer(getParam(r, "id")); err != nil {
 		handleErr(&w, err)
 		return
 	}
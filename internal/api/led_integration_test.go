@@ -1,13 +1,20 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	"github.com/pthum/stripcontrol-golang/internal/database/csv"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	mhm "github.com/pthum/stripcontrol-golang/internal/messaging/mocks"
 	"github.com/pthum/stripcontrol-golang/internal/model"
 	"github.com/pthum/stripcontrol-golang/internal/service"
 	"github.com/samber/do"
@@ -63,6 +70,7 @@ func TestGetAllLEDStripsIT_Error(t *testing.T) {
 func TestGetLEDStripIT(t *testing.T) {
 	mocks := createLEDHandlerITMocks(t)
 	retObj := createValidDummyStrip()
+	retObj.Version = 4
 	reqId := idStr(retObj.ID)
 	mocks.expectDBStripGet(retObj, nil)
 	req, w := prepareHttpTest(http.MethodGet, ledstripIDPath, uv{"id": reqId}, nil)
@@ -76,6 +84,7 @@ func TestGetLEDStripIT(t *testing.T) {
 
 	assert.Equal(t, *retObj, result)
 	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `"4"`, res.Header.Get("ETag"))
 }
 
 func TestGetLEDStripIT_Error(t *testing.T) {
@@ -98,6 +107,7 @@ func TestCreateLEDStripIT(t *testing.T) {
 	mocks := createLEDHandlerITMocks(t)
 	reqObj := createValidDummyStrip()
 	var newId int64
+	mocks.expectDBStripNextID(500)
 	mocks.lsDbh.
 		EXPECT().
 		Create(mock.Anything).
@@ -109,7 +119,7 @@ func TestCreateLEDStripIT(t *testing.T) {
 		Return(nil).
 		Once()
 	body := objToReader(t, reqObj)
-	mocks.expectPublishStripEvent(t, model.Save, newId, true, false, nil)
+	mocks.expectPublishStripEvent(t, model.Save, 500, true, false, nil)
 	req, w := prepareHttpTest(http.MethodPost, ledstripPath, nil, body)
 
 	mocks.lh.CreateLedStrip(w, req)
@@ -133,6 +143,7 @@ func TestCreateLEDStripIT(t *testing.T) {
 func TestCreateLEDStripIT_SaveError(t *testing.T) {
 	mocks := createLEDHandlerITMocks(t)
 	reqObj := createValidDummyStrip()
+	mocks.expectDBStripNextID(500)
 	mocks.lsDbh.
 		EXPECT().
 		Create(mock.Anything).
@@ -149,10 +160,33 @@ func TestCreateLEDStripIT_SaveError(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
 }
 
+func TestCreateLEDStripIT_Invalid(t *testing.T) {
+	mocks := createLEDHandlerITMocks(t)
+	reqObj := createValidDummyStrip()
+	reqObj.SpeedHz = null.IntFrom(0)
+	reqObj.MisoPin = null.IntFrom(40)
+	body := objToReader(t, reqObj)
+	req, w := prepareHttpTest(http.MethodPost, ledstripPath, nil, body)
+
+	mocks.lh.CreateLedStrip(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	var result model.ValidationError
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	assert.Equal(t, []model.FieldError{
+		{Field: "misoPin", Message: "must be between 0 and 27"},
+		{Field: "speedHz", Message: "must be between 1 and 32000000"},
+	}, result.Errors)
+}
+
 func TestCreateLEDStripIT_PublishError(t *testing.T) {
 	mocks := createLEDHandlerITMocks(t)
 	reqObj := createValidDummyStrip()
 	var newId int64
+	mocks.expectDBStripNextID(500)
 	mocks.lsDbh.
 		EXPECT().
 		Create(mock.Anything).
@@ -164,7 +198,7 @@ func TestCreateLEDStripIT_PublishError(t *testing.T) {
 		Return(nil).
 		Once()
 	body := objToReader(t, reqObj)
-	mocks.expectPublishStripEvent(t, model.Save, newId, true, false, errors.New("publish failed"))
+	mocks.expectPublishStripEvent(t, model.Save, 500, true, false, errors.New("publish failed"))
 	req, w := prepareHttpTest(http.MethodPost, ledstripPath, nil, body)
 
 	mocks.lh.CreateLedStrip(w, req)
@@ -205,6 +239,63 @@ func TestDeleteLEDStripIT(t *testing.T) {
 	assert.Equal(t, http.StatusNoContent, res.StatusCode)
 }
 
+func TestDeleteLEDStripIT_Broker(t *testing.T) {
+	mocks, broker := createLEDHandlerBrokerITMocks(t)
+	dbObj := createValidDummyStrip()
+	mocks.expectDBStripGet(dbObj, nil)
+	mocks.lsDbh.
+		EXPECT().
+		Delete(dbObj).
+		Return(nil)
+	req, w := prepareHttpTest(http.MethodDelete, ledstripIDPath, uv{"id": "185"}, nil)
+
+	mocks.lh.DeleteLedStrip(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	assert.JSONEq(t, `{"type":"DELETE","id":185,"state":null}`, string(broker.next(t, "ledstrip")))
+	// the retained state is removed
+	assert.Empty(t, broker.next(t, "ledstrip/185/state"))
+}
+
+func TestDeleteLEDStripIT_IfMatch(t *testing.T) {
+	mocks := createLEDHandlerITMocks(t)
+	getObj := createValidDummyStrip()
+	getObj.Version = 3
+	mocks.expectDBStripGet(getObj, nil)
+	mocks.lsDbh.
+		EXPECT().
+		Delete(mock.Anything).
+		Return(nil)
+	mocks.expectPublishStripEvent(t, model.Delete, getObj.ID, false, false, nil)
+	req, w := prepareHttpTest(http.MethodDelete, ledstripIDPath, uv{"id": "185"}, nil)
+	req.Header.Set("If-Match", `"3"`)
+
+	mocks.lh.DeleteLedStrip(w, req)
+
+	// small sleep to have the async routines run
+	time.Sleep(50 * time.Millisecond)
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
+
+func TestDeleteLEDStripIT_IfMatchMismatch(t *testing.T) {
+	mocks := createLEDHandlerITMocks(t)
+	getObj := createValidDummyStrip()
+	getObj.Version = 3
+	mocks.expectDBStripGet(getObj, nil)
+	req, w := prepareHttpTest(http.MethodDelete, ledstripIDPath, uv{"id": "185"}, nil)
+	req.Header.Set("If-Match", `"2"`)
+
+	mocks.lh.DeleteLedStrip(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusPreconditionFailed, res.StatusCode)
+}
+
 func TestDeleteLEDStripIT_MissingDBStrip(t *testing.T) {
 	mocks := createLEDHandlerITMocks(t)
 	mocks.expectDBStripGet(nil, errors.New("not found"))
@@ -294,6 +385,66 @@ func TestUpdateLEDStripIT(t *testing.T) {
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 }
 
+func TestUpdateLEDStripIT_Unchanged(t *testing.T) {
+	i := do.New()
+	cfg := &config.Config{CSV: config.CSVConfig{DataDir: t.TempDir()}}
+	do.ProvideValue(i, cfg)
+	lsDbh := csv.NewHandler[model.LedStrip](&cfg.CSV)
+	do.ProvideValue[database.DBHandler[model.LedStrip]](i, lsDbh)
+	do.ProvideValue[database.DBHandler[model.ColorProfile]](i, csv.NewHandler[model.ColorProfile](&cfg.CSV))
+	provideTimerDeps(i, t)
+	mh := mhm.NewEventHandler(t)
+	mh.EXPECT().PublishStripEvent(mock.Anything).Return(nil).Maybe()
+	do.ProvideValue[messaging.EventHandler](i, mh)
+	ls, err := service.NewLEDService(i)
+	assert.NoError(t, err)
+	do.ProvideValue(i, ls)
+	lh, err := NewLEDHandler(i)
+	assert.NoError(t, err)
+	strip := createValidDummyStrip()
+	assert.NoError(t, lsDbh.Create(strip))
+
+	// an unchanged strip still gets a new version, which the next request can match
+	etag := `"1"`
+	for _, expected := range []string{`"2"`, `"3"`} {
+		req, w := prepareHttpTest(http.MethodPut, ledstripIDPath, uv{"id": "185"}, objToReader(t, strip))
+		req.Header.Set("If-Match", etag)
+		lh.(*ledHandlerImpl).UpdateLedStrip(w, req)
+		res := w.Result()
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		etag = res.Header.Get("ETag")
+		assert.Equal(t, expected, etag)
+	}
+	stored, err := lsDbh.Get("185")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), stored.Version)
+}
+
+func TestUpdateLEDStripIT_VersionConflict(t *testing.T) {
+	dbObj := createValidDummyStrip()
+	dbObj.Version = 2
+	inputObj := createValidDummyStrip()
+	inputObj.Name = "changed"
+
+	mocks := createLEDHandlerITMocks(t)
+	mocks.expectDBStripGet(dbObj, nil)
+	// the strip has been changed between reading and writing it
+	mocks.lsDbh.
+		EXPECT().
+		Update(*dbObj, mock.Anything).
+		Return(database.ErrVersionConflict)
+	body := objToReader(t, inputObj)
+	req, w := prepareHttpTest(http.MethodPut, ledstripIDPath, uv{"id": "185"}, body)
+	req.Header.Set("If-Match", `"2"`)
+
+	mocks.lh.UpdateLedStrip(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusPreconditionFailed, res.StatusCode)
+}
+
 func TestUpdateLEDStripIT_MissingDBProfile(t *testing.T) {
 	inputObj := createValidDummyStrip()
 	inputObj.ProfileID = null.IntFrom(15)
@@ -393,6 +544,130 @@ func TestUpdateLEDStripIT_PublishError(t *testing.T) {
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 }
 
+func TestPatchLEDStripIT(t *testing.T) {
+	dbObj := createValidDummyStrip()
+	dbObj.ProfileID = null.IntFrom(15)
+	fakeProfile := createDummyProfile()
+	fakeProfile.ID = 15
+	expected := *dbObj
+	expected.Enabled = true
+	expected.Description = ""
+
+	mocks := createLEDHandlerITMocks(t)
+	mocks.expectDBStripGet(dbObj, nil)
+	mocks.lsDbh.
+		EXPECT().
+		Update(*dbObj, expected).
+		Return(nil)
+	mocks.expectPublishStripEvent(t, model.Save, dbObj.ID, true, true, nil)
+	mocks.expectDBProfileGet(fakeProfile, nil)
+	body := strings.NewReader(`{"enabled":true,"description":null}`)
+	req, w := prepareHttpTest(http.MethodPatch, ledstripIDPath, uv{"id": "185"}, body)
+	req.Header.Set("Content-Type", model.MergePatchContentType)
+
+	mocks.lh.PatchLedStrip(w, req)
+
+	// small sleep to have the async routines run
+	time.Sleep(50 * time.Millisecond)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	var result model.LedStrip
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	// the result has the version after the update
+	expected.Version = dbObj.Version + 1
+	assert.Equal(t, expected, result)
+	assert.Equal(t, `"1"`, res.Header.Get("ETag"))
+}
+
+func TestPatchLEDStripIT_Broker(t *testing.T) {
+	dbObj := createValidDummyStrip()
+	expected := *dbObj
+	expected.Enabled = true
+	mocks, broker := createLEDHandlerBrokerITMocks(t)
+	mocks.expectDBStripGet(dbObj, nil)
+	mocks.lsDbh.
+		EXPECT().
+		Update(*dbObj, expected).
+		Return(nil)
+	body := strings.NewReader(`{"enabled":true}`)
+	req, w := prepareHttpTest(http.MethodPatch, ledstripIDPath, uv{"id": "185"}, body)
+	req.Header.Set("Content-Type", model.MergePatchContentType)
+
+	mocks.lh.PatchLedStrip(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var event model.StripEvent
+	assert.NoError(t, json.Unmarshal(broker.next(t, "ledstrip"), &event))
+	assert.Equal(t, model.Save, event.Type)
+	assert.Equal(t, int64(185), event.ID.Int64)
+	assert.True(t, event.Strip.Valid)
+	assert.True(t, event.Strip.Strip.Enabled)
+	// the retained state of the strip is updated as well
+	var state model.StripEvent
+	assert.NoError(t, json.Unmarshal(broker.next(t, "ledstrip/185/state"), &state))
+	assert.Equal(t, event, state)
+}
+
+func TestPatchLEDStripIT_IfMatchMismatch(t *testing.T) {
+	mocks := createLEDHandlerITMocks(t)
+	dbObj := createValidDummyStrip()
+	dbObj.Version = 5
+	mocks.expectDBStripGet(dbObj, nil)
+	body := strings.NewReader(`{"enabled":true}`)
+	req, w := prepareHttpTest(http.MethodPatch, ledstripIDPath, uv{"id": "185"}, body)
+	req.Header.Set("If-Match", `"4"`)
+
+	mocks.lh.PatchLedStrip(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusPreconditionFailed, res.StatusCode)
+}
+
+func TestPatchLEDStripIT_MissingDBStrip(t *testing.T) {
+	mocks := createLEDHandlerITMocks(t)
+	mocks.expectDBStripGet(nil, errors.New("not found"))
+	body := strings.NewReader(`{"enabled":true}`)
+	req, w := prepareHttpTest(http.MethodPatch, ledstripIDPath, uv{"id": "185"}, body)
+
+	mocks.lh.PatchLedStrip(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestPatchLEDStripIT_MalformedBody(t *testing.T) {
+	mocks := createLEDHandlerITMocks(t)
+	mocks.expectDBStripGet(createValidDummyStrip(), nil)
+	body := strings.NewReader(`{"enabled":`)
+	req, w := prepareHttpTest(http.MethodPatch, ledstripIDPath, uv{"id": "185"}, body)
+
+	mocks.lh.PatchLedStrip(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestPatchLEDStripIT_UnsupportedContentType(t *testing.T) {
+	mocks := createLEDHandlerITMocks(t)
+	body := strings.NewReader(`enabled=true`)
+	req, w := prepareHttpTest(http.MethodPatch, ledstripIDPath, uv{"id": "185"}, body)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	mocks.lh.PatchLedStrip(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+}
+
 func TestGetProfileForLEDStripIT(t *testing.T) {
 	fakeProfile := createDummyProfile()
 	returnObj := createValidDummyStrip()
@@ -754,12 +1029,21 @@ func (lhm *lhITMocks) expectPublishStripEvent(t *testing.T, typ model.EventType,
 		Return(publishError)
 }
 
+// createLEDHandlerBrokerITMocks creates the handler publishing the events to an embedded broker
+func createLEDHandlerBrokerITMocks(t *testing.T) (*lhITMocks, *brokerIT) {
+	i := do.New()
+	bm, broker := createBrokerBaseMocks(i, t)
+	lh, err := NewLEDHandler(i)
+	assert.NoError(t, err)
+	return &lhITMocks{
+		baseMocks: bm,
+		lh:        lh.(*ledHandlerImpl),
+	}, broker
+}
+
 func createLEDHandlerITMocks(t *testing.T) *lhITMocks {
 	i := do.New()
 	bm := createBaseMocks(i, t)
-	ls, err := service.NewLEDService(i)
-	assert.NoError(t, err)
-	do.ProvideValue(i, ls)
 	lh, err := NewLEDHandler(i)
 	assert.NoError(t, err)
 	return &lhITMocks{
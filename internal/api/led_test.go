@@ -25,7 +25,7 @@ type lhMocks struct {
 func TestLedRoutes(t *testing.T) {
 	mcks := createLEDHandlerMocks(t)
 	routes := mcks.lh.ledRoutes()
-	assert.Equal(t, 8, len(routes))
+	assert.Equal(t, 11, len(routes))
 }
 
 func TestGetAllLEDStrips(t *testing.T) {
@@ -76,16 +76,51 @@ func TestGetLEDStrip(t *testing.T) {
 		GetLEDStrip(reqId).
 		Return(retObj, nil).
 		Once()
+	mocks.lsvc.
+		EXPECT().
+		GetTimer(retObj.ID).
+		Return(nil).
+		Once()
 	req, w := prepareHttpTest(http.MethodGet, ledstripIDPath, uv{"id": reqId}, nil)
 
 	mocks.lh.GetLedStrip(w, req)
 	res := w.Result()
 	defer res.Body.Close()
 
-	var result model.LedStrip
+	var result model.StripInfo
 	bodyToObj(t, res, &result)
 
-	assert.Equal(t, *retObj, result)
+	assert.Equal(t, *retObj, result.LedStrip)
+	assert.Nil(t, result.Timer)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestGetLEDStrip_Timer(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	retObj := createValidDummyStrip()
+	reqId := idStr(retObj.ID)
+	timer := createDummyTimerInfo()
+	mocks.lsvc.
+		EXPECT().
+		GetLEDStrip(reqId).
+		Return(retObj, nil).
+		Once()
+	mocks.lsvc.
+		EXPECT().
+		GetTimer(retObj.ID).
+		Return(timer).
+		Once()
+	req, w := prepareHttpTest(http.MethodGet, ledstripIDPath, uv{"id": reqId}, nil)
+
+	mocks.lh.GetLedStrip(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	var result model.StripInfo
+	bodyToObj(t, res, &result)
+
+	assert.Equal(t, *retObj, result.LedStrip)
+	assert.Equal(t, timer, result.Timer)
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 }
 
@@ -171,7 +206,7 @@ func TestDeleteLEDStrip(t *testing.T) {
 	mocks := createLEDHandlerMocks(t)
 	mocks.lsvc.
 		EXPECT().
-		DeleteLEDStrip(mock.Anything).
+		DeleteLEDStrip(mock.Anything, int64(0)).
 		Return(nil).
 		Once()
 	req, w := prepareHttpTest(http.MethodDelete, ledstripIDPath, uv{"id": "185"}, nil)
@@ -187,7 +222,7 @@ func TestDeleteLEDStrip_DeleteError(t *testing.T) {
 	mocks := createLEDHandlerMocks(t)
 	mocks.lsvc.
 		EXPECT().
-		DeleteLEDStrip(mock.Anything).
+		DeleteLEDStrip(mock.Anything, int64(0)).
 		Return(assert.AnError).
 		Once()
 	req, w := prepareHttpTest(http.MethodDelete, ledstripIDPath, uv{"id": "185"}, nil)
@@ -207,10 +242,12 @@ func TestUpdateLEDStrip(t *testing.T) {
 
 	mocks := createLEDHandlerMocks(t)
 	body := objToReader(t, inputObj)
+	stored := *inputObj
+	stored.Version = 3
 	mocks.lsvc.
 		EXPECT().
 		UpdateLEDStrip(mock.Anything, mock.Anything).
-		Return(nil).
+		Return(&stored, nil).
 		Once()
 
 	req, w := prepareHttpTest(http.MethodPut, ledstripIDPath, uv{"id": "185"}, body)
@@ -219,8 +256,12 @@ func TestUpdateLEDStrip(t *testing.T) {
 
 	res := w.Result()
 	defer res.Body.Close()
+	var result model.LedStrip
+	bodyToObj(t, res, &result)
 
 	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `"3"`, res.Header.Get("ETag"))
+	assert.Equal(t, stored, result)
 }
 
 func TestUpdateLEDStrip_MissingBody(t *testing.T) {
@@ -242,7 +283,7 @@ func TestUpdateLEDStrip_UpdateError(t *testing.T) {
 	mocks.lsvc.
 		EXPECT().
 		UpdateLEDStrip(mock.Anything, mock.Anything).
-		Return(assert.AnError).
+		Return(nil, assert.AnError).
 		Once()
 	body := objToReader(t, inputObj)
 	req, w := prepareHttpTest(http.MethodPut, ledstripIDPath, uv{"id": "185"}, body)
@@ -425,6 +466,100 @@ func TestRemoveProfileForLEDStrip_Error(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
 }
 
+func TestSetTimerForLEDStrip(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	reqId := idStr(185)
+	input := model.TimerRequest{Duration: "30m"}
+	timer := createDummyTimerInfo()
+	mocks.lsvc.
+		EXPECT().
+		SetTimer(reqId, input).
+		Return(timer, nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodPost, ledstripIDTimerPath, uv{"id": reqId}, objToReader(t, input))
+
+	mocks.lh.SetTimerForStrip(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	var result model.TimerInfo
+	bodyToObj(t, res, &result)
+
+	assert.Equal(t, *timer, result)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+}
+
+func TestSetTimerForLEDStrip_MissingBody(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	req, w := prepareHttpTest(http.MethodPost, ledstripIDTimerPath, uv{"id": "185"}, nil)
+
+	mocks.lh.SetTimerForStrip(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestSetTimerForLEDStrip_Error(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	input := model.TimerRequest{Duration: "30"}
+	mocks.lsvc.
+		EXPECT().
+		SetTimer("185", input).
+		Return(nil, model.NewAppErr(http.StatusUnprocessableEntity, assert.AnError)).
+		Once()
+	req, w := prepareHttpTest(http.MethodPost, ledstripIDTimerPath, uv{"id": "185"}, objToReader(t, input))
+
+	mocks.lh.SetTimerForStrip(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+}
+
+func TestCancelTimerForLEDStrip(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	mocks.lsvc.
+		EXPECT().
+		CancelTimer("185").
+		Return(nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodDelete, ledstripIDTimerPath, uv{"id": "185"}, nil)
+
+	mocks.lh.CancelTimerForStrip(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
+
+func TestCancelTimerForLEDStrip_Error(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	mocks.lsvc.
+		EXPECT().
+		CancelTimer("185").
+		Return(model.NewAppErr(http.StatusNotFound, assert.AnError)).
+		Once()
+	req, w := prepareHttpTest(http.MethodDelete, ledstripIDTimerPath, uv{"id": "185"}, nil)
+
+	mocks.lh.CancelTimerForStrip(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func createDummyTimerInfo() *model.TimerInfo {
+	return &model.TimerInfo{
+		Timer: model.Timer{
+			BaseModel: model.BaseModel{ID: 185, Version: 1},
+			Deadline:  time.Date(2024, 5, 6, 7, 30, 0, 0, time.UTC),
+			Action:    model.ActionOff,
+		},
+		Remaining: 1800,
+	}
+}
+
 func createValidDummyStrip() *model.LedStrip {
 	return &model.LedStrip{
 		BaseModel:   model.BaseModel{ID: 185},
@@ -442,7 +577,8 @@ func createLEDHandlerMocks(t *testing.T) *lhMocks {
 	i := do.New()
 	bm := createBaseMocks(i, t)
 	ls := servicemocks.NewLEDService(t)
-	do.ProvideValue[service.LEDService](i, ls)
+	ls.EXPECT().WithActor("rest:192.0.2.1").Return(ls).Maybe()
+	do.OverrideValue[service.LEDService](i, ls)
 	lh, err := NewLEDHandler(i)
 	assert.NoError(t, err)
 	return &lhMocks{
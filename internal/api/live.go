@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/service"
+	"github.com/samber/do"
+)
+
+const (
+	ledstripIDLivePath     = ledstripIDPath + "/live"
+	defaultPreviewInterval = 100 * time.Millisecond
+	// liveReadLimit the maximum size of a message of the client
+	liveReadLimit = 1024
+)
+
+type LiveHandler interface {
+	LiveLedStrip(w http.ResponseWriter, r *http.Request)
+}
+
+type liveHandlerImpl struct {
+	lsvc     service.LEDService
+	upgrader websocket.Upgrader
+	interval time.Duration
+	l        alog.Logger
+}
+
+func NewLiveHandler(i *do.Injector) (LiveHandler, error) {
+	cfg := do.MustInvoke[*config.Config](i)
+	lsvc := do.MustInvoke[service.LEDService](i)
+	interval := cfg.Messaging.PreviewInterval
+	if interval <= 0 {
+		interval = defaultPreviewInterval
+	}
+	return &liveHandlerImpl{
+		lsvc:     lsvc,
+		interval: interval,
+		l:        alog.NewLogger("livehandler"),
+	}, nil
+}
+
+func (h *liveHandlerImpl) liveRoutes() []Route {
+	return []Route{
+		{http.MethodGet, ledstripIDLivePath, h.LiveLedStrip},
+	}
+}
+
+// LiveLedStrip opens a websocket for the live color adjustment of a strip. Previews are forwarded to the strip at
+// most once per interval, the latest one wins. A commit stores the color, if the client leaves without a commit,
+// the stored state is restored.
+func (h *liveHandlerImpl) LiveLedStrip(w http.ResponseWriter, r *http.Request) {
+	id := getParam(r, "id")
+	if _, err := h.lsvc.GetLEDStrip(id); err != nil {
+		handleError(&w, http.StatusNotFound, stripNotFoundMsg)
+		return
+	}
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already replied with an error
+		h.l.Warn("error upgrading the connection: %s", err)
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(liveReadLimit)
+
+	messages := make(chan []byte)
+	go func() {
+		defer close(messages)
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			messages <- data
+		}
+	}()
+	s := &liveSession{id: id, conn: conn, lsvc: h.lsvc.WithActor(restActor(r)), l: h.l}
+	s.run(messages, h.interval)
+}
+
+// liveSession the state of a live connection, it is only accessed by the goroutine running it
+type liveSession struct {
+	id   string
+	conn *websocket.Conn
+	lsvc service.LEDService
+	l    alog.Logger
+	// pending the preview waiting for the end of the interval
+	pending *model.ColorProfile
+	// uncommitted the values of all previews since the last commit
+	uncommitted *model.ColorProfile
+}
+
+// run handles the messages until the client disconnects
+func (s *liveSession) run(messages <-chan []byte, interval time.Duration) {
+	var wait <-chan time.Time
+	for {
+		select {
+		case data, ok := <-messages:
+			if !ok {
+				s.restore()
+				return
+			}
+			if err := s.handle(data); err != nil {
+				s.reply(model.LiveReply{Type: model.LiveError, Error: err.Error()})
+			}
+			// the first preview is shown immediately, the following wait for the interval
+			if s.pending != nil && wait == nil {
+				s.flush()
+				wait = time.After(interval)
+			}
+		case <-wait:
+			wait = nil
+			if s.pending != nil {
+				s.flush()
+				wait = time.After(interval)
+			}
+		}
+	}
+}
+
+func (s *liveSession) handle(data []byte) error {
+	var msg model.LiveMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	color := msg.Color()
+	switch msg.Type {
+	case model.LivePreview:
+		s.pending = merged(s.pending, color)
+		s.uncommitted = merged(s.uncommitted, color)
+		return nil
+	case model.LiveCommit:
+		color = *merged(s.uncommitted, color)
+		if !color.Red.Valid && !color.Green.Valid && !color.Blue.Valid && !color.Brightness.Valid {
+			return errors.New("nothing to commit")
+		}
+		profile, err := s.lsvc.SetColorForStrip(s.id, color)
+		if err != nil {
+			return err
+		}
+		// a pending preview would overwrite the stored color
+		s.pending = nil
+		s.uncommitted = nil
+		s.reply(model.LiveReply{Type: model.LiveCommitted, Profile: profile})
+		return nil
+	default:
+		return errors.New("unknown message type " + msg.Type)
+	}
+}
+
+// flush publishes the pending preview
+func (s *liveSession) flush() {
+	color := *s.pending
+	s.pending = nil
+	if err := s.lsvc.PreviewColorForStrip(s.id, color); err != nil {
+		s.reply(model.LiveReply{Type: model.LiveError, Error: err.Error()})
+	}
+}
+
+// restore publishes the stored state, if previews weren't committed
+func (s *liveSession) restore() {
+	if s.uncommitted == nil {
+		return
+	}
+	if err := s.lsvc.PreviewColorForStrip(s.id, model.ColorProfile{}); err != nil {
+		s.l.Warn("error restoring the state of strip %s: %s", s.id, err)
+	}
+}
+
+func (s *liveSession) reply(reply model.LiveReply) {
+	if err := s.conn.WriteJSON(reply); err != nil {
+		s.l.Debug("error writing the reply: %s", err)
+	}
+}
+
+// merged returns base with the valid values of color, an empty profile is used for a missing base
+func merged(base *model.ColorProfile, color model.ColorProfile) *model.ColorProfile {
+	var result model.ColorProfile
+	if base != nil {
+		result = *base
+	}
+	result = result.WithColor(color)
+	return &result
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/service"
+	servicemocks "github.com/pthum/stripcontrol-golang/internal/service/mocks"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type liveMocks struct {
+	lsvc     *servicemocks.LEDService
+	lvh      *liveHandlerImpl
+	previews chan model.ColorProfile
+}
+
+func TestLiveRoutes(t *testing.T) {
+	mocks := createLiveMocks(t, time.Millisecond)
+	assert.Equal(t, 1, len(mocks.lvh.liveRoutes()))
+}
+
+func TestLiveLedStrip_Throttled(t *testing.T) {
+	mocks := createLiveMocks(t, 200*time.Millisecond)
+	mocks.expectStrip("1")
+	committed := model.ColorProfile{BaseModel: model.BaseModel{ID: 5, Version: 2}, Red: null.IntFrom(3), Green: null.IntFrom(5)}
+	mocks.lsvc.EXPECT().
+		SetColorForStrip("1", model.ColorProfile{Red: null.IntFrom(3), Green: null.IntFrom(5)}).
+		Return(&committed, nil).
+		Once()
+	conn := dialLive(t, mocks.lvh, "1")
+	defer conn.Close()
+
+	for _, msg := range []string{`{"type":"preview","red":1}`, `{"type":"preview","red":2}`, `{"type":"preview","red":3,"green":5}`} {
+		assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(msg)))
+	}
+
+	// the first preview is shown immediately, the others are combined after the interval
+	assert.Equal(t, model.ColorProfile{Red: null.IntFrom(1)}, <-mocks.previews)
+	start := time.Now()
+	assert.Equal(t, model.ColorProfile{Red: null.IntFrom(3), Green: null.IntFrom(5)}, <-mocks.previews)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	// the commit stores the values of the previews
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"commit"}`)))
+	var reply model.LiveReply
+	assert.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, model.LiveCommitted, reply.Type)
+	assert.Equal(t, &committed, reply.Profile)
+
+	// nothing to restore after the commit
+	conn.Close()
+	select {
+	case p := <-mocks.previews:
+		t.Fatalf("unexpected preview %v", p)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestLiveLedStrip_RestoreWithoutCommit(t *testing.T) {
+	mocks := createLiveMocks(t, time.Millisecond)
+	mocks.expectStrip("1")
+	conn := dialLive(t, mocks.lvh, "1")
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"preview","blue":7}`)))
+	assert.Equal(t, model.ColorProfile{Blue: null.IntFrom(7)}, <-mocks.previews)
+	conn.Close()
+
+	// the stored state is published again
+	assert.Equal(t, model.ColorProfile{}, <-mocks.previews)
+}
+
+func TestLiveLedStrip_Errors(t *testing.T) {
+	mocks := createLiveMocks(t, time.Millisecond)
+	mocks.expectStrip("1")
+	mocks.lsvc.EXPECT().
+		SetColorForStrip("1", mock.Anything).
+		Return(nil, errors.New("invalid color")).
+		Once()
+	conn := dialLive(t, mocks.lvh, "1")
+	defer conn.Close()
+
+	for msg, expected := range map[string]string{
+		`{"type":"preview"`:           "unexpected end",
+		`{"type":"dim"}`:              "unknown message type dim",
+		`{"type":"commit"}`:           "nothing to commit",
+		`{"type":"commit","red":300}`: "invalid color",
+	} {
+		assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(msg)))
+		var reply model.LiveReply
+		assert.NoError(t, conn.ReadJSON(&reply))
+		assert.Equal(t, model.LiveError, reply.Type)
+		assert.True(t, strings.Contains(reply.Error, expected), reply.Error)
+	}
+}
+
+func TestLiveLedStrip_UnknownStrip(t *testing.T) {
+	mocks := createLiveMocks(t, time.Millisecond)
+	mocks.lsvc.EXPECT().GetLEDStrip("9").Return(nil, errors.New("not found"))
+	req, w := prepareHttpTest(http.MethodGet, "/api/ledstrip/9/live", map[string]string{"id": "9"}, nil)
+
+	mocks.lvh.LiveLedStrip(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func (m *liveMocks) expectStrip(id string) {
+	m.lsvc.EXPECT().GetLEDStrip(id).Return(&model.LedStrip{}, nil).Once()
+	m.lsvc.EXPECT().
+		PreviewColorForStrip(id, mock.Anything).
+		Run(func(_ string, color model.ColorProfile) {
+			m.previews <- color
+		}).
+		Return(nil).
+		Maybe()
+}
+
+// dialLive opens the websocket of the strip on a test server
+func dialLive(t *testing.T, lvh *liveHandlerImpl, id string) *websocket.Conn {
+	router := mux.NewRouter()
+	router.HandleFunc(ledstripIDLivePath, lvh.LiveLedStrip)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/ledstrip/" + id + "/live"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NoError(t, err)
+	return conn
+}
+
+func createLiveMocks(t *testing.T, interval time.Duration) *liveMocks {
+	i := do.New()
+	do.ProvideValue(i, &config.Config{Messaging: config.MessagingConfig{PreviewInterval: interval}})
+	lsvc := servicemocks.NewLEDService(t)
+	lsvc.EXPECT().WithActor("rest:127.0.0.1").Return(lsvc).Maybe()
+	do.ProvideValue[service.LEDService](i, lsvc)
+	lvh, err := NewLiveHandler(i)
+	assert.NoError(t, err)
+	return &liveMocks{
+		lsvc:     lsvc,
+		lvh:      lvh.(*liveHandlerImpl),
+		previews: make(chan model.ColorProfile, 10),
+	}
+}
@@ -15,11 +15,29 @@ func NewRouter(i *do.Injector, enableDebug bool) *mux.Router {
 	router := mux.NewRouter().StrictSlash(true)
 	cph := do.MustInvoke[CPHandler](i).(*cpHandlerImpl)
 	lh := do.MustInvoke[LEDHandler](i).(*ledHandlerImpl)
+	sh := do.MustInvoke[StatusHandler](i).(*statusHandlerImpl)
+	eh := do.MustInvoke[EventsHandler](i).(*eventsHandlerImpl)
+	lvh := do.MustInvoke[LiveHandler](i).(*liveHandlerImpl)
+	sch := do.MustInvoke[SceneHandler](i).(*sceneHandlerImpl)
+	schh := do.MustInvoke[ScheduleHandler](i).(*scheduleHandlerImpl)
+	gh := do.MustInvoke[GroupHandler](i).(*groupHandlerImpl)
 	var routes []Route
 	var cproutes = cph.colorProfileRoutes()
 	var lroutes = lh.ledRoutes()
+	var sroutes = sh.statusRoutes()
+	var eroutes = eh.eventsRoutes()
+	var lvroutes = lvh.liveRoutes()
+	var scroutes = sch.sceneRoutes()
+	var schroutes = schh.scheduleRoutes()
+	var groutes = gh.groupRoutes()
 	routes = append(routes, cproutes...)
 	routes = append(routes, lroutes...)
+	routes = append(routes, sroutes...)
+	routes = append(routes, eroutes...)
+	routes = append(routes, lvroutes...)
+	routes = append(routes, scroutes...)
+	routes = append(routes, schroutes...)
+	routes = append(routes, groutes...)
 
 	for _, route := range routes {
 		l.Info("appending \"%v\": %v %v \n", route.HandlerName(), route.Method, route.Pattern)
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"net/http"
+
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/service"
+	"github.com/samber/do"
+)
+
+const (
+	sceneNotFoundMsg  = "Scene not found!"
+	scenePath         = "/api/scene"
+	sceneCapturePath  = scenePath + "/capture"
+	sceneIDPath       = scenePath + "/{id}"
+	sceneActivatePath = sceneIDPath + "/activate"
+)
+
+type SceneHandler interface {
+	GetAllScenes(w http.ResponseWriter, r *http.Request)
+	GetScene(w http.ResponseWriter, r *http.Request)
+	CreateScene(w http.ResponseWriter, r *http.Request)
+	UpdateScene(w http.ResponseWriter, r *http.Request)
+	DeleteScene(w http.ResponseWriter, r *http.Request)
+	ActivateScene(w http.ResponseWriter, r *http.Request)
+	CaptureScene(w http.ResponseWriter, r *http.Request)
+}
+
+type sceneHandlerImpl struct {
+	ss service.SceneService
+	l  alog.Logger
+}
+
+func NewSceneHandler(i *do.Injector) (SceneHandler, error) {
+	return &sceneHandlerImpl{
+		ss: do.MustInvoke[service.SceneService](i),
+		l:  alog.NewLogger("scenehandler"),
+	}, nil
+}
+
+func (h *sceneHandlerImpl) sceneRoutes() []Route {
+	return []Route{
+		{http.MethodGet, scenePath, h.GetAllScenes},
+		{http.MethodPost, scenePath, h.CreateScene},
+		{http.MethodPost, sceneCapturePath, h.CaptureScene},
+		{http.MethodGet, sceneIDPath, h.GetScene},
+		{http.MethodPut, sceneIDPath, h.UpdateScene},
+		{http.MethodDelete, sceneIDPath, h.DeleteScene},
+		{http.MethodPost, sceneActivatePath, h.ActivateScene},
+	}
+}
+
+// GetAllScenes get all scenes
+func (h *sceneHandlerImpl) GetAllScenes(w http.ResponseWriter, r *http.Request) {
+	scenes, err := h.ss.GetAll()
+	if err != nil {
+		handleError(&w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	handleJSON(&w, http.StatusOK, scenes)
+}
+
+// GetScene get a single scene
+func (h *sceneHandlerImpl) GetScene(w http.ResponseWriter, r *http.Request) {
+	scene, err := h.ss.GetScene(getParam(r, "id"))
+	if err != nil {
+		handleError(&w, http.StatusNotFound, sceneNotFoundMsg)
+		return
+	}
+
+	setETag(w, scene)
+	handleJSON(&w, http.StatusOK, scene)
+}
+
+// CreateScene create a scene
+func (h *sceneHandlerImpl) CreateScene(w http.ResponseWriter, r *http.Request) {
+	var input model.Scene
+	if err := bindJSON(r, &input); err != nil {
+		handleError(&w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.ss.CreateScene(&input); err != nil {
+		h.l.Error("Error: %s", err)
+		handleErrWithStatus(&w, err, http.StatusBadRequest)
+		return
+	}
+	respondWithCreated(r, w, &input)
+}
+
+// CaptureScene create a scene from the current state of the strips, all strips are captured if none are given
+func (h *sceneHandlerImpl) CaptureScene(w http.ResponseWriter, r *http.Request) {
+	var input model.Scene
+	if err := bindJSON(r, &input); err != nil {
+		handleError(&w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.ss.CaptureScene(&input); err != nil {
+		h.l.Error("Error: %s", err)
+		handleErrWithStatus(&w, err, http.StatusBadRequest)
+		return
+	}
+	w.Header().Add("Location", scenePath+"/"+input.GetStringID())
+	setETag(w, &input)
+	handleJSON(&w, http.StatusCreated, input)
+}
+
+// UpdateScene update a scene
+func (h *sceneHandlerImpl) UpdateScene(w http.ResponseWriter, r *http.Request) {
+	var input model.Scene
+	if err := bindJSON(r, &input); err != nil {
+		handleErr(&w, model.NewAppErr(http.StatusBadRequest, err))
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+	updMdl := input
+	updMdl.Version = version
+	scene, err := h.ss.UpdateScene(getParam(r, "id"), updMdl)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+
+	setETag(w, scene)
+	handleJSON(&w, http.StatusOK, scene)
+}
+
+// DeleteScene delete a scene
+func (h *sceneHandlerImpl) DeleteScene(w http.ResponseWriter, r *http.Request) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+	if err := h.ss.DeleteScene(getParam(r, "id"), version); err != nil {
+		handleErr(&w, err)
+		return
+	}
+
+	handleJSON(&w, http.StatusNoContent, nil)
+}
+
+// ActivateScene apply the states of the scene to its strips and return the changed strips
+func (h *sceneHandlerImpl) ActivateScene(w http.ResponseWriter, r *http.Request) {
+	strips, err := h.ss.WithActor(restActor(r)).ActivateScene(getParam(r, "id"))
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+
+	handleJSON(&w, http.StatusOK, strips)
+}
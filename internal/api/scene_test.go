@@ -0,0 +1,272 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/service"
+	servicemocks "github.com/pthum/stripcontrol-golang/internal/service/mocks"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type sceneMocks struct {
+	ss  *servicemocks.SceneService
+	sch *sceneHandlerImpl
+}
+
+func TestSceneRoutes(t *testing.T) {
+	mcks := createSceneHandlerMocks(t)
+	routes := mcks.sch.sceneRoutes()
+	assert.Equal(t, 7, len(routes))
+}
+
+func TestGetAllScenes(t *testing.T) {
+	mocks := createSceneHandlerMocks(t)
+	scene := createDummyScene()
+	mocks.ss.
+		EXPECT().
+		GetAll().
+		Return([]model.Scene{*scene}, nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodGet, scenePath, nil, nil)
+
+	mocks.sch.GetAllScenes(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	var result []model.Scene
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []model.Scene{*scene}, result)
+}
+
+func TestGetScene(t *testing.T) {
+	mocks := createSceneHandlerMocks(t)
+	scene := createDummyScene()
+	scene.Version = 3
+	mocks.ss.
+		EXPECT().
+		GetScene("7").
+		Return(scene, nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodGet, sceneIDPath, uv{"id": "7"}, nil)
+
+	mocks.sch.GetScene(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	var result model.Scene
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `"3"`, res.Header.Get("ETag"))
+	assert.Equal(t, *scene, result)
+}
+
+func TestGetScene_Error(t *testing.T) {
+	mocks := createSceneHandlerMocks(t)
+	mocks.ss.
+		EXPECT().
+		GetScene("7").
+		Return(nil, errors.New("not found")).
+		Once()
+	req, w := prepareHttpTest(http.MethodGet, sceneIDPath, uv{"id": "7"}, nil)
+
+	mocks.sch.GetScene(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestCreateScene(t *testing.T) {
+	mocks := createSceneHandlerMocks(t)
+	scene := createDummyScene()
+	mocks.ss.
+		EXPECT().
+		CreateScene(mock.Anything).
+		Return(nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodPost, scenePath, nil, objToReader(t, scene))
+
+	mocks.sch.CreateScene(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	var result model.Scene
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, *scene, result)
+	assert.Contains(t, res.Header.Get("Location"), idStr(scene.ID))
+}
+
+func TestCreateScene_Errors(t *testing.T) {
+	mocks := createSceneHandlerMocks(t)
+	var body io.Reader
+	req, w := prepareHttpTest(http.MethodPost, scenePath, nil, body)
+	mocks.sch.CreateScene(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+
+	mocks.ss.
+		EXPECT().
+		CreateScene(mock.Anything).
+		Return(model.NewAppErr(http.StatusUnprocessableEntity, &model.ValidationError{})).
+		Once()
+	req, w = prepareHttpTest(http.MethodPost, scenePath, nil, objToReader(t, createDummyScene()))
+	mocks.sch.CreateScene(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+}
+
+func TestCaptureScene(t *testing.T) {
+	mocks := createSceneHandlerMocks(t)
+	mocks.ss.
+		EXPECT().
+		CaptureScene(mock.Anything).
+		Run(func(mdl *model.Scene) {
+			assert.Equal(t, "Now", mdl.Name)
+			mdl.ID = 8
+			mdl.Strips = model.StripStates{{StripID: 185, Enabled: true}}
+		}).
+		Return(nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodPost, sceneCapturePath, nil, objToReader(t, model.Scene{Name: "Now"}))
+
+	mocks.sch.CaptureScene(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	var result model.Scene
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, scenePath+"/8", res.Header.Get("Location"))
+	assert.Len(t, result.Strips, 1)
+}
+
+func TestUpdateScene(t *testing.T) {
+	mocks := createSceneHandlerMocks(t)
+	scene := createDummyScene()
+	stored := *scene
+	stored.Version = 3
+	mocks.ss.
+		EXPECT().
+		UpdateScene("7", mock.Anything).
+		Run(func(id string, updMdl model.Scene) {
+			assert.Equal(t, int64(2), updMdl.Version)
+		}).
+		Return(&stored, nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodPut, sceneIDPath, uv{"id": "7"}, objToReader(t, scene))
+	req.Header.Set("If-Match", `"2"`)
+
+	mocks.sch.UpdateScene(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `"3"`, res.Header.Get("ETag"))
+}
+
+func TestUpdateScene_Error(t *testing.T) {
+	mocks := createSceneHandlerMocks(t)
+	mocks.ss.
+		EXPECT().
+		UpdateScene("7", mock.Anything).
+		Return(nil, model.NewAppErr(http.StatusPreconditionFailed, errors.New("version mismatch"))).
+		Once()
+	req, w := prepareHttpTest(http.MethodPut, sceneIDPath, uv{"id": "7"}, objToReader(t, createDummyScene()))
+
+	mocks.sch.UpdateScene(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Result().StatusCode)
+}
+
+func TestDeleteScene(t *testing.T) {
+	mocks := createSceneHandlerMocks(t)
+	mocks.ss.
+		EXPECT().
+		DeleteScene("7", int64(0)).
+		Return(nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodDelete, sceneIDPath, uv{"id": "7"}, nil)
+
+	mocks.sch.DeleteScene(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+}
+
+func TestDeleteScene_Error(t *testing.T) {
+	mocks := createSceneHandlerMocks(t)
+	mocks.ss.
+		EXPECT().
+		DeleteScene("7", int64(0)).
+		Return(model.NewAppErr(http.StatusNotFound, errors.New("not found"))).
+		Once()
+	req, w := prepareHttpTest(http.MethodDelete, sceneIDPath, uv{"id": "7"}, nil)
+
+	mocks.sch.DeleteScene(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+}
+
+func TestActivateScene(t *testing.T) {
+	mocks := createSceneHandlerMocks(t)
+	strip := model.LedStrip{BaseModel: model.BaseModel{ID: 185, Version: 2}, Enabled: true, ProfileID: null.IntFrom(15)}
+	mocks.ss.
+		EXPECT().
+		ActivateScene("7").
+		Return([]model.LedStrip{strip}, nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodPost, sceneActivatePath, uv{"id": "7"}, nil)
+
+	mocks.sch.ActivateScene(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	var result []model.LedStrip
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []model.LedStrip{strip}, result)
+}
+
+func TestActivateScene_Error(t *testing.T) {
+	mocks := createSceneHandlerMocks(t)
+	mocks.ss.
+		EXPECT().
+		ActivateScene("7").
+		Return(nil, model.NewAppErr(http.StatusNotFound, errors.New("strip 185 not found"))).
+		Once()
+	req, w := prepareHttpTest(http.MethodPost, sceneActivatePath, uv{"id": "7"}, nil)
+
+	mocks.sch.ActivateScene(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+}
+
+func createDummyScene() *model.Scene {
+	return &model.Scene{
+		BaseModel: model.BaseModel{ID: 7},
+		Name:      "Movie",
+		Strips: model.StripStates{
+			{StripID: 185, Enabled: true, ProfileID: null.IntFrom(15)},
+			{StripID: 186, Color: &model.ColorProfile{Red: null.IntFrom(255)}},
+		},
+	}
+}
+
+func createSceneHandlerMocks(t *testing.T) *sceneMocks {
+	i := do.New()
+	ss := servicemocks.NewSceneService(t)
+	ss.EXPECT().WithActor("rest:192.0.2.1").Return(ss).Maybe()
+	do.ProvideValue[service.SceneService](i, ss)
+	sch, err := NewSceneHandler(i)
+	assert.NoError(t, err)
+	return &sceneMocks{
+		ss:  ss,
+		sch: sch.(*sceneHandlerImpl),
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"net/http"
+
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/service"
+	"github.com/samber/do"
+)
+
+const (
+	scheduleNotFoundMsg = "Schedule not found!"
+	schedulePath        = "/api/schedule"
+	scheduleIDPath      = schedulePath + "/{id}"
+)
+
+type ScheduleHandler interface {
+	GetAllSchedules(w http.ResponseWriter, r *http.Request)
+	GetSchedule(w http.ResponseWriter, r *http.Request)
+	CreateSchedule(w http.ResponseWriter, r *http.Request)
+	UpdateSchedule(w http.ResponseWriter, r *http.Request)
+	DeleteSchedule(w http.ResponseWriter, r *http.Request)
+}
+
+type scheduleHandlerImpl struct {
+	ss service.ScheduleService
+	l  alog.Logger
+}
+
+func NewScheduleHandler(i *do.Injector) (ScheduleHandler, error) {
+	return &scheduleHandlerImpl{
+		ss: do.MustInvoke[service.ScheduleService](i),
+		l:  alog.NewLogger("schedulehandler"),
+	}, nil
+}
+
+func (h *scheduleHandlerImpl) scheduleRoutes() []Route {
+	return []Route{
+		{http.MethodGet, schedulePath, h.GetAllSchedules},
+		{http.MethodPost, schedulePath, h.CreateSchedule},
+		{http.MethodGet, scheduleIDPath, h.GetSchedule},
+		{http.MethodPut, scheduleIDPath, h.UpdateSchedule},
+		{http.MethodDelete, scheduleIDPath, h.DeleteSchedule},
+	}
+}
+
+// GetAllSchedules get all schedules with their next run
+func (h *scheduleHandlerImpl) GetAllSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.ss.GetAll()
+	if err != nil {
+		handleError(&w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	handleJSON(&w, http.StatusOK, schedules)
+}
+
+// GetSchedule get a single schedule with its next run
+func (h *scheduleHandlerImpl) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, err := h.ss.GetSchedule(getParam(r, "id"))
+	if err != nil {
+		handleError(&w, http.StatusNotFound, scheduleNotFoundMsg)
+		return
+	}
+
+	setETag(w, schedule)
+	handleJSON(&w, http.StatusOK, schedule)
+}
+
+// CreateSchedule create a schedule and respond with its next run
+func (h *scheduleHandlerImpl) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var input model.Schedule
+	if err := bindJSON(r, &input); err != nil {
+		handleError(&w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.ss.CreateSchedule(&input); err != nil {
+		h.l.Error("Error: %s", err)
+		handleErrWithStatus(&w, err, http.StatusBadRequest)
+		return
+	}
+	respondWithCreated(r, w, &model.ScheduleInfo{Schedule: input, NextRun: h.ss.NextRun(input.ID)})
+}
+
+// UpdateSchedule update a schedule and respond with the stored schedule and its next run
+func (h *scheduleHandlerImpl) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	var input model.Schedule
+	if err := bindJSON(r, &input); err != nil {
+		handleErr(&w, model.NewAppErr(http.StatusBadRequest, err))
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+	updMdl := input
+	updMdl.Version = version
+	if err := h.ss.UpdateSchedule(getParam(r, "id"), updMdl); err != nil {
+		handleErr(&w, err)
+		return
+	}
+	schedule, err := h.ss.GetSchedule(getParam(r, "id"))
+	if err != nil {
+		handleError(&w, http.StatusNotFound, scheduleNotFoundMsg)
+		return
+	}
+
+	setETag(w, schedule)
+	handleJSON(&w, http.StatusOK, schedule)
+}
+
+// DeleteSchedule delete a schedule
+func (h *scheduleHandlerImpl) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		handleErr(&w, err)
+		return
+	}
+	if err := h.ss.DeleteSchedule(getParam(r, "id"), version); err != nil {
+		handleErr(&w, err)
+		return
+	}
+
+	handleJSON(&w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/service"
+	servicemocks "github.com/pthum/stripcontrol-golang/internal/service/mocks"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type scheduleMocks struct {
+	ss  *servicemocks.ScheduleService
+	sch *scheduleHandlerImpl
+}
+
+func TestScheduleRoutes(t *testing.T) {
+	mcks := createScheduleHandlerMocks(t)
+	routes := mcks.sch.scheduleRoutes()
+	assert.Equal(t, 5, len(routes))
+}
+
+func TestGetAllSchedules(t *testing.T) {
+	mocks := createScheduleHandlerMocks(t)
+	nextRun := time.Date(2024, 5, 6, 5, 30, 0, 0, time.UTC)
+	info := model.ScheduleInfo{Schedule: *createDummySchedule(), NextRun: &nextRun}
+	mocks.ss.
+		EXPECT().
+		GetAll().
+		Return([]model.ScheduleInfo{info}, nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodGet, schedulePath, nil, nil)
+
+	mocks.sch.GetAllSchedules(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	var result []model.ScheduleInfo
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []model.ScheduleInfo{info}, result)
+}
+
+func TestGetAllSchedules_Error(t *testing.T) {
+	mocks := createScheduleHandlerMocks(t)
+	mocks.ss.
+		EXPECT().
+		GetAll().
+		Return(nil, errors.New("get error")).
+		Once()
+	req, w := prepareHttpTest(http.MethodGet, schedulePath, nil, nil)
+
+	mocks.sch.GetAllSchedules(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+}
+
+func TestGetSchedule(t *testing.T) {
+	mocks := createScheduleHandlerMocks(t)
+	info := &model.ScheduleInfo{Schedule: *createDummySchedule()}
+	info.Version = 2
+	mocks.ss.
+		EXPECT().
+		GetSchedule("3").
+		Return(info, nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodGet, scheduleIDPath, uv{"id": "3"}, nil)
+
+	mocks.sch.GetSchedule(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	var result model.ScheduleInfo
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `"2"`, res.Header.Get("ETag"))
+	assert.Equal(t, *info, result)
+}
+
+func TestGetSchedule_Error(t *testing.T) {
+	mocks := createScheduleHandlerMocks(t)
+	mocks.ss.
+		EXPECT().
+		GetSchedule("3").
+		Return(nil, errors.New("not found")).
+		Once()
+	req, w := prepareHttpTest(http.MethodGet, scheduleIDPath, uv{"id": "3"}, nil)
+
+	mocks.sch.GetSchedule(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+}
+
+func TestCreateSchedule(t *testing.T) {
+	mocks := createScheduleHandlerMocks(t)
+	schedule := createDummySchedule()
+	schedule.ID = 0
+	nextRun := time.Date(2024, 5, 6, 5, 30, 0, 0, time.UTC)
+	mocks.ss.
+		EXPECT().
+		CreateSchedule(mock.Anything).
+		Run(func(mdl *model.Schedule) {
+			mdl.ID = 4
+		}).
+		Return(nil).
+		Once()
+	mocks.ss.
+		EXPECT().
+		NextRun(int64(4)).
+		Return(&nextRun).
+		Once()
+	req, w := prepareHttpTest(http.MethodPost, schedulePath, nil, objToReader(t, schedule))
+
+	mocks.sch.CreateSchedule(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	var result model.ScheduleInfo
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, schedulePath+"/4", res.Header.Get("Location"))
+	assert.Equal(t, int64(4), result.ID)
+	assert.Equal(t, nextRun, *result.NextRun)
+}
+
+func TestCreateSchedule_Errors(t *testing.T) {
+	mocks := createScheduleHandlerMocks(t)
+	var body io.Reader
+	req, w := prepareHttpTest(http.MethodPost, schedulePath, nil, body)
+	mocks.sch.CreateSchedule(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+
+	mocks.ss.
+		EXPECT().
+		CreateSchedule(mock.Anything).
+		Return(model.NewAppErr(http.StatusUnprocessableEntity, &model.ValidationError{})).
+		Once()
+	req, w = prepareHttpTest(http.MethodPost, schedulePath, nil, objToReader(t, createDummySchedule()))
+	mocks.sch.CreateSchedule(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+}
+
+func TestUpdateSchedule(t *testing.T) {
+	mocks := createScheduleHandlerMocks(t)
+	schedule := createDummySchedule()
+	stored := &model.ScheduleInfo{Schedule: *schedule}
+	stored.Version = 3
+	mocks.ss.
+		EXPECT().
+		UpdateSchedule("3", mock.Anything).
+		Run(func(id string, updMdl model.Schedule) {
+			assert.Equal(t, int64(2), updMdl.Version)
+		}).
+		Return(nil).
+		Once()
+	mocks.ss.
+		EXPECT().
+		GetSchedule("3").
+		Return(stored, nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodPut, scheduleIDPath, uv{"id": "3"}, objToReader(t, schedule))
+	req.Header.Set("If-Match", `"2"`)
+
+	mocks.sch.UpdateSchedule(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `"3"`, res.Header.Get("ETag"))
+}
+
+func TestUpdateSchedule_Error(t *testing.T) {
+	mocks := createScheduleHandlerMocks(t)
+	mocks.ss.
+		EXPECT().
+		UpdateSchedule("3", mock.Anything).
+		Return(model.NewAppErr(http.StatusPreconditionFailed, errors.New("version mismatch"))).
+		Once()
+	req, w := prepareHttpTest(http.MethodPut, scheduleIDPath, uv{"id": "3"}, objToReader(t, createDummySchedule()))
+
+	mocks.sch.UpdateSchedule(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Result().StatusCode)
+}
+
+func TestDeleteSchedule(t *testing.T) {
+	mocks := createScheduleHandlerMocks(t)
+	mocks.ss.
+		EXPECT().
+		DeleteSchedule("3", int64(0)).
+		Return(nil).
+		Once()
+	req, w := prepareHttpTest(http.MethodDelete, scheduleIDPath, uv{"id": "3"}, nil)
+
+	mocks.sch.DeleteSchedule(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+}
+
+func TestDeleteSchedule_Error(t *testing.T) {
+	mocks := createScheduleHandlerMocks(t)
+	mocks.ss.
+		EXPECT().
+		DeleteSchedule("3", int64(0)).
+		Return(model.NewAppErr(http.StatusNotFound, errors.New("not found"))).
+		Once()
+	req, w := prepareHttpTest(http.MethodDelete, scheduleIDPath, uv{"id": "3"}, nil)
+
+	mocks.sch.DeleteSchedule(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+}
+
+func createDummySchedule() *model.Schedule {
+	return &model.Schedule{
+		BaseModel:  model.BaseModel{ID: 3},
+		Name:       "Wake up",
+		Weekdays:   model.Weekdays{"mon", "fri"},
+		Time:       "07:30",
+		Timezone:   "Europe/Berlin",
+		TargetType: model.TargetStrip,
+		TargetID:   185,
+		Action:     model.ActionSetProfile,
+		ProfileID:  null.IntFrom(15),
+	}
+}
+
+func createScheduleHandlerMocks(t *testing.T) *scheduleMocks {
+	i := do.New()
+	ss := servicemocks.NewScheduleService(t)
+	do.ProvideValue[service.ScheduleService](i, ss)
+	sch, err := NewScheduleHandler(i)
+	assert.NoError(t, err)
+	return &scheduleMocks{
+		ss:  ss,
+		sch: sch.(*scheduleHandlerImpl),
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/samber/do"
+)
+
+const (
+	statusPath = "/api/status"
+	// messagingDisabled the state of the messaging, if there is no broker connection
+	messagingDisabled = "disabled"
+)
+
+type StatusHandler interface {
+	GetStatus(w http.ResponseWriter, r *http.Request)
+}
+
+type statusHandlerImpl struct {
+	mh messaging.EventHandler
+	l  alog.Logger
+}
+
+func NewStatusHandler(i *do.Injector) (StatusHandler, error) {
+	mh := do.MustInvoke[messaging.EventHandler](i)
+	l := alog.NewLogger("statushandler")
+	return &statusHandlerImpl{
+		mh: mh,
+		l:  l,
+	}, nil
+}
+
+func (h *statusHandlerImpl) statusRoutes() []Route {
+	return []Route{
+		{http.MethodGet, statusPath, h.GetStatus},
+	}
+}
+
+// GetStatus get the runtime status
+func (h *statusHandlerImpl) GetStatus(w http.ResponseWriter, r *http.Request) {
+	var status model.Status
+	status.Messaging.State = messagingDisabled
+	if cr, ok := h.mh.(messaging.ConnectionReporter); ok {
+		status.Messaging.State = string(cr.ConnectionState())
+	}
+	// only asynchronous handlers have pending events
+	if pc, ok := h.mh.(messaging.PendingCounter); ok {
+		pending, err := pc.Pending()
+		if err != nil {
+			h.l.Error("Error: %s", err)
+			handleError(&w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		status.Messaging.PendingEvents = pending
+	}
+
+	handleJSON(&w, http.StatusOK, status)
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	mhm "github.com/pthum/stripcontrol-golang/internal/messaging/mocks"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/assert"
+)
+
+// pendingEventHandler an event handler with pending events
+type pendingEventHandler struct {
+	*mhm.EventHandler
+	pending int
+	err     error
+}
+
+func (p *pendingEventHandler) Pending() (int, error) {
+	return p.pending, p.err
+}
+
+func (p *pendingEventHandler) ConnectionState() messaging.ConnectionState {
+	return messaging.Connecting
+}
+
+func TestStatusRoutes(t *testing.T) {
+	sh := createStatusHandler(t, mhm.NewEventHandler(t))
+	assert.Equal(t, 1, len(sh.statusRoutes()))
+}
+
+func TestGetStatus(t *testing.T) {
+	sh := createStatusHandler(t, &pendingEventHandler{EventHandler: mhm.NewEventHandler(t), pending: 3})
+	req, w := prepareHttpTest(http.MethodGet, statusPath, nil, nil)
+
+	sh.GetStatus(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	var result model.Status
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 3, result.Messaging.PendingEvents)
+	assert.Equal(t, "connecting", result.Messaging.State)
+}
+
+func TestGetStatus_Synchronous(t *testing.T) {
+	sh := createStatusHandler(t, mhm.NewEventHandler(t))
+	req, w := prepareHttpTest(http.MethodGet, statusPath, nil, nil)
+
+	sh.GetStatus(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	var result model.Status
+	bodyToObj(t, res, &result)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 0, result.Messaging.PendingEvents)
+	assert.Equal(t, "disabled", result.Messaging.State)
+}
+
+func TestGetStatus_Error(t *testing.T) {
+	sh := createStatusHandler(t, &pendingEventHandler{EventHandler: mhm.NewEventHandler(t), err: errors.New("read error")})
+	req, w := prepareHttpTest(http.MethodGet, statusPath, nil, nil)
+
+	sh.GetStatus(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+}
+
+func createStatusHandler(t *testing.T, mh messaging.EventHandler) *statusHandlerImpl {
+	i := do.New()
+	do.ProvideValue(i, mh)
+	sh, err := NewStatusHandler(i)
+	assert.NoError(t, err)
+	return sh.(*statusHandlerImpl)
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 	"gopkg.in/yaml.v2"
@@ -12,29 +13,142 @@ import (
 // Config the configuration of this service
 type Config struct {
 	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
 	Messaging MessagingConfig `yaml:"messaging"`
 	CSV       CSVConfig       `yaml:"csv"`
 	Telegram  TelegramConfig  `yaml:"telegram"`
+	Location  LocationConfig  `yaml:"location"`
 }
 
 type ServerConfig struct {
 	Host string `yaml:"host" envconfig:"SERVER_HOST"`
 	Port string `yaml:"port" envconfig:"SERVER_PORT"`
 	Mode string `yaml:"mode" envconfig:"SERVER_MODE"`
+	// EventBuffer the number of events kept for clients resuming the event stream, defaults to 100
+	EventBuffer int `yaml:"eventbuffer" envconfig:"SERVER_EVENT_BUFFER"`
 }
 
+const (
+	DBTypeCSV    = "csv"
+	DBTypeSQLite = "sqlite"
+)
+
+const (
+	// ProfileDeleteReject rejects the deletion of profiles which are still referenced by strips
+	ProfileDeleteReject = "reject"
+	// ProfileDeleteDetach removes the profile from all referencing strips before deleting it
+	ProfileDeleteDetach = "detach"
+)
+
+const (
+	// EventFormatLegacy sends the plain events, as expected by the existing controllers
+	EventFormatLegacy = "legacy"
+	// EventFormatEnvelope wraps the events in an envelope with their metadata and schema version
+	EventFormatEnvelope = "envelope"
+)
+
+// DatabaseConfig the database configuration, for sqlite the host is the path to the database file
+type DatabaseConfig struct {
+	// Type the database backend, csv (default) or sqlite. The data isn't migrated when switching the backend.
+	Type                string `yaml:"type" envconfig:"DB_TYPE"`
+	User                string `yaml:"user" envconfig:"DB_USER"`
+	Pass                string `yaml:"pass" envconfig:"DB_PASS"`
+	Host                string `yaml:"host" envconfig:"DB_HOST"`
+	Port                string `yaml:"port" envconfig:"DB_PORT"`
+	Name                string `yaml:"name" envconfig:"DB_NAME"`
+	ProfileDeletePolicy string `yaml:"profiledeletepolicy" envconfig:"DB_PROFILE_DELETE_POLICY"`
+}
+
+// MessagingConfig the configuration of the message broker and the delivery of the events
 type MessagingConfig struct {
-	Host         string `yaml:"host" envconfig:"MQ_HOST"`
-	Port         string `yaml:"port" envconfig:"MQ_PORT"`
+	// Scheme the protocol of the broker connection, tcp, ssl, tls, mqtts, ws or wss, defaults to tcp
+	Scheme string `yaml:"scheme" envconfig:"MQ_SCHEME"`
+	Host   string `yaml:"host" envconfig:"MQ_HOST"`
+	Port   string `yaml:"port" envconfig:"MQ_PORT"`
+	// Username and Password authenticate the client at the broker, both are optional
+	Username string `yaml:"username" envconfig:"MQ_USERNAME"`
+	Password string `yaml:"password" envconfig:"MQ_PASSWORD"`
+	// CAFile the PEM encoded certificate(s) to verify the broker with, the system pool is used if empty
+	CAFile string `yaml:"cafile" envconfig:"MQ_CA_FILE"`
+	// CertFile and KeyFile the PEM encoded client certificate and key, for brokers requiring client certificates
+	CertFile string `yaml:"certfile" envconfig:"MQ_CERT_FILE"`
+	KeyFile  string `yaml:"keyfile" envconfig:"MQ_KEY_FILE"`
+	// ClientID the id of the client at the broker, defaults to stripcontrol-go
+	ClientID string `yaml:"clientid" envconfig:"MQ_CLIENT_ID"`
+	// KeepAlive the interval of the pings to the broker, the default of the client library is used if empty
+	KeepAlive time.Duration `yaml:"keepalive" envconfig:"MQ_KEEPALIVE"`
+	// StripTopic the topic of the strip events, the retained state of each strip is published to <striptopic>/<id>/state
 	StripTopic   string `yaml:"striptopic" envconfig:"MQ_STRIPTOPIC"`
 	ProfileTopic string `yaml:"profiletopic" envconfig:"MQ_STRIPTOPIC"`
-	Disabled     bool   `yaml:"disabled" envconfig:"MQ_DISABLED"`
+	// Disabled disables the message broker, the webhooks are used regardless
+	Disabled bool `yaml:"disabled" envconfig:"MQ_DISABLED"`
+	// QoS the quality of service of the published events, 1 or 2. 0 means the default 1, at most once delivery isn't
+	// supported, as an event is only removed from the outbox once it is acknowledged.
+	QoS int `yaml:"qos" envconfig:"MQ_QOS"`
+	// PublishTimeout the time to wait for the acknowledgement of an event
+	PublishTimeout time.Duration `yaml:"publishtimeout" envconfig:"MQ_PUBLISH_TIMEOUT"`
+	// RetryInterval the delay before the first retry of a failed delivery, it doubles with every attempt
+	RetryInterval time.Duration `yaml:"retryinterval" envconfig:"MQ_RETRY_INTERVAL"`
+	// MaxRetryInterval the upper limit of the delay between two retries
+	MaxRetryInterval time.Duration `yaml:"maxretryinterval" envconfig:"MQ_MAX_RETRY_INTERVAL"`
+	// EventFormat the format of the published events, legacy or envelope, defaults to legacy
+	EventFormat string `yaml:"eventformat" envconfig:"MQ_EVENT_FORMAT"`
+	// Source identifies this instance in the event envelope, defaults to the hostname
+	Source string `yaml:"source" envconfig:"MQ_SOURCE"`
+	// PreviewInterval the minimum time between two color previews of a strip, defaults to 100ms
+	PreviewInterval time.Duration `yaml:"previewinterval" envconfig:"MQ_PREVIEW_INTERVAL"`
+	// AvailabilityTopic the retained availability (online/offline) of the service, offline is set by the last will,
+	// defaults to <striptopic>/availability
+	AvailabilityTopic string `yaml:"availabilitytopic" envconfig:"MQ_AVAILABILITY_TOPIC"`
+	// Discovery enables the Home Assistant MQTT discovery of the strips
+	Discovery bool `yaml:"discovery" envconfig:"MQ_DISCOVERY"`
+	// DiscoveryPrefix the discovery prefix configured in Home Assistant, defaults to homeassistant
+	DiscoveryPrefix string `yaml:"discoveryprefix" envconfig:"MQ_DISCOVERY_PREFIX"`
+	// Webhooks the urls receiving the events in addition to the message broker
+	Webhooks []WebhookConfig `yaml:"webhooks" ignored:"true"`
+	// Broker the embedded message broker, the service connects to it instead of the configured host
+	Broker BrokerConfig `yaml:"broker"`
+}
+
+// BrokerConfig the configuration of the embedded message broker, for installations without an external broker.
+// The configured username and password are required from all clients, if set.
+type BrokerConfig struct {
+	Enable bool `yaml:"enable" envconfig:"MQ_BROKER_ENABLE"`
+	// Address the listen address of the broker, defaults to :1883
+	Address string `yaml:"address" envconfig:"MQ_BROKER_ADDRESS"`
+}
+
+// WebhookConfig the configuration of a webhook, which receives the events as http post requests
+type WebhookConfig struct {
+	URL string `yaml:"url"`
+	// Secret the key of the HMAC-SHA256 signature of the requests, they aren't signed if it is empty
+	Secret string `yaml:"secret"`
+	// Timeout the timeout of a single request, defaults to 10s
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxAttempts the number of attempts to deliver an event, defaults to 3
+	MaxAttempts int `yaml:"maxattempts"`
+	// RetryInterval the delay before the first retry, it doubles with every attempt, defaults to 1s
+	RetryInterval time.Duration `yaml:"retryinterval"`
 }
 type CSVConfig struct {
 	DataDir  string `yaml:"datadir"`
 	Interval int    `yaml:"intervalmin"`
 }
 
+// LocationConfig the position of the installation, the solar events of the schedules are computed for it. Solar
+// schedules are rejected as long as both are 0.
+type LocationConfig struct {
+	// Latitude in degrees, north is positive
+	Latitude float64 `yaml:"latitude" envconfig:"LOCATION_LATITUDE"`
+	// Longitude in degrees, east is positive
+	Longitude float64 `yaml:"longitude" envconfig:"LOCATION_LONGITUDE"`
+}
+
+// IsSet whether a location is configured
+func (l LocationConfig) IsSet() bool {
+	return l.Latitude != 0 || l.Longitude != 0
+}
+
 type TelegramConfig struct {
 	Enable         bool    `yaml:"enable" envconfig:"TG_ENABLE"`
 	EnableDebug    bool    `yaml:"enabledebug" envconfig:"TG_ENABLE_DEBUG"`
@@ -56,10 +170,35 @@ func InitConfig(configFile string) (cfg *Config, err error) {
 	if err = cfg.readConf(data); err != nil {
 		return cfg, err
 	}
-	log.Printf("%+v", cfg)
+	log.Printf("%+v", cfg.redacted())
 	return cfg, nil
 }
 
+// redactedValue replaces the credentials in the logged configuration
+const redactedValue = "***"
+
+// redacted returns a copy of the configuration without the credentials, which can be logged
+func (cfg *Config) redacted() *Config {
+	c := *cfg
+	c.Database.Pass = redact(c.Database.Pass)
+	c.Messaging.Password = redact(c.Messaging.Password)
+	c.Messaging.Webhooks = make([]WebhookConfig, len(cfg.Messaging.Webhooks))
+	for i, wh := range cfg.Messaging.Webhooks {
+		wh.Secret = redact(wh.Secret)
+		c.Messaging.Webhooks[i] = wh
+	}
+	c.Telegram.BotKey = redact(c.Telegram.BotKey)
+	return &c
+}
+
+// redact hides a set credential, an empty one stays empty to show that it isn't set
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redactedValue
+}
+
 func (cfg *Config) readConf(data []byte) (err error) {
 	err = yaml.Unmarshal(data, &cfg)
 	if err != nil {
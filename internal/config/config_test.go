@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -12,6 +13,7 @@ server:
   host: localhost
   port: 8080
   mode: debug
+  eventbuffer: 50
 database:
   type: sqlite
   user: sa
@@ -19,12 +21,44 @@ database:
   host: stripcontrol.sqlite
   port: 5432
   name: stripcontrol
+  profiledeletepolicy: detach
 messaging:
+  scheme: ssl
   host: mqtthost
   port: 1234
+  username: mquser
+  password: mqpass
+  cafile: /certs/ca.pem
+  certfile: /certs/client.pem
+  keyfile: /certs/client.key
+  clientid: stripcontrol-test
+  keepalive: 45s
   striptopic: ledstripz
   profiletopic: profilez
   disabled: true
+  qos: 2
+  publishtimeout: 5s
+  retryinterval: 500ms
+  maxretryinterval: 2m
+  previewinterval: 250ms
+  eventformat: envelope
+  source: living-room
+  availabilitytopic: stripcontrol/status
+  discovery: true
+  discoveryprefix: ha
+  webhooks:
+    - url: https://example.com/hook
+      secret: s3cret
+      timeout: 3s
+      maxattempts: 5
+      retryinterval: 200ms
+    - url: http://localhost:9000/events
+  broker:
+    enable: true
+    address: 127.0.0.1:1884
+location:
+  latitude: 52.52
+  longitude: -13.405
 `
 	conf := &Config{}
 	err := conf.readConf([]byte(testConf))
@@ -32,11 +66,65 @@ messaging:
 	assert.Equal(t, "localhost", conf.Server.Host)
 	assert.Equal(t, "8080", conf.Server.Port)
 	assert.Equal(t, "debug", conf.Server.Mode)
+	assert.Equal(t, 50, conf.Server.EventBuffer)
+	assert.Equal(t, DBTypeSQLite, conf.Database.Type)
+	assert.Equal(t, "stripcontrol.sqlite", conf.Database.Host)
+	assert.Equal(t, "stripcontrol", conf.Database.Name)
+	assert.Equal(t, ProfileDeleteDetach, conf.Database.ProfileDeletePolicy)
 	assert.Equal(t, "mqtthost", conf.Messaging.Host)
 	assert.Equal(t, "1234", conf.Messaging.Port)
+	assert.Equal(t, "ssl", conf.Messaging.Scheme)
+	assert.Equal(t, "mquser", conf.Messaging.Username)
+	assert.Equal(t, "mqpass", conf.Messaging.Password)
+	assert.Equal(t, "/certs/ca.pem", conf.Messaging.CAFile)
+	assert.Equal(t, "/certs/client.pem", conf.Messaging.CertFile)
+	assert.Equal(t, "/certs/client.key", conf.Messaging.KeyFile)
+	assert.Equal(t, "stripcontrol-test", conf.Messaging.ClientID)
+	assert.Equal(t, 45*time.Second, conf.Messaging.KeepAlive)
 	assert.Equal(t, "ledstripz", conf.Messaging.StripTopic)
 	assert.Equal(t, "profilez", conf.Messaging.ProfileTopic)
 	assert.Equal(t, true, conf.Messaging.Disabled)
+	assert.Equal(t, 2, conf.Messaging.QoS)
+	assert.Equal(t, 5*time.Second, conf.Messaging.PublishTimeout)
+	assert.Equal(t, 500*time.Millisecond, conf.Messaging.RetryInterval)
+	assert.Equal(t, 2*time.Minute, conf.Messaging.MaxRetryInterval)
+	assert.Equal(t, 250*time.Millisecond, conf.Messaging.PreviewInterval)
+	assert.Equal(t, EventFormatEnvelope, conf.Messaging.EventFormat)
+	assert.Equal(t, "living-room", conf.Messaging.Source)
+	assert.Equal(t, "stripcontrol/status", conf.Messaging.AvailabilityTopic)
+	assert.True(t, conf.Messaging.Discovery)
+	assert.Equal(t, "ha", conf.Messaging.DiscoveryPrefix)
+	assert.Equal(t, []WebhookConfig{
+		{URL: "https://example.com/hook", Secret: "s3cret", Timeout: 3 * time.Second, MaxAttempts: 5, RetryInterval: 200 * time.Millisecond},
+		{URL: "http://localhost:9000/events"},
+	}, conf.Messaging.Webhooks)
+	assert.Equal(t, BrokerConfig{Enable: true, Address: "127.0.0.1:1884"}, conf.Messaging.Broker)
+	assert.Equal(t, LocationConfig{Latitude: 52.52, Longitude: -13.405}, conf.Location)
+	assert.True(t, conf.Location.IsSet())
+	assert.False(t, LocationConfig{}.IsSet())
+}
+
+func TestConfigRedacted(t *testing.T) {
+	cfg := &Config{
+		Database:  DatabaseConfig{User: "sa", Pass: "password"},
+		Messaging: MessagingConfig{Username: "user", Webhooks: []WebhookConfig{{URL: "https://example.com/hook", Secret: "secret"}, {URL: "https://example.com/unsigned"}}},
+		Telegram:  TelegramConfig{BotKey: "123456789:asdf"},
+	}
+
+	red := cfg.redacted()
+
+	assert.Equal(t, "sa", red.Database.User)
+	assert.Equal(t, redactedValue, red.Database.Pass)
+	assert.Equal(t, "user", red.Messaging.Username)
+	// unset credentials stay empty
+	assert.Empty(t, red.Messaging.Password)
+	assert.Equal(t, redactedValue, red.Messaging.Webhooks[0].Secret)
+	assert.Empty(t, red.Messaging.Webhooks[1].Secret)
+	assert.Equal(t, redactedValue, red.Telegram.BotKey)
+	// the configuration itself is unchanged
+	assert.Equal(t, "password", cfg.Database.Pass)
+	assert.Equal(t, "secret", cfg.Messaging.Webhooks[0].Secret)
+	assert.Equal(t, "123456789:asdf", cfg.Telegram.BotKey)
 }
 
 func TestConfigLoadError(t *testing.T) {
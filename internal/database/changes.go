@@ -0,0 +1,53 @@
+package database
+
+import (
+	"reflect"
+)
+
+// managedFields the fields maintained by the database handlers, they are never part of the changes
+var managedFields = map[string]bool{"ID": true, "Version": true}
+
+// ChangedFields returns the names of the fields which differ between the object from the database and the input.
+// Fields of embedded structs are checked as well, the id and version are skipped.
+func ChangedFields[T any](dbObject T, input T) []string {
+	return changedFields(reflect.ValueOf(dbObject), reflect.ValueOf(input))
+}
+
+// ApplyFields copies the given fields from the source to the target
+func ApplyFields[T any](target *T, source T, fields []string) {
+	tv := reflect.ValueOf(target).Elem()
+	sv := reflect.ValueOf(source)
+	if tv.Kind() != reflect.Struct {
+		return
+	}
+	for _, name := range fields {
+		tf := tv.FieldByName(name)
+		if tf.IsValid() && tf.CanSet() {
+			tf.Set(sv.FieldByName(name))
+		}
+	}
+}
+
+func changedFields(a, b reflect.Value) []string {
+	if a.Kind() != reflect.Struct {
+		return nil
+	}
+	changed := []string{}
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			changed = append(changed, changedFields(a.Field(i), b.Field(i))...)
+			continue
+		}
+		if managedFields[field.Name] {
+			continue
+		}
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			changed = append(changed, field.Name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedFields(t *testing.T) {
+	dbObj := model.LedStrip{BaseModel: model.BaseModel{ID: 1}, Name: "strip", NumLeds: null.IntFrom(10)}
+	input := dbObj
+	assert.Empty(t, ChangedFields(dbObj, input))
+
+	input.ID = 2
+	input.Version = 5
+	input.Enabled = true
+	input.NumLeds = null.NewInt(0, false)
+	// id and version are never reported as change
+	assert.Equal(t, []string{"Enabled", "NumLeds"}, ChangedFields(dbObj, input))
+}
+
+func TestApplyFields(t *testing.T) {
+	current := model.LedStrip{BaseModel: model.BaseModel{ID: 1}, Name: "current", Description: "changed concurrently"}
+	input := model.LedStrip{BaseModel: model.BaseModel{ID: 1}, Name: "input", Enabled: true}
+
+	ApplyFields(&current, input, []string{"Enabled"})
+
+	assert.Equal(t, model.LedStrip{BaseModel: model.BaseModel{ID: 1}, Name: "current", Description: "changed concurrently", Enabled: true}, current)
+}
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/go-co-op/gocron"
 	"github.com/gocarina/gocsv"
@@ -25,7 +27,12 @@ type CSVHandler[T any] struct {
 	cfg           *config.CSVConfig
 	iMap          *SyncMap[string, T]
 	lastCheckHash string
-	l             alog.Logger
+	// mu serializes the write operations with the persisting of the file, to keep csv file and journal consistent
+	mu     sync.Mutex
+	jrnl   *journal[T]
+	seqMu  sync.Mutex
+	lastID int64
+	l      alog.Logger
 }
 
 func NewHandler[T any](cfg *config.CSVConfig) *CSVHandler[T] {
@@ -73,31 +80,120 @@ func (c *CSVHandler[T]) Get(id string) (*T, error) {
 }
 
 func (c *CSVHandler[T]) Save(input *T) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	id := c.findId(input)
+	var version int64 = 1
+	if current, ok := c.iMap.Load(id); ok {
+		version = database.VersionOf(&current) + 1
+	}
+	database.SetVersion(input, version)
+	if err := c.writeJournal(journalEntry[T]{Op: opSave, ID: id, Object: input}); err != nil {
+		return err
+	}
 	c.iMap.Store(id, *input)
 	return nil
 }
 
+// Update applies the fields which differ between the database object and the input to the stored object, the
+// version is incremented even if no field differs
 func (c *CSVHandler[T]) Update(dbObject T, input T) (err error) {
-	// only fullupdate atm
-	return c.Save(&input)
+	fields := database.ChangedFields(dbObject, input)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := c.findId(&dbObject)
+	current, ok := c.iMap.Load(id)
+	if !ok {
+		return errors.New("object not found")
+	}
+	version := database.VersionOf(&current)
+	if version != database.VersionOf(&dbObject) {
+		return database.ErrVersionConflict
+	}
+	database.ApplyFields(&current, input, fields)
+	database.SetVersion(&current, version+1)
+	if err := c.writeJournal(journalEntry[T]{Op: opSave, ID: id, Object: &current}); err != nil {
+		return err
+	}
+	c.iMap.Store(id, current)
+	return nil
 }
 
 func (c *CSVHandler[T]) Create(input *T) (err error) {
-	return c.Save(input)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := c.findId(input)
+	if _, ok := c.iMap.Load(id); ok {
+		return database.ErrConflict
+	}
+	database.SetVersion(input, 1)
+	if err := c.writeJournal(journalEntry[T]{Op: opSave, ID: id, Object: input}); err != nil {
+		return err
+	}
+	c.iMap.Store(id, *input)
+	return nil
+}
+
+// NextID returns the next id of the table, the sequence is persisted if a data dir is given
+func (c *CSVHandler[T]) NextID() (int64, error) {
+	c.seqMu.Lock()
+	defer c.seqMu.Unlock()
+	next := max(c.lastID, c.maxID()) + 1
+	if c.cfg.DataDir != "" {
+		err := writeFileAtomic(c.filePath(".seq"), func(f *os.File) error {
+			_, err := f.WriteString(strconv.FormatInt(next, 10))
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	c.lastID = next
+	return next, nil
 }
 
 func (c *CSVHandler[T]) Delete(input *T) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	id := c.findId(input)
+	if err := c.writeJournal(journalEntry[T]{Op: opDelete, ID: id}); err != nil {
+		return err
+	}
 	c.iMap.Delete(id)
 	return nil
 }
 
 func (c *CSVHandler[T]) Close() {
-	// nothing to close
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.jrnl == nil {
+		return
+	}
+	if err := c.jrnl.close(); err != nil {
+		c.l.Error("error closing the journal of %v: %s", c.tableName(), err.Error())
+	}
 }
 
+// Flush writes the data to the csv file if it changed since the last write
+func (c *CSVHandler[T]) Flush() error {
+	_, err := c.flush()
+	return err
+}
+
+// Shutdown writes pending changes and closes the journal, it is called on shutdown of the injector.
+// Errors are only logged, as returning them would skip the shutdown of the remaining services
 func (c *CSVHandler[T]) Shutdown() error {
+	tName := c.tableName()
+	written, err := c.flush()
+	switch {
+	case err != nil:
+		c.l.Error("Flushing %v on shutdown failed: %s", tName, err.Error())
+	case written:
+		c.l.Info("Flushed %v on shutdown", tName)
+	default:
+		c.l.Info("No changes to flush for %v on shutdown", tName)
+	}
+	c.Close()
 	return nil
 }
 
@@ -123,6 +219,16 @@ func (c *CSVHandler[T]) findId(input any) string {
 	return strconv.FormatInt(ider.GetID(), 10)
 }
 
+func (c *CSVHandler[T]) maxID() int64 {
+	var maxID int64
+	for _, obj := range c.iMap.LoadAll() {
+		if ider := c.asIDer(&obj); ider != nil {
+			maxID = max(maxID, ider.GetID())
+		}
+	}
+	return maxID
+}
+
 func (c *CSVHandler[T]) tableName() string {
 	var dummy T
 	ider := c.asIDer(&dummy)
@@ -147,11 +253,32 @@ func (c *CSVHandler[T]) openFile() (*os.File, error) {
 	if c.cfg.DataDir == "" {
 		return nil, errors.New("no datadir")
 	}
-	fName := c.tableName() + ".csv"
-	return os.OpenFile(c.cfg.DataDir+fName, os.O_RDWR|os.O_CREATE, os.ModePerm)
+	return os.OpenFile(c.filePath(".csv"), os.O_RDWR|os.O_CREATE, os.ModePerm)
+}
+
+func (c *CSVHandler[T]) filePath(suffix string) string {
+	return filepath.Join(c.cfg.DataDir, c.tableName()+suffix)
+}
+
+func (c *CSVHandler[T]) loadSequence() {
+	data, err := os.ReadFile(c.filePath(".seq"))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			c.l.Error("error reading the sequence of %v: %s", c.tableName(), err.Error())
+		}
+		return
+	}
+	lastID, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		c.l.Error("error parsing the sequence of %v: %s", c.tableName(), err.Error())
+		return
+	}
+	c.lastID = lastID
 }
 
 func (c *CSVHandler[T]) load() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	elems := []T{}
 	if c.cfg.DataDir != "" {
 		dataFile, err := c.openFile()
@@ -165,39 +292,93 @@ func (c *CSVHandler[T]) load() {
 				panic(err)
 			}
 		}
+		c.loadSequence()
 	} else {
 		c.l.Warn("No data dir given, skip loading existing data")
 	}
 
 	for i := range elems {
-		if err := c.Save(&elems[i]); err != nil {
-			c.l.Error("error: %s\n", err.Error())
-		}
+		c.iMap.Store(c.findId(&elems[i]), elems[i])
 	}
 	var err error
 	if c.lastCheckHash, err = c.hashEntries(); err != nil {
 		panic(err)
 	}
+	if c.cfg.DataDir != "" {
+		// operations since the last persist are only contained in the journal
+		c.replayJournal()
+	}
 }
 
-func (c *CSVHandler[T]) persistIfNecessary() {
-	tName := c.tableName()
-	c.l.Info("Running job for " + tName)
+func (c *CSVHandler[T]) replayJournal() {
+	if c.jrnl != nil {
+		c.jrnl.close()
+		c.jrnl = nil
+	}
+	entries, err := c.getJournal().read()
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		switch entry.Op {
+		case opSave:
+			if entry.Object != nil {
+				c.iMap.Store(entry.ID, *entry.Object)
+			}
+		case opDelete:
+			c.iMap.Delete(entry.ID)
+		}
+	}
+	if len(entries) > 0 {
+		c.l.Info("Replayed %d journal entries for %v", len(entries), c.tableName())
+	}
+}
+
+// writeJournal records the operation before it is applied, does nothing if there is no data dir
+func (c *CSVHandler[T]) writeJournal(entry journalEntry[T]) error {
+	if c.cfg.DataDir == "" {
+		return nil
+	}
+	return c.getJournal().append(entry)
+}
+
+// getJournal returns the journal of the table, it is opened lazily on first use
+func (c *CSVHandler[T]) getJournal() *journal[T] {
+	if c.jrnl == nil {
+		c.jrnl = newJournal[T](c.filePath(".journal"))
+	}
+	return c.jrnl
+}
+
+// flush persists the data if the hash changed, returns whether the file has been written
+func (c *CSVHandler[T]) flush() (written bool, err error) {
+	if c.cfg.DataDir == "" {
+		// nothing to flush if no data dir given
+		return false, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	currentHash, err := c.hashEntries()
 	if err != nil {
-		c.l.Error("error calculating the hash in job %v: %s\n", tName, err.Error())
-		return
+		return false, err
 	}
 	if strings.EqualFold(currentHash, c.lastCheckHash) {
-		c.l.Info("Hashes are equal for job %v, skip writing", tName)
-		return
+		c.l.Info("Hashes are equal for %v, skip writing", c.tableName())
+		return false, c.getJournal().reset()
 	}
-	err = c.persist()
-	if err != nil {
-		c.l.Error("error persisting updates for %v: %s\n", tName, err.Error())
-		return
+	if err := c.persist(); err != nil {
+		return false, err
 	}
 	c.lastCheckHash = currentHash
+	return true, nil
+}
+
+func (c *CSVHandler[T]) persistIfNecessary() {
+	tName := c.tableName()
+	c.l.Info("Running job for " + tName)
+	if err := c.Flush(); err != nil {
+		c.l.Error("error persisting updates for %v: %s\n", tName, err.Error())
+	}
 }
 
 func (c *CSVHandler[T]) hashEntries() (string, error) {
@@ -217,21 +398,21 @@ func (c *CSVHandler[T]) hashEntries() (string, error) {
 	return sum, nil
 }
 
+// persist replaces the csv file with the current data and empties the journal afterwards
 func (c *CSVHandler[T]) persist() error {
-	dataFile, err := c.openFile()
-	if err != nil {
-		return err
+	if c.cfg.DataDir == "" {
+		return errors.New("no datadir")
 	}
-	defer dataFile.Close()
-
 	models, err := c.GetAll()
 	if err != nil {
 		return err
 	}
 
-	err = gocsv.MarshalFile(models, dataFile)
+	err = writeFileAtomic(c.filePath(".csv"), func(f *os.File) error {
+		return gocsv.MarshalFile(models, f)
+	})
 	if err != nil {
 		return err
 	}
-	return nil
+	return c.getJournal().reset()
 }
@@ -1,13 +1,17 @@
 package csv
 
 import (
+	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-co-op/gocron"
 	"github.com/pthum/null"
 	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/database"
 	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/samber/do"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,6 +24,49 @@ func TestCreateAndRead(t *testing.T) {
 	assert.Equal(t, testProfile, *result)
 	assert.NoError(t, err)
 }
+func TestCreateConflict(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(123)
+	assert.NoError(t, dbh.Create(&testProfile))
+
+	otherProfile := createTestProfile(123)
+	otherProfile.Blue = null.IntFrom(42)
+	err := dbh.Create(&otherProfile)
+
+	assert.ErrorIs(t, err, database.ErrConflict)
+	// existing object must not be overwritten
+	result, _ := dbh.Get("123")
+	assert.Equal(t, testProfile, *result)
+}
+
+func TestNextID(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(123)
+	dbh.Create(&testProfile)
+
+	first, err := dbh.NextID()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(124), first)
+	second, err := dbh.NextID()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(125), second)
+}
+
+func TestNextID_PersistedSequence(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	dbh.cfg.DataDir = t.TempDir()
+	id, err := dbh.NextID()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	// the id is not used, but must not be handed out again after a restart
+	dbh.lastID = 0
+	dbh.load()
+	id, err = dbh.NextID()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), id)
+}
+
 func TestGetMissing(t *testing.T) {
 	dbh := initHandler[model.ColorProfile](t)
 	result, err := dbh.Get("123")
@@ -64,10 +111,78 @@ func TestUpdate(t *testing.T) {
 	// test with changes
 	dbh.Update(testProfile, otherProfile)
 	result, err := dbh.Get("235")
+	// the version is incremented on each write
+	otherProfile.Version = 2
 	assert.Equal(t, otherProfile, *result)
 	assert.NoError(t, err)
 }
 
+func TestUpdate_Partial(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(235)
+	dbh.Create(&testProfile)
+
+	otherProfile := testProfile
+	otherProfile.Blue = null.IntFrom(42)
+	otherProfile.Green = null.NewInt(0, false)
+	assert.NoError(t, dbh.Update(testProfile, otherProfile))
+
+	result, err := dbh.Get("235")
+	assert.NoError(t, err)
+	expected := otherProfile
+	expected.Version = 2
+	assert.Equal(t, expected, *result)
+}
+
+func TestUpdate_Unchanged(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(235)
+	dbh.Create(&testProfile)
+	// the version is incremented even without changes
+	assert.NoError(t, dbh.Update(testProfile, testProfile))
+	result, err := dbh.Get("235")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), result.Version)
+
+	// the stale version is rejected
+	assert.ErrorIs(t, dbh.Update(testProfile, testProfile), database.ErrVersionConflict)
+}
+
+func TestUpdate_VersionConflict(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(235)
+	dbh.Create(&testProfile)
+	// a concurrent change after testProfile has been read
+	concurrent := testProfile
+	concurrent.Red = null.IntFrom(100)
+	dbh.Save(&concurrent)
+
+	otherProfile := testProfile
+	otherProfile.Blue = null.IntFrom(42)
+	err := dbh.Update(testProfile, otherProfile)
+
+	assert.ErrorIs(t, err, database.ErrVersionConflict)
+	result, _ := dbh.Get("235")
+	assert.Equal(t, concurrent, *result)
+}
+
+func TestVersion(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(235)
+	dbh.Create(&testProfile)
+	assert.Equal(t, int64(1), testProfile.Version)
+
+	assert.NoError(t, dbh.Save(&testProfile))
+	assert.Equal(t, int64(2), testProfile.Version)
+
+	// the version of the input doesn't matter on save
+	stale := createTestProfile(235)
+	assert.NoError(t, dbh.Save(&stale))
+	assert.Equal(t, int64(3), stale.Version)
+	result, _ := dbh.Get("235")
+	assert.Equal(t, int64(3), result.Version)
+}
+
 func TestTableName(t *testing.T) {
 	dbh := initHandler[model.ColorProfile](t)
 	tn := dbh.tableName()
@@ -111,12 +226,9 @@ func TestPersistAndLoad(t *testing.T) {
 	assert.Equal(t, hashAfterFirstSave, hashAfterSecondSave)
 
 	// cleanup map to load freshly from file
-	all, _ := dbh.GetAll()
-	for _, a := range all {
-		dbh.Delete(&a)
-	}
+	simulateRestart(dbh)
 	// consistency check: map should be empty
-	all, _ = dbh.GetAll()
+	all, _ := dbh.GetAll()
 	assert.Len(t, all, 0)
 
 	dbh.load()
@@ -126,6 +238,135 @@ func TestPersistAndLoad(t *testing.T) {
 	assert.Equal(t, testProfile, all[0])
 }
 
+func TestPersist_RemovesStaleRows(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	dbh.cfg.DataDir = t.TempDir()
+	for _, id := range []int64{1, 2, 3} {
+		p := createTestProfile(id)
+		dbh.Create(&p)
+	}
+	assert.NoError(t, dbh.Flush())
+
+	// shrink the dataset, the file must not contain the old rows afterwards
+	p := createTestProfile(2)
+	dbh.Delete(&p)
+	p = createTestProfile(3)
+	dbh.Delete(&p)
+	assert.NoError(t, dbh.Flush())
+
+	data, err := os.ReadFile(dbh.filePath(".csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(strings.TrimSpace(string(data)), "\n")+1)
+}
+
+func TestJournalReplay(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	dbh.cfg.DataDir = t.TempDir()
+	persisted := createTestProfile(1)
+	dbh.Create(&persisted)
+	assert.NoError(t, dbh.Flush())
+
+	// changes after the last persist are only in the journal
+	updated := persisted
+	updated.Red = null.IntFrom(200)
+	dbh.Save(&updated)
+	created := createTestProfile(2)
+	dbh.Create(&created)
+	deleted := createTestProfile(3)
+	dbh.Create(&deleted)
+	dbh.Delete(&deleted)
+
+	simulateRestart(dbh)
+	dbh.load()
+
+	all, _ := dbh.GetAll()
+	assert.Equal(t, []model.ColorProfile{updated, created}, all)
+	// replayed changes have to be written on the next persist
+	assert.NoError(t, dbh.Flush())
+	simulateRestart(dbh)
+	os.Remove(dbh.filePath(".journal"))
+	dbh.load()
+	all, _ = dbh.GetAll()
+	assert.Equal(t, []model.ColorProfile{updated, created}, all)
+}
+
+func TestJournalReplay_PartialEntry(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	dbh.cfg.DataDir = t.TempDir()
+	created := createTestProfile(1)
+	dbh.Create(&created)
+	// simulate a crash while writing the next entry
+	f, err := os.OpenFile(dbh.filePath(".journal"), os.O_WRONLY|os.O_APPEND, 0600)
+	assert.NoError(t, err)
+	f.WriteString(`{"op":"SAVE","id":"2","obj`)
+	f.Close()
+
+	simulateRestart(dbh)
+	dbh.load()
+
+	all, _ := dbh.GetAll()
+	assert.Equal(t, []model.ColorProfile{created}, all)
+}
+
+func TestShutdown(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	dbh.cfg.DataDir = t.TempDir()
+	testProfile := createTestProfile(1)
+	dbh.Create(&testProfile)
+
+	assert.NoError(t, dbh.Shutdown())
+
+	// everything has been written to the csv file, the journal is empty
+	journalInfo, err := os.Stat(dbh.filePath(".journal"))
+	assert.NoError(t, err)
+	assert.Zero(t, journalInfo.Size())
+	simulateRestart(dbh)
+	dbh.load()
+	all, _ := dbh.GetAll()
+	assert.Equal(t, []model.ColorProfile{testProfile}, all)
+}
+
+func TestShutdown_Injector(t *testing.T) {
+	acfg := &config.Config{CSV: config.CSVConfig{DataDir: t.TempDir(), Interval: 60}}
+	inj := do.New()
+	do.ProvideValue(inj, acfg)
+	do.ProvideValue(inj, gocron.NewScheduler(time.UTC))
+	do.Provide(inj, NewHandlerI[model.ColorProfile])
+	dbh := do.MustInvoke[database.DBHandler[model.ColorProfile]](inj)
+	testProfile := createTestProfile(1)
+	dbh.Create(&testProfile)
+
+	assert.NoError(t, inj.Shutdown())
+
+	restarted := NewHandler[model.ColorProfile](&acfg.CSV)
+	os.Remove(restarted.filePath(".journal"))
+	restarted.iMap = NewSyncMap[string, model.ColorProfile]()
+	restarted.load()
+	all, _ := restarted.GetAll()
+	assert.Equal(t, []model.ColorProfile{testProfile}, all)
+}
+
+func TestFlush_MissingDataDir(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(1)
+	dbh.Create(&testProfile)
+	assert.NoError(t, dbh.Flush())
+}
+
+func TestLoad_WithoutVersion(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	dbh.cfg.DataDir = t.TempDir()
+	// files written before the version was introduced
+	err := os.WriteFile(dbh.filePath(".csv"), []byte("id,blue,brightness,green,red\n7,1,2,4,3\n"), 0600)
+	assert.NoError(t, err)
+
+	dbh.load()
+
+	result, err := dbh.Get("7")
+	assert.NoError(t, err)
+	assert.Equal(t, createTestProfile(7), *result)
+}
+
 func TestLoadEmptyFile(t *testing.T) {
 	dbh := initHandler[model.ColorProfile](t)
 	dbh.cfg.DataDir = t.TempDir()
@@ -177,6 +418,12 @@ func initHandler[T any](t *testing.T) *CSVHandler[T] {
 	return dbh
 }
 
+// simulateRestart drops the in-memory state, the files stay untouched
+func simulateRestart[T any](dbh *CSVHandler[T]) {
+	dbh.Close()
+	dbh.iMap = NewSyncMap[string, T]()
+}
+
 func createTestProfile(id int64) model.ColorProfile {
 	return model.ColorProfile{
 		BaseModel:  model.BaseModel{ID: id},
@@ -0,0 +1,138 @@
+package csv
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const (
+	opSave   = "SAVE"
+	opDelete = "DELETE"
+)
+
+// journalEntry a single write operation, stored as one json line in the journal
+type journalEntry[T any] struct {
+	Op     string `json:"op"`
+	ID     string `json:"id"`
+	Object *T     `json:"object,omitempty"`
+}
+
+// journal an append-only log of all write operations since the last persist of the csv file
+type journal[T any] struct {
+	path string
+	file *os.File
+}
+
+func newJournal[T any](path string) *journal[T] {
+	return &journal[T]{path: path}
+}
+
+// append writes the entry and syncs it to disk before returning
+func (j *journal[T]) append(entry journalEntry[T]) error {
+	if j.file == nil {
+		f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return err
+		}
+		j.file = f
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// read returns all entries of the journal, a partially written last entry is skipped
+func (j *journal[T]) read() ([]journalEntry[T], error) {
+	f, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []journalEntry[T]{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry journalEntry[T]
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// the entry has been written only partially, everything up to here is consistent
+			return entries, nil
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// reset empties the journal, has to be called after the data has been persisted
+func (j *journal[T]) reset() error {
+	if j.file == nil {
+		err := os.Truncate(j.path, 0)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := j.file.Truncate(0); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+func (j *journal[T]) close() error {
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// writeFileAtomic writes the file to a temporary file in the same directory and renames it afterwards,
+// so the target is either the old or the new version, even if the process crashes in between
+func writeFileAtomic(path string, write func(f *os.File) error) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if err = write(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir makes the rename durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -1,4 +1,4 @@
-// Code generated by mockery v2.14.0. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
@@ -17,7 +17,7 @@ func (_m *DBHandler[T]) EXPECT() *DBHandler_Expecter[T] {
 	return &DBHandler_Expecter[T]{mock: &_m.Mock}
 }
 
-// Close provides a mock function with given fields:
+// Close provides a mock function with no fields
 func (_m *DBHandler[T]) Close() {
 	_m.Called()
 }
@@ -44,10 +44,19 @@ func (_c *DBHandler_Close_Call[T]) Return() *DBHandler_Close_Call[T] {
 	return _c
 }
 
+func (_c *DBHandler_Close_Call[T]) RunAndReturn(run func()) *DBHandler_Close_Call[T] {
+	_c.Run(run)
+	return _c
+}
+
 // Create provides a mock function with given fields: input
 func (_m *DBHandler[T]) Create(input *T) error {
 	ret := _m.Called(input)
 
+	if len(ret) == 0 {
+		panic("no return value specified for Create")
+	}
+
 	var r0 error
 	if rf, ok := ret.Get(0).(func(*T) error); ok {
 		r0 = rf(input)
@@ -81,10 +90,19 @@ func (_c *DBHandler_Create_Call[T]) Return(err error) *DBHandler_Create_Call[T]
 	return _c
 }
 
+func (_c *DBHandler_Create_Call[T]) RunAndReturn(run func(*T) error) *DBHandler_Create_Call[T] {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Delete provides a mock function with given fields: input
 func (_m *DBHandler[T]) Delete(input *T) error {
 	ret := _m.Called(input)
 
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
 	var r0 error
 	if rf, ok := ret.Get(0).(func(*T) error); ok {
 		r0 = rf(input)
@@ -118,11 +136,24 @@ func (_c *DBHandler_Delete_Call[T]) Return(err error) *DBHandler_Delete_Call[T]
 	return _c
 }
 
+func (_c *DBHandler_Delete_Call[T]) RunAndReturn(run func(*T) error) *DBHandler_Delete_Call[T] {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Get provides a mock function with given fields: id
 func (_m *DBHandler[T]) Get(id string) (*T, error) {
 	ret := _m.Called(id)
 
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
 	var r0 *T
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*T, error)); ok {
+		return rf(id)
+	}
 	if rf, ok := ret.Get(0).(func(string) *T); ok {
 		r0 = rf(id)
 	} else {
@@ -131,7 +162,6 @@ func (_m *DBHandler[T]) Get(id string) (*T, error) {
 		}
 	}
 
-	var r1 error
 	if rf, ok := ret.Get(1).(func(string) error); ok {
 		r1 = rf(id)
 	} else {
@@ -164,11 +194,24 @@ func (_c *DBHandler_Get_Call[T]) Return(_a0 *T, _a1 error) *DBHandler_Get_Call[T
 	return _c
 }
 
-// GetAll provides a mock function with given fields:
+func (_c *DBHandler_Get_Call[T]) RunAndReturn(run func(string) (*T, error)) *DBHandler_Get_Call[T] {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAll provides a mock function with no fields
 func (_m *DBHandler[T]) GetAll() ([]T, error) {
 	ret := _m.Called()
 
+	if len(ret) == 0 {
+		panic("no return value specified for GetAll")
+	}
+
 	var r0 []T
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]T, error)); ok {
+		return rf()
+	}
 	if rf, ok := ret.Get(0).(func() []T); ok {
 		r0 = rf()
 	} else {
@@ -177,7 +220,6 @@ func (_m *DBHandler[T]) GetAll() ([]T, error) {
 		}
 	}
 
-	var r1 error
 	if rf, ok := ret.Get(1).(func() error); ok {
 		r1 = rf()
 	} else {
@@ -209,10 +251,74 @@ func (_c *DBHandler_GetAll_Call[T]) Return(_a0 []T, _a1 error) *DBHandler_GetAll
 	return _c
 }
 
+func (_c *DBHandler_GetAll_Call[T]) RunAndReturn(run func() ([]T, error)) *DBHandler_GetAll_Call[T] {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NextID provides a mock function with no fields
+func (_m *DBHandler[T]) NextID() (int64, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for NextID")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (int64, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() int64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DBHandler_NextID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NextID'
+type DBHandler_NextID_Call[T interface{}] struct {
+	*mock.Call
+}
+
+// NextID is a helper method to define mock.On call
+func (_e *DBHandler_Expecter[T]) NextID() *DBHandler_NextID_Call[T] {
+	return &DBHandler_NextID_Call[T]{Call: _e.mock.On("NextID")}
+}
+
+func (_c *DBHandler_NextID_Call[T]) Run(run func()) *DBHandler_NextID_Call[T] {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *DBHandler_NextID_Call[T]) Return(id int64, err error) *DBHandler_NextID_Call[T] {
+	_c.Call.Return(id, err)
+	return _c
+}
+
+func (_c *DBHandler_NextID_Call[T]) RunAndReturn(run func() (int64, error)) *DBHandler_NextID_Call[T] {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Save provides a mock function with given fields: input
 func (_m *DBHandler[T]) Save(input *T) error {
 	ret := _m.Called(input)
 
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
 	var r0 error
 	if rf, ok := ret.Get(0).(func(*T) error); ok {
 		r0 = rf(input)
@@ -246,10 +352,19 @@ func (_c *DBHandler_Save_Call[T]) Return(err error) *DBHandler_Save_Call[T] {
 	return _c
 }
 
+func (_c *DBHandler_Save_Call[T]) RunAndReturn(run func(*T) error) *DBHandler_Save_Call[T] {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Update provides a mock function with given fields: dbObject, input
 func (_m *DBHandler[T]) Update(dbObject T, input T) error {
 	ret := _m.Called(dbObject, input)
 
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
 	var r0 error
 	if rf, ok := ret.Get(0).(func(T, T) error); ok {
 		r0 = rf(dbObject, input)
@@ -284,13 +399,17 @@ func (_c *DBHandler_Update_Call[T]) Return(err error) *DBHandler_Update_Call[T]
 	return _c
 }
 
-type mockConstructorTestingTNewDBHandler interface {
-	mock.TestingT
-	Cleanup(func())
+func (_c *DBHandler_Update_Call[T]) RunAndReturn(run func(T, T) error) *DBHandler_Update_Call[T] {
+	_c.Call.Return(run)
+	return _c
 }
 
 // NewDBHandler creates a new instance of DBHandler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
-func NewDBHandler[T interface{}](t mockConstructorTestingTNewDBHandler) *DBHandler[T] {
+// The first argument is typically a *testing.T value.
+func NewDBHandler[T interface{}](t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *DBHandler[T] {
 	mock := &DBHandler[T]{}
 	mock.Mock.Test(t)
 
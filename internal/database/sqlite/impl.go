@@ -0,0 +1,198 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/samber/do"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// interface guard
+var _ database.DBHandler[any] = (*SQLiteHandler[any])(nil)
+
+// pragmas applied to every connection, the busy timeout is required as both tables share the same file
+const dsnPragmas = "?_pragma=busy_timeout(5000)"
+
+// nextIDQuery increments the sequence of a table atomically, the sequence never falls behind the highest existing id
+const nextIDQuery = `INSERT INTO ` + sequenceTable + ` (name, last_id)
+VALUES (?, (SELECT coalesce(max(id), 0) FROM %[1]s) + 1)
+ON CONFLICT (name) DO UPDATE SET last_id = max(last_id + 1, excluded.last_id)
+RETURNING last_id`
+
+const sequenceTable = "stripcontrol_sequence"
+
+// sequence the last allocated id per table
+type sequence struct {
+	Name   string `gorm:"primaryKey"`
+	LastID int64  `gorm:"column:last_id"`
+}
+
+func (sequence) TableName() string {
+	return sequenceTable
+}
+
+type SQLiteHandler[T any] struct {
+	cfg *config.DatabaseConfig
+	db  *gorm.DB
+	l   alog.Logger
+}
+
+func NewHandler[T any](cfg *config.DatabaseConfig) (*SQLiteHandler[T], error) {
+	if cfg.Host == "" {
+		return nil, errors.New("no database file given")
+	}
+	gl := logger.New(alog.NewLogLogger("gorm"), logger.Config{
+		SlowThreshold:             200 * time.Millisecond,
+		LogLevel:                  logger.Warn,
+		IgnoreRecordNotFoundError: true,
+	})
+	db, err := gorm.Open(sqlite.Open(cfg.Host+dsnPragmas), &gorm.Config{Logger: gl, TranslateError: true})
+	if err != nil {
+		return nil, err
+	}
+	sh := &SQLiteHandler[T]{
+		cfg: cfg,
+		db:  db,
+		l:   alog.NewLogger("sqlitehandler"),
+	}
+	if err := sh.migrate(); err != nil {
+		sh.Close()
+		return nil, err
+	}
+	return sh, nil
+}
+
+func NewHandlerI[T any](inj *do.Injector) (database.DBHandler[T], error) {
+	acfg := do.MustInvoke[*config.Config](inj)
+	return NewHandler[T](&acfg.Database)
+}
+
+func (s *SQLiteHandler[T]) GetAll() ([]T, error) {
+	objs := []T{}
+	if err := s.db.Order("id").Find(&objs).Error; err != nil {
+		return nil, err
+	}
+	return objs, nil
+}
+
+func (s *SQLiteHandler[T]) Get(id string) (*T, error) {
+	var obj T
+	err := s.db.Where("id = ?", id).Take(&obj).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("object not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+func (s *SQLiteHandler[T]) Save(input *T) (err error) {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var current T
+		err := tx.Where("id = ?", s.idOf(input)).Take(&current).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			database.SetVersion(input, 1)
+		case err != nil:
+			return err
+		default:
+			database.SetVersion(input, database.VersionOf(&current)+1)
+		}
+		return tx.Save(input).Error
+	})
+}
+
+// Update writes only the columns which differ between the database object and the input, the version is
+// incremented even if no column differs
+func (s *SQLiteHandler[T]) Update(dbObject T, input T) (err error) {
+	fields := database.ChangedFields(dbObject, input)
+	iv := reflect.ValueOf(input)
+	changes := make(map[string]any, len(fields)+1)
+	for _, name := range fields {
+		changes[name] = iv.FieldByName(name).Interface()
+	}
+	version := database.VersionOf(&dbObject)
+	changes["Version"] = version + 1
+	// the row is only updated if nobody else changed it since it was read
+	res := s.db.Model(&dbObject).Where("version = ?", version).Updates(changes)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return database.ErrVersionConflict
+	}
+	return nil
+}
+
+func (s *SQLiteHandler[T]) Create(input *T) (err error) {
+	database.SetVersion(input, 1)
+	err = s.db.Create(input).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return database.ErrConflict
+	}
+	return err
+}
+
+// NextID returns the next id of the table, the sequence is stored in its own table
+func (s *SQLiteHandler[T]) NextID() (id int64, err error) {
+	table := s.tableName()
+	if table == "" {
+		return 0, errors.New("object has no table")
+	}
+	err = s.db.Raw(fmt.Sprintf(nextIDQuery, table), table).Scan(&id).Error
+	return id, err
+}
+
+func (s *SQLiteHandler[T]) Delete(input *T) (err error) {
+	return s.db.Delete(input).Error
+}
+
+func (s *SQLiteHandler[T]) Close() {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		s.l.Error("error getting the connection: %s", err.Error())
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		s.l.Error("error closing the connection: %s", err.Error())
+	}
+}
+
+func (s *SQLiteHandler[T]) Shutdown() error {
+	s.Close()
+	return nil
+}
+
+// migrate creates the table if it doesn't exist yet, existing tables of the other stripcontrol
+// implementations are reused as they share the same table and column names
+func (s *SQLiteHandler[T]) migrate() error {
+	var dummy T
+	return s.db.AutoMigrate(&dummy, &sequence{})
+}
+
+func (s *SQLiteHandler[T]) idOf(input *T) int64 {
+	ider, ok := any(input).(model.IDer)
+	if !ok {
+		return 0
+	}
+	return ider.GetID()
+}
+
+func (s *SQLiteHandler[T]) tableName() string {
+	var dummy T
+	ider, ok := any(&dummy).(model.IDer)
+	if !ok {
+		return ""
+	}
+	return ider.TableName()
+}
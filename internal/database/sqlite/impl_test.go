@@ -0,0 +1,266 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCreateAndRead(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(123)
+
+	assert.NoError(t, dbh.Create(&testProfile))
+	result, err := dbh.Get("123")
+	assert.NoError(t, err)
+	assert.Equal(t, testProfile, *result)
+}
+
+func TestCreateConflict(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(123)
+	assert.NoError(t, dbh.Create(&testProfile))
+
+	otherProfile := createTestProfile(123)
+	otherProfile.Blue = null.IntFrom(42)
+	err := dbh.Create(&otherProfile)
+
+	assert.ErrorIs(t, err, database.ErrConflict)
+	// existing object must not be overwritten
+	result, _ := dbh.Get("123")
+	assert.Equal(t, testProfile, *result)
+}
+
+func TestNextID(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	first, err := dbh.NextID()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), first)
+
+	// the sequence shouldn't hand out ids below existing ones
+	testProfile := createTestProfile(123)
+	dbh.Create(&testProfile)
+	second, err := dbh.NextID()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(124), second)
+
+	// ids are not reused, even if the object is gone
+	dbh.Delete(&testProfile)
+	third, err := dbh.NextID()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(125), third)
+}
+
+func TestGetMissing(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	result, err := dbh.Get("123")
+	assert.Nil(t, result)
+	assert.Error(t, err)
+}
+
+func TestSaveGetAllAndDelete(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(242)
+	dbh.Create(&testProfile)
+
+	testProfile.Blue = null.IntFrom(42)
+	dbh.Save(&testProfile)
+
+	testProfile2 := createTestProfile(23)
+	dbh.Save(&testProfile2)
+
+	result, err := dbh.GetAll()
+
+	assert.Equal(t, 2, len(result))
+	// order of GetAll should be stable, by id
+	assert.Equal(t, testProfile2, result[0])
+	assert.Equal(t, testProfile, result[1])
+	assert.NoError(t, err)
+
+	dbh.Delete(&testProfile)
+
+	resultAfterDelete, err := dbh.GetAll()
+	assert.Equal(t, 1, len(resultAfterDelete))
+	assert.Equal(t, testProfile2, resultAfterDelete[0])
+	assert.NoError(t, err)
+}
+
+func TestUpdate(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(235)
+	dbh.Create(&testProfile)
+	// copy the profile
+	otherProfile := testProfile
+	otherProfile.Blue = null.IntFrom(42)
+	// test with changes
+	dbh.Update(testProfile, otherProfile)
+	result, err := dbh.Get("235")
+	// the version is incremented on each write
+	otherProfile.Version = 2
+	assert.Equal(t, otherProfile, *result)
+	assert.NoError(t, err)
+}
+
+func TestUpdate_Partial(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(235)
+	dbh.Create(&testProfile)
+
+	otherProfile := testProfile
+	otherProfile.Blue = null.IntFrom(42)
+	otherProfile.Green = null.NewInt(0, false)
+	assert.NoError(t, dbh.Update(testProfile, otherProfile))
+
+	result, err := dbh.Get("235")
+	assert.NoError(t, err)
+	expected := otherProfile
+	expected.Version = 2
+	assert.Equal(t, expected, *result)
+}
+
+func TestUpdate_Unchanged(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(235)
+	dbh.Create(&testProfile)
+	// the version is incremented even without changes
+	assert.NoError(t, dbh.Update(testProfile, testProfile))
+	result, err := dbh.Get("235")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), result.Version)
+
+	// the stale version is rejected
+	assert.ErrorIs(t, dbh.Update(testProfile, testProfile), database.ErrVersionConflict)
+}
+
+func TestUpdate_VersionConflict(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(235)
+	dbh.Create(&testProfile)
+	// a concurrent change after testProfile has been read
+	concurrent := testProfile
+	concurrent.Red = null.IntFrom(100)
+	dbh.Save(&concurrent)
+
+	otherProfile := testProfile
+	otherProfile.Blue = null.IntFrom(42)
+	err := dbh.Update(testProfile, otherProfile)
+
+	assert.ErrorIs(t, err, database.ErrVersionConflict)
+	result, _ := dbh.Get("235")
+	assert.Equal(t, concurrent, *result)
+}
+
+func TestVersion(t *testing.T) {
+	dbh := initHandler[model.ColorProfile](t)
+	testProfile := createTestProfile(235)
+	dbh.Create(&testProfile)
+	assert.Equal(t, int64(1), testProfile.Version)
+
+	assert.NoError(t, dbh.Save(&testProfile))
+	assert.Equal(t, int64(2), testProfile.Version)
+
+	// the version of the input doesn't matter on save
+	stale := createTestProfile(235)
+	assert.NoError(t, dbh.Save(&stale))
+	assert.Equal(t, int64(3), stale.Version)
+	result, _ := dbh.Get("235")
+	assert.Equal(t, int64(3), result.Version)
+}
+
+func TestLedStripColumns(t *testing.T) {
+	dbh := initHandler[model.LedStrip](t)
+	strip := model.LedStrip{
+		BaseModel: model.BaseModel{ID: 12},
+		Name:      "test",
+		Enabled:   true,
+		MisoPin:   null.IntFrom(10),
+		NumLeds:   null.IntFrom(20),
+		SclkPin:   null.IntFrom(11),
+		SpeedHz:   null.IntFrom(80000),
+		ProfileID: null.IntFrom(3),
+	}
+	assert.NoError(t, dbh.Create(&strip))
+
+	// the column names have to match the ones of the other stripcontrol implementations
+	var cnt int64
+	err := dbh.db.Table(model.Table_LedStrip).
+		Where("miso_pin = ? AND num_leds = ? AND sclk_pin = ? AND speed_hz = ? AND profile_id = ?", 10, 20, 11, 80000, 3).
+		Count(&cnt).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	// unset values should be stored as null
+	strip.ProfileID = null.NewInt(0, false)
+	assert.NoError(t, dbh.Save(&strip))
+	result, err := dbh.Get("12")
+	assert.NoError(t, err)
+	assert.False(t, result.ProfileID.Valid)
+}
+
+func TestReopen(t *testing.T) {
+	cfg := &config.DatabaseConfig{Type: config.DBTypeSQLite, Host: filepath.Join(t.TempDir(), "test.sqlite")}
+	dbh, err := NewHandler[model.ColorProfile](cfg)
+	assert.NoError(t, err)
+	testProfile := createTestProfile(235)
+	dbh.Create(&testProfile)
+	assert.NoError(t, dbh.Shutdown())
+
+	dbh, err = NewHandler[model.ColorProfile](cfg)
+	assert.NoError(t, err)
+	defer dbh.Close()
+	all, err := dbh.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, all, 1)
+	assert.Equal(t, testProfile, all[0])
+}
+
+func TestMigrate_WithoutVersion(t *testing.T) {
+	cfg := &config.DatabaseConfig{Type: config.DBTypeSQLite, Host: filepath.Join(t.TempDir(), "test.sqlite")}
+	db, err := gorm.Open(sqlite.Open(cfg.Host), &gorm.Config{})
+	assert.NoError(t, err)
+	// table as created by the other stripcontrol implementations
+	assert.NoError(t, db.Exec("CREATE TABLE color_profile (id integer PRIMARY KEY, blue integer, brightness integer, green integer, red integer)").Error)
+	assert.NoError(t, db.Exec("INSERT INTO color_profile VALUES (7, 1, 2, 4, 3)").Error)
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	dbh, err := NewHandler[model.ColorProfile](cfg)
+	assert.NoError(t, err)
+	defer dbh.Close()
+	result, err := dbh.Get("7")
+	assert.NoError(t, err)
+	assert.Equal(t, createTestProfile(7), *result)
+}
+
+func TestNewHandler_MissingFile(t *testing.T) {
+	dbh, err := NewHandler[model.ColorProfile](&config.DatabaseConfig{})
+	assert.Nil(t, dbh)
+	assert.Error(t, err)
+}
+
+func initHandler[T any](t *testing.T) *SQLiteHandler[T] {
+	cfg := &config.DatabaseConfig{Type: config.DBTypeSQLite, Host: filepath.Join(t.TempDir(), "test.sqlite")}
+	dbh, err := NewHandler[T](cfg)
+	if err != nil {
+		t.Fatalf("could not open database: %v", err)
+	}
+	t.Cleanup(dbh.Close)
+	return dbh
+}
+
+func createTestProfile(id int64) model.ColorProfile {
+	return model.ColorProfile{
+		BaseModel:  model.BaseModel{ID: id},
+		Blue:       null.IntFrom(1),
+		Brightness: null.IntFrom(2),
+		Red:        null.IntFrom(3),
+		Green:      null.IntFrom(4),
+	}
+}
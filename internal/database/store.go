@@ -1,16 +1,35 @@
 package database
 
+import (
+	"errors"
+
+	"github.com/pthum/stripcontrol-golang/internal/model"
+)
+
+// maxIDAttempts the number of ids tried before giving up on creating an object
+const maxIDAttempts = 5
+
+// ErrConflict is returned on create if an object with the same id already exists
+var ErrConflict = errors.New("object already exists")
+
+// ErrVersionConflict is returned on update if the object has been changed since it was read
+var ErrVersionConflict = errors.New("object has been modified in the meantime")
+
 type DBReader[T any] interface {
 	GetAll() ([]T, error)
 	Get(id string) (*T, error)
 	Close()
 }
 
+// DBWriter writes objects, all writes increment the version of objects implementing model.Versioned
 type DBWriter[T any] interface {
 	Save(input *T) (err error)
+	// Update applies the changes between dbObject and input and increments the version, even without changes.
+	// It fails with ErrVersionConflict if the stored object doesn't have the version of dbObject anymore
 	Update(dbObject T, input T) (err error)
 	Create(input *T) (err error)
 	Delete(input *T) (err error)
+	NextID() (id int64, err error)
 	Close()
 }
 
@@ -19,3 +38,38 @@ type DBHandler[T any] interface {
 	DBReader[T]
 	DBWriter[T]
 }
+
+// CreateWithNextID assigns the next free id of the table to the input and creates it,
+// a new id is allocated if the creation fails due to a conflicting id
+func CreateWithNextID[T any](dbw DBWriter[T], input *T) (err error) {
+	ider, ok := any(input).(model.IDSetter)
+	if !ok {
+		return errors.New("object does not support setting an id")
+	}
+	for attempt := 0; attempt < maxIDAttempts; attempt++ {
+		var id int64
+		if id, err = dbw.NextID(); err != nil {
+			return err
+		}
+		ider.SetID(id)
+		if err = dbw.Create(input); !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// VersionOf returns the version of the object, 0 if it isn't versioned
+func VersionOf(obj any) int64 {
+	if v, ok := obj.(model.Versioned); ok {
+		return v.GetVersion()
+	}
+	return 0
+}
+
+// SetVersion sets the version of the object, if it is versioned
+func SetVersion(obj any, version int64) {
+	if v, ok := obj.(model.Versioned); ok {
+		v.SetVersion(version)
+	}
+}
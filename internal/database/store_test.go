@@ -0,0 +1,66 @@
+package database
+
+import (
+	"testing"
+
+	dbm "github.com/pthum/stripcontrol-golang/internal/database/mocks"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateWithNextID(t *testing.T) {
+	dbh := dbm.NewDBHandler[model.ColorProfile](t)
+	dbh.EXPECT().NextID().Return(12, nil).Once()
+	dbh.EXPECT().Create(mock.Anything).Return(nil).Once()
+
+	input := model.ColorProfile{}
+	err := CreateWithNextID[model.ColorProfile](dbh, &input)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), input.ID)
+}
+
+func TestCreateWithNextID_RetryOnConflict(t *testing.T) {
+	dbh := dbm.NewDBHandler[model.ColorProfile](t)
+	dbh.EXPECT().NextID().Return(12, nil).Once()
+	dbh.EXPECT().NextID().Return(13, nil).Once()
+	dbh.EXPECT().Create(mock.Anything).Return(ErrConflict).Once()
+	dbh.EXPECT().Create(mock.Anything).Return(nil).Once()
+
+	input := model.ColorProfile{}
+	err := CreateWithNextID[model.ColorProfile](dbh, &input)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(13), input.ID)
+}
+
+func TestCreateWithNextID_PermanentConflict(t *testing.T) {
+	dbh := dbm.NewDBHandler[model.ColorProfile](t)
+	dbh.EXPECT().NextID().Return(12, nil).Times(maxIDAttempts)
+	dbh.EXPECT().Create(mock.Anything).Return(ErrConflict).Times(maxIDAttempts)
+
+	input := model.ColorProfile{}
+	err := CreateWithNextID[model.ColorProfile](dbh, &input)
+
+	assert.ErrorIs(t, err, ErrConflict)
+}
+
+func TestCreateWithNextID_NextIDError(t *testing.T) {
+	dbh := dbm.NewDBHandler[model.ColorProfile](t)
+	dbh.EXPECT().NextID().Return(0, assert.AnError).Once()
+
+	input := model.ColorProfile{}
+	err := CreateWithNextID[model.ColorProfile](dbh, &input)
+
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestCreateWithNextID_NoIDSetter(t *testing.T) {
+	dbh := dbm.NewDBHandler[string](t)
+
+	input := "test"
+	err := CreateWithNextID[string](dbh, &input)
+
+	assert.Error(t, err)
+}
@@ -29,6 +29,11 @@ func NewLogLogger(name string) *log.Logger {
 	return slog.NewLogLogger(newLogHandler(), slog.LevelDebug)
 }
 
+// NewSlogLogger creates a structured logger for libraries, only warnings and errors are logged
+func NewSlogLogger(name string) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn})).WithGroup(name)
+}
+
 func newLogHandler() slog.Handler {
 	return slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
 }
@@ -0,0 +1,149 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/service"
+	"github.com/samber/do"
+)
+
+const (
+	setSuffix = "/set"
+	stateOn   = "ON"
+	stateOff  = "OFF"
+	// actor the actor of the events caused by the commands
+	actor = "mqtt"
+)
+
+// cmdHandler routes the commands received on <striptopic>/<id>/set to the led service
+type cmdHandler struct {
+	topic string
+	mh    messaging.EventHandler
+	lsvc  service.LEDService
+	l     alog.Logger
+}
+
+func NewHandler(i *do.Injector) *cmdHandler {
+	cfg := do.MustInvoke[*config.Config](i)
+	return &cmdHandler{
+		topic: cfg.Messaging.StripTopic,
+		mh:    do.MustInvoke[messaging.EventHandler](i),
+		lsvc:  do.MustInvoke[service.LEDService](i).WithActor(actor),
+		l:     alog.NewLogger("command"),
+	}
+}
+
+// Subscribe subscribes to the command topics of all strips, nothing is done if the messaging can't receive messages
+func (h *cmdHandler) Subscribe() error {
+	s, ok := h.mh.(messaging.Subscriber)
+	if !ok {
+		h.l.Info("messaging doesn't support subscriptions, commands are disabled")
+		return nil
+	}
+	err := s.Subscribe(h.topic+"/+"+setSuffix, h.handleMessage)
+	if errors.Is(err, messaging.ErrSubscriptionsUnsupported) {
+		h.l.Info("messaging doesn't support subscriptions, commands are disabled")
+		return nil
+	}
+	return err
+}
+
+func (h *cmdHandler) handleMessage(topic string, payload []byte) {
+	id, err := h.stripID(topic)
+	if err != nil {
+		h.l.Warn("ignoring command: %s", err.Error())
+		return
+	}
+	var cmd model.StripCommand
+	if err := json.Unmarshal(payload, &cmd); err != nil {
+		h.l.Warn("ignoring invalid command for strip %s: %s", id, err.Error())
+		return
+	}
+	if err := h.execute(id, cmd); err != nil {
+		h.l.Error("error executing command for strip %s: %s", id, err.Error())
+	}
+}
+
+// stripID extracts the id of the strip from the command topic
+func (h *cmdHandler) stripID(topic string) (string, error) {
+	id, ok := strings.CutPrefix(topic, h.topic+"/")
+	if ok {
+		id, ok = strings.CutSuffix(id, setSuffix)
+	}
+	if !ok || id == "" || strings.Contains(id, "/") {
+		return "", fmt.Errorf("unexpected topic %s", topic)
+	}
+	return id, nil
+}
+
+// execute applies the command, the state of the strip is changed first, followed by its profile and color
+func (h *cmdHandler) execute(id string, cmd model.StripCommand) error {
+	enabled, err := enabledOf(cmd)
+	if err != nil {
+		return err
+	}
+	changesColor := cmd.Color != nil || cmd.Brightness.Valid
+	if !enabled.Valid && !cmd.ProfileID.Valid && !changesColor {
+		return errors.New("command contains no change")
+	}
+	if enabled.Valid {
+		strip, err := h.lsvc.GetLEDStrip(id)
+		if err != nil {
+			return err
+		}
+		strip.Enabled = enabled.Bool
+		// the command is applied to the latest state, without a version check
+		strip.Version = 0
+		if _, err := h.lsvc.UpdateLEDStrip(id, *strip); err != nil {
+			return err
+		}
+	}
+	if cmd.ProfileID.Valid {
+		profile := model.ColorProfile{BaseModel: model.BaseModel{ID: cmd.ProfileID.Int64}}
+		if _, err := h.lsvc.UpdateProfileForStrip(id, profile); err != nil {
+			return err
+		}
+	}
+	if changesColor {
+		return h.applyColor(id, cmd)
+	}
+	return nil
+}
+
+// enabledOf returns the requested state of the strip, either from enabled or from the state of home assistant
+func enabledOf(cmd model.StripCommand) (null.Bool, error) {
+	if !cmd.State.Valid {
+		return cmd.Enabled, nil
+	}
+	switch cmd.State.String {
+	case stateOn:
+		return null.BoolFrom(true), nil
+	case stateOff:
+		return null.BoolFrom(false), nil
+	default:
+		return null.Bool{}, fmt.Errorf("unknown state %s", cmd.State.String)
+	}
+}
+
+// applyColor changes the color and brightness of the profile of the strip, which affects all strips using the
+// profile. A new profile is created for a strip without one.
+func (h *cmdHandler) applyColor(id string, cmd model.StripCommand) error {
+	var color model.ColorProfile
+	if cmd.Color != nil {
+		color.Red = null.IntFrom(cmd.Color.R)
+		color.Green = null.IntFrom(cmd.Color.G)
+		color.Blue = null.IntFrom(cmd.Color.B)
+	}
+	// only the received values are applied, the others are kept
+	color.Brightness = cmd.Brightness
+	_, err := h.lsvc.SetColorForStrip(id, color)
+	return err
+}
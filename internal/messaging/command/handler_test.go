@@ -0,0 +1,193 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	mhm "github.com/pthum/stripcontrol-golang/internal/messaging/mocks"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/service"
+	servicemocks "github.com/pthum/stripcontrol-golang/internal/service/mocks"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// subscriberFake an event handler recording the subscriptions
+type subscriberFake struct {
+	*mhm.EventHandler
+	subs map[string]messaging.MessageHandler
+	err  error
+}
+
+type cmdMocks struct {
+	lsvc *servicemocks.LEDService
+	ch   *cmdHandler
+}
+
+func TestSubscribe(t *testing.T) {
+	sub := &subscriberFake{EventHandler: mhm.NewEventHandler(t), subs: map[string]messaging.MessageHandler{}}
+	mocks := createCmdHandlerMocks(t, sub)
+
+	err := mocks.ch.Subscribe()
+
+	assert.NoError(t, err)
+	assert.Contains(t, sub.subs, "stripcontrol/strip/+/set")
+}
+
+func TestSubscribe_NotSupported(t *testing.T) {
+	mocks := createCmdHandlerMocks(t, mhm.NewEventHandler(t))
+
+	assert.NoError(t, mocks.ch.Subscribe())
+}
+
+func TestSubscribe_NoSubscriber(t *testing.T) {
+	sub := &subscriberFake{EventHandler: mhm.NewEventHandler(t), err: messaging.ErrSubscriptionsUnsupported}
+	mocks := createCmdHandlerMocks(t, sub)
+
+	assert.NoError(t, mocks.ch.Subscribe())
+}
+
+func TestHandleMessage_Enable(t *testing.T) {
+	mocks := createCmdHandlerMocks(t, mhm.NewEventHandler(t))
+	strip := &model.LedStrip{BaseModel: model.BaseModel{ID: 12, Version: 3}, Name: "strip"}
+	mocks.lsvc.EXPECT().GetLEDStrip("12").Return(strip, nil)
+	mocks.lsvc.EXPECT().
+		UpdateLEDStrip("12", mock.Anything).
+		Run(func(id string, updMdl model.LedStrip) {
+			assert.True(t, updMdl.Enabled)
+			assert.Equal(t, "strip", updMdl.Name)
+			assert.Equal(t, int64(0), updMdl.Version)
+		}).
+		Return(strip, nil)
+
+	mocks.ch.handleMessage("stripcontrol/strip/12/set", []byte(`{"enabled":true}`))
+}
+
+func TestHandleMessage_Profile(t *testing.T) {
+	mocks := createCmdHandlerMocks(t, mhm.NewEventHandler(t))
+	mocks.lsvc.EXPECT().
+		UpdateProfileForStrip("12", mock.Anything).
+		Run(func(id string, updProf model.ColorProfile) {
+			assert.Equal(t, int64(3), updProf.ID)
+		}).
+		Return(&model.ColorProfile{}, nil)
+
+	mocks.ch.handleMessage("stripcontrol/strip/12/set", []byte(`{"profileId":3}`))
+}
+
+func TestHandleMessage_EnableAndProfile(t *testing.T) {
+	mocks := createCmdHandlerMocks(t, mhm.NewEventHandler(t))
+	mocks.lsvc.EXPECT().GetLEDStrip("12").Return(&model.LedStrip{Enabled: true}, nil)
+	mocks.lsvc.EXPECT().
+		UpdateLEDStrip("12", mock.Anything).
+		Run(func(id string, updMdl model.LedStrip) {
+			assert.False(t, updMdl.Enabled)
+		}).
+		Return(&model.LedStrip{}, nil)
+	mocks.lsvc.EXPECT().UpdateProfileForStrip("12", mock.Anything).Return(&model.ColorProfile{}, nil)
+
+	mocks.ch.handleMessage("stripcontrol/strip/12/set", []byte(`{"enabled":false,"profileId":3}`))
+}
+
+func TestHandleMessage_UpdateError(t *testing.T) {
+	mocks := createCmdHandlerMocks(t, mhm.NewEventHandler(t))
+	mocks.lsvc.EXPECT().GetLEDStrip("12").Return(&model.LedStrip{}, nil)
+	mocks.lsvc.EXPECT().UpdateLEDStrip("12", mock.Anything).Return(nil, assert.AnError)
+
+	// the profile isn't changed, if the update failed
+	mocks.ch.handleMessage("stripcontrol/strip/12/set", []byte(`{"enabled":true,"profileId":3}`))
+}
+
+func TestHandleMessage_HomeAssistantState(t *testing.T) {
+	mocks := createCmdHandlerMocks(t, mhm.NewEventHandler(t))
+	mocks.lsvc.EXPECT().GetLEDStrip("12").Return(&model.LedStrip{Enabled: true}, nil)
+	mocks.lsvc.EXPECT().
+		UpdateLEDStrip("12", mock.Anything).
+		Run(func(id string, updMdl model.LedStrip) {
+			assert.False(t, updMdl.Enabled)
+		}).
+		Return(&model.LedStrip{}, nil)
+
+	mocks.ch.handleMessage("stripcontrol/strip/12/set", []byte(`{"state":"OFF"}`))
+}
+
+func TestHandleMessage_HomeAssistantColor(t *testing.T) {
+	mocks := createCmdHandlerMocks(t, mhm.NewEventHandler(t))
+	strip := &model.LedStrip{BaseModel: model.BaseModel{ID: 12}, Enabled: true, ProfileID: null.IntFrom(3)}
+	mocks.lsvc.EXPECT().GetLEDStrip("12").Return(strip, nil)
+	mocks.lsvc.EXPECT().UpdateLEDStrip("12", mock.Anything).Return(strip, nil)
+	mocks.lsvc.EXPECT().
+		SetColorForStrip("12", mock.Anything).
+		Run(func(id string, color model.ColorProfile) {
+			assert.Equal(t, null.IntFrom(255), color.Red)
+			assert.Equal(t, null.IntFrom(10), color.Green)
+			assert.Equal(t, null.IntFrom(0), color.Blue)
+			assert.Equal(t, null.IntFrom(20), color.Brightness)
+		}).
+		Return(&model.ColorProfile{}, nil)
+
+	mocks.ch.handleMessage("stripcontrol/strip/12/set", []byte(`{"state":"ON","brightness":20,"color":{"r":255,"g":10,"b":0}}`))
+}
+
+func TestHandleMessage_HomeAssistantBrightness(t *testing.T) {
+	mocks := createCmdHandlerMocks(t, mhm.NewEventHandler(t))
+	mocks.lsvc.EXPECT().
+		SetColorForStrip("12", mock.Anything).
+		Run(func(id string, color model.ColorProfile) {
+			// the colors are kept
+			assert.False(t, color.Red.Valid)
+			assert.False(t, color.Green.Valid)
+			assert.False(t, color.Blue.Valid)
+			assert.Equal(t, null.IntFrom(5), color.Brightness)
+		}).
+		Return(&model.ColorProfile{}, nil)
+
+	mocks.ch.handleMessage("stripcontrol/strip/12/set", []byte(`{"brightness":5}`))
+}
+
+func TestHandleMessage_Ignored(t *testing.T) {
+	tests := []struct {
+		name    string
+		topic   string
+		payload string
+	}{
+		{"invalid json", "stripcontrol/strip/12/set", `{"enabled":`},
+		{"unknown state", "stripcontrol/strip/12/set", `{"state":"DIM"}`},
+		{"no change", "stripcontrol/strip/12/set", `{}`},
+		{"other topic", "stripcontrol/profile/12/set", `{"enabled":true}`},
+		{"missing id", "stripcontrol/strip//set", `{"enabled":true}`},
+		{"nested topic", "stripcontrol/strip/12/x/set", `{"enabled":true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the service mock fails on any call
+			mocks := createCmdHandlerMocks(t, mhm.NewEventHandler(t))
+
+			mocks.ch.handleMessage(tt.topic, []byte(tt.payload))
+		})
+	}
+}
+
+func createCmdHandlerMocks(t *testing.T, mh messaging.EventHandler) *cmdMocks {
+	i := do.New()
+	do.ProvideValue(i, &config.Config{Messaging: config.MessagingConfig{StripTopic: "stripcontrol/strip"}})
+	do.ProvideValue(i, mh)
+	lsvc := servicemocks.NewLEDService(t)
+	lsvc.EXPECT().WithActor(actor).Return(lsvc).Once()
+	do.ProvideValue[service.LEDService](i, lsvc)
+	return &cmdMocks{
+		lsvc: lsvc,
+		ch:   NewHandler(i),
+	}
+}
+
+func (s *subscriberFake) Subscribe(topic string, handler messaging.MessageHandler) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.subs[topic] = handler
+	return nil
+}
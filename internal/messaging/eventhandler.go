@@ -1,12 +1,90 @@
 package messaging
 
 import (
+	"errors"
+
 	"github.com/pthum/stripcontrol-golang/internal/model"
 )
 
+// ErrNotConnected is returned if an event can't be published, because there is no connection to the broker
+var ErrNotConnected = errors.New("not connected to the message broker")
+
+// ErrPreviewsUnsupported is returned if none of the event handlers can publish previews
+var ErrPreviewsUnsupported = errors.New("previews are not supported")
+
+// ErrSubscriptionsUnsupported is returned if none of the event handlers can receive messages
+var ErrSubscriptionsUnsupported = errors.New("subscriptions are not supported")
+
+// ConnectionState the state of the connection to the message broker
+type ConnectionState string
+
+const (
+	Disconnected ConnectionState = "disconnected"
+	Connecting   ConnectionState = "connecting"
+	Connected    ConnectionState = "connected"
+)
+
 //go:generate mockery --name=EventHandler --with-expecter=true
 type EventHandler interface {
 	Shutdown() error
 	PublishProfileEvent(event *model.ProfileEvent) error
 	PublishStripEvent(event *model.StripEvent) error
 }
+
+// PendingCounter is implemented by event handlers which deliver the events asynchronously
+type PendingCounter interface {
+	// Pending returns the number of events which haven't been delivered yet
+	Pending() (int, error)
+}
+
+// MessageHandler handles a received message
+type MessageHandler func(topic string, payload []byte)
+
+// Subscriber is implemented by event handlers which can receive messages
+type Subscriber interface {
+	// Subscribe registers the handler for the topic, the subscription is renewed after every reconnect
+	Subscribe(topic string, handler MessageHandler) error
+}
+
+// Previewer is implemented by event handlers which can publish transient states of a strip
+type Previewer interface {
+	// PublishPreview publishes the strip event to the devices only, it is neither stored nor retried
+	PublishPreview(event *model.StripEvent) error
+}
+
+// ConnectionReporter is implemented by event handlers which are connected to a message broker
+type ConnectionReporter interface {
+	// ConnectionState returns the current state of the connection
+	ConnectionState() ConnectionState
+}
+
+const (
+	// StreamKindStrip the kind of stream events of led strips
+	StreamKindStrip = "strip"
+	// StreamKindProfile the kind of stream events of color profiles
+	StreamKindProfile = "profile"
+	// StreamKindResync replaces the missed events, if they aren't buffered anymore. The listener has to fetch the
+	// current state, as it may have missed any change.
+	StreamKindResync = "resync"
+)
+
+// StreamEvent an event distributed to the in-process subscribers
+type StreamEvent struct {
+	// ID the sequence number of the event
+	ID uint64
+	// Kind strip or profile
+	Kind string
+	// SubjectID the id of the strip or profile
+	SubjectID int64
+	// Data the json encoded event
+	Data []byte
+}
+
+// EventStream distributes the published events to in-process subscribers, like the clients of the event api
+type EventStream interface {
+	EventHandler
+	// Listen returns the buffered events after lastID and a channel receiving the following events, a lastID of 0
+	// starts with the next event. A single resync event is returned instead, if the missed events aren't buffered
+	// completely. The channel is closed if the listener can't keep up, cancel ends the listening.
+	Listen(lastID uint64) (missed []StreamEvent, events <-chan StreamEvent, cancel func())
+}
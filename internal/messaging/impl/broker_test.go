@@ -0,0 +1,231 @@
+package messagingimpl
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/stretchr/testify/assert"
+)
+
+// brokerStandIn an in-process broker, which accepts the connections and acknowledges the packets of the client,
+// without forwarding any message
+type brokerStandIn struct {
+	ln       net.Listener
+	username string
+	password string
+	mu       sync.Mutex
+	connects []*packets.ConnectPacket
+	messages []*packets.PublishPacket
+	subs     []string
+}
+
+// testCerts the files of a ca, the server and the client certificate signed by it
+type testCerts struct {
+	caFile         string
+	serverCertFile string
+	serverKeyFile  string
+	clientCertFile string
+	clientKeyFile  string
+}
+
+// startBroker starts the broker stand-in, it uses tls if a config is given and requires the credentials if set
+func startBroker(t *testing.T, tlsCfg *tls.Config, username, password string) *brokerStandIn {
+	var ln net.Listener
+	var err error
+	if tlsCfg != nil {
+		ln, err = tls.Listen("tcp", "127.0.0.1:0", tlsCfg)
+	} else {
+		ln, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	assert.NoError(t, err)
+	b := &brokerStandIn{ln: ln, username: username, password: password}
+	go b.accept()
+	t.Cleanup(func() { ln.Close() })
+	return b
+}
+
+func (b *brokerStandIn) port() string {
+	return fmt.Sprint(b.ln.Addr().(*net.TCPAddr).Port)
+}
+
+func (b *brokerStandIn) accept() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		go b.serve(conn)
+	}
+}
+
+func (b *brokerStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+	p, err := packets.ReadPacket(conn)
+	if err != nil {
+		return
+	}
+	connect, ok := p.(*packets.ConnectPacket)
+	if !ok {
+		return
+	}
+	b.mu.Lock()
+	b.connects = append(b.connects, connect)
+	b.mu.Unlock()
+	connack := packets.NewControlPacket(packets.Connack).(*packets.ConnackPacket)
+	if b.username != "" && (connect.Username != b.username || string(connect.Password) != b.password) {
+		connack.ReturnCode = packets.ErrRefusedNotAuthorised
+		connack.Write(conn)
+		return
+	}
+	if err := connack.Write(conn); err != nil {
+		return
+	}
+	for {
+		p, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		var resp packets.ControlPacket
+		switch pk := p.(type) {
+		case *packets.PublishPacket:
+			b.mu.Lock()
+			b.messages = append(b.messages, pk)
+			b.mu.Unlock()
+			if pk.Qos == 1 {
+				puback := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				puback.MessageID = pk.MessageID
+				resp = puback
+			} else if pk.Qos == 2 {
+				pubrec := packets.NewControlPacket(packets.Pubrec).(*packets.PubrecPacket)
+				pubrec.MessageID = pk.MessageID
+				resp = pubrec
+			}
+		case *packets.PubrelPacket:
+			pubcomp := packets.NewControlPacket(packets.Pubcomp).(*packets.PubcompPacket)
+			pubcomp.MessageID = pk.MessageID
+			resp = pubcomp
+		case *packets.SubscribePacket:
+			b.mu.Lock()
+			b.subs = append(b.subs, pk.Topics...)
+			b.mu.Unlock()
+			suback := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			suback.MessageID = pk.MessageID
+			suback.ReturnCodes = pk.Qoss
+			resp = suback
+		case *packets.PingreqPacket:
+			resp = packets.NewControlPacket(packets.Pingresp)
+		case *packets.DisconnectPacket:
+			return
+		}
+		if resp != nil {
+			if err := resp.Write(conn); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// connect returns the first connect packet received
+func (b *brokerStandIn) connect() *packets.ConnectPacket {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.connects) == 0 {
+		return nil
+	}
+	return b.connects[0]
+}
+
+// message returns the last message published to the topic
+func (b *brokerStandIn) message(topic string) *packets.PublishPacket {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := len(b.messages) - 1; i >= 0; i-- {
+		if b.messages[i].TopicName == topic {
+			return b.messages[i]
+		}
+	}
+	return nil
+}
+
+// serverTLSConfig the tls config of the broker, which requires a client certificate signed by the ca
+func (c testCerts) serverTLSConfig(t *testing.T) *tls.Config {
+	cert, err := tls.LoadX509KeyPair(c.serverCertFile, c.serverKeyFile)
+	assert.NoError(t, err)
+	ca, err := os.ReadFile(c.caFile)
+	assert.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca)
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+}
+
+// createTestCerts creates a ca, a server certificate for 127.0.0.1 and a client certificate
+func createTestCerts(t *testing.T) testCerts {
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	assert.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	assert.NoError(t, err)
+
+	certs := testCerts{caFile: filepath.Join(dir, "ca.pem")}
+	writePEM(t, certs.caFile, "CERTIFICATE", caDER)
+	certs.serverCertFile, certs.serverKeyFile = createSignedCert(t, dir, "server", 2, caCert, caKey, x509.ExtKeyUsageServerAuth)
+	certs.clientCertFile, certs.clientKeyFile = createSignedCert(t, dir, "client", 3, caCert, caKey, x509.ExtKeyUsageClientAuth)
+	return certs
+}
+
+func createSignedCert(t *testing.T, dir, name string, serial int64, ca *x509.Certificate, caKey *ecdsa.PrivateKey, usage x509.ExtKeyUsage) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	certFile := filepath.Join(dir, name+".pem")
+	keyFile := filepath.Join(dir, name+".key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, file, typ string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	assert.NoError(t, os.WriteFile(file, data, 0600))
+}
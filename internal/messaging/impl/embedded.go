@@ -0,0 +1,106 @@
+package messagingimpl
+
+import (
+	"net"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/samber/do"
+)
+
+const (
+	defaultBrokerAddress = ":1883"
+	brokerListenerID     = "stripcontrol"
+	// localHost the host to connect to, if the broker listens on all interfaces
+	localHost = "127.0.0.1"
+)
+
+// EmbeddedBroker a message broker running within the service, the strip controllers connect to it directly
+type EmbeddedBroker struct {
+	server   *mochi.Server
+	listener *listeners.TCP
+	l        alog.Logger
+}
+
+// NewEmbeddedBroker starts the embedded broker of the config
+func NewEmbeddedBroker(inj *do.Injector) (*EmbeddedBroker, error) {
+	cfg := do.MustInvoke[*config.Config](inj)
+	return startEmbeddedBroker(cfg.Messaging)
+}
+
+// startEmbeddedBroker starts the broker on the configured address, clients have to use the configured credentials
+// if set, otherwise all clients are accepted
+func startEmbeddedBroker(cfg config.MessagingConfig) (*EmbeddedBroker, error) {
+	address := cfg.Broker.Address
+	if address == "" {
+		address = defaultBrokerAddress
+	}
+	server := mochi.New(&mochi.Options{
+		Logger: alog.NewSlogLogger("broker"),
+	})
+	if err := server.AddHook(new(auth.Hook), &auth.Options{Ledger: brokerLedger(cfg)}); err != nil {
+		return nil, err
+	}
+	listener := listeners.NewTCP(listeners.Config{ID: brokerListenerID, Address: address})
+	if err := server.AddListener(listener); err != nil {
+		return nil, err
+	}
+	if err := server.Serve(); err != nil {
+		server.Close()
+		return nil, err
+	}
+	b := &EmbeddedBroker{
+		server:   server,
+		listener: listener,
+		l:        alog.NewLogger("broker"),
+	}
+	b.l.Info("embedded message broker listening on %s", listener.Address())
+	return b, nil
+}
+
+// brokerLedger allows all clients with the configured credentials to publish and subscribe to every topic
+func brokerLedger(cfg config.MessagingConfig) *auth.Ledger {
+	rule := auth.AuthRule{Allow: true}
+	if cfg.Username != "" || cfg.Password != "" {
+		rule.Username = auth.RString(cfg.Username)
+		rule.Password = auth.RString(cfg.Password)
+	}
+	return &auth.Ledger{
+		Auth: auth.AuthRules{rule},
+		ACL:  auth.ACLRules{{}},
+	}
+}
+
+// Address returns the host and port the clients of this service connect to
+func (b *EmbeddedBroker) Address() (host, port string) {
+	host, port, err := net.SplitHostPort(b.listener.Address())
+	if err != nil {
+		return localHost, port
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = localHost
+	}
+	return host, port
+}
+
+// clientConfig returns the config of the connection to the embedded broker, it replaces the configured broker
+func (b *EmbeddedBroker) clientConfig(cfg config.MessagingConfig) config.MessagingConfig {
+	cfg.Scheme = defaultScheme
+	cfg.Host, cfg.Port = b.Address()
+	cfg.CAFile = ""
+	cfg.CertFile = ""
+	cfg.KeyFile = ""
+	return cfg
+}
+
+// Shutdown disconnects all clients and stops the broker
+func (b *EmbeddedBroker) Shutdown() error {
+	if err := b.server.Close(); err != nil {
+		return err
+	}
+	b.l.Info("embedded message broker stopped")
+	return nil
+}
@@ -0,0 +1,133 @@
+package messagingimpl
+
+import (
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddedBroker_PublishStripEvent(t *testing.T) {
+	broker := startTestBroker(t, testConfig)
+	handler := newTestMQTT(t, broker.clientConfig(testConfig), nil)
+	defer handler.Shutdown()
+	connectAndWait(t, handler)
+	received := make(chan mqtt.Message, 10)
+	client, err := connectTestClient(broker, "controller", "", "")
+	assert.NoError(t, err)
+	defer client.Disconnect(100)
+	token := client.Subscribe("TestStrip", 1, func(_ mqtt.Client, msg mqtt.Message) {
+		received <- msg
+	})
+	assert.True(t, token.WaitTimeout(5*time.Second))
+	assert.NoError(t, token.Error())
+	event := model.NewStripEvent(null.IntFrom(3), model.Save).With(&model.LedStrip{BaseModel: model.BaseModel{ID: 3}})
+
+	assert.NoError(t, handler.PublishStripEvent(event))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, testutils.JsonEncode(t, event), string(msg.Payload()))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestEmbeddedBroker_Subscribe(t *testing.T) {
+	broker := startTestBroker(t, testConfig)
+	handler := newTestMQTT(t, broker.clientConfig(testConfig), nil)
+	defer handler.Shutdown()
+	commands := make(chan string, 1)
+	assert.NoError(t, handler.Subscribe("TestStrip/+/set", func(topic string, payload []byte) {
+		commands <- topic + " " + string(payload)
+	}))
+	connectAndWait(t, handler)
+	client, err := connectTestClient(broker, "controller", "", "")
+	assert.NoError(t, err)
+	defer client.Disconnect(100)
+
+	// the subscription is established asynchronously after the connect
+	assert.Eventually(t, func() bool {
+		client.Publish("TestStrip/3/set", 1, false, `{"state":"ON"}`).Wait()
+		select {
+		case cmd := <-commands:
+			assert.Equal(t, `TestStrip/3/set {"state":"ON"}`, cmd)
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestEmbeddedBroker_Credentials(t *testing.T) {
+	cfg := testConfig
+	cfg.Username = "mquser"
+	cfg.Password = "mqpass"
+	broker := startTestBroker(t, cfg)
+
+	_, err := connectTestClient(broker, "wrong", "mquser", "wrong")
+	assert.Error(t, err)
+	_, err = connectTestClient(broker, "anonymous", "", "")
+	assert.Error(t, err)
+	client, err := connectTestClient(broker, "controller", "mquser", "mqpass")
+	assert.NoError(t, err)
+	client.Disconnect(100)
+}
+
+func TestEmbeddedBroker_InvalidAddress(t *testing.T) {
+	cfg := testConfig
+	cfg.Broker = config.BrokerConfig{Enable: true, Address: "127.0.0.1:abc"}
+
+	_, err := startEmbeddedBroker(cfg)
+
+	assert.Error(t, err)
+}
+
+func TestEmbeddedBroker_ClientConfig(t *testing.T) {
+	cfg := testConfig
+	cfg.Scheme = "mqtts"
+	cfg.CAFile = "/certs/ca.pem"
+	cfg.Broker = config.BrokerConfig{Enable: true, Address: ":0"}
+	broker, err := startEmbeddedBroker(cfg)
+	assert.NoError(t, err)
+	defer broker.Shutdown()
+
+	clientCfg := broker.clientConfig(cfg)
+
+	_, port := broker.Address()
+	assert.Equal(t, "tcp", clientCfg.Scheme)
+	assert.Equal(t, localHost, clientCfg.Host)
+	assert.Equal(t, port, clientCfg.Port)
+	assert.NotEqual(t, "0", clientCfg.Port)
+	assert.Empty(t, clientCfg.CAFile)
+	assert.Equal(t, cfg.StripTopic, clientCfg.StripTopic)
+}
+
+// startTestBroker starts the embedded broker on a free port, it is stopped at the end of the test
+func startTestBroker(t *testing.T, cfg config.MessagingConfig) *EmbeddedBroker {
+	cfg.Broker = config.BrokerConfig{Enable: true, Address: "127.0.0.1:0"}
+	broker, err := startEmbeddedBroker(cfg)
+	assert.NoError(t, err)
+	t.Cleanup(func() { broker.Shutdown() })
+	return broker
+}
+
+// connectTestClient connects a client to the broker, like a strip controller would
+func connectTestClient(broker *EmbeddedBroker, clientID, username, password string) (mqtt.Client, error) {
+	host, port := broker.Address()
+	opts := mqtt.NewClientOptions().
+		AddBroker("tcp://" + host + ":" + port).
+		SetClientID(clientID).
+		SetUsername(username).
+		SetPassword(password).
+		SetConnectRetry(false)
+	client := mqtt.NewClient(opts)
+	token := client.Connect()
+	token.WaitTimeout(5 * time.Second)
+	return client, token.Error()
+}
@@ -0,0 +1,59 @@
+package messagingimpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+)
+
+const defaultSource = "stripcontrol"
+
+// EventEncoder encodes the events in the configured format
+type EventEncoder struct {
+	envelope bool
+	source   string
+}
+
+// NewEventEncoder creates the encoder for the event format of the config
+func NewEventEncoder(cfg config.MessagingConfig) (EventEncoder, error) {
+	var enc EventEncoder
+	switch cfg.EventFormat {
+	case config.EventFormatLegacy, "":
+	case config.EventFormatEnvelope:
+		enc.envelope = true
+	default:
+		return enc, fmt.Errorf("unsupported event format %q", cfg.EventFormat)
+	}
+	enc.source = cfg.Source
+	if enc.source == "" {
+		enc.source = defaultSource
+		if hostname, err := os.Hostname(); err == nil {
+			enc.source = hostname
+		}
+	}
+	return enc, nil
+}
+
+// Strip encodes the strip event
+func (e EventEncoder) Strip(event *model.StripEvent) ([]byte, error) {
+	return e.encode(model.EventKindStrip, event.Meta, event)
+}
+
+// Profile encodes the profile event
+func (e EventEncoder) Profile(event *model.ProfileEvent) ([]byte, error) {
+	return e.encode(model.EventKindProfile, event.Meta, event)
+}
+
+func (e EventEncoder) encode(kind string, meta model.EventMeta, event any) ([]byte, error) {
+	if !e.envelope {
+		return json.Marshal(event)
+	}
+	env, err := model.NewEventEnvelope(kind, e.source, meta, event)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(env)
+}
@@ -0,0 +1,63 @@
+package messagingimpl
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventEncoder_Legacy(t *testing.T) {
+	enc, err := NewEventEncoder(config.MessagingConfig{})
+	assert.NoError(t, err)
+	event := model.NewStripEvent(null.IntFrom(3), model.Save).With(&model.LedStrip{BaseModel: model.BaseModel{ID: 3}}).By("mqtt")
+
+	data, err := enc.Strip(event)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testutils.JsonEncode(t, event), string(data))
+}
+
+func TestEventEncoder_Envelope(t *testing.T) {
+	enc, err := NewEventEncoder(config.MessagingConfig{EventFormat: config.EventFormatEnvelope, Source: "living-room"})
+	assert.NoError(t, err)
+	event := model.NewProfileEvent(null.IntFrom(5), model.Delete).By("rest:127.0.0.1")
+
+	data, err := enc.Profile(event)
+
+	assert.NoError(t, err)
+	var env model.EventEnvelope
+	assert.NoError(t, json.Unmarshal(data, &env))
+	assert.Equal(t, event.Meta.ID, env.ID)
+	assert.True(t, event.Meta.Time.Equal(env.Time))
+	assert.Equal(t, "living-room", env.Source)
+	assert.Equal(t, "rest:127.0.0.1", env.Actor)
+	assert.Equal(t, model.EventSchemaVersion, env.SchemaVersion)
+	assert.Equal(t, model.EventKindProfile, env.Kind)
+	assert.JSONEq(t, testutils.JsonEncode(t, event), string(env.Data))
+}
+
+func TestEventEncoder_EnvelopeWithoutMeta(t *testing.T) {
+	enc, err := NewEventEncoder(config.MessagingConfig{EventFormat: config.EventFormatEnvelope})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, enc.source)
+
+	data, err := enc.Strip(model.NewStripEvent(null.IntFrom(3), model.Save))
+
+	assert.NoError(t, err)
+	var env model.EventEnvelope
+	assert.NoError(t, json.Unmarshal(data, &env))
+	assert.NotEmpty(t, env.ID)
+	assert.False(t, env.Time.IsZero())
+	assert.Empty(t, env.Actor)
+	assert.Equal(t, model.EventKindStrip, env.Kind)
+}
+
+func TestEventEncoder_UnknownFormat(t *testing.T) {
+	_, err := NewEventEncoder(config.MessagingConfig{EventFormat: "xml"})
+	assert.Error(t, err)
+}
@@ -1,17 +1,70 @@
 package messagingimpl
 
 import (
+	"fmt"
+
 	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/database"
 	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	"github.com/pthum/stripcontrol-golang/internal/model"
 	"github.com/samber/do"
 )
 
+// New creates the event handler for the configured sinks, the message broker and the webhooks. The webhooks are
+// called in the background, so publishing an event never waits for them. The event stream receives all events,
+// regardless of the configured sinks.
 func New(inj *do.Injector) (messaging.EventHandler, error) {
 	acfg := do.MustInvoke[*config.Config](inj)
-	// return NoOp implementation if disabled
-	if acfg.Messaging.Disabled {
-		return &NoOpEventHandler{}, nil
+	cfg := acfg.Messaging
+	stream := do.MustInvoke[messaging.EventStream](inj)
+	var handlers []messaging.EventHandler
+	if !cfg.Disabled {
+		mh, err := newBroker(inj, cfg)
+		if err != nil {
+			return nil, err
+		}
+		handlers = append(handlers, mh)
+	}
+	enc, err := NewEventEncoder(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, whCfg := range cfg.Webhooks {
+		wh, err := NewWebhook(whCfg, enc)
+		if err != nil {
+			return nil, err
+		}
+		handlers = append(handlers, newQueue(wh, defaultQueueSize))
+	}
+
+	if len(handlers) == 0 {
+		// only the event stream if all sinks are disabled
+		return stream, nil
 	}
+	return newFanOut(handlers...).observedBy(stream), nil
+}
 
-	return NewMQTT(acfg.Messaging), nil
+// newBroker creates the handler of the message broker, the events are stored in the outbox until the broker
+// acknowledged them. If the embedded broker is enabled, it is started and used instead of the configured one.
+func newBroker(inj *do.Injector, cfg config.MessagingConfig) (messaging.EventHandler, error) {
+	if cfg.QoS < 0 || cfg.QoS > 2 {
+		return nil, fmt.Errorf("unsupported qos %d, has to be 1 or 2, or 0 for the default 1", cfg.QoS)
+	}
+	if cfg.Broker.Enable {
+		broker, err := do.Invoke[*EmbeddedBroker](inj)
+		if err != nil {
+			return nil, err
+		}
+		cfg = broker.clientConfig(cfg)
+	}
+
+	dbh := do.MustInvoke[database.DBHandler[model.OutboxEntry]](inj)
+	lsdb := do.MustInvoke[database.DBHandler[model.LedStrip]](inj)
+	cpdb := do.MustInvoke[database.DBHandler[model.ColorProfile]](inj)
+	mh, err := NewMQTT(cfg, newStateLoader(lsdb, cpdb))
+	if err != nil {
+		return nil, err
+	}
+	mh.Connect()
+	return newOutbox(mh, dbh, cfg.RetryInterval, cfg.MaxRetryInterval), nil
 }
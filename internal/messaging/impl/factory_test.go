@@ -2,20 +2,25 @@ package messagingimpl
 
 import (
 	"testing"
+	"time"
 
 	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	"github.com/pthum/stripcontrol-golang/internal/database/csv"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	"github.com/pthum/stripcontrol-golang/internal/model"
 	"github.com/samber/do"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestNewNoOp(t *testing.T) {
+func TestNewStreamOnly(t *testing.T) {
 	cfg := config.MessagingConfig{
 		Disabled: true,
 	}
 	inj := provideCfg(cfg)
 	mh, err := New(inj)
 	assert.NoError(t, err)
-	_, ok := mh.(*NoOpEventHandler)
+	_, ok := mh.(*stream)
 	assert.True(t, ok)
 }
 
@@ -26,8 +31,94 @@ func TestNewMQTT(t *testing.T) {
 	inj := provideCfg(cfg)
 	mh, err := New(inj)
 	assert.NoError(t, err)
-	_, ok := mh.(*mqttHandler)
+	f, ok := mh.(*fanOut)
 	assert.True(t, ok)
+	assert.Len(t, f.handlers, 1)
+	ob, ok := f.handlers[0].(*outbox)
+	assert.True(t, ok)
+	_, ok = ob.next.(*mqttHandler)
+	assert.True(t, ok)
+	assert.Len(t, f.observers, 1)
+	assert.NoError(t, f.Shutdown())
+}
+
+func TestNewEmbeddedBroker(t *testing.T) {
+	cfg := config.MessagingConfig{
+		Broker: config.BrokerConfig{Enable: true, Address: "127.0.0.1:0"},
+	}
+	inj := provideCfg(cfg)
+	do.Provide(inj, New)
+	mh, err := do.Invoke[messaging.EventHandler](inj)
+	assert.NoError(t, err)
+	f, ok := mh.(*fanOut)
+	assert.True(t, ok)
+	mqh := f.handlers[0].(*outbox).next.(*mqttHandler)
+	broker := do.MustInvoke[*EmbeddedBroker](inj)
+	_, port := broker.Address()
+	assert.Equal(t, "tcp://127.0.0.1:"+port, mqh.opts.Servers[0].String())
+
+	// the service connects to the embedded broker, it is stopped after the handler
+	assert.Eventually(t, func() bool {
+		return f.ConnectionState() == messaging.Connected
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, inj.Shutdown())
+}
+
+func TestNewMQTT_InvalidQoS(t *testing.T) {
+	for _, qos := range []int{-1, 3} {
+		cfg := config.MessagingConfig{
+			QoS: qos,
+		}
+		inj := provideCfg(cfg)
+		_, err := New(inj)
+		assert.ErrorContains(t, err, "unsupported qos", qos)
+	}
+}
+
+func TestNewWebhook(t *testing.T) {
+	cfg := config.MessagingConfig{
+		Disabled: true,
+		Webhooks: []config.WebhookConfig{{URL: "https://example.com/hook"}},
+	}
+	inj := provideCfg(cfg)
+	mh, err := New(inj)
+	assert.NoError(t, err)
+	f, ok := mh.(*fanOut)
+	assert.True(t, ok)
+	assert.Len(t, f.handlers, 1)
+	q, ok := f.handlers[0].(*queue)
+	if assert.True(t, ok) {
+		_, ok = q.next.(*webhookHandler)
+		assert.True(t, ok)
+	}
+	assert.NoError(t, f.Shutdown())
+}
+
+func TestNewFanOut(t *testing.T) {
+	cfg := config.MessagingConfig{
+		Webhooks: []config.WebhookConfig{{URL: "https://example.com/hook"}, {URL: "http://localhost:9000/events"}},
+	}
+	inj := provideCfg(cfg)
+	mh, err := New(inj)
+	assert.NoError(t, err)
+	f, ok := mh.(*fanOut)
+	assert.True(t, ok)
+	assert.Len(t, f.handlers, 3)
+	_, ok = f.handlers[0].(*outbox)
+	assert.True(t, ok)
+	_, ok = f.observers[0].(*stream)
+	assert.True(t, ok)
+	assert.NoError(t, f.Shutdown())
+}
+
+func TestNew_InvalidWebhook(t *testing.T) {
+	cfg := config.MessagingConfig{
+		Disabled: true,
+		Webhooks: []config.WebhookConfig{{URL: "example.com"}},
+	}
+	inj := provideCfg(cfg)
+	_, err := New(inj)
+	assert.Error(t, err)
 }
 
 func provideCfg(cfg config.MessagingConfig) *do.Injector {
@@ -36,5 +127,10 @@ func provideCfg(cfg config.MessagingConfig) *do.Injector {
 	}
 	inj := do.New()
 	do.ProvideValue(inj, &acfg)
+	do.Provide(inj, NewStream)
+	do.Provide(inj, NewEmbeddedBroker)
+	do.ProvideValue[database.DBHandler[model.OutboxEntry]](inj, csv.NewHandler[model.OutboxEntry](&acfg.CSV))
+	do.ProvideValue[database.DBHandler[model.LedStrip]](inj, csv.NewHandler[model.LedStrip](&acfg.CSV))
+	do.ProvideValue[database.DBHandler[model.ColorProfile]](inj, csv.NewHandler[model.ColorProfile](&acfg.CSV))
 	return inj
 }
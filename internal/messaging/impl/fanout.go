@@ -0,0 +1,136 @@
+package messagingimpl
+
+import (
+	"errors"
+
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+)
+
+// interface guard
+var _ messaging.EventHandler = (*fanOut)(nil)
+var _ messaging.PendingCounter = (*fanOut)(nil)
+var _ messaging.Subscriber = (*fanOut)(nil)
+var _ messaging.Previewer = (*fanOut)(nil)
+var _ messaging.ConnectionReporter = (*fanOut)(nil)
+
+// fanOut publishes the events to several handlers, a failing handler doesn't prevent the delivery to the others.
+// The observers receive the events as well, but don't take part in the pending events, subscriptions and the
+// connection state.
+type fanOut struct {
+	handlers  []messaging.EventHandler
+	observers []messaging.EventHandler
+}
+
+func newFanOut(handlers ...messaging.EventHandler) *fanOut {
+	return &fanOut{handlers: handlers}
+}
+
+// observedBy adds the observers to the fan out
+func (f *fanOut) observedBy(observers ...messaging.EventHandler) *fanOut {
+	f.observers = append(f.observers, observers...)
+	return f
+}
+
+// PublishStripEvent publishes the strip event to all handlers and observers
+func (f *fanOut) PublishStripEvent(event *model.StripEvent) error {
+	return f.all(func(h messaging.EventHandler) error {
+		return h.PublishStripEvent(event)
+	})
+}
+
+// PublishProfileEvent publishes the profile event to all handlers and observers
+func (f *fanOut) PublishProfileEvent(event *model.ProfileEvent) error {
+	return f.all(func(h messaging.EventHandler) error {
+		return h.PublishProfileEvent(event)
+	})
+}
+
+// PublishPreview publishes the preview to all handlers supporting previews
+func (f *fanOut) PublishPreview(event *model.StripEvent) error {
+	published := false
+	err := f.each(func(h messaging.EventHandler) error {
+		p, ok := h.(messaging.Previewer)
+		if !ok {
+			return nil
+		}
+		published = true
+		return p.PublishPreview(event)
+	})
+	if !published {
+		return messaging.ErrPreviewsUnsupported
+	}
+	return err
+}
+
+// Shutdown shuts down all handlers and observers
+func (f *fanOut) Shutdown() error {
+	return f.all(func(h messaging.EventHandler) error {
+		return h.Shutdown()
+	})
+}
+
+// Pending returns the sum of the pending events of all handlers
+func (f *fanOut) Pending() (int, error) {
+	sum := 0
+	for _, h := range f.handlers {
+		if pc, ok := h.(messaging.PendingCounter); ok {
+			pending, err := pc.Pending()
+			if err != nil {
+				return 0, err
+			}
+			sum += pending
+		}
+	}
+	return sum, nil
+}
+
+// Subscribe subscribes to the topic at all handlers supporting subscriptions
+func (f *fanOut) Subscribe(topic string, handler messaging.MessageHandler) error {
+	subscribed := false
+	err := f.each(func(h messaging.EventHandler) error {
+		s, ok := h.(messaging.Subscriber)
+		if !ok {
+			return nil
+		}
+		subscribed = true
+		return s.Subscribe(topic, handler)
+	})
+	if !subscribed {
+		return messaging.ErrSubscriptionsUnsupported
+	}
+	return err
+}
+
+// ConnectionState returns the state of the first handler with a connection, it is connected if none has one
+func (f *fanOut) ConnectionState() messaging.ConnectionState {
+	for _, h := range f.handlers {
+		if cr, ok := h.(messaging.ConnectionReporter); ok {
+			return cr.ConnectionState()
+		}
+	}
+	return messaging.Connected
+}
+
+// each calls the function for all handlers and returns the joined errors
+func (f *fanOut) each(fn func(h messaging.EventHandler) error) error {
+	var errs []error
+	for _, h := range f.handlers {
+		if err := fn(h); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// all calls the function for all observers and handlers and returns the joined errors. The observers are called
+// first, so the in-process subscribers never wait for the network sinks.
+func (f *fanOut) all(fn func(h messaging.EventHandler) error) error {
+	var errs []error
+	for _, o := range f.observers {
+		if err := fn(o); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(append(errs, f.each(fn))...)
+}
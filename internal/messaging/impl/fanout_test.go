@@ -0,0 +1,119 @@
+package messagingimpl
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFanOutPublish(t *testing.T) {
+	failing := &eventHandlerFake{failures: 10}
+	working := &eventHandlerFake{}
+	f := newFanOut(failing, working)
+
+	err := f.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save))
+	assert.ErrorIs(t, err, errReturn)
+	err = f.PublishProfileEvent(model.NewProfileEvent(null.IntFrom(2), model.Save))
+	assert.ErrorIs(t, err, errReturn)
+
+	// the failing handler doesn't prevent the delivery
+	assert.Equal(t, 2, failing.getCalls())
+	assert.Equal(t, []int64{1, 2}, working.getDelivered())
+}
+
+func TestFanOutShutdown(t *testing.T) {
+	first := &eventHandlerFake{}
+	second := &eventHandlerFake{}
+	observer := &eventHandlerFake{}
+	f := newFanOut(first, second).observedBy(observer)
+
+	assert.NoError(t, f.Shutdown())
+	assert.True(t, first.shutdown)
+	assert.True(t, second.shutdown)
+	assert.True(t, observer.shutdown)
+}
+
+func TestFanOutObserver(t *testing.T) {
+	mh := createMqttMocks(t, nil)
+	observer := newStream(10)
+	f := newFanOut(&eventHandlerFake{}).observedBy(mh, observer)
+
+	assert.NoError(t, f.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save)))
+	missed, _, cancel := observer.Listen(math.MaxUint64)
+	defer cancel()
+	assert.Len(t, missed, 1)
+
+	// observers don't take part in the subscriptions and the connection state
+	assert.ErrorIs(t, f.Subscribe("TestStrip/+/set", func(string, []byte) {}), messaging.ErrSubscriptionsUnsupported)
+	mh.onConnectionLost(nil, errReturn)
+	assert.Equal(t, messaging.Connected, f.ConnectionState())
+}
+
+func TestFanOutObserverFirst(t *testing.T) {
+	slow := &blockingHandlerFake{release: make(chan struct{})}
+	observer := newStream(10)
+	f := newFanOut(slow).observedBy(observer)
+	done := make(chan error)
+
+	go func() {
+		done <- f.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save))
+	}()
+
+	// the observer receives the event while the handler is still busy
+	assert.Eventually(t, func() bool {
+		missed, _, cancel := observer.Listen(math.MaxUint64)
+		defer cancel()
+		return len(missed) == 1
+	}, time.Second, time.Millisecond)
+	close(slow.release)
+	assert.NoError(t, <-done)
+	assert.Equal(t, []int64{1}, slow.getDelivered())
+}
+
+func TestFanOutPending(t *testing.T) {
+	ob := newOutbox(&eventHandlerFake{failures: 10}, newOutboxDB(t, ""), time.Hour, time.Hour)
+	defer ob.Shutdown()
+	f := newFanOut(&eventHandlerFake{}, ob)
+	assert.NoError(t, f.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save)))
+	assert.NoError(t, f.PublishStripEvent(model.NewStripEvent(null.IntFrom(2), model.Save)))
+
+	// the first event is in delivery, the second waits for it
+	pending, err := f.Pending()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pending)
+}
+
+func TestFanOutPublishPreview(t *testing.T) {
+	mh := createMqttMocks(t, nil)
+	observer := newStream(10)
+	f := newFanOut(&eventHandlerFake{}, mh).observedBy(observer)
+
+	assert.NoError(t, f.PublishPreview(model.NewStripEvent(null.IntFrom(1), model.Save)))
+	assert.Len(t, mh.mqclient.(*mqttClientFake).published, 1)
+	// the observers don't receive previews
+	assert.Empty(t, observer.buffer)
+	assert.ErrorIs(t, newFanOut(&eventHandlerFake{}).PublishPreview(model.NewStripEvent(null.IntFrom(1), model.Save)), messaging.ErrPreviewsUnsupported)
+}
+
+func TestFanOutSubscribe(t *testing.T) {
+	mh := createMqttMocks(t, nil)
+	f := newFanOut(&eventHandlerFake{}, mh)
+
+	assert.NoError(t, f.Subscribe("TestStrip/+/set", func(string, []byte) {}))
+	assert.Contains(t, mh.mqclient.(*mqttClientFake).subscribed, "TestStrip/+/set")
+	assert.Error(t, newFanOut(&eventHandlerFake{}).Subscribe("TestStrip/+/set", func(string, []byte) {}))
+}
+
+func TestFanOutConnectionState(t *testing.T) {
+	mh := createMqttMocks(t, nil)
+	f := newFanOut(&eventHandlerFake{}, mh)
+	mh.onConnectionLost(nil, errReturn)
+
+	assert.Equal(t, messaging.Connecting, f.ConnectionState())
+	assert.Equal(t, messaging.Connected, newFanOut(&eventHandlerFake{}).ConnectionState())
+}
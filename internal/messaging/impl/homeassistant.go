@@ -0,0 +1,151 @@
+package messagingimpl
+
+import (
+	"encoding/json"
+	"fmt"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+)
+
+const (
+	defaultDiscoveryPrefix = "homeassistant"
+	payloadOnline          = "online"
+	payloadOffline         = "offline"
+	haStateOn              = "ON"
+	haStateOff             = "OFF"
+	haColorModeRGB         = "rgb"
+	// haBrightnessScale the brightness of the profiles, home assistant scales its brightness to it
+	haBrightnessScale = 31
+)
+
+// haLightConfig the discovery config of a json schema light
+type haLightConfig struct {
+	Name                string   `json:"name"`
+	UniqueID            string   `json:"unique_id"`
+	ObjectID            string   `json:"object_id"`
+	Schema              string   `json:"schema"`
+	StateTopic          string   `json:"state_topic"`
+	CommandTopic        string   `json:"command_topic"`
+	AvailabilityTopic   string   `json:"availability_topic"`
+	PayloadAvailable    string   `json:"payload_available"`
+	PayloadNotAvailable string   `json:"payload_not_available"`
+	Brightness          bool     `json:"brightness"`
+	BrightnessScale     int      `json:"brightness_scale"`
+	SupportedColorModes []string `json:"supported_color_modes"`
+	QoS                 byte     `json:"qos"`
+	Device              haDevice `json:"device"`
+}
+
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+}
+
+// haLightState the state of a json schema light
+type haLightState struct {
+	State      string          `json:"state"`
+	ColorMode  string          `json:"color_mode,omitempty"`
+	Brightness *int64          `json:"brightness,omitempty"`
+	Color      *model.RGBColor `json:"color,omitempty"`
+}
+
+// publishDiscovery publishes the discovery config and the light state of the strip, a deleted strip is removed
+func (m *mqttHandler) publishDiscovery(client mqtt.Client, event *model.StripEvent) error {
+	id := event.ID.Int64
+	if event.Type == model.Delete {
+		// an empty retained config removes the light from home assistant
+		if err := m.send(client, m.discoveryTopic(id), true, []byte{}); err != nil {
+			return err
+		}
+		return m.send(client, m.lightStateTopic(id), true, []byte{})
+	}
+	if !event.Strip.Valid {
+		return nil
+	}
+	config, err := json.Marshal(m.lightConfig(event))
+	if err != nil {
+		return err
+	}
+	if err := m.send(client, m.discoveryTopic(id), true, config); err != nil {
+		return err
+	}
+	state, err := json.Marshal(lightState(event))
+	if err != nil {
+		return err
+	}
+	return m.send(client, m.lightStateTopic(id), true, state)
+}
+
+func (m *mqttHandler) lightConfig(event *model.StripEvent) haLightConfig {
+	uid := fmt.Sprintf("stripcontrol_%d", event.ID.Int64)
+	name := event.Strip.Strip.Name
+	if name == "" {
+		name = uid
+	}
+	return haLightConfig{
+		Name:                name,
+		UniqueID:            uid,
+		ObjectID:            uid,
+		Schema:              "json",
+		StateTopic:          m.lightStateTopic(event.ID.Int64),
+		CommandTopic:        m.commandTopic(event.ID.Int64),
+		AvailabilityTopic:   m.availabilityTopic(),
+		PayloadAvailable:    payloadOnline,
+		PayloadNotAvailable: payloadOffline,
+		Brightness:          true,
+		BrightnessScale:     haBrightnessScale,
+		SupportedColorModes: []string{haColorModeRGB},
+		QoS:                 m.qos(),
+		Device: haDevice{
+			Identifiers:  []string{uid},
+			Name:         name,
+			Manufacturer: "stripcontrol",
+		},
+	}
+}
+
+// lightState maps the strip and its profile onto the state of the light
+func lightState(event *model.StripEvent) haLightState {
+	strip := event.Strip.Strip
+	state := haLightState{State: haStateOff}
+	if strip.Enabled {
+		state.State = haStateOn
+	}
+	if !strip.Profile.Valid {
+		return state
+	}
+	profile := strip.Profile.Profile
+	state.ColorMode = haColorModeRGB
+	state.Color = &model.RGBColor{R: profile.Red.Int64, G: profile.Green.Int64, B: profile.Blue.Int64}
+	if profile.Brightness.Valid {
+		state.Brightness = &profile.Brightness.Int64
+	}
+	return state
+}
+
+func (m *mqttHandler) discoveryTopic(id int64) string {
+	prefix := m.cfg.DiscoveryPrefix
+	if prefix == "" {
+		prefix = defaultDiscoveryPrefix
+	}
+	return fmt.Sprintf("%s/light/stripcontrol_%d/config", prefix, id)
+}
+
+// lightStateTopic returns the topic with the state of the strip in the format of home assistant
+func (m *mqttHandler) lightStateTopic(id int64) string {
+	return fmt.Sprintf("%s/%d/light", m.cfg.StripTopic, id)
+}
+
+// commandTopic returns the topic which receives the commands for the strip
+func (m *mqttHandler) commandTopic(id int64) string {
+	return fmt.Sprintf("%s/%d/set", m.cfg.StripTopic, id)
+}
+
+func (m *mqttHandler) availabilityTopic() string {
+	if m.cfg.AvailabilityTopic == "" {
+		return m.cfg.StripTopic + "/availability"
+	}
+	return m.cfg.AvailabilityTopic
+}
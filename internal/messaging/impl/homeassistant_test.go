@@ -0,0 +1,123 @@
+package messagingimpl
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishDiscovery(t *testing.T) {
+	handler := createDiscoveryMocks(t)
+	event := createDiscoveryEvent(true)
+
+	assert.NoError(t, handler.PublishStripEvent(event))
+
+	published := handler.mqclient.(*mqttClientFake).published
+	assert.Len(t, published, 4)
+	config := findMessage(t, published, "homeassistant/light/stripcontrol_12/config")
+	assert.True(t, config.retained)
+	var cfg map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(config.payload), &cfg))
+	assert.Equal(t, "kitchen", cfg["name"])
+	assert.Equal(t, "stripcontrol_12", cfg["unique_id"])
+	assert.Equal(t, "json", cfg["schema"])
+	assert.Equal(t, "TestStrip/12/light", cfg["state_topic"])
+	assert.Equal(t, "TestStrip/12/set", cfg["command_topic"])
+	assert.Equal(t, "TestStrip/availability", cfg["availability_topic"])
+	assert.Equal(t, true, cfg["brightness"])
+	assert.Equal(t, float64(31), cfg["brightness_scale"])
+	assert.Equal(t, []any{"rgb"}, cfg["supported_color_modes"])
+
+	state := findMessage(t, published, "TestStrip/12/light")
+	assert.True(t, state.retained)
+	assert.JSONEq(t, `{"state":"ON","color_mode":"rgb","brightness":20,"color":{"r":255,"g":100,"b":0}}`, state.payload)
+}
+
+func TestPublishDiscovery_WithoutProfile(t *testing.T) {
+	handler := createDiscoveryMocks(t)
+	event := createDiscoveryEvent(false)
+	event.Strip.Strip.Enabled = false
+
+	assert.NoError(t, handler.PublishStripEvent(event))
+
+	state := findMessage(t, handler.mqclient.(*mqttClientFake).published, "TestStrip/12/light")
+	assert.JSONEq(t, `{"state":"OFF"}`, state.payload)
+}
+
+func TestPublishDiscovery_Delete(t *testing.T) {
+	handler := createDiscoveryMocks(t)
+	handler.cfg.DiscoveryPrefix = "ha"
+
+	assert.NoError(t, handler.PublishStripEvent(model.NewStripEvent(null.IntFrom(12), model.Delete)))
+
+	published := handler.mqclient.(*mqttClientFake).published
+	config := findMessage(t, published, "ha/light/stripcontrol_12/config")
+	assert.True(t, config.retained)
+	assert.Empty(t, config.payload)
+	state := findMessage(t, published, "TestStrip/12/light")
+	assert.True(t, state.retained)
+	assert.Empty(t, state.payload)
+}
+
+func TestPublishDiscovery_Disabled(t *testing.T) {
+	handler := createMqttMocks(t, nil)
+
+	assert.NoError(t, handler.PublishStripEvent(createDiscoveryEvent(true)))
+
+	published := handler.mqclient.(*mqttClientFake).published
+	assert.Len(t, published, 2)
+	for _, msg := range published {
+		assert.NotContains(t, msg.topic, "homeassistant")
+	}
+}
+
+func TestAvailabilityTopic(t *testing.T) {
+	handler := newTestMQTT(t, testConfig, nil)
+	assert.Equal(t, "TestStrip/availability", handler.availabilityTopic())
+	assert.Equal(t, "TestStrip/availability", handler.opts.WillTopic)
+	assert.Equal(t, []byte(payloadOffline), handler.opts.WillPayload)
+	assert.True(t, handler.opts.WillRetained)
+
+	cfg := testConfig
+	cfg.AvailabilityTopic = "stripcontrol/status"
+	handler = newTestMQTT(t, cfg, nil)
+	assert.Equal(t, "stripcontrol/status", handler.availabilityTopic())
+	assert.Equal(t, "stripcontrol/status", handler.opts.WillTopic)
+}
+
+func createDiscoveryMocks(t *testing.T) *mqttHandler {
+	handler := createMqttMocks(t, nil)
+	handler.cfg.Discovery = true
+	return handler
+}
+
+func createDiscoveryEvent(withProfile bool) *model.StripEvent {
+	event := model.NewStripEvent(null.IntFrom(12), model.Save).With(&model.LedStrip{
+		BaseModel: model.BaseModel{ID: 12},
+		Name:      "kitchen",
+		Enabled:   true,
+	})
+	if withProfile {
+		event.Strip.With(model.ColorProfile{
+			BaseModel:  model.BaseModel{ID: 3},
+			Red:        null.IntFrom(255),
+			Green:      null.IntFrom(100),
+			Blue:       null.IntFrom(0),
+			Brightness: null.IntFrom(20),
+		})
+	}
+	return event
+}
+
+func findMessage(t *testing.T, published []fakeMessage, topic string) fakeMessage {
+	for _, msg := range published {
+		if msg.topic == topic {
+			return msg
+		}
+	}
+	assert.Fail(t, "no message published", "topic %s", topic)
+	return fakeMessage{}
+}
@@ -1,28 +1,50 @@
 package messagingimpl
 
 import (
-	"encoding/json"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"log"
+	"os"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/pthum/stripcontrol-golang/internal/config"
 	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
 	"github.com/pthum/stripcontrol-golang/internal/model"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
-var once sync.Once
+const (
+	defaultQoS            = 1
+	defaultPublishTimeout = 10 * time.Second
+	defaultScheme         = "tcp"
+	defaultClientID       = "stripcontrol-go"
+)
+
+var supportedSchemes = []string{"tcp", "ssl", "tls", "mqtts", "ws", "wss"}
+
+// interface guard
+var _ messaging.EventHandler = (*mqttHandler)(nil)
+var _ messaging.Subscriber = (*mqttHandler)(nil)
+var _ messaging.Previewer = (*mqttHandler)(nil)
+var _ messaging.ConnectionReporter = (*mqttHandler)(nil)
 
 type mqttHandler struct {
-	mqclient   mqtt.Client
-	opts       *mqtt.ClientOptions
-	cfg        config.MessagingConfig
-	intialized bool
-	l          alog.Logger
+	mqclient mqtt.Client
+	opts     *mqtt.ClientOptions
+	cfg      config.MessagingConfig
+	enc      EventEncoder
+	stateMu  sync.RWMutex
+	state    messaging.ConnectionState
+	states   stateLoader
+	subMu    sync.Mutex
+	subs     map[string]messaging.MessageHandler
+	l        alog.Logger
 }
 
 // define a function for the default message handler
@@ -30,86 +52,327 @@ var f mqtt.MessageHandler = func(client mqtt.Client, msg mqtt.Message) {
 	log.Printf("TOPIC: %s new message: %s\n", msg.Topic(), msg.Payload())
 }
 
-var connectHandler mqtt.OnConnectHandler = func(client mqtt.Client) {
-	log.Println("Connected")
+// NewMQTT creates the mqtt handler, the states are published retained on every (re)connect
+func NewMQTT(cfg config.MessagingConfig, states stateLoader) (*mqttHandler, error) {
+	opts, err := buildClientOpts(cfg)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := NewEventEncoder(cfg)
+	if err != nil {
+		return nil, err
+	}
+	m := &mqttHandler{
+		opts:   opts,
+		cfg:    cfg,
+		enc:    enc,
+		state:  messaging.Disconnected,
+		states: states,
+		subs:   map[string]messaging.MessageHandler{},
+		l:      alog.NewLogger("mqtt"),
+	}
+	m.opts.SetOnConnectHandler(m.onConnect)
+	m.opts.SetConnectionLostHandler(m.onConnectionLost)
+	m.opts.SetReconnectingHandler(m.onReconnecting)
+	// the broker marks the service as offline, if the connection is lost
+	m.opts.SetWill(m.availabilityTopic(), payloadOffline, m.qos(), true)
+	m.mqclient = mqtt.NewClient(m.opts)
+	return m, nil
 }
 
-var connectLostHandler mqtt.ConnectionLostHandler = func(client mqtt.Client, err error) {
-	log.Printf("Connect lost: %v", err)
+// Connect connects to the broker in the background, the connection is retried until it is established
+func (m *mqttHandler) Connect() {
+	m.setState(messaging.Connecting)
+	go func() {
+		token := m.mqclient.Connect()
+		token.Wait()
+		if err := token.Error(); err != nil {
+			m.l.Error("error connecting to the message broker: %s", err.Error())
+			m.setState(messaging.Disconnected)
+		}
+	}()
 }
 
-var reconnectHandler mqtt.ReconnectHandler = func(c mqtt.Client, co *mqtt.ClientOptions) {
-	log.Printf("Trying to reconnect")
+// ConnectionState returns the state of the connection to the broker
+func (m *mqttHandler) ConnectionState() messaging.ConnectionState {
+	m.stateMu.RLock()
+	defer m.stateMu.RUnlock()
+	return m.state
 }
 
-func NewMQTT(cfg config.MessagingConfig) *mqttHandler {
-	return &mqttHandler{
-		opts: buildClientOpts(cfg),
-		cfg:  cfg,
-		l:    alog.NewLogger("mqtt"),
-	}
+func (m *mqttHandler) setState(state messaging.ConnectionState) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	m.state = state
 }
 
-func (m *mqttHandler) getClient() mqtt.Client {
-	if !m.intialized {
-		once.Do(func() {
-			//create and start a client using the above ClientOptions
-			m.mqclient = mqtt.NewClient(m.opts)
-			if token := m.mqclient.Connect(); token.Wait() && token.Error() != nil {
-				panic(token.Error())
-			}
-			m.l.Info("initialized messaging")
-			m.intialized = true
-		})
-	}
-	return m.mqclient
+func (m *mqttHandler) onConnectionLost(_ mqtt.Client, err error) {
+	m.l.Warn("connection lost: %v", err)
+	m.setState(messaging.Connecting)
 }
 
-func buildClientOpts(cfg config.MessagingConfig) *mqtt.ClientOptions {
+func (m *mqttHandler) onReconnecting(mqtt.Client, *mqtt.ClientOptions) {
+	m.l.Info("trying to reconnect")
+	m.setState(messaging.Connecting)
+}
+
+func buildClientOpts(cfg config.MessagingConfig) (*mqtt.ClientOptions, error) {
 	//create a ClientOptions struct setting the broker address, clientid, turn
 	//off trace output and set the default message handler
-	configString := fmt.Sprintf("tcp://%s:%s", cfg.Host, cfg.Port)
+	scheme := cfg.Scheme
+	if scheme == "" {
+		scheme = defaultScheme
+	}
+	if !slices.Contains(supportedSchemes, scheme) {
+		return nil, fmt.Errorf("unsupported scheme %q", scheme)
+	}
+	configString := fmt.Sprintf("%s://%s:%s", scheme, cfg.Host, cfg.Port)
 	opts := mqtt.NewClientOptions().AddBroker(configString)
-	opts.SetClientID("stripcontrol-go")
+	clientID := cfg.ClientID
+	if clientID == "" {
+		clientID = defaultClientID
+	}
+	opts.SetClientID(clientID)
+	opts.SetUsername(cfg.Username)
+	opts.SetPassword(cfg.Password)
+	if cfg.KeepAlive > 0 {
+		opts.SetKeepAlive(cfg.KeepAlive)
+	}
+	tlsCfg, err := buildTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		opts.SetTLSConfig(tlsCfg)
+	}
 	opts.SetDefaultPublishHandler(f)
-	opts.SetConnectionLostHandler(connectLostHandler)
-	opts.SetOnConnectHandler(connectHandler)
 	opts.SetConnectRetry(true)
 	opts.SetConnectRetryInterval(1 * time.Second)
 	opts.SetAutoReconnect(true)
 	opts.SetMaxReconnectInterval(60 * time.Second)
-	opts.SetReconnectingHandler(reconnectHandler)
-	return opts
+	return opts, nil
+}
+
+// buildTLSConfig loads the configured certificates, nil is returned if none are configured
+func buildTLSConfig(cfg config.MessagingConfig) (*tls.Config, error) {
+	if cfg.CAFile == "" && cfg.CertFile == "" && cfg.KeyFile == "" {
+		return nil, nil
+	}
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in ca file %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
 }
 
 // Close closes connections to message broker
 func (m *mqttHandler) Shutdown() error {
+	state := m.ConnectionState()
+	if state == messaging.Disconnected {
+		// never connected, nothing to close
+		return nil
+	}
+	if state == messaging.Connected {
+		// the last will isn't sent on a graceful disconnect
+		if err := m.send(m.mqclient, m.availabilityTopic(), true, []byte(payloadOffline)); err != nil {
+			m.l.Warn("error publishing the availability: %s", err.Error())
+		}
+	}
+	// stops the connection attempts as well
 	m.mqclient.Disconnect(100)
+	m.setState(messaging.Disconnected)
 	m.l.Info("message broker connection gracefully closed")
 	return nil
 }
 
-// PublishStripEvent publishes a strip event
+// PublishStripEvent publishes a strip event and updates the retained state of the strip
 func (m *mqttHandler) PublishStripEvent(event *model.StripEvent) error {
-	return m.publish(m.cfg.StripTopic, event)
+	if err := m.checkConnected(); err != nil {
+		return err
+	}
+	data, err := m.enc.Strip(event)
+	if err != nil {
+		return err
+	}
+	if err := m.send(m.mqclient, m.cfg.StripTopic, false, data); err != nil {
+		return err
+	}
+	return m.publishState(m.mqclient, event)
 }
 
-// PublishProfileEvent publishes a profile event
+// PublishPreview publishes the strip event without updating the retained state, so a reconnecting device gets the
+// last saved state
+func (m *mqttHandler) PublishPreview(event *model.StripEvent) error {
+	if err := m.checkConnected(); err != nil {
+		return err
+	}
+	data, err := m.enc.Strip(event)
+	if err != nil {
+		return err
+	}
+	return m.send(m.mqclient, m.cfg.StripTopic, false, data)
+}
+
+// PublishProfileEvent publishes a profile event and updates the retained states, as they contain the profiles
 func (m *mqttHandler) PublishProfileEvent(event *model.ProfileEvent) error {
-	return m.publish(m.cfg.ProfileTopic, event)
+	if err := m.checkConnected(); err != nil {
+		return err
+	}
+	data, err := m.enc.Profile(event)
+	if err != nil {
+		return err
+	}
+	if err := m.send(m.mqclient, m.cfg.ProfileTopic, false, data); err != nil {
+		return err
+	}
+	return m.publishStates(m.mqclient)
 }
 
-func (m *mqttHandler) publish(topic string, event interface{}) (err error) {
-	data, err := json.Marshal(event)
+// checkConnected returns ErrNotConnected, if there is no connection to the broker
+func (m *mqttHandler) checkConnected() error {
+	if m.ConnectionState() != messaging.Connected {
+		return messaging.ErrNotConnected
+	}
+	return nil
+}
+
+// onConnect publishes the state of all strips, so the retained states are up to date after the broker was offline
+func (m *mqttHandler) onConnect(client mqtt.Client) {
+	m.l.Info("Connected")
+	m.setState(messaging.Connected)
+	if err := m.send(client, m.availabilityTopic(), true, []byte(payloadOnline)); err != nil {
+		m.l.Error("error publishing the availability: %s", err.Error())
+	}
+	m.resubscribe(client)
+	if err := m.publishStates(client); err != nil {
+		m.l.Error("error publishing the strip states: %s", err.Error())
+	}
+}
+
+// publishStates publishes the retained states of all strips
+func (m *mqttHandler) publishStates(client mqtt.Client) error {
+	if m.states == nil {
+		return nil
+	}
+	events, err := m.states()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, event := range events {
+		if err := m.publishState(client, event); err != nil {
+			m.l.Error("error publishing the state of strip %d: %s", event.ID.Int64, err.Error())
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Subscribe subscribes to the topic, the subscription is renewed on every (re)connect. Without a connection the
+// subscription is made once it is established.
+func (m *mqttHandler) Subscribe(topic string, handler messaging.MessageHandler) error {
+	m.subMu.Lock()
+	m.subs[topic] = handler
+	m.subMu.Unlock()
+	if m.ConnectionState() != messaging.Connected {
+		return nil
+	}
+	return m.subscribe(m.mqclient, topic, handler)
+}
+
+// resubscribe renews all subscriptions, they are lost with a new session
+func (m *mqttHandler) resubscribe(client mqtt.Client) {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+	for topic, handler := range m.subs {
+		if err := m.subscribe(client, topic, handler); err != nil {
+			m.l.Error("error subscribing to %s: %s", topic, err.Error())
+		}
+	}
+}
+
+func (m *mqttHandler) subscribe(client mqtt.Client, topic string, handler messaging.MessageHandler) error {
+	token := client.Subscribe(topic, m.qos(), func(_ mqtt.Client, msg mqtt.Message) {
+		handler(msg.Topic(), msg.Payload())
+	})
+	if !token.WaitTimeout(m.publishTimeout()) {
+		return fmt.Errorf("timeout subscribing to %s", topic)
+	}
+	if token.Error() != nil {
+		return token.Error()
+	}
+	m.l.Info("subscribed to %s", topic)
+	return nil
+}
+
+// publishState publishes the strip state retained to the state topic of the strip, a deleted strip clears it
+func (m *mqttHandler) publishState(client mqtt.Client, event *model.StripEvent) error {
+	if !event.ID.Valid {
+		return nil
+	}
+	if m.cfg.Discovery {
+		if err := m.publishDiscovery(client, event); err != nil {
+			return err
+		}
+	}
+	topic := m.stateTopic(event.ID.Int64)
+	if event.Type == model.Delete {
+		// an empty retained message removes the retained state
+		return m.send(client, topic, true, []byte{})
+	}
+	data, err := m.enc.Strip(event)
 	if err != nil {
 		m.l.Error("Error %s", err.Error())
-		return
+		return err
 	}
+	return m.send(client, topic, true, data)
+}
+
+// stateTopic returns the topic with the retained state of the strip
+func (m *mqttHandler) stateTopic(id int64) string {
+	return fmt.Sprintf("%s/%d/state", m.cfg.StripTopic, id)
+}
+
+func (m *mqttHandler) send(client mqtt.Client, topic string, retained bool, data []byte) (err error) {
 	m.l.Info("sending to topic %s event: %s", topic, string(data))
-	token := m.getClient().Publish(topic, 0, false, data)
-	if token.Wait() && token.Error() != nil {
+	token := client.Publish(topic, m.qos(), retained, data)
+	if !token.WaitTimeout(m.publishTimeout()) {
+		m.l.Error("error: no acknowledge for message to topic %s", topic)
+		return errors.New("timeout sending message")
+	}
+	if token.Error() != nil {
 		m.l.Error("error: %s", token.Error().Error())
 		err = errors.New("failed to send message")
 	}
 	return
 }
+
+// qos returns the configured quality of service, at least once delivery is used if nothing is configured
+func (m *mqttHandler) qos() byte {
+	if m.cfg.QoS == 0 {
+		return defaultQoS
+	}
+	return byte(m.cfg.QoS)
+}
+
+func (m *mqttHandler) publishTimeout() time.Duration {
+	if m.cfg.PublishTimeout <= 0 {
+		return defaultPublishTimeout
+	}
+	return m.cfg.PublishTimeout
+}
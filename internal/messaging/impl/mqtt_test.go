@@ -3,11 +3,15 @@ package messagingimpl
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/pthum/null"
 	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
 	"github.com/pthum/stripcontrol-golang/internal/model"
 	"github.com/pthum/stripcontrol-golang/internal/testutils"
 	"github.com/stretchr/testify/assert"
@@ -19,11 +23,28 @@ type mqttClientFake struct {
 	mqtt.Client
 	PublishCheck publishFunc
 	disconnected bool
+	qos          byte
+	timeout      bool
+	published    []fakeMessage
+	subscribed   map[string]mqtt.MessageHandler
+}
+
+type fakeMessage struct {
+	topic    string
+	retained bool
+	payload  string
+}
+
+type messageFake struct {
+	mqtt.Message
+	topic   string
+	payload []byte
 }
 
 type mqttTokenFake struct {
 	mqtt.Token
-	err error
+	err     error
+	timeout bool
 }
 
 var testConfig = config.MessagingConfig{
@@ -58,14 +79,33 @@ func TestMqttPublishProfileEventWithError(t *testing.T) {
 }
 
 func TestMqttPublishStripEvent(t *testing.T) {
-	stripEvent := model.NewStripEvent(null.IntFrom(123), model.Delete)
+	stripEvent := model.NewStripEvent(null.IntFrom(123), model.Save).With(&model.LedStrip{
+		BaseModel: model.BaseModel{ID: 123},
+		Name:      "strip",
+	})
 	expectedPayload := testutils.JsonEncode(t, stripEvent)
+	handler := createMqttMocks(t, func(string, interface{}) error { return nil })
 
-	testFunc := createTestFunc(t, testConfig.StripTopic, expectedPayload, nil)
-	handler := createMqttMocks(t, testFunc)
+	err := handler.PublishStripEvent(stripEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, []fakeMessage{
+		{topic: testConfig.StripTopic, payload: expectedPayload},
+		{topic: "TestStrip/123/state", retained: true, payload: expectedPayload},
+	}, handler.mqclient.(*mqttClientFake).published)
+}
+
+func TestMqttPublishStripEvent_Delete(t *testing.T) {
+	stripEvent := model.NewStripEvent(null.IntFrom(123), model.Delete)
+	expectedPayload := testutils.JsonEncode(t, stripEvent)
+	handler := createMqttMocks(t, func(string, interface{}) error { return nil })
 
 	err := handler.PublishStripEvent(stripEvent)
 	assert.Nil(t, err)
+	// the retained state is cleared with an empty payload
+	assert.Equal(t, []fakeMessage{
+		{topic: testConfig.StripTopic, payload: expectedPayload},
+		{topic: "TestStrip/123/state", retained: true, payload: ""},
+	}, handler.mqclient.(*mqttClientFake).published)
 }
 
 func TestMqttPublishStripEventWithError(t *testing.T) {
@@ -76,12 +116,168 @@ func TestMqttPublishStripEventWithError(t *testing.T) {
 
 	actualError := handler.PublishStripEvent(stripEvent)
 	assert.Equal(t, errExpectedPublish, actualError)
+	assert.Len(t, handler.mqclient.(*mqttClientFake).published, 1)
+}
+
+func TestMqttPublishPreview(t *testing.T) {
+	stripEvent := model.NewStripEvent(null.IntFrom(123), model.Save).With(&model.LedStrip{
+		BaseModel: model.BaseModel{ID: 123},
+		Name:      "strip",
+	})
+	handler := createMqttMocks(t, nil)
+
+	err := handler.PublishPreview(stripEvent)
+	assert.Nil(t, err)
+	// the retained state isn't changed
+	assert.Equal(t, []fakeMessage{
+		{topic: testConfig.StripTopic, payload: testutils.JsonEncode(t, stripEvent)},
+	}, handler.mqclient.(*mqttClientFake).published)
+}
+
+func TestMqttPublishPreview_NotConnected(t *testing.T) {
+	handler := createMqttMocks(t, nil)
+	handler.onConnectionLost(nil, errReturn)
+
+	err := handler.PublishPreview(model.NewStripEvent(null.IntFrom(123), model.Save))
+	assert.ErrorIs(t, err, messaging.ErrNotConnected)
+	assert.Empty(t, handler.mqclient.(*mqttClientFake).published)
+}
+
+func TestMqttPublishStripEvent_StateError(t *testing.T) {
+	stripEvent := model.NewStripEvent(null.IntFrom(123), model.Delete)
+	handler := createMqttMocks(t, func(topic string, _ interface{}) error {
+		if topic == "TestStrip/123/state" {
+			return errReturn
+		}
+		return nil
+	})
+
+	actualError := handler.PublishStripEvent(stripEvent)
+	assert.Equal(t, errExpectedPublish, actualError)
+}
+
+func TestMqttOnConnect(t *testing.T) {
+	events := []*model.StripEvent{
+		model.NewStripEvent(null.IntFrom(1), model.Save).With(&model.LedStrip{BaseModel: model.BaseModel{ID: 1}}),
+		model.NewStripEvent(null.IntFrom(2), model.Save).With(&model.LedStrip{BaseModel: model.BaseModel{ID: 2}}),
+	}
+	handler := newTestMQTT(t, testConfig, func() ([]*model.StripEvent, error) { return events, nil })
+	fake := &mqttClientFake{PublishCheck: func(string, interface{}) error { return nil }}
+
+	handler.onConnect(fake)
+
+	assert.Equal(t, []fakeMessage{
+		{topic: "TestStrip/availability", retained: true, payload: "online"},
+		{topic: "TestStrip/1/state", retained: true, payload: testutils.JsonEncode(t, events[0])},
+		{topic: "TestStrip/2/state", retained: true, payload: testutils.JsonEncode(t, events[1])},
+	}, fake.published)
+}
+
+func TestMqttOnConnect_LoadError(t *testing.T) {
+	handler := newTestMQTT(t, testConfig, func() ([]*model.StripEvent, error) { return nil, errReturn })
+	fake := &mqttClientFake{PublishCheck: func(string, interface{}) error { return nil }}
+
+	handler.onConnect(fake)
+
+	assert.Equal(t, []fakeMessage{
+		{topic: "TestStrip/availability", retained: true, payload: "online"},
+	}, fake.published)
+}
+
+func TestMqttSubscribe(t *testing.T) {
+	handler := createMqttMocks(t, nil)
+	var received []string
+	err := handler.Subscribe("TestStrip/+/set", func(topic string, payload []byte) {
+		received = append(received, topic+" "+string(payload))
+	})
+	assert.NoError(t, err)
+
+	fake := handler.mqclient.(*mqttClientFake)
+	assert.Contains(t, fake.subscribed, "TestStrip/+/set")
+	fake.subscribed["TestStrip/+/set"](fake, messageFake{topic: "TestStrip/1/set", payload: []byte("{}")})
+	assert.Equal(t, []string{"TestStrip/1/set {}"}, received)
+}
+
+func TestMqttSubscribe_Timeout(t *testing.T) {
+	handler := createMqttMocks(t, nil)
+	handler.mqclient.(*mqttClientFake).timeout = true
+
+	err := handler.Subscribe("TestStrip/+/set", func(string, []byte) {})
+	assert.Error(t, err)
+}
+
+func TestMqttOnConnect_Resubscribes(t *testing.T) {
+	handler := createMqttMocks(t, nil)
+	assert.NoError(t, handler.Subscribe("TestStrip/+/set", func(string, []byte) {}))
+	// a new session after the reconnect
+	fake := &mqttClientFake{}
+
+	handler.onConnect(fake)
+
+	assert.Contains(t, fake.subscribed, "TestStrip/+/set")
+}
+
+func TestMqttPublishQoS(t *testing.T) {
+	tests := []struct {
+		qos      int
+		expected byte
+	}{
+		{0, 1},
+		{1, 1},
+		{2, 2},
+	}
+	for _, tt := range tests {
+		handler := createMqttMocks(t, func(string, interface{}) error { return nil })
+		handler.cfg.QoS = tt.qos
+
+		assert.NoError(t, handler.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save)))
+		assert.Equal(t, tt.expected, handler.mqclient.(*mqttClientFake).qos)
+	}
+}
+
+func TestMqttPublishTimeout(t *testing.T) {
+	handler := createMqttMocks(t, func(string, interface{}) error { return nil })
+	handler.mqclient.(*mqttClientFake).timeout = true
+
+	err := handler.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save))
+	assert.EqualError(t, err, "timeout sending message")
+}
+
+func TestMqttPublishProfileEvent_RefreshesStates(t *testing.T) {
+	profileEvent := model.NewProfileEvent(null.IntFrom(5), model.Save)
+	stripEvent := model.NewStripEvent(null.IntFrom(1), model.Save).With(&model.LedStrip{BaseModel: model.BaseModel{ID: 1}})
+	handler := createMqttMocks(t, nil)
+	handler.states = func() ([]*model.StripEvent, error) { return []*model.StripEvent{stripEvent}, nil }
+
+	assert.NoError(t, handler.PublishProfileEvent(profileEvent))
+
+	assert.Equal(t, []fakeMessage{
+		{topic: testConfig.ProfileTopic, payload: testutils.JsonEncode(t, profileEvent)},
+		{topic: "TestStrip/1/state", retained: true, payload: testutils.JsonEncode(t, stripEvent)},
+	}, handler.mqclient.(*mqttClientFake).published)
+}
+
+func TestMqttPublishProfileEvent_RefreshError(t *testing.T) {
+	handler := createMqttMocks(t, nil)
+	handler.states = func() ([]*model.StripEvent, error) { return nil, errReturn }
+
+	err := handler.PublishProfileEvent(model.NewProfileEvent(null.IntFrom(5), model.Save))
+	assert.Equal(t, errReturn, err)
 }
 
 func TestMqttClose(t *testing.T) {
 	handler := createMqttMocks(t, nil)
 	handler.Shutdown()
 	assert.False(t, handler.mqclient.IsConnected())
+	// the availability is set to offline before disconnecting
+	assert.Equal(t, []fakeMessage{
+		{topic: "TestStrip/availability", retained: true, payload: "offline"},
+	}, handler.mqclient.(*mqttClientFake).published)
+}
+
+func TestMqttClose_NotConnected(t *testing.T) {
+	handler := newTestMQTT(t, testConfig, nil)
+	assert.NoError(t, handler.Shutdown())
 }
 
 func createTestFunc(t *testing.T, expectedTopic string, expectedPayload string, returnError error) publishFunc {
@@ -97,20 +293,34 @@ func createMqttMocks(t *testing.T, pubFunc publishFunc) *mqttHandler {
 	fake := &mqttClientFake{
 		PublishCheck: pubFunc,
 	}
-	handler := NewMQTT(testConfig)
-	handler.intialized = true
+	handler := newTestMQTT(t, testConfig, nil)
 	handler.mqclient = fake
+	handler.setState(messaging.Connected)
 	return handler
 }
 
 func (c *mqttClientFake) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
 	fmt.Println("PUBLISH HANDLER CALLED")
-	err := c.PublishCheck(topic, payload)
+	var err error
+	if c.PublishCheck != nil {
+		err = c.PublishCheck(topic, payload)
+	}
+	c.qos = qos
+	c.published = append(c.published, fakeMessage{topic: topic, retained: retained, payload: string(payload.([]byte))})
 	return mqttTokenFake{
-		err: err,
+		err:     err,
+		timeout: c.timeout,
 	}
 }
 
+func (c *mqttClientFake) Subscribe(topic string, qos byte, callback mqtt.MessageHandler) mqtt.Token {
+	if c.subscribed == nil {
+		c.subscribed = map[string]mqtt.MessageHandler{}
+	}
+	c.subscribed[topic] = callback
+	return mqttTokenFake{timeout: c.timeout}
+}
+
 func (c *mqttClientFake) Disconnect(quiesce uint) {
 	c.disconnected = true
 }
@@ -122,6 +332,234 @@ func (t mqttTokenFake) Wait() bool {
 	return t.err != nil
 }
 
+func (t mqttTokenFake) WaitTimeout(time.Duration) bool {
+	return !t.timeout
+}
+
 func (t mqttTokenFake) Error() error {
 	return t.err
 }
+
+func (m messageFake) Topic() string {
+	return m.topic
+}
+
+func (m messageFake) Payload() []byte {
+	return m.payload
+}
+
+func newTestMQTT(t *testing.T, cfg config.MessagingConfig, states stateLoader) *mqttHandler {
+	handler, err := NewMQTT(cfg, states)
+	assert.NoError(t, err)
+	return handler
+}
+
+func TestMqttTLSBroker(t *testing.T) {
+	certs := createTestCerts(t)
+	broker := startBroker(t, certs.serverTLSConfig(t), "mquser", "mqpass")
+	cfg := testConfig
+	cfg.Scheme = "ssl"
+	cfg.Host = "127.0.0.1"
+	cfg.Port = broker.port()
+	cfg.Username = "mquser"
+	cfg.Password = "mqpass"
+	cfg.CAFile = certs.caFile
+	cfg.CertFile = certs.clientCertFile
+	cfg.KeyFile = certs.clientKeyFile
+	cfg.ClientID = "stripcontrol-test"
+	cfg.KeepAlive = 15 * time.Second
+	cfg.QoS = 2
+	cfg.AvailabilityTopic = "stripcontrol/status"
+	handler := newTestMQTT(t, cfg, nil)
+	connectAndWait(t, handler)
+
+	err := handler.PublishStripEvent(model.NewStripEvent(null.IntFrom(3), model.Delete))
+	assert.NoError(t, err)
+
+	connect := broker.connect()
+	assert.Equal(t, "stripcontrol-test", connect.ClientIdentifier)
+	assert.Equal(t, "mquser", connect.Username)
+	assert.Equal(t, "mqpass", string(connect.Password))
+	assert.Equal(t, uint16(15), connect.Keepalive)
+	assert.True(t, connect.WillFlag)
+	assert.True(t, connect.WillRetain)
+	assert.Equal(t, byte(2), connect.WillQos)
+	assert.Equal(t, "stripcontrol/status", connect.WillTopic)
+	assert.Equal(t, payloadOffline, string(connect.WillMessage))
+
+	msg := broker.message(testConfig.StripTopic)
+	assert.NotNil(t, msg)
+	assert.Equal(t, byte(2), msg.Qos)
+	state := broker.message("TestStrip/3/state")
+	assert.NotNil(t, state)
+	assert.True(t, state.Retain)
+
+	assert.NoError(t, handler.Shutdown())
+	assert.Equal(t, payloadOffline, string(broker.message("stripcontrol/status").Payload))
+}
+
+func TestBuildClientOpts(t *testing.T) {
+	opts, err := buildClientOpts(testConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, "tcp://localhost:1234", opts.Servers[0].String())
+	assert.Equal(t, defaultClientID, opts.ClientID)
+	assert.Empty(t, opts.Username)
+	assert.Nil(t, opts.TLSConfig)
+
+	cfg := testConfig
+	cfg.Scheme = "wss"
+	cfg.ClientID = "client"
+	cfg.Username = "user"
+	cfg.Password = "pass"
+	cfg.KeepAlive = time.Minute
+	opts, err = buildClientOpts(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "wss://localhost:1234", opts.Servers[0].String())
+	assert.Equal(t, "client", opts.ClientID)
+	assert.Equal(t, "user", opts.Username)
+	assert.Equal(t, "pass", opts.Password)
+	assert.Equal(t, int64(60), opts.KeepAlive)
+}
+
+func TestBuildClientOpts_TLS(t *testing.T) {
+	certs := createTestCerts(t)
+	cfg := testConfig
+	cfg.CAFile = certs.caFile
+	cfg.CertFile = certs.clientCertFile
+	cfg.KeyFile = certs.clientKeyFile
+
+	opts, err := buildClientOpts(cfg)
+	assert.NoError(t, err)
+	assert.NotNil(t, opts.TLSConfig.RootCAs)
+	assert.Len(t, opts.TLSConfig.Certificates, 1)
+}
+
+func TestBuildClientOpts_Invalid(t *testing.T) {
+	certs := createTestCerts(t)
+	tests := []struct {
+		name string
+		cfg  func(cfg *config.MessagingConfig)
+	}{
+		{"unsupported scheme", func(cfg *config.MessagingConfig) { cfg.Scheme = "http" }},
+		{"missing ca file", func(cfg *config.MessagingConfig) { cfg.CAFile = filepath.Join(t.TempDir(), "missing.pem") }},
+		{"no certificate in ca file", func(cfg *config.MessagingConfig) { cfg.CAFile = certs.clientKeyFile }},
+		{"missing key", func(cfg *config.MessagingConfig) { cfg.CertFile = certs.clientCertFile }},
+		{"key not matching", func(cfg *config.MessagingConfig) {
+			cfg.CertFile = certs.clientCertFile
+			cfg.KeyFile = certs.serverKeyFile
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig
+			tt.cfg(&cfg)
+			_, err := buildClientOpts(cfg)
+			assert.Error(t, err)
+			_, err = NewMQTT(cfg, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func connectAndWait(t *testing.T, handler *mqttHandler) {
+	handler.Connect()
+	assert.Eventually(t, func() bool {
+		return handler.ConnectionState() == messaging.Connected
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestMqttPublish_NotConnected(t *testing.T) {
+	handler := newTestMQTT(t, testConfig, nil)
+
+	err := handler.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save))
+	assert.ErrorIs(t, err, messaging.ErrNotConnected)
+	err = handler.PublishProfileEvent(model.NewProfileEvent(null.IntFrom(1), model.Save))
+	assert.ErrorIs(t, err, messaging.ErrNotConnected)
+}
+
+func TestMqttConnect_Unreachable(t *testing.T) {
+	// a port without a listener
+	broker := startBroker(t, nil, "", "")
+	broker.ln.Close()
+	cfg := testConfig
+	cfg.Host = "127.0.0.1"
+	cfg.Port = broker.port()
+	handler := newTestMQTT(t, cfg, nil)
+
+	handler.Connect()
+
+	assert.Equal(t, messaging.Connecting, handler.ConnectionState())
+	err := handler.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save))
+	assert.ErrorIs(t, err, messaging.ErrNotConnected)
+	assert.NoError(t, handler.Shutdown())
+	assert.Equal(t, messaging.Disconnected, handler.ConnectionState())
+}
+
+func TestMqttConnect_NotAuthorized(t *testing.T) {
+	broker := startBroker(t, nil, "mquser", "mqpass")
+	cfg := testConfig
+	cfg.Host = "127.0.0.1"
+	cfg.Port = broker.port()
+	cfg.Username = "mquser"
+	cfg.Password = "wrong"
+	handler := newTestMQTT(t, cfg, nil)
+	defer handler.Shutdown()
+
+	handler.Connect()
+
+	assert.Eventually(t, func() bool { return broker.connect() != nil }, 5*time.Second, 10*time.Millisecond)
+	assert.NotEqual(t, messaging.Connected, handler.ConnectionState())
+	err := handler.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save))
+	assert.ErrorIs(t, err, messaging.ErrNotConnected)
+}
+
+func TestMqttConnect_IndependentHandlers(t *testing.T) {
+	broker := startBroker(t, nil, "", "")
+	cfg := testConfig
+	cfg.Host = "127.0.0.1"
+	cfg.Port = broker.port()
+	cfg.ClientID = "first"
+	first := newTestMQTT(t, cfg, nil)
+	cfg.ClientID = "second"
+	second := newTestMQTT(t, cfg, nil)
+
+	connectAndWait(t, first)
+	connectAndWait(t, second)
+
+	assert.NoError(t, first.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save)))
+	assert.NoError(t, first.Shutdown())
+	// the second handler keeps its connection
+	assert.Equal(t, messaging.Connected, second.ConnectionState())
+	assert.NoError(t, second.PublishStripEvent(model.NewStripEvent(null.IntFrom(2), model.Save)))
+	assert.NoError(t, second.Shutdown())
+}
+
+func TestMqttSubscribe_BeforeConnect(t *testing.T) {
+	broker := startBroker(t, nil, "", "")
+	cfg := testConfig
+	cfg.Host = "127.0.0.1"
+	cfg.Port = broker.port()
+	handler := newTestMQTT(t, cfg, nil)
+	defer handler.Shutdown()
+
+	assert.NoError(t, handler.Subscribe("TestStrip/+/set", func(string, []byte) {}))
+	connectAndWait(t, handler)
+
+	assert.Eventually(t, func() bool {
+		broker.mu.Lock()
+		defer broker.mu.Unlock()
+		return slices.Contains(broker.subs, "TestStrip/+/set")
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestMqttConnectionState(t *testing.T) {
+	handler := createMqttMocks(t, nil)
+	assert.Equal(t, messaging.Connected, handler.ConnectionState())
+
+	handler.onConnectionLost(handler.mqclient, errReturn)
+	assert.Equal(t, messaging.Connecting, handler.ConnectionState())
+	handler.onConnect(handler.mqclient)
+	assert.Equal(t, messaging.Connected, handler.ConnectionState())
+	handler.onReconnecting(handler.mqclient, handler.opts)
+	assert.Equal(t, messaging.Connecting, handler.ConnectionState())
+}
@@ -0,0 +1,251 @@
+package messagingimpl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+)
+
+const (
+	defaultRetryInterval    = 1 * time.Second
+	defaultMaxRetryInterval = 5 * time.Minute
+)
+
+// interface guard
+var _ messaging.EventHandler = (*outbox)(nil)
+var _ messaging.PendingCounter = (*outbox)(nil)
+var _ messaging.Subscriber = (*outbox)(nil)
+var _ messaging.Previewer = (*outbox)(nil)
+var _ messaging.ConnectionReporter = (*outbox)(nil)
+
+// outbox stores the events before they are delivered to the wrapped handler, failed deliveries are retried
+// with an exponential backoff until they succeed. The events are delivered in the order they were published.
+type outbox struct {
+	next             messaging.EventHandler
+	dbh              database.DBHandler[model.OutboxEntry]
+	retryInterval    time.Duration
+	maxRetryInterval time.Duration
+	notify           chan struct{}
+	stop             chan struct{}
+	done             chan struct{}
+	stopOnce         sync.Once
+	l                alog.Logger
+}
+
+func newOutbox(next messaging.EventHandler, dbh database.DBHandler[model.OutboxEntry], retryInterval, maxRetryInterval time.Duration) *outbox {
+	if retryInterval <= 0 {
+		retryInterval = defaultRetryInterval
+	}
+	if maxRetryInterval < retryInterval {
+		maxRetryInterval = max(defaultMaxRetryInterval, retryInterval)
+	}
+	o := &outbox{
+		next:             next,
+		dbh:              dbh,
+		retryInterval:    retryInterval,
+		maxRetryInterval: maxRetryInterval,
+		notify:           make(chan struct{}, 1),
+		stop:             make(chan struct{}),
+		done:             make(chan struct{}),
+		l:                alog.NewLogger("outbox"),
+	}
+	go o.run()
+	return o
+}
+
+// PublishStripEvent stores the strip event for delivery
+func (o *outbox) PublishStripEvent(event *model.StripEvent) error {
+	return o.record(model.OutboxKindStrip, event.Meta, event)
+}
+
+// PublishProfileEvent stores the profile event for delivery
+func (o *outbox) PublishProfileEvent(event *model.ProfileEvent) error {
+	return o.record(model.OutboxKindProfile, event.Meta, event)
+}
+
+// Pending returns the number of events which haven't been delivered yet
+func (o *outbox) Pending() (int, error) {
+	entries, err := o.dbh.GetAll()
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// Shutdown stops the delivery and shuts down the wrapped handler, undelivered events are kept for the next start
+func (o *outbox) Shutdown() error {
+	o.stopOnce.Do(func() {
+		close(o.stop)
+	})
+	<-o.done
+	if pending, err := o.Pending(); err == nil && pending > 0 {
+		o.l.Warn("%d event(s) not delivered yet, they are sent after the next start", pending)
+	}
+	return o.next.Shutdown()
+}
+
+// PublishPreview publishes the preview directly, previews are transient and not stored in the outbox
+func (o *outbox) PublishPreview(event *model.StripEvent) error {
+	p, ok := o.next.(messaging.Previewer)
+	if !ok {
+		return messaging.ErrPreviewsUnsupported
+	}
+	return p.PublishPreview(event)
+}
+
+// Subscribe subscribes to the topic, if the wrapped handler supports subscriptions
+func (o *outbox) Subscribe(topic string, handler messaging.MessageHandler) error {
+	s, ok := o.next.(messaging.Subscriber)
+	if !ok {
+		return errors.New("subscriptions are not supported")
+	}
+	return s.Subscribe(topic, handler)
+}
+
+// ConnectionState returns the connection state of the wrapped handler, it is connected if it has no connection
+func (o *outbox) ConnectionState() messaging.ConnectionState {
+	if cr, ok := o.next.(messaging.ConnectionReporter); ok {
+		return cr.ConnectionState()
+	}
+	return messaging.Connected
+}
+
+// record stores the event in an envelope, so its metadata is kept until the delivery
+func (o *outbox) record(kind string, meta model.EventMeta, event any) error {
+	env, err := model.NewEventEnvelope(kind, "", meta, event)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	entry := &model.OutboxEntry{Kind: kind, Payload: string(data)}
+	if err := database.CreateWithNextID(o.dbh, entry); err != nil {
+		o.l.Error("error storing %s event: %s", kind, err.Error())
+		return err
+	}
+	// wake up the delivery, if it is waiting for new events
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (o *outbox) run() {
+	defer close(o.done)
+	for {
+		// the events recorded until now are covered by the lookup, only later ones have to wake up the delivery
+		select {
+		case <-o.notify:
+		default:
+		}
+		entry, err := o.oldest()
+		if err != nil {
+			o.l.Error("error reading the outbox: %s", err.Error())
+		}
+		if entry == nil {
+			// wait for new events
+			select {
+			case <-o.notify:
+				continue
+			case <-o.stop:
+				return
+			}
+		}
+		if err := o.deliver(entry); err != nil {
+			entry.Attempts++
+			delay := o.backoff(entry.Attempts)
+			o.l.Warn("delivery of %s event %d failed (attempt %d), retrying in %v: %s", entry.Kind, entry.ID, entry.Attempts, delay, err.Error())
+			if err := o.dbh.Save(entry); err != nil {
+				o.l.Error("error updating event %d: %s", entry.ID, err.Error())
+			}
+			// a new event hints that the broker may be reachable again, so the backoff is cut short
+			select {
+			case <-time.After(delay):
+				continue
+			case <-o.notify:
+				continue
+			case <-o.stop:
+				return
+			}
+		}
+		if err := o.dbh.Delete(entry); err != nil {
+			o.l.Error("error removing delivered event %d: %s", entry.ID, err.Error())
+		}
+	}
+}
+
+// unwrapEntry returns the metadata and the event of the entry, entries stored before the envelope was introduced
+// contain only the event
+func unwrapEntry(entry *model.OutboxEntry) (model.EventMeta, []byte, error) {
+	var probe struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}
+	if err := json.Unmarshal([]byte(entry.Payload), &probe); err != nil {
+		return model.EventMeta{}, nil, err
+	}
+	if probe.SchemaVersion == 0 {
+		return model.EventMeta{}, []byte(entry.Payload), nil
+	}
+	var env model.EventEnvelope
+	if err := json.Unmarshal([]byte(entry.Payload), &env); err != nil {
+		return model.EventMeta{}, nil, err
+	}
+	return env.Meta(), env.Data, nil
+}
+
+// oldest returns the oldest undelivered entry, nil if there is none
+func (o *outbox) oldest() (*model.OutboxEntry, error) {
+	entries, err := o.dbh.GetAll()
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+func (o *outbox) deliver(entry *model.OutboxEntry) error {
+	meta, data, err := unwrapEntry(entry)
+	if err != nil {
+		return o.drop(entry, err)
+	}
+	switch entry.Kind {
+	case model.OutboxKindStrip:
+		event := model.StripEvent{Meta: meta}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return o.drop(entry, err)
+		}
+		return o.next.PublishStripEvent(&event)
+	case model.OutboxKindProfile:
+		event := model.ProfileEvent{Meta: meta}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return o.drop(entry, err)
+		}
+		return o.next.PublishProfileEvent(&event)
+	default:
+		return o.drop(entry, fmt.Errorf("unknown kind %q", entry.Kind))
+	}
+}
+
+// drop discards an entry which can never be delivered, it shouldn't block the following events
+func (o *outbox) drop(entry *model.OutboxEntry, err error) error {
+	o.l.Error("dropping undeliverable event %d: %s", entry.ID, err.Error())
+	return nil
+}
+
+// backoff returns the delay before the next attempt, it doubles with every attempt up to the maximum
+func (o *outbox) backoff(attempts int64) time.Duration {
+	delay := o.retryInterval
+	for i := int64(1); i < attempts && delay < o.maxRetryInterval; i++ {
+		delay *= 2
+	}
+	return min(delay, o.maxRetryInterval)
+}
@@ -0,0 +1,257 @@
+package messagingimpl
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/database/csv"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// eventHandlerFake records the delivered events and fails the first failures deliveries
+type eventHandlerFake struct {
+	mu        sync.Mutex
+	failures  int
+	calls     int
+	delivered []int64
+	strips    []*model.StripEvent
+	shutdown  bool
+}
+
+func TestOutboxDeliversInOrder(t *testing.T) {
+	fake := &eventHandlerFake{}
+	ob := newOutbox(fake, newOutboxDB(t, ""), time.Millisecond, time.Millisecond)
+	defer ob.Shutdown()
+
+	assert.NoError(t, ob.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save)))
+	assert.NoError(t, ob.PublishProfileEvent(model.NewProfileEvent(null.IntFrom(2), model.Delete)))
+	assert.NoError(t, ob.PublishStripEvent(model.NewStripEvent(null.IntFrom(3), model.Delete)))
+
+	waitForPending(t, ob, 0)
+	assert.Equal(t, []int64{1, 2, 3}, fake.getDelivered())
+}
+
+func TestOutboxKeepsEventState(t *testing.T) {
+	fake := &eventHandlerFake{}
+	ob := newOutbox(fake, newOutboxDB(t, ""), time.Millisecond, time.Millisecond)
+	defer ob.Shutdown()
+	event := model.NewStripEvent(null.IntFrom(4), model.Save).With(&model.LedStrip{
+		BaseModel: model.BaseModel{ID: 4},
+		Name:      "strip",
+		Enabled:   true,
+	}).By("rest:127.0.0.1")
+	event.Strip.With(model.ColorProfile{BaseModel: model.BaseModel{ID: 9}, Red: null.IntFrom(12)})
+
+	assert.NoError(t, ob.PublishStripEvent(event))
+
+	waitForPending(t, ob, 0)
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	// the state and the metadata are kept
+	assert.Equal(t, []*model.StripEvent{event}, fake.strips)
+}
+
+func TestOutboxDeliversLegacyEntries(t *testing.T) {
+	fake := &eventHandlerFake{}
+	dbh := newOutboxDB(t, "")
+	// entries stored before the envelope was introduced contain only the event
+	assert.NoError(t, dbh.Create(&model.OutboxEntry{BaseModel: model.BaseModel{ID: 1}, Kind: model.OutboxKindStrip, Payload: `{"type":"SAVE","id":5,"state":{"id":5,"name":"strip"}}`}))
+	ob := newOutbox(fake, dbh, time.Millisecond, time.Millisecond)
+	defer ob.Shutdown()
+
+	waitForPending(t, ob, 0)
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if assert.Len(t, fake.strips, 1) {
+		assert.Equal(t, "strip", fake.strips[0].Strip.Strip.Name)
+		assert.Empty(t, fake.strips[0].Meta.ID)
+	}
+}
+
+func TestOutboxRetriesFailedDelivery(t *testing.T) {
+	fake := &eventHandlerFake{failures: 2}
+	ob := newOutbox(fake, newOutboxDB(t, ""), time.Millisecond, 2*time.Millisecond)
+	defer ob.Shutdown()
+
+	assert.NoError(t, ob.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save)))
+	assert.NoError(t, ob.PublishStripEvent(model.NewStripEvent(null.IntFrom(2), model.Save)))
+
+	waitForPending(t, ob, 0)
+	// the second event is only delivered after the first one
+	assert.Equal(t, []int64{1, 2}, fake.getDelivered())
+	assert.Equal(t, 4, fake.getCalls())
+}
+
+func TestOutboxRetriesOnNewEvent(t *testing.T) {
+	fake := &eventHandlerFake{failures: 1}
+	ob := newOutbox(fake, newOutboxDB(t, ""), time.Hour, time.Hour)
+	defer ob.Shutdown()
+
+	assert.NoError(t, ob.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save)))
+	assert.Eventually(t, func() bool { return fake.getCalls() == 1 }, time.Second, time.Millisecond)
+	// the delivery doesn't wait for the backoff, once there is a new event
+	assert.NoError(t, ob.PublishStripEvent(model.NewStripEvent(null.IntFrom(2), model.Save)))
+
+	waitForPending(t, ob, 0)
+	assert.Equal(t, []int64{1, 2}, fake.getDelivered())
+}
+
+func TestOutboxKeepsUndeliveredEvents(t *testing.T) {
+	dir := t.TempDir()
+	dbh := newOutboxDB(t, dir)
+	failing := &eventHandlerFake{failures: 1000}
+	ob := newOutbox(failing, dbh, time.Hour, time.Hour)
+	assert.NoError(t, ob.PublishStripEvent(model.NewStripEvent(null.IntFrom(7), model.Save)))
+	assert.Eventually(t, func() bool { return failing.getCalls() == 1 }, time.Second, time.Millisecond)
+
+	assert.NoError(t, ob.Shutdown())
+	assert.True(t, failing.shutdown)
+	assert.NoError(t, dbh.Shutdown())
+
+	// simulate the restart, the event is delivered by the new outbox
+	dbh = newOutboxDB(t, dir)
+	entries, _ := dbh.GetAll()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, int64(1), entries[0].Attempts)
+	fake := &eventHandlerFake{}
+	ob = newOutbox(fake, dbh, time.Millisecond, time.Millisecond)
+	defer ob.Shutdown()
+
+	waitForPending(t, ob, 0)
+	assert.Equal(t, []int64{7}, fake.getDelivered())
+}
+
+func TestOutboxDropsUndeliverableEvents(t *testing.T) {
+	fake := &eventHandlerFake{}
+	dbh := newOutboxDB(t, "")
+	assert.NoError(t, dbh.Create(&model.OutboxEntry{BaseModel: model.BaseModel{ID: 1}, Kind: "unknown", Payload: "{}"}))
+	assert.NoError(t, dbh.Create(&model.OutboxEntry{BaseModel: model.BaseModel{ID: 2}, Kind: model.OutboxKindStrip, Payload: "{"}))
+	ob := newOutbox(fake, dbh, time.Millisecond, time.Millisecond)
+	defer ob.Shutdown()
+
+	waitForPending(t, ob, 0)
+	assert.Empty(t, fake.getDelivered())
+}
+
+func TestOutboxPublishPreview(t *testing.T) {
+	mh := createMqttMocks(t, nil)
+	ob := newOutbox(mh, newOutboxDB(t, ""), time.Millisecond, time.Millisecond)
+	defer ob.Shutdown()
+
+	assert.NoError(t, ob.PublishPreview(model.NewStripEvent(null.IntFrom(1), model.Save)))
+	assert.Len(t, mh.mqclient.(*mqttClientFake).published, 1)
+	// previews aren't stored
+	pending, err := ob.Pending()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pending)
+
+	unsupported := newOutbox(&eventHandlerFake{}, newOutboxDB(t, ""), time.Millisecond, time.Millisecond)
+	defer unsupported.Shutdown()
+	assert.ErrorIs(t, unsupported.PublishPreview(model.NewStripEvent(null.IntFrom(1), model.Save)), messaging.ErrPreviewsUnsupported)
+}
+
+func TestOutboxSubscribe(t *testing.T) {
+	mh := createMqttMocks(t, nil)
+	ob := newOutbox(mh, newOutboxDB(t, ""), time.Millisecond, time.Millisecond)
+	defer ob.Shutdown()
+
+	assert.NoError(t, ob.Subscribe("TestStrip/+/set", func(string, []byte) {}))
+	assert.Contains(t, mh.mqclient.(*mqttClientFake).subscribed, "TestStrip/+/set")
+}
+
+func TestOutboxSubscribe_NotSupported(t *testing.T) {
+	ob := newOutbox(&eventHandlerFake{}, newOutboxDB(t, ""), time.Millisecond, time.Millisecond)
+	defer ob.Shutdown()
+
+	assert.Error(t, ob.Subscribe("TestStrip/+/set", func(string, []byte) {}))
+}
+
+func TestOutboxConnectionState(t *testing.T) {
+	mh := createMqttMocks(t, nil)
+	ob := newOutbox(mh, newOutboxDB(t, ""), time.Millisecond, time.Millisecond)
+	defer ob.Shutdown()
+	assert.Equal(t, messaging.Connected, ob.ConnectionState())
+
+	mh.onConnectionLost(nil, errReturn)
+	assert.Equal(t, messaging.Connecting, ob.ConnectionState())
+}
+
+func TestOutboxConnectionState_NoConnection(t *testing.T) {
+	ob := newOutbox(&eventHandlerFake{}, newOutboxDB(t, ""), time.Millisecond, time.Millisecond)
+	defer ob.Shutdown()
+	assert.Equal(t, messaging.Connected, ob.ConnectionState())
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	ob := &outbox{retryInterval: time.Second, maxRetryInterval: 5 * time.Second}
+	assert.Equal(t, time.Second, ob.backoff(1))
+	assert.Equal(t, 2*time.Second, ob.backoff(2))
+	assert.Equal(t, 4*time.Second, ob.backoff(3))
+	assert.Equal(t, 5*time.Second, ob.backoff(4))
+	assert.Equal(t, 5*time.Second, ob.backoff(100))
+}
+
+func TestOutboxDefaults(t *testing.T) {
+	ob := newOutbox(&eventHandlerFake{}, newOutboxDB(t, ""), 0, 0)
+	defer ob.Shutdown()
+	assert.Equal(t, defaultRetryInterval, ob.retryInterval)
+	assert.Equal(t, defaultMaxRetryInterval, ob.maxRetryInterval)
+}
+
+func newOutboxDB(t *testing.T, dir string) *csv.CSVHandler[model.OutboxEntry] {
+	return csv.NewHandler[model.OutboxEntry](&config.CSVConfig{DataDir: dir})
+}
+
+func waitForPending(t *testing.T, ob *outbox, expected int) {
+	assert.Eventually(t, func() bool {
+		pending, err := ob.Pending()
+		return err == nil && pending == expected
+	}, time.Second, time.Millisecond)
+}
+
+func (f *eventHandlerFake) PublishStripEvent(event *model.StripEvent) error {
+	if err := f.deliver(event.ID.Int64); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.strips = append(f.strips, event)
+	return nil
+}
+
+func (f *eventHandlerFake) PublishProfileEvent(event *model.ProfileEvent) error {
+	return f.deliver(event.ID.Int64)
+}
+
+func (f *eventHandlerFake) Shutdown() error {
+	f.shutdown = true
+	return nil
+}
+
+func (f *eventHandlerFake) deliver(id int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.calls <= f.failures {
+		return errReturn
+	}
+	f.delivered = append(f.delivered, id)
+	return nil
+}
+
+func (f *eventHandlerFake) getDelivered() []int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int64{}, f.delivered...)
+}
+
+func (f *eventHandlerFake) getCalls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
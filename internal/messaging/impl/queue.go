@@ -0,0 +1,109 @@
+package messagingimpl
+
+import (
+	"errors"
+	"sync"
+
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+)
+
+// defaultQueueSize the number of events queued for a handler, before further events are dropped
+const defaultQueueSize = 100
+
+// errQueueFull is returned if an event is dropped, because the handler doesn't keep up with the events
+var errQueueFull = errors.New("event queue is full")
+
+// interface guard
+var _ messaging.EventHandler = (*queue)(nil)
+var _ messaging.PendingCounter = (*queue)(nil)
+
+// queue delivers the events to the wrapped handler in the background, so a slow handler, like a retrying webhook,
+// doesn't block the publisher. The events are delivered in the order they were published, but they are only kept in
+// memory.
+type queue struct {
+	next     messaging.EventHandler
+	events   chan func(h messaging.EventHandler) error
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	l        alog.Logger
+}
+
+func newQueue(next messaging.EventHandler, size int) *queue {
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	q := &queue{
+		next:   next,
+		events: make(chan func(h messaging.EventHandler) error, size),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		l:      alog.NewLogger("eventqueue"),
+	}
+	go q.run()
+	return q
+}
+
+// PublishStripEvent queues the strip event for delivery
+func (q *queue) PublishStripEvent(event *model.StripEvent) error {
+	return q.enqueue(func(h messaging.EventHandler) error {
+		return h.PublishStripEvent(event)
+	})
+}
+
+// PublishProfileEvent queues the profile event for delivery
+func (q *queue) PublishProfileEvent(event *model.ProfileEvent) error {
+	return q.enqueue(func(h messaging.EventHandler) error {
+		return h.PublishProfileEvent(event)
+	})
+}
+
+// Pending returns the number of queued events
+func (q *queue) Pending() (int, error) {
+	return len(q.events), nil
+}
+
+// Shutdown stops the delivery and shuts down the wrapped handler, which cancels a running delivery. Queued events
+// are discarded.
+func (q *queue) Shutdown() error {
+	q.stopOnce.Do(func() {
+		close(q.stop)
+	})
+	err := q.next.Shutdown()
+	<-q.done
+	if pending := len(q.events); pending > 0 {
+		q.l.Warn("%d queued event(s) discarded", pending)
+	}
+	return err
+}
+
+func (q *queue) enqueue(publish func(h messaging.EventHandler) error) error {
+	select {
+	case q.events <- publish:
+		return nil
+	default:
+		q.l.Error("dropping event: %s", errQueueFull.Error())
+		return errQueueFull
+	}
+}
+
+func (q *queue) run() {
+	defer close(q.done)
+	for {
+		// no further event is delivered after the shutdown, even if there are queued ones
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+		select {
+		case publish := <-q.events:
+			// the wrapped handler reports its failures itself
+			_ = publish(q.next)
+		case <-q.stop:
+			return
+		}
+	}
+}
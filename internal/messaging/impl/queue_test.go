@@ -0,0 +1,61 @@
+package messagingimpl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingHandlerFake blocks every delivery until it is released or shut down
+type blockingHandlerFake struct {
+	eventHandlerFake
+	release chan struct{}
+}
+
+func TestQueueDeliversInOrder(t *testing.T) {
+	fake := &eventHandlerFake{}
+	q := newQueue(fake, 0)
+	defer q.Shutdown()
+
+	assert.NoError(t, q.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save)))
+	assert.NoError(t, q.PublishProfileEvent(model.NewProfileEvent(null.IntFrom(2), model.Delete)))
+	assert.NoError(t, q.PublishStripEvent(model.NewStripEvent(null.IntFrom(3), model.Delete)))
+
+	assert.Eventually(t, func() bool {
+		return len(fake.getDelivered()) == 3
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []int64{1, 2, 3}, fake.getDelivered())
+}
+
+func TestQueueDoesntWaitForTheHandler(t *testing.T) {
+	fake := &blockingHandlerFake{release: make(chan struct{})}
+	q := newQueue(fake, 1)
+
+	// the first event is delivered, the second queued and the third dropped
+	assert.NoError(t, q.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save)))
+	assert.Eventually(t, func() bool {
+		pending, _ := q.Pending()
+		return pending == 0
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, q.PublishStripEvent(model.NewStripEvent(null.IntFrom(2), model.Save)))
+	assert.ErrorIs(t, q.PublishStripEvent(model.NewStripEvent(null.IntFrom(3), model.Save)), errQueueFull)
+
+	// the shutdown cancels the blocked delivery and discards the queued event
+	assert.NoError(t, q.Shutdown())
+	assert.Equal(t, []int64{1}, fake.getDelivered())
+	assert.True(t, fake.shutdown)
+}
+
+func (f *blockingHandlerFake) PublishStripEvent(event *model.StripEvent) error {
+	err := f.eventHandlerFake.PublishStripEvent(event)
+	<-f.release
+	return err
+}
+
+func (f *blockingHandlerFake) Shutdown() error {
+	close(f.release)
+	return f.eventHandlerFake.Shutdown()
+}
@@ -0,0 +1,34 @@
+package messagingimpl
+
+import (
+	"strconv"
+
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+)
+
+// stateLoader loads the current state of all strips, as save events including their profile
+type stateLoader func() ([]*model.StripEvent, error)
+
+// newStateLoader creates a stateLoader reading the strips and profiles from the database
+func newStateLoader(lsdb database.DBHandler[model.LedStrip], cpdb database.DBHandler[model.ColorProfile]) stateLoader {
+	return func() ([]*model.StripEvent, error) {
+		strips, err := lsdb.GetAll()
+		if err != nil {
+			return nil, err
+		}
+		events := make([]*model.StripEvent, 0, len(strips))
+		for i := range strips {
+			strip := strips[i]
+			event := model.NewStripEvent(strip.GetNullID(), model.Save).With(&strip)
+			if strip.ProfileID.Valid {
+				// the state is published without the profile if it is missing
+				if profile, err := cpdb.Get(strconv.FormatInt(strip.ProfileID.Int64, 10)); err == nil {
+					event.Strip.With(*profile)
+				}
+			}
+			events = append(events, event)
+		}
+		return events, nil
+	}
+}
@@ -0,0 +1,45 @@
+package messagingimpl
+
+import (
+	"testing"
+
+	"github.com/pthum/null"
+	dbm "github.com/pthum/stripcontrol-golang/internal/database/mocks"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateLoader(t *testing.T) {
+	lsdb := dbm.NewDBHandler[model.LedStrip](t)
+	cpdb := dbm.NewDBHandler[model.ColorProfile](t)
+	strips := []model.LedStrip{
+		{BaseModel: model.BaseModel{ID: 1}, Name: "without profile"},
+		{BaseModel: model.BaseModel{ID: 2}, Name: "with profile", ProfileID: null.IntFrom(5)},
+		{BaseModel: model.BaseModel{ID: 3}, Name: "missing profile", ProfileID: null.IntFrom(6)},
+	}
+	profile := model.ColorProfile{BaseModel: model.BaseModel{ID: 5}, Red: null.IntFrom(100)}
+	lsdb.EXPECT().GetAll().Return(strips, nil)
+	cpdb.EXPECT().Get("5").Return(&profile, nil)
+	cpdb.EXPECT().Get("6").Return(nil, errReturn)
+
+	events, err := newStateLoader(lsdb, cpdb)()
+
+	assert.NoError(t, err)
+	withProfile := model.NewStripEvent(null.IntFrom(2), model.Save).With(&strips[1])
+	withProfile.Strip.With(profile)
+	assert.Equal(t, []*model.StripEvent{
+		model.NewStripEvent(null.IntFrom(1), model.Save).With(&strips[0]),
+		withProfile,
+		model.NewStripEvent(null.IntFrom(3), model.Save).With(&strips[2]),
+	}, events)
+}
+
+func TestStateLoader_Error(t *testing.T) {
+	lsdb := dbm.NewDBHandler[model.LedStrip](t)
+	cpdb := dbm.NewDBHandler[model.ColorProfile](t)
+	lsdb.EXPECT().GetAll().Return(nil, errReturn)
+
+	_, err := newStateLoader(lsdb, cpdb)()
+
+	assert.Equal(t, errReturn, err)
+}
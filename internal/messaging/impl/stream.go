@@ -0,0 +1,151 @@
+package messagingimpl
+
+import (
+	"sync"
+
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/samber/do"
+)
+
+const (
+	defaultEventBuffer = 100
+	// listenerBuffer the number of events queued for a listener, before it is dropped
+	listenerBuffer = 16
+)
+
+// interface guard
+var _ messaging.EventStream = (*stream)(nil)
+
+// stream keeps the last events in a bounded buffer and distributes the published events to the listeners
+type stream struct {
+	mu        sync.Mutex
+	size      int
+	buffer    []messaging.StreamEvent
+	lastID    uint64
+	listeners map[chan messaging.StreamEvent]struct{}
+	closed    bool
+	enc       EventEncoder
+	l         alog.Logger
+}
+
+// NewStream creates the event stream with the configured buffer size and event format
+func NewStream(i *do.Injector) (messaging.EventStream, error) {
+	cfg := do.MustInvoke[*config.Config](i)
+	enc, err := NewEventEncoder(cfg.Messaging)
+	if err != nil {
+		return nil, err
+	}
+	s := newStream(cfg.Server.EventBuffer)
+	s.enc = enc
+	return s, nil
+}
+
+func newStream(size int) *stream {
+	if size <= 0 {
+		size = defaultEventBuffer
+	}
+	return &stream{
+		size:      size,
+		listeners: make(map[chan messaging.StreamEvent]struct{}),
+		l:         alog.NewLogger("eventstream"),
+	}
+}
+
+// PublishStripEvent adds the strip event to the stream
+func (s *stream) PublishStripEvent(event *model.StripEvent) error {
+	data, err := s.enc.Strip(event)
+	if err != nil {
+		return err
+	}
+	return s.add(messaging.StreamKindStrip, event.ID.Int64, data)
+}
+
+// PublishProfileEvent adds the profile event to the stream
+func (s *stream) PublishProfileEvent(event *model.ProfileEvent) error {
+	data, err := s.enc.Profile(event)
+	if err != nil {
+		return err
+	}
+	return s.add(messaging.StreamKindProfile, event.ID.Int64, data)
+}
+
+// Listen returns the buffered events after lastID and the channel for the following events. A lastID of 0 starts
+// with the next event. If some of the events after lastID have already left the buffer or lastID is unknown, e.g.
+// because the service was restarted in the meantime, a resync event with the current id is returned instead.
+func (s *stream) Listen(lastID uint64) ([]messaging.StreamEvent, <-chan messaging.StreamEvent, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := make(chan messaging.StreamEvent, listenerBuffer)
+	if s.closed {
+		close(ch)
+		return nil, ch, func() {}
+	}
+	s.listeners[ch] = struct{}{}
+	cancel := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.remove(ch)
+	}
+	return s.missed(lastID), ch, cancel
+}
+
+// Shutdown closes the channels of all listeners
+func (s *stream) Shutdown() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for ch := range s.listeners {
+		s.remove(ch)
+	}
+	return nil
+}
+
+func (s *stream) add(kind string, subjectID int64, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	se := messaging.StreamEvent{ID: s.lastID, Kind: kind, SubjectID: subjectID, Data: data}
+	s.buffer = append(s.buffer, se)
+	if len(s.buffer) > s.size {
+		s.buffer = s.buffer[len(s.buffer)-s.size:]
+	}
+	for ch := range s.listeners {
+		select {
+		case ch <- se:
+		default:
+			// the listener can't keep up, it has to resume with the last received id
+			s.l.Warn("dropping slow listener of the event stream")
+			s.remove(ch)
+		}
+	}
+	return nil
+}
+
+// missed returns a copy of the buffered events after lastID, the caller has to hold the lock
+func (s *stream) missed(lastID uint64) []messaging.StreamEvent {
+	if lastID == 0 {
+		return nil
+	}
+	if lastID > s.lastID || (len(s.buffer) > 0 && s.buffer[0].ID > lastID+1) {
+		// a partial replay would look complete to the listener
+		return []messaging.StreamEvent{{ID: s.lastID, Kind: messaging.StreamKindResync, Data: []byte("{}")}}
+	}
+	var missed []messaging.StreamEvent
+	for _, se := range s.buffer {
+		if se.ID > lastID {
+			missed = append(missed, se)
+		}
+	}
+	return missed
+}
+
+// remove closes the channel of the listener, the caller has to hold the lock
+func (s *stream) remove(ch chan messaging.StreamEvent) {
+	if _, ok := s.listeners[ch]; ok {
+		delete(s.listeners, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,119 @@
+package messagingimpl
+
+import (
+	"testing"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamPublish(t *testing.T) {
+	s := newStream(10)
+	_, events, cancel := s.Listen(0)
+	defer cancel()
+
+	assert.NoError(t, s.PublishStripEvent(model.NewStripEvent(null.IntFrom(3), model.Save)))
+	assert.NoError(t, s.PublishProfileEvent(model.NewProfileEvent(null.IntFrom(4), model.Delete)))
+
+	se := <-events
+	assert.Equal(t, uint64(1), se.ID)
+	assert.Equal(t, messaging.StreamKindStrip, se.Kind)
+	assert.Equal(t, int64(3), se.SubjectID)
+	assert.JSONEq(t, `{"type":"SAVE","id":3,"state":null}`, string(se.Data))
+	se = <-events
+	assert.Equal(t, uint64(2), se.ID)
+	assert.Equal(t, messaging.StreamKindProfile, se.Kind)
+	assert.Equal(t, int64(4), se.SubjectID)
+}
+
+func TestStreamResume(t *testing.T) {
+	s := newStream(3)
+	for i := int64(1); i <= 5; i++ {
+		assert.NoError(t, s.PublishStripEvent(model.NewStripEvent(null.IntFrom(i), model.Save)))
+	}
+
+	// a new listener starts with the next event
+	missed, _, cancel := s.Listen(0)
+	cancel()
+	assert.Empty(t, missed)
+
+	missed, _, cancel = s.Listen(3)
+	cancel()
+	assert.Equal(t, []uint64{4, 5}, streamIDs(missed))
+
+	missed, _, cancel = s.Listen(2)
+	cancel()
+	assert.Equal(t, []uint64{3, 4, 5}, streamIDs(missed))
+
+	// the events before the buffer are lost, the listener has to resync
+	missed, _, cancel = s.Listen(1)
+	cancel()
+	assertResync(t, missed, 5)
+
+	// unknown ids, e.g. after a restart, have to resync as well
+	missed, _, cancel = s.Listen(42)
+	cancel()
+	assertResync(t, missed, 5)
+}
+
+func assertResync(t *testing.T, missed []messaging.StreamEvent, id uint64) {
+	if assert.Len(t, missed, 1) {
+		assert.Equal(t, messaging.StreamKindResync, missed[0].Kind)
+		assert.Equal(t, id, missed[0].ID)
+	}
+}
+
+func TestStreamSlowListener(t *testing.T) {
+	s := newStream(100)
+	_, events, cancel := s.Listen(0)
+	defer cancel()
+
+	for i := int64(0); i <= listenerBuffer; i++ {
+		assert.NoError(t, s.PublishStripEvent(model.NewStripEvent(null.IntFrom(i), model.Save)))
+	}
+
+	// the queued events are delivered, afterwards the channel is closed
+	received := 0
+	for range events {
+		received++
+	}
+	assert.Equal(t, listenerBuffer, received)
+	assert.Empty(t, s.listeners)
+}
+
+func TestStreamCancel(t *testing.T) {
+	s := newStream(10)
+	_, events, cancel := s.Listen(0)
+	cancel()
+	cancel()
+
+	_, ok := <-events
+	assert.False(t, ok)
+	assert.NoError(t, s.PublishStripEvent(model.NewStripEvent(null.IntFrom(1), model.Save)))
+}
+
+func TestStreamShutdown(t *testing.T) {
+	s := newStream(0)
+	assert.Equal(t, defaultEventBuffer, s.size)
+	_, events, _ := s.Listen(0)
+
+	assert.NoError(t, s.Shutdown())
+	_, ok := <-events
+	assert.False(t, ok)
+
+	// listening after the shutdown ends immediately
+	_, events, cancel := s.Listen(0)
+	defer cancel()
+	_, ok = <-events
+	assert.False(t, ok)
+}
+
+func streamIDs(events []messaging.StreamEvent) []uint64 {
+	var ids []uint64
+	for _, se := range events {
+		ids = append(ids, se.ID)
+	}
+	return ids
+}
@@ -0,0 +1,172 @@
+package messagingimpl
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+)
+
+const (
+	// HeaderEvent the kind of the event, strip or profile
+	HeaderEvent = "X-Stripcontrol-Event"
+	// HeaderTimestamp the unix time of the request, it is part of the signature
+	HeaderTimestamp = "X-Stripcontrol-Timestamp"
+	// HeaderSignature the hex encoded HMAC-SHA256 of "<timestamp>.<body>", prefixed with sha256=
+	HeaderSignature = "X-Stripcontrol-Signature"
+
+	defaultWebhookTimeout       = 10 * time.Second
+	defaultWebhookMaxAttempts   = 3
+	defaultWebhookRetryInterval = 1 * time.Second
+)
+
+// interface guard
+var _ messaging.EventHandler = (*webhookHandler)(nil)
+
+// webhookHandler posts the events to an url, failed requests are retried with an exponential backoff
+type webhookHandler struct {
+	cfg    config.WebhookConfig
+	enc    EventEncoder
+	client *http.Client
+	// ctx is cancelled on shutdown, which ends the running request and the retries
+	ctx    context.Context
+	cancel context.CancelFunc
+	l      alog.Logger
+}
+
+// permanentErr is returned for requests which will fail again on a retry
+type permanentErr struct {
+	err error
+}
+
+func (e *permanentErr) Error() string {
+	return e.err.Error()
+}
+
+// NewWebhook creates the webhook handler, missing settings are set to their defaults
+func NewWebhook(cfg config.WebhookConfig, enc EventEncoder) (*webhookHandler, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook url %q, has to be an absolute http(s) url", cfg.URL)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultWebhookTimeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultWebhookMaxAttempts
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = defaultWebhookRetryInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &webhookHandler{
+		cfg:    cfg,
+		enc:    enc,
+		client: &http.Client{Timeout: cfg.Timeout},
+		ctx:    ctx,
+		cancel: cancel,
+		l:      alog.NewLogger("webhook"),
+	}, nil
+}
+
+// PublishStripEvent posts the strip event
+func (w *webhookHandler) PublishStripEvent(event *model.StripEvent) error {
+	data, err := w.enc.Strip(event)
+	if err != nil {
+		return err
+	}
+	return w.publish(model.EventKindStrip, data)
+}
+
+// PublishProfileEvent posts the profile event
+func (w *webhookHandler) PublishProfileEvent(event *model.ProfileEvent) error {
+	data, err := w.enc.Profile(event)
+	if err != nil {
+		return err
+	}
+	return w.publish(model.EventKindProfile, data)
+}
+
+// Shutdown cancels the running request and stops pending retries
+func (w *webhookHandler) Shutdown() error {
+	w.cancel()
+	return nil
+}
+
+func (w *webhookHandler) publish(kind string, data []byte) error {
+	var err error
+	delay := w.cfg.RetryInterval
+	for attempt := 1; ; attempt++ {
+		err = w.post(kind, data)
+		var perm *permanentErr
+		if err == nil || errors.As(err, &perm) || attempt >= w.cfg.MaxAttempts {
+			break
+		}
+		w.l.Warn("delivery of %s event to %s failed (attempt %d), retrying in %v: %s", kind, w.cfg.URL, attempt, delay, err.Error())
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-w.ctx.Done():
+			return fmt.Errorf("webhook shut down: %w", err)
+		}
+	}
+	if err != nil {
+		w.l.Error("delivery of %s event to %s failed: %s", kind, w.cfg.URL, err.Error())
+	}
+	return err
+}
+
+func (w *webhookHandler) post(kind string, data []byte) error {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(data))
+	if err != nil {
+		return &permanentErr{err}
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, kind)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if w.cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, "sha256="+Sign(w.cfg.Secret, timestamp, data))
+	}
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	// drain the body to reuse the connection
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("unexpected status %d", res.StatusCode)
+	// only server errors and rate limits might succeed on a retry
+	if res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+	return &permanentErr{err}
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the timestamp and body, as sent in the signature header
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,170 @@
+package messagingimpl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookReceiver records the received requests and answers with the given status codes, the last one is repeated
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func TestWebhookPublishStripEvent(t *testing.T) {
+	rcv, srv := startWebhookReceiver(t, http.StatusOK)
+	wh := newTestWebhook(t, config.WebhookConfig{URL: srv.URL, Secret: "s3cret"})
+	event := model.NewStripEvent(null.IntFrom(3), model.Save).With(&model.LedStrip{BaseModel: model.BaseModel{ID: 3}})
+
+	assert.NoError(t, wh.PublishStripEvent(event))
+
+	assert.Len(t, rcv.requests, 1)
+	req := rcv.requests[0]
+	body := rcv.bodies[0]
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, "strip", req.Header.Get(HeaderEvent))
+	assert.Equal(t, testutils.JsonEncode(t, event), body)
+	// verify the signature like a receiver would
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(req.Header.Get(HeaderTimestamp) + "." + body))
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), req.Header.Get(HeaderSignature))
+}
+
+func TestWebhookPublishProfileEvent_Unsigned(t *testing.T) {
+	rcv, srv := startWebhookReceiver(t, http.StatusNoContent)
+	wh := newTestWebhook(t, config.WebhookConfig{URL: srv.URL})
+
+	assert.NoError(t, wh.PublishProfileEvent(model.NewProfileEvent(null.IntFrom(4), model.Delete)))
+
+	assert.Len(t, rcv.requests, 1)
+	assert.Equal(t, "profile", rcv.requests[0].Header.Get(HeaderEvent))
+	assert.NotEmpty(t, rcv.requests[0].Header.Get(HeaderTimestamp))
+	assert.Empty(t, rcv.requests[0].Header.Get(HeaderSignature))
+}
+
+func TestWebhookRetries(t *testing.T) {
+	rcv, srv := startWebhookReceiver(t, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK)
+	wh := newTestWebhook(t, config.WebhookConfig{URL: srv.URL, MaxAttempts: 3, RetryInterval: time.Millisecond})
+
+	assert.NoError(t, wh.PublishStripEvent(model.NewStripEvent(null.IntFrom(3), model.Delete)))
+	assert.Len(t, rcv.requests, 3)
+}
+
+func TestWebhookRetries_GivesUp(t *testing.T) {
+	rcv, srv := startWebhookReceiver(t, http.StatusInternalServerError)
+	wh := newTestWebhook(t, config.WebhookConfig{URL: srv.URL, MaxAttempts: 2, RetryInterval: time.Millisecond})
+
+	assert.Error(t, wh.PublishStripEvent(model.NewStripEvent(null.IntFrom(3), model.Delete)))
+	assert.Len(t, rcv.requests, 2)
+}
+
+func TestWebhookRetries_ClientError(t *testing.T) {
+	rcv, srv := startWebhookReceiver(t, http.StatusBadRequest)
+	wh := newTestWebhook(t, config.WebhookConfig{URL: srv.URL, MaxAttempts: 3, RetryInterval: time.Millisecond})
+
+	assert.Error(t, wh.PublishStripEvent(model.NewStripEvent(null.IntFrom(3), model.Delete)))
+	// the request is rejected, a retry won't help
+	assert.Len(t, rcv.requests, 1)
+}
+
+func TestWebhookShutdown_StopsRetries(t *testing.T) {
+	_, srv := startWebhookReceiver(t, http.StatusInternalServerError)
+	wh := newTestWebhook(t, config.WebhookConfig{URL: srv.URL, MaxAttempts: 5, RetryInterval: time.Hour})
+	done := make(chan error)
+	go func() {
+		done <- wh.PublishStripEvent(model.NewStripEvent(null.IntFrom(3), model.Delete))
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	assert.NoError(t, wh.Shutdown())
+
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "retry not stopped")
+	}
+}
+
+func TestWebhookShutdown_CancelsRequest(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	wh := newTestWebhook(t, config.WebhookConfig{URL: srv.URL, Timeout: time.Hour})
+	done := make(chan error)
+	go func() {
+		done <- wh.PublishStripEvent(model.NewStripEvent(null.IntFrom(3), model.Delete))
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// the request doesn't run until its timeout
+	assert.NoError(t, wh.Shutdown())
+
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "request not cancelled")
+	}
+}
+
+func TestWebhookDefaults(t *testing.T) {
+	wh := newTestWebhook(t, config.WebhookConfig{URL: "https://example.com/hook"})
+	assert.Equal(t, defaultWebhookTimeout, wh.client.Timeout)
+	assert.Equal(t, defaultWebhookMaxAttempts, wh.cfg.MaxAttempts)
+	assert.Equal(t, defaultWebhookRetryInterval, wh.cfg.RetryInterval)
+}
+
+func TestNewWebhook_InvalidURL(t *testing.T) {
+	for _, u := range []string{"", "example.com/hook", "ftp://example.com", "http://", "http://a b"} {
+		_, err := NewWebhook(config.WebhookConfig{URL: u}, EventEncoder{})
+		assert.Error(t, err, u)
+	}
+}
+
+func TestSign(t *testing.T) {
+	assert.Equal(t, Sign("key", "1", []byte("{}")), Sign("key", "1", []byte("{}")))
+	assert.NotEqual(t, Sign("key", "1", []byte("{}")), Sign("key", "2", []byte("{}")))
+	assert.NotEqual(t, Sign("key", "1", []byte("{}")), Sign("other", "1", []byte("{}")))
+}
+
+func newTestWebhook(t *testing.T, cfg config.WebhookConfig) *webhookHandler {
+	wh, err := NewWebhook(cfg, EventEncoder{})
+	assert.NoError(t, err)
+	return wh
+}
+
+func startWebhookReceiver(t *testing.T, statuses ...int) (*webhookReceiver, *httptest.Server) {
+	rcv := &webhookReceiver{statuses: statuses}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rcv.mu.Lock()
+		defer rcv.mu.Unlock()
+		rcv.requests = append(rcv.requests, r)
+		rcv.bodies = append(rcv.bodies, strings.TrimSpace(string(body)))
+		status := rcv.statuses[min(len(rcv.requests), len(rcv.statuses))-1]
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return rcv, srv
+}
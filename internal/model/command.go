@@ -0,0 +1,23 @@
+package model
+
+import "github.com/pthum/null"
+
+// StripCommand a command to change a strip, received through the messaging. Besides its own fields it understands
+// the commands of a home assistant json schema light.
+type StripCommand struct {
+	Enabled   null.Bool `json:"enabled"`
+	ProfileID null.Int  `json:"profileId"`
+	// State ON or OFF, sent by home assistant
+	State null.String `json:"state"`
+	// Brightness the brightness for the profile of the strip, sent by home assistant
+	Brightness null.Int `json:"brightness"`
+	// Color the color for the profile of the strip, sent by home assistant
+	Color *RGBColor `json:"color"`
+}
+
+// RGBColor a color in the format of home assistant
+type RGBColor struct {
+	R int64 `json:"r"`
+	G int64 `json:"g"`
+	B int64 `json:"b"`
+}
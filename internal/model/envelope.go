@@ -0,0 +1,68 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventSchemaVersion the version of the envelope and its payloads, it is incremented on incompatible changes
+const EventSchemaVersion = 1
+
+const (
+	EventKindStrip   = "strip"
+	EventKindProfile = "profile"
+)
+
+// EventMeta the metadata of an event, it is only sent in the envelope
+type EventMeta struct {
+	ID    string
+	Time  time.Time
+	Actor string
+}
+
+// NewEventMeta creates the metadata of an event caused by the actor now
+func NewEventMeta(actor string) EventMeta {
+	return EventMeta{
+		ID:    uuid.NewString(),
+		Time:  time.Now().UTC(),
+		Actor: actor,
+	}
+}
+
+// EventEnvelope wraps an event with its metadata
+type EventEnvelope struct {
+	ID            string          `json:"id"`
+	Time          time.Time       `json:"time"`
+	Source        string          `json:"source,omitempty"`
+	Actor         string          `json:"actor,omitempty"`
+	SchemaVersion int             `json:"schemaVersion"`
+	Kind          string          `json:"kind"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// NewEventEnvelope wraps the event, an event without metadata gets a new id and the current time
+func NewEventEnvelope(kind, source string, meta EventMeta, event any) (*EventEnvelope, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	if meta.ID == "" {
+		meta = NewEventMeta(meta.Actor)
+	}
+	return &EventEnvelope{
+		ID:            meta.ID,
+		Time:          meta.Time,
+		Source:        source,
+		Actor:         meta.Actor,
+		SchemaVersion: EventSchemaVersion,
+		Kind:          kind,
+		Data:          data,
+	}, nil
+}
+
+// Meta returns the metadata of the wrapped event
+func (e *EventEnvelope) Meta() EventMeta {
+	return EventMeta{ID: e.ID, Time: e.Time, Actor: e.Actor}
+}
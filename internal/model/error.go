@@ -1,5 +1,7 @@
 package model
 
+import "strings"
+
 type AppError struct {
 	Err  error
 	Code int
@@ -15,3 +17,26 @@ func NewAppErr(code int, err error) *AppError {
 func (e *AppError) Error() string {
 	return e.Err.Error()
 }
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// FieldError describes why the value of a single field is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError contains all invalid fields of an object
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
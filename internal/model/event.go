@@ -11,6 +11,7 @@ type StripEvent struct {
 	Type  EventType `json:"type,omitempty"`
 	ID    null.Int  `json:"id,omitempty"`
 	Strip OptStrip  `json:"state,omitempty"`
+	Meta  EventMeta `json:"-"`
 }
 
 func NewStripEvent(id null.Int, typ EventType) *StripEvent {
@@ -21,6 +22,12 @@ func NewStripEvent(id null.Int, typ EventType) *StripEvent {
 	return evnt
 }
 
+// By sets the metadata of the event caused by the actor
+func (pe *StripEvent) By(actor string) *StripEvent {
+	pe.Meta = NewEventMeta(actor)
+	return pe
+}
+
 func (pe *StripEvent) With(strip *LedStrip) *StripEvent {
 	if strip != nil {
 		pe.Strip.Valid = true
@@ -66,6 +73,7 @@ type ProfileEvent struct {
 	Type  EventType  `json:"type,omitempty"`
 	ID    null.Int   `json:"id,omitempty"`
 	State OptProfile `json:"state,omitempty"`
+	Meta  EventMeta  `json:"-"`
 }
 
 func NewProfileEvent(id null.Int, typ EventType) *ProfileEvent {
@@ -77,6 +85,12 @@ func NewProfileEvent(id null.Int, typ EventType) *ProfileEvent {
 	return evnt
 }
 
+// By sets the metadata of the event caused by the actor
+func (pe *ProfileEvent) By(actor string) *ProfileEvent {
+	pe.Meta = NewEventMeta(actor)
+	return pe
+}
+
 func (pe *ProfileEvent) With(profile ColorProfile) *ProfileEvent {
 	pe.State.Valid = true
 	pe.State.Profile = profile
@@ -105,6 +119,26 @@ func (strip OptStrip) MarshalJSON() (data []byte, err error) {
 	return
 }
 
+// UnmarshalJSON unmarshals json for OptProfile
+func (profile *OptProfile) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*profile = OptProfile{}
+		return nil
+	}
+	profile.Valid = true
+	return json.Unmarshal(data, &profile.Profile)
+}
+
+// UnmarshalJSON unmarshals json for OptStrip
+func (strip *OptStrip) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*strip = OptStrip{}
+		return nil
+	}
+	strip.Valid = true
+	return json.Unmarshal(data, &strip.Strip)
+}
+
 //go:generate enumer -type=EventType -json -text -transform=upper
 type EventType int
 
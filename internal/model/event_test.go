@@ -65,3 +65,63 @@ func TestProfileEventJson(t *testing.T) {
 
 	runEncodeTests(t, tests)
 }
+
+func TestStripEventJsonDecode(t *testing.T) {
+	stripSave := *NewStripEvent(null.IntFrom(234), Save).With(&LedStrip{
+		BaseModel: BaseModel{ID: 234},
+		Name:      "test",
+	})
+	stripSave.Strip.With(ColorProfile{
+		BaseModel:  BaseModel{ID: 185},
+		Red:        null.IntFrom(123),
+		Green:      null.IntFrom(234),
+		Blue:       null.IntFrom(12),
+		Brightness: null.IntFrom(1),
+	})
+	tests := []decodeTest[StripEvent]{
+		{
+			name:  "save stripevent",
+			input: `{"type":"SAVE","id":234,"state":{"id":234,"name":"test","profile":{"id":185,"blue":12,"brightness":1,"green":234,"red":123}}}`,
+			want:  stripSave,
+		},
+		{
+			name:  "save stripevent without profile",
+			input: `{"type":"SAVE","id":234,"state":{"id":234,"name":"test","profile":null}}`,
+			want: *NewStripEvent(null.IntFrom(234), Save).With(&LedStrip{
+				BaseModel: BaseModel{ID: 234},
+				Name:      "test",
+			}),
+		},
+		{
+			name:  "delete stripevent",
+			input: `{"type":"DELETE","id":234,"state":null}`,
+			want:  *NewStripEvent(null.IntFrom(234), Delete),
+		},
+	}
+
+	runDecodeTests(t, tests)
+}
+
+func TestProfileEventJsonDecode(t *testing.T) {
+	dummyProfile := ColorProfile{
+		BaseModel:  BaseModel{ID: 185},
+		Red:        null.IntFrom(123),
+		Green:      null.IntFrom(234),
+		Blue:       null.IntFrom(12),
+		Brightness: null.IntFrom(1),
+	}
+	tests := []decodeTest[ProfileEvent]{
+		{
+			name:  "save profileevent",
+			input: `{"type":"SAVE","id":123,"state":{"id":185,"blue":12,"brightness":1,"green":234,"red":123}}`,
+			want:  *NewProfileEvent(null.IntFrom(123), Save).With(dummyProfile),
+		},
+		{
+			name:  "delete profileevent",
+			input: `{"type":"DELETE","id":123,"state":null}`,
+			want:  *NewProfileEvent(null.IntFrom(123), Delete),
+		},
+	}
+
+	runDecodeTests(t, tests)
+}
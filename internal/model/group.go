@@ -0,0 +1,97 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const Table_Group = "strip_group"
+
+// Group a named set of strips, like a room, which are controlled together. A group may contain other groups, their
+// strips belong to the group as well.
+type Group struct {
+	BaseModel
+	Name        string `json:"name,omitempty" csv:"name"`
+	Description string `json:"description,omitempty" csv:"description"`
+	// Strips the ids of the member strips
+	Strips IDs `json:"strips" gorm:"column:strips" csv:"strips"`
+	// Groups the ids of the nested groups
+	Groups IDs `json:"groups" gorm:"column:groups" csv:"groups"`
+}
+
+// TableName sets the table name for the group
+func (Group) TableName() string {
+	return Table_Group
+}
+
+// GroupResult the aggregated result of an action on the strips of a group
+type GroupResult struct {
+	// Strips the strips the action has been applied to
+	Strips []LedStrip `json:"strips"`
+	// Failed the strips the action couldn't be applied to
+	Failed []StripFailure `json:"failed,omitempty"`
+}
+
+// StripFailure the reason an action couldn't be applied to a strip
+type StripFailure struct {
+	StripID int64  `json:"stripId"`
+	Error   string `json:"error"`
+}
+
+// IDs the ids of referenced objects, they are stored comma separated in a single column
+type IDs []int64
+
+// MarshalCSV stores the ids comma separated
+func (ids IDs) MarshalCSV() (string, error) {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// UnmarshalCSV reads the comma separated ids
+func (ids *IDs) UnmarshalCSV(data string) error {
+	if data == "" {
+		*ids = nil
+		return nil
+	}
+	parts := strings.Split(data, ",")
+	result := make(IDs, len(parts))
+	for i, part := range parts {
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid id %q: %w", part, err)
+		}
+		result[i] = id
+	}
+	*ids = result
+	return nil
+}
+
+// GormDataType the column type of the ids
+func (IDs) GormDataType() string {
+	return "text"
+}
+
+// Value stores the ids comma separated
+func (ids IDs) Value() (driver.Value, error) {
+	return ids.MarshalCSV()
+}
+
+// Scan reads the comma separated ids
+func (ids *IDs) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*ids = nil
+		return nil
+	case string:
+		return ids.UnmarshalCSV(v)
+	case []byte:
+		return ids.UnmarshalCSV(string(v))
+	default:
+		return fmt.Errorf("unsupported type %T of the ids", value)
+	}
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupJsonEncode(t *testing.T) {
+	tests := []encodeTest[Group]{
+		{
+			name:  "test members",
+			input: Group{BaseModel: BaseModel{ID: 2}, Name: "Ground floor", Strips: IDs{1, 5}, Groups: IDs{3}},
+			want:  `{"id":2,"name":"Ground floor","strips":[1,5],"groups":[3]}`,
+		},
+		{
+			name:  "test empty",
+			input: Group{Name: "Empty"},
+			want:  `{"name":"Empty","strips":null,"groups":null}`,
+		},
+	}
+
+	runEncodeTests(t, tests)
+}
+
+func TestGroupTableName(t *testing.T) {
+	assert.Equal(t, "strip_group", Group{}.TableName())
+}
+
+func TestIDsCSV(t *testing.T) {
+	ids := IDs{4, 12}
+
+	data, err := ids.MarshalCSV()
+	assert.NoError(t, err)
+	assert.Equal(t, "4,12", data)
+	var result IDs
+	assert.NoError(t, result.UnmarshalCSV(data))
+	assert.Equal(t, ids, result)
+
+	assert.NoError(t, result.UnmarshalCSV(""))
+	assert.Nil(t, result)
+	assert.Error(t, result.UnmarshalCSV("4,x"))
+}
+
+func TestIDsScan(t *testing.T) {
+	for _, input := range []any{"7,8", []byte("7,8")} {
+		var result IDs
+		assert.NoError(t, result.Scan(input))
+		assert.Equal(t, IDs{7, 8}, result)
+	}
+	var result IDs
+	assert.NoError(t, result.Scan(nil))
+	assert.Nil(t, result)
+	assert.Error(t, result.Scan(5))
+}
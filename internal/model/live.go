@@ -0,0 +1,35 @@
+package model
+
+import "github.com/pthum/null"
+
+const (
+	// LivePreview shows the color on the strip without storing it
+	LivePreview = "preview"
+	// LiveCommit stores the color, including the values of the previews since the last commit
+	LiveCommit = "commit"
+	// LiveCommitted the reply to a successful commit
+	LiveCommitted = "committed"
+	// LiveError the reply to a failed message
+	LiveError = "error"
+)
+
+// LiveMessage a message of the client on the live color channel of a strip, values which aren't set are kept
+type LiveMessage struct {
+	Type       string   `json:"type"`
+	Red        null.Int `json:"red,omitempty"`
+	Green      null.Int `json:"green,omitempty"`
+	Blue       null.Int `json:"blue,omitempty"`
+	Brightness null.Int `json:"brightness,omitempty"`
+}
+
+// LiveReply a reply on the live color channel of a strip
+type LiveReply struct {
+	Type    string        `json:"type"`
+	Profile *ColorProfile `json:"profile,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Color returns the color values of the message as profile
+func (m LiveMessage) Color() ColorProfile {
+	return ColorProfile{Red: m.Red, Green: m.Green, Blue: m.Blue, Brightness: m.Brightness}
+}
@@ -0,0 +1,21 @@
+package model
+
+const Table_Outbox = "event_outbox"
+
+const (
+	OutboxKindStrip   = "strip"
+	OutboxKindProfile = "profile"
+)
+
+// OutboxEntry an event which hasn't been delivered to the message broker yet
+type OutboxEntry struct {
+	BaseModel
+	Kind     string `json:"kind,omitempty" csv:"kind"`
+	Payload  string `json:"payload,omitempty" csv:"payload"`
+	Attempts int64  `json:"attempts,omitempty" csv:"attempts"`
+}
+
+// TableName sets the table name for the outbox
+func (OutboxEntry) TableName() string {
+	return Table_Outbox
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// MergePatchContentType the media type of a json merge patch (RFC 7396)
+const MergePatchContentType = "application/merge-patch+json"
+
+// ApplyMergePatch applies the json merge patch (RFC 7396) to the object and returns the patched copy.
+// Members with a null value are removed, which resets the corresponding field to its zero value.
+func ApplyMergePatch[T any](original T, patch []byte) (T, error) {
+	var result T
+	var patchDoc any
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return result, NewAppErr(http.StatusBadRequest, err)
+	}
+	if _, ok := patchDoc.(map[string]any); !ok {
+		return result, NewAppErr(http.StatusBadRequest, errors.New("patch has to be a json object"))
+	}
+
+	origData, err := json.Marshal(original)
+	if err != nil {
+		return result, err
+	}
+	var origDoc any
+	if err := json.Unmarshal(origData, &origDoc); err != nil {
+		return result, err
+	}
+
+	patchedData, err := json.Marshal(mergePatch(origDoc, patchDoc))
+	if err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal(patchedData, &result); err != nil {
+		return result, NewAppErr(http.StatusBadRequest, err)
+	}
+	return result, nil
+}
+
+// mergePatch implements the MergePatch function of RFC 7396
+func mergePatch(target any, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for name, value := range patchObj {
+		if value == nil {
+			delete(targetObj, name)
+			continue
+		}
+		targetObj[name] = mergePatch(targetObj[name], value)
+	}
+	return targetObj
+}
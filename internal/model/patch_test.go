@@ -0,0 +1,70 @@
+package model
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pthum/null"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	original := LedStrip{
+		BaseModel: BaseModel{ID: 12},
+		Name:      "strip",
+		Enabled:   true,
+		NumLeds:   null.IntFrom(60),
+		SpeedHz:   null.IntFrom(800000),
+	}
+	tests := []struct {
+		name     string
+		patch    string
+		expected LedStrip
+	}{
+		{"empty patch", `{}`, original},
+		{"set value", `{"name":"new","misoPin":10}`, func() LedStrip {
+			s := original
+			s.Name = "new"
+			s.MisoPin = null.IntFrom(10)
+			return s
+		}()},
+		{"set false", `{"enabled":false}`, func() LedStrip {
+			s := original
+			s.Enabled = false
+			return s
+		}()},
+		{"remove value", `{"speedHz":null}`, func() LedStrip {
+			s := original
+			s.SpeedHz = null.NewInt(0, false)
+			return s
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ApplyMergePatch(original, []byte(tt.patch))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+	// the original must not be modified
+	assert.Equal(t, "strip", original.Name)
+}
+
+func TestApplyMergePatch_Invalid(t *testing.T) {
+	for _, patch := range []string{`{"name":`, `[{"name":"x"}]`, `"name"`, `{"numLeds":"many"}`} {
+		_, err := ApplyMergePatch(LedStrip{}, []byte(patch))
+		var aerr *AppError
+		if assert.ErrorAs(t, err, &aerr, patch) {
+			assert.Equal(t, http.StatusBadRequest, aerr.Code)
+		}
+	}
+}
+
+func TestMergePatch_Nested(t *testing.T) {
+	target := map[string]any{"a": map[string]any{"b": "c", "d": "e"}, "f": "g"}
+	patch := map[string]any{"a": map[string]any{"b": nil, "x": "y"}, "f": []any{"h"}}
+
+	result := mergePatch(target, patch)
+
+	assert.Equal(t, map[string]any{"a": map[string]any{"d": "e", "x": "y"}, "f": []any{"h"}}, result)
+}
@@ -1,9 +1,7 @@
 package model
 
 import (
-	"math/rand"
 	"strconv"
-	"time"
 
 	"github.com/pthum/null"
 )
@@ -18,8 +16,19 @@ type IDer interface {
 	TableName() string
 }
 
+type IDSetter interface {
+	SetID(id int64)
+}
+
+// Versioned an object with a version, which is incremented by the database on every write
+type Versioned interface {
+	GetVersion() int64
+	SetVersion(version int64)
+}
+
 type BaseModel struct {
-	ID int64 `json:"id,omitempty" gorm:"primary_key" csv:"id"`
+	ID      int64 `json:"id,omitempty" gorm:"primary_key" csv:"id"`
+	Version int64 `json:"version,omitempty" gorm:"column:version;not null;default:0" csv:"version"`
 }
 
 func (b *BaseModel) GetID() int64 {
@@ -32,15 +41,16 @@ func (b *BaseModel) GetStringID() string {
 	return strconv.FormatInt(b.ID, 10)
 }
 
-// GenerateID generates an ID between 0 and 500
-func (b *BaseModel) GenerateID() {
-	// we have to generate an ID, as in contrast to spring/quarkus, gorm does not provide the generate-id on ORM side
-	// (as we do not want to migrate the DB to keep compatibility between the different implementations)
-	// we keep this logic simple and just generate a random int64. we do not expect too much strips, so the chance of a collision should be low
-	s1 := rand.NewSource(time.Now().UnixNano())
-	//#nosec G404 - false positive, math random is okay here
-	r1 := rand.New(s1)
-	b.ID = r1.Int63n(500)
+func (b *BaseModel) SetID(id int64) {
+	b.ID = id
+}
+
+func (b *BaseModel) GetVersion() int64 {
+	return b.Version
+}
+
+func (b *BaseModel) SetVersion(version int64) {
+	b.Version = version
 }
 
 // ColorProfile The ColorProfile which reflects color and brightness
@@ -57,6 +67,23 @@ func (ColorProfile) TableName() string {
 	return Table_ColorProfile
 }
 
+// WithColor returns a copy of the profile with the valid color and brightness values of color
+func (c ColorProfile) WithColor(color ColorProfile) ColorProfile {
+	if color.Red.Valid {
+		c.Red = color.Red
+	}
+	if color.Green.Valid {
+		c.Green = color.Green
+	}
+	if color.Blue.Valid {
+		c.Blue = color.Blue
+	}
+	if color.Brightness.Valid {
+		c.Brightness = color.Brightness
+	}
+	return c
+}
+
 // LedStrip definition of a LED strip and its configuration
 type LedStrip struct {
 	BaseModel
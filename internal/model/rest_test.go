@@ -151,13 +151,25 @@ func TestTableName(t *testing.T) {
 	}
 }
 
-func TestGenerateID(t *testing.T) {
-	const maxID int64 = 500
-	const minID int64 = 0
+func TestSetID(t *testing.T) {
 	bm := BaseModel{}
-	for i := 1; i <= 2000; i++ {
-		bm.GenerateID()
-		assert.GreaterOrEqual(t, bm.GetID(), minID)
-		assert.LessOrEqual(t, bm.GetID(), maxID)
-	}
+	bm.SetID(42)
+	assert.Equal(t, int64(42), bm.GetID())
+	assert.Equal(t, "42", bm.GetStringID())
+}
+
+func TestSetVersion(t *testing.T) {
+	bm := BaseModel{}
+	bm.SetVersion(3)
+	assert.Equal(t, int64(3), bm.GetVersion())
+}
+
+func TestWithColor(t *testing.T) {
+	profile := ColorProfile{BaseModel: BaseModel{ID: 3}, Red: null.IntFrom(1), Green: null.IntFrom(2), Blue: null.IntFrom(3), Brightness: null.IntFrom(4)}
+
+	result := profile.WithColor(ColorProfile{BaseModel: BaseModel{ID: 9}, Green: null.IntFrom(20), Brightness: null.IntFrom(31)})
+
+	assert.Equal(t, ColorProfile{BaseModel: BaseModel{ID: 3}, Red: null.IntFrom(1), Green: null.IntFrom(20), Blue: null.IntFrom(3), Brightness: null.IntFrom(31)}, result)
+	// the profile itself is unchanged
+	assert.Equal(t, null.IntFrom(2), profile.Green)
 }
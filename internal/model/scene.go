@@ -0,0 +1,76 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pthum/null"
+)
+
+const Table_Scene = "scene"
+
+// Scene a named snapshot of the state of several strips, which are applied together
+type Scene struct {
+	BaseModel
+	Name        string      `json:"name,omitempty" csv:"name"`
+	Description string      `json:"description,omitempty" csv:"description"`
+	Strips      StripStates `json:"strips" gorm:"column:strips" csv:"strips"`
+}
+
+// TableName sets the table name for the scene
+func (Scene) TableName() string {
+	return Table_Scene
+}
+
+// StripState the state of a single strip, the profile is either referenced or its color is given inline. Without
+// both, the profile of the strip isn't changed.
+type StripState struct {
+	StripID   int64         `json:"stripId"`
+	Enabled   bool          `json:"enabled"`
+	ProfileID null.Int      `json:"profileId,omitempty"`
+	Color     *ColorProfile `json:"color,omitempty"`
+}
+
+// StripStates the states of the strips of a scene, they are stored as json in a single column
+type StripStates []StripState
+
+// MarshalCSV stores the states as json
+func (s StripStates) MarshalCSV() (string, error) {
+	data, err := json.Marshal(s)
+	return string(data), err
+}
+
+// UnmarshalCSV reads the states from json
+func (s *StripStates) UnmarshalCSV(data string) error {
+	if data == "" {
+		*s = nil
+		return nil
+	}
+	return json.Unmarshal([]byte(data), s)
+}
+
+// GormDataType the column type of the states
+func (StripStates) GormDataType() string {
+	return "text"
+}
+
+// Value stores the states as json
+func (s StripStates) Value() (driver.Value, error) {
+	return s.MarshalCSV()
+}
+
+// Scan reads the states from json
+func (s *StripStates) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case string:
+		return s.UnmarshalCSV(v)
+	case []byte:
+		return s.UnmarshalCSV(string(v))
+	default:
+		return fmt.Errorf("unsupported type %T of the strip states", value)
+	}
+}
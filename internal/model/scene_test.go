@@ -0,0 +1,67 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/pthum/null"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSceneJsonEncode(t *testing.T) {
+	tests := []encodeTest[Scene]{
+		{
+			name: "test filled",
+			input: Scene{
+				BaseModel: BaseModel{ID: 3},
+				Name:      "Evening",
+				Strips: StripStates{
+					{StripID: 1, Enabled: true, ProfileID: null.IntFrom(5)},
+					{StripID: 2, Color: &ColorProfile{Red: null.IntFrom(255), Brightness: null.IntFrom(10)}},
+				},
+			},
+			want: `{"id":3,"name":"Evening","strips":[{"stripId":1,"enabled":true,"profileId":5},` +
+				`{"stripId":2,"enabled":false,"profileId":null,"color":{"blue":null,"brightness":10,"green":null,"red":255}}]}`,
+		},
+		{
+			name:  "test empty",
+			input: Scene{},
+			want:  `{"strips":null}`,
+		},
+	}
+
+	runEncodeTests(t, tests)
+}
+
+func TestSceneTableName(t *testing.T) {
+	assert.Equal(t, "scene", Scene{}.TableName())
+}
+
+func TestStripStatesCSV(t *testing.T) {
+	states := StripStates{{StripID: 1, Enabled: true, ProfileID: null.IntFrom(5)}}
+
+	data, err := states.MarshalCSV()
+	assert.NoError(t, err)
+	var result StripStates
+	assert.NoError(t, result.UnmarshalCSV(data))
+	assert.Equal(t, states, result)
+
+	assert.NoError(t, result.UnmarshalCSV(""))
+	assert.Nil(t, result)
+	assert.Error(t, result.UnmarshalCSV("{"))
+}
+
+func TestStripStatesScan(t *testing.T) {
+	states := StripStates{{StripID: 2, Color: &ColorProfile{Green: null.IntFrom(3)}}}
+	value, err := states.Value()
+	assert.NoError(t, err)
+
+	for _, input := range []any{value, []byte(value.(string))} {
+		var result StripStates
+		assert.NoError(t, result.Scan(input))
+		assert.Equal(t, states, result)
+	}
+	var result StripStates
+	assert.NoError(t, result.Scan(nil))
+	assert.Nil(t, result)
+	assert.Error(t, result.Scan(5))
+}
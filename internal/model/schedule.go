@@ -0,0 +1,123 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pthum/null"
+)
+
+const Table_Schedule = "schedule"
+
+// TargetType the kind of object a schedule is applied to
+type TargetType string
+
+const (
+	TargetStrip TargetType = "strip"
+	TargetScene TargetType = "scene"
+	TargetGroup TargetType = "group"
+)
+
+// ScheduleAction the action a schedule performs on its target
+type ScheduleAction string
+
+const (
+	// ActionOn enables the strips of the target, a scene is activated
+	ActionOn ScheduleAction = "on"
+	// ActionOff disables the strips of the target
+	ActionOff ScheduleAction = "off"
+	// ActionSetProfile enables the strips of the target with the profile of the schedule
+	ActionSetProfile ScheduleAction = "set-profile"
+)
+
+// SolarEvent the position of the sun a schedule runs at
+type SolarEvent string
+
+const (
+	Sunrise SolarEvent = "sunrise"
+	Sunset  SolarEvent = "sunset"
+	// CivilDawn the begin of the civil twilight in the morning, the sun is 6° below the horizon
+	CivilDawn SolarEvent = "civil-dawn"
+	// CivilDusk the end of the civil twilight in the evening, the sun is 6° below the horizon
+	CivilDusk SolarEvent = "civil-dusk"
+)
+
+// Schedule runs an action on a target at the times given either by a cron expression, by the weekdays and the
+// time of day or by the weekdays and a solar event. The times are evaluated in the timezone of the schedule.
+type Schedule struct {
+	BaseModel
+	Name string `json:"name,omitempty" csv:"name"`
+	// Cron a standard cron expression with five fields
+	Cron string `json:"cron,omitempty" csv:"cron"`
+	// Weekdays the days the schedule runs at the time or solar event, every day if none are given
+	Weekdays Weekdays `json:"weekdays,omitempty" gorm:"column:weekdays" csv:"weekdays"`
+	// Time the time of day as HH:MM
+	Time string `json:"time,omitempty" csv:"time"`
+	// Solar the solar event at the configured location, the schedule runs the offset in minutes before (negative) or
+	// after it
+	Solar  SolarEvent `json:"solar,omitempty" csv:"solar"`
+	Offset int        `json:"offset,omitempty" csv:"offset"`
+	// Timezone the IANA name of the timezone, UTC if none is given
+	Timezone   string         `json:"timezone,omitempty" csv:"timezone"`
+	TargetType TargetType     `json:"targetType" csv:"target_type"`
+	TargetID   int64          `json:"targetId" csv:"target_id"`
+	Action     ScheduleAction `json:"action" csv:"action"`
+	// ProfileID the profile for the set-profile action
+	ProfileID null.Int `json:"profileId,omitempty" csv:"profile_id"`
+}
+
+// TableName sets the table name for the schedule
+func (Schedule) TableName() string {
+	return Table_Schedule
+}
+
+// ScheduleInfo the schedule with the time of its next run, which is only known while the scheduler is running
+type ScheduleInfo struct {
+	Schedule
+	NextRun *time.Time `json:"nextRun,omitempty"`
+}
+
+// Weekdays the abbreviated names of the days of the week, like mon or sat
+type Weekdays []string
+
+// MarshalCSV stores the days comma separated
+func (w Weekdays) MarshalCSV() (string, error) {
+	return strings.Join(w, ","), nil
+}
+
+// UnmarshalCSV reads the comma separated days
+func (w *Weekdays) UnmarshalCSV(data string) error {
+	if data == "" {
+		*w = nil
+		return nil
+	}
+	*w = strings.Split(data, ",")
+	return nil
+}
+
+// GormDataType the column type of the days
+func (Weekdays) GormDataType() string {
+	return "text"
+}
+
+// Value stores the days comma separated
+func (w Weekdays) Value() (driver.Value, error) {
+	return w.MarshalCSV()
+}
+
+// Scan reads the comma separated days
+func (w *Weekdays) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*w = nil
+		return nil
+	case string:
+		return w.UnmarshalCSV(v)
+	case []byte:
+		return w.UnmarshalCSV(string(v))
+	default:
+		return fmt.Errorf("unsupported type %T of the weekdays", value)
+	}
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pthum/null"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleInfoJsonEncode(t *testing.T) {
+	nextRun := time.Date(2024, 5, 6, 7, 30, 0, 0, time.UTC)
+	tests := []encodeTest[ScheduleInfo]{
+		{
+			name: "test weekdays",
+			input: ScheduleInfo{
+				Schedule: Schedule{
+					BaseModel:  BaseModel{ID: 2},
+					Name:       "Wake up",
+					Weekdays:   Weekdays{"mon", "fri"},
+					Time:       "07:30",
+					Timezone:   "Europe/Berlin",
+					TargetType: TargetStrip,
+					TargetID:   5,
+					Action:     ActionSetProfile,
+					ProfileID:  null.IntFrom(3),
+				},
+				NextRun: &nextRun,
+			},
+			want: `{"id":2,"name":"Wake up","weekdays":["mon","fri"],"time":"07:30","timezone":"Europe/Berlin",` +
+				`"targetType":"strip","targetId":5,"action":"set-profile","profileId":3,"nextRun":"2024-05-06T07:30:00Z"}`,
+		},
+		{
+			name: "test cron",
+			input: ScheduleInfo{
+				Schedule: Schedule{Cron: "0 22 * * *", TargetType: TargetScene, TargetID: 1, Action: ActionOn},
+			},
+			want: `{"cron":"0 22 * * *","targetType":"scene","targetId":1,"action":"on","profileId":null}`,
+		},
+		{
+			name: "test solar",
+			input: ScheduleInfo{
+				Schedule: Schedule{Solar: CivilDusk, Offset: -20, TargetType: TargetStrip, TargetID: 1, Action: ActionOn},
+			},
+			want: `{"solar":"civil-dusk","offset":-20,"targetType":"strip","targetId":1,"action":"on","profileId":null}`,
+		},
+	}
+
+	runEncodeTests(t, tests)
+}
+
+func TestScheduleTableName(t *testing.T) {
+	assert.Equal(t, "schedule", Schedule{}.TableName())
+}
+
+func TestWeekdaysCSV(t *testing.T) {
+	days := Weekdays{"mon", "wed"}
+
+	data, err := days.MarshalCSV()
+	assert.NoError(t, err)
+	assert.Equal(t, "mon,wed", data)
+	var result Weekdays
+	assert.NoError(t, result.UnmarshalCSV(data))
+	assert.Equal(t, days, result)
+
+	assert.NoError(t, result.UnmarshalCSV(""))
+	assert.Nil(t, result)
+}
+
+func TestWeekdaysScan(t *testing.T) {
+	for _, input := range []any{"sat,sun", []byte("sat,sun")} {
+		var result Weekdays
+		assert.NoError(t, result.Scan(input))
+		assert.Equal(t, Weekdays{"sat", "sun"}, result)
+	}
+	var result Weekdays
+	assert.NoError(t, result.Scan(nil))
+	assert.Nil(t, result)
+	assert.Error(t, result.Scan(5))
+}
@@ -0,0 +1,14 @@
+package model
+
+// Status the runtime status of the application
+type Status struct {
+	Messaging MessagingStatus `json:"messaging"`
+}
+
+// MessagingStatus the status of the event delivery
+type MessagingStatus struct {
+	// State the connection state of the message broker, disabled if there is none
+	State string `json:"state"`
+	// PendingEvents the number of events which haven't been delivered yet
+	PendingEvents int `json:"pendingEvents"`
+}
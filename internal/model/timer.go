@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/pthum/null"
+)
+
+const Table_Timer = "timer"
+
+// Timer runs the action on a strip at the deadline, it has the id of its strip as a strip has at most one timer
+type Timer struct {
+	BaseModel
+	Deadline time.Time `json:"deadline" csv:"deadline"`
+	// Action the end action, the profile is only used by ActionSetProfile
+	Action    ScheduleAction `json:"action" csv:"action"`
+	ProfileID null.Int       `json:"profileId,omitempty" gorm:"column:profile_id" csv:"profile_id"`
+}
+
+// TableName sets the table name for the timer
+func (Timer) TableName() string {
+	return Table_Timer
+}
+
+// TimerRequest starts a timer, which runs the action once the duration has passed
+type TimerRequest struct {
+	// Duration a duration like 30m or 1h30m
+	Duration string `json:"duration"`
+	// Action the end action, the strip is switched off if none is given
+	Action    ScheduleAction `json:"action,omitempty"`
+	ProfileID null.Int       `json:"profileId,omitempty"`
+}
+
+// TimerInfo the timer of a strip with the remaining time until its deadline
+type TimerInfo struct {
+	Timer
+	// Remaining the remaining time in seconds
+	Remaining int64 `json:"remaining"`
+}
+
+// StripInfo the strip with its running timer
+type StripInfo struct {
+	LedStrip
+	Timer *TimerInfo `json:"timer,omitempty"`
+}
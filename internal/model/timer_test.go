@@ -0,0 +1,42 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pthum/null"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripInfoJsonEncode(t *testing.T) {
+	tests := []encodeTest[StripInfo]{
+		{
+			name:  "test without timer",
+			input: StripInfo{LedStrip: LedStrip{BaseModel: BaseModel{ID: 2}, Name: "Kitchen"}},
+			want:  `{"id":2,"name":"Kitchen","misoPin":null,"numLeds":null,"sclkPin":null,"speedHz":null,"profileId":null}`,
+		},
+		{
+			name: "test with timer",
+			input: StripInfo{
+				LedStrip: LedStrip{BaseModel: BaseModel{ID: 2}, Name: "Kitchen", Enabled: true},
+				Timer: &TimerInfo{
+					Timer: Timer{
+						BaseModel: BaseModel{ID: 2, Version: 1},
+						Deadline:  time.Date(2024, 5, 6, 7, 30, 0, 0, time.UTC),
+						Action:    ActionSetProfile,
+						ProfileID: null.IntFrom(3),
+					},
+					Remaining: 1800,
+				},
+			},
+			want: `{"id":2,"name":"Kitchen","enabled":true,"misoPin":null,"numLeds":null,"sclkPin":null,"speedHz":null,"profileId":null,` +
+				`"timer":{"id":2,"version":1,"deadline":"2024-05-06T07:30:00Z","action":"set-profile","profileId":3,"remaining":1800}}`,
+		},
+	}
+
+	runEncodeTests(t, tests)
+}
+
+func TestTimerTableName(t *testing.T) {
+	assert.Equal(t, "timer", Timer{}.TableName())
+}
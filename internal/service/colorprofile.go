@@ -1,36 +1,76 @@
 package service
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/config"
 	"github.com/pthum/stripcontrol-golang/internal/database"
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
 	"github.com/pthum/stripcontrol-golang/internal/messaging"
 	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/validation"
 	"github.com/samber/do"
 )
 
 //go:generate mockery --name=CPService --with-expecter=true --outpkg=servicemocks
 type CPService interface {
+	// WithActor returns the service, which publishes its events as caused by the actor
+	WithActor(actor string) CPService
 	GetAll() ([]model.ColorProfile, error)
 	GetColorProfile(id string) (*model.ColorProfile, error)
 	CreateColorProfile(mdl *model.ColorProfile) error
-	UpdateColorProfile(id string, updMdl model.ColorProfile) error
-	DeleteColorProfile(id string) error
+	// UpdateColorProfile replaces the profile and returns the stored profile, a version other than 0 on the input
+	// has to match the current version
+	UpdateColorProfile(id string, updMdl model.ColorProfile) (*model.ColorProfile, error)
+	// PatchColorProfile applies a json merge patch, a version other than 0 has to match the current version
+	PatchColorProfile(id string, version int64, patch []byte) (*model.ColorProfile, error)
+	// DeleteColorProfile deletes the profile, a version other than 0 has to match the current version
+	DeleteColorProfile(id string, version int64) error
 }
 
 type cpService struct {
-	dbh database.DBHandler[model.ColorProfile]
-	mh  messaging.EventHandler
+	dbh          database.DBHandler[model.ColorProfile]
+	lsDbh        database.DBHandler[model.LedStrip]
+	lsvc         LEDService
+	mh           messaging.EventHandler
+	deletePolicy string
+	actor        string
+	l            alog.Logger
 }
 
 func NewCPService(i *do.Injector) (CPService, error) {
 	dbh := do.MustInvoke[database.DBHandler[model.ColorProfile]](i)
+	lsDbh := do.MustInvoke[database.DBHandler[model.LedStrip]](i)
+	lsvc := do.MustInvoke[LEDService](i)
 	mh := do.MustInvoke[messaging.EventHandler](i)
+	acfg := do.MustInvoke[*config.Config](i)
+	deletePolicy := acfg.Database.ProfileDeletePolicy
+	switch deletePolicy {
+	case config.ProfileDeleteReject, config.ProfileDeleteDetach:
+	case "":
+		deletePolicy = config.ProfileDeleteReject
+	default:
+		return nil, fmt.Errorf("unsupported profile delete policy %q", deletePolicy)
+	}
 	return &cpService{
-		dbh: dbh,
-		mh:  mh,
+		dbh:          dbh,
+		lsDbh:        lsDbh,
+		lsvc:         lsvc,
+		mh:           mh,
+		deletePolicy: deletePolicy,
+		l:            alog.NewLogger("cpservice"),
 	}, nil
 }
 
+func (s *cpService) WithActor(actor string) CPService {
+	svc := *s
+	svc.actor = actor
+	return &svc
+}
+
 func (s *cpService) GetAll() ([]model.ColorProfile, error) {
 	return s.dbh.GetAll()
 }
@@ -40,39 +80,144 @@ func (s *cpService) GetColorProfile(id string) (*model.ColorProfile, error) {
 }
 
 func (s *cpService) CreateColorProfile(mdl *model.ColorProfile) error {
-	// generate an id
-	mdl.GenerateID()
+	if err := validation.ValidateColorProfile(*mdl); err != nil {
+		return err
+	}
+	if err := database.CreateWithNextID(s.dbh, mdl); err != nil {
+		return err
+	}
 
-	return s.dbh.Create(mdl)
+	var event = model.NewProfileEvent(mdl.GetNullID(), model.Save).With(*mdl).By(s.actor)
+	s.publishProfileEvent(event)
+	return nil
 }
 
-func (s *cpService) UpdateColorProfile(id string, updMdl model.ColorProfile) error {
+func (s *cpService) UpdateColorProfile(id string, updMdl model.ColorProfile) (*model.ColorProfile, error) {
+	if err := validation.ValidateColorProfile(updMdl); err != nil {
+		return nil, err
+	}
 	// Get model if exist
 	profile, err := s.dbh.Get(id)
 	if err != nil {
-		return model.NewAppErr(404, err)
+		return nil, model.NewAppErr(404, err)
+	}
+	if err := checkVersion(updMdl.Version, profile); err != nil {
+		return nil, err
+	}
+	updMdl.ID = profile.ID
+	if err := s.update(*profile, &updMdl); err != nil {
+		return nil, err
 	}
+	return &updMdl, nil
+}
 
-	if err = s.dbh.Update(*profile, updMdl); err != nil {
-		return model.NewAppErr(400, err)
+// PatchColorProfile applies the json merge patch to the profile and returns the patched profile
+func (s *cpService) PatchColorProfile(id string, version int64, patch []byte) (*model.ColorProfile, error) {
+	// Get model if exist
+	profile, err := s.dbh.Get(id)
+	if err != nil {
+		return nil, model.NewAppErr(404, err)
+	}
+	if err := checkVersion(version, profile); err != nil {
+		return nil, err
+	}
+	updMdl, err := model.ApplyMergePatch(*profile, patch)
+	if err != nil {
+		return nil, err
+	}
+	// the id can't be changed
+	updMdl.ID = profile.ID
+	if err := validation.ValidateColorProfile(updMdl); err != nil {
+		return nil, err
+	}
+	if err := s.update(*profile, &updMdl); err != nil {
+		return nil, err
+	}
+	return &updMdl, nil
+}
+
+// update writes the changes of the profile, the version of updMdl is set to the one after the write
+func (s *cpService) update(profile model.ColorProfile, updMdl *model.ColorProfile) error {
+	updMdl.Version = profile.Version
+	if err := s.dbh.Update(profile, *updMdl); err != nil {
+		return updateErr(err)
 	}
+	updMdl.Version++
 
-	var event = model.NewProfileEvent(null.NewInt(updMdl.ID, true), model.Save).With(updMdl)
-	go s.mh.PublishProfileEvent(event)
+	var event = model.NewProfileEvent(null.NewInt(updMdl.ID, true), model.Save).With(*updMdl).By(s.actor)
+	s.publishProfileEvent(event)
 	return nil
 }
 
-func (s *cpService) DeleteColorProfile(id string) error {
+func (s *cpService) DeleteColorProfile(id string, version int64) error {
 	// Get model if exist
 	profile, err := s.dbh.Get(id)
 	if err != nil {
 		return model.NewAppErr(404, err)
 	}
+	if err := checkVersion(version, profile); err != nil {
+		return err
+	}
+
+	strips, err := s.referencingStrips(profile.ID)
+	if err != nil {
+		return model.NewAppErr(500, err)
+	}
+	if len(strips) > 0 && s.deletePolicy != config.ProfileDeleteDetach {
+		return model.NewAppErr(409, fmt.Errorf("profile %d is still used by the strip(s) %s", profile.ID, joinIDs(strips)))
+	}
+
+	// the strips are only detached once the profile is gone, so a failing delete leaves them unchanged
 	if err := s.dbh.Delete(profile); err != nil {
 		return model.NewAppErr(400, err)
 	}
 
-	var event = model.NewProfileEvent(null.NewInt(profile.ID, true), model.Delete)
-	go s.mh.PublishProfileEvent(event)
+	var event = model.NewProfileEvent(null.NewInt(profile.ID, true), model.Delete).By(s.actor)
+	s.publishProfileEvent(event)
+	s.detachStrips(strips)
 	return nil
 }
+
+// referencingStrips returns all strips which use the profile
+func (s *cpService) referencingStrips(profileID int64) ([]model.LedStrip, error) {
+	all, err := s.lsDbh.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	strips := []model.LedStrip{}
+	for _, strip := range all {
+		if strip.ProfileID.Valid && strip.ProfileID.Int64 == profileID {
+			strips = append(strips, strip)
+		}
+	}
+	return strips, nil
+}
+
+// detachStrips removes the deleted profile from the strips through the led service, which stops their timers and
+// publishes the changed strips. A failing strip is only logged, as the profile is already deleted.
+func (s *cpService) detachStrips(strips []model.LedStrip) {
+	lsvc := s.lsvc.WithActor(s.actor)
+	for _, strip := range strips {
+		if err := lsvc.RemoveProfileForStrip(strconv.FormatInt(strip.ID, 10)); err != nil {
+			s.l.Error("error detaching the deleted profile from strip %d: %s", strip.ID, err.Error())
+			continue
+		}
+		s.l.Info("Detached profile from strip %d", strip.ID)
+	}
+}
+
+// publishProfileEvent records the profile event for delivery, it is called before returning from the write, so the
+// events are recorded in the order of the writes
+func (s *cpService) publishProfileEvent(event *model.ProfileEvent) {
+	if err := s.mh.PublishProfileEvent(event); err != nil {
+		s.l.Error("error publishing profile event: %s", err.Error())
+	}
+}
+
+func joinIDs(strips []model.LedStrip) string {
+	ids := make([]string, len(strips))
+	for i := range strips {
+		ids[i] = strips[i].GetStringID()
+	}
+	return strings.Join(ids, ", ")
+}
@@ -2,11 +2,15 @@ package service
 
 import (
 	"errors"
+	"net/http"
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/go-co-op/gocron"
 	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/config"
 	"github.com/pthum/stripcontrol-golang/internal/database"
 	dbm "github.com/pthum/stripcontrol-golang/internal/database/mocks"
 	"github.com/pthum/stripcontrol-golang/internal/messaging"
@@ -24,7 +28,9 @@ type cphMocks struct {
 type baseMocks struct {
 	cpDbh *dbm.DBHandler[model.ColorProfile]
 	lsDbh *dbm.DBHandler[model.LedStrip]
+	tDbh  *dbm.DBHandler[model.Timer]
 	mh    *mhm.EventHandler
+	sched *gocron.Scheduler
 }
 
 func TestGetAllColorProfiles(t *testing.T) {
@@ -86,6 +92,7 @@ func TestCreateColorProfile(t *testing.T) {
 	inBody := *createDummyProfile()
 	input := inBody
 	var newId int64
+	mocks.expectDBProfileNextID(500)
 	mocks.cpDbh.
 		EXPECT().
 		Create(mock.Anything).
@@ -96,9 +103,13 @@ func TestCreateColorProfile(t *testing.T) {
 		}).
 		Return(nil).
 		Once()
+	published := inBody
+	published.ID = 500
+	wg := mocks.expectPublishProfileEvent(t, model.Save, 500, &published)
 
 	expectedObj := inBody
 	err := mocks.cps.CreateColorProfile(&input)
+	wg.Wait()
 
 	expectedObj.ID = newId
 
@@ -110,6 +121,7 @@ func TestCreateColorProfile_SaveError(t *testing.T) {
 	mocks := createCPHandlerMocks(t)
 	inBody := *createDummyProfile()
 	input := inBody
+	mocks.expectDBProfileNextID(500)
 	mocks.cpDbh.
 		EXPECT().
 		Create(mock.Anything).
@@ -124,10 +136,23 @@ func TestCreateColorProfile_SaveError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestCreateColorProfile_Invalid(t *testing.T) {
+	mocks := createCPHandlerMocks(t)
+	input := *createProfile(0, 256, 0, 0, 5)
+
+	err := mocks.cps.CreateColorProfile(&input)
+
+	// invalid profiles must not reach the database
+	var aerr *model.AppError
+	assert.ErrorAs(t, err, &aerr)
+	assert.Equal(t, http.StatusUnprocessableEntity, aerr.Code)
+}
+
 func TestDeleteColorProfile(t *testing.T) {
 	mocks := createCPHandlerMocks(t)
 	getObj := createDummyProfile()
 	mocks.expectDBProfileGet(getObj, nil)
+	mocks.expectDBStripGetAll([]model.LedStrip{}, nil)
 
 	mocks.cpDbh.
 		EXPECT().
@@ -136,18 +161,31 @@ func TestDeleteColorProfile(t *testing.T) {
 	wg := mocks.expectPublishProfileEvent(t, model.Delete, getObj.ID, nil)
 	idS := idStr(getObj.ID)
 
-	err := mocks.cps.DeleteColorProfile(idS)
+	err := mocks.cps.DeleteColorProfile(idS, 0)
 	wg.Wait()
 	assert.NoError(t, err)
 }
 
+func TestDeleteColorProfile_VersionMismatch(t *testing.T) {
+	mocks := createCPHandlerMocks(t)
+	getObj := createDummyProfile()
+	getObj.Version = 2
+	mocks.expectDBProfileGet(getObj, nil)
+
+	err := mocks.cps.DeleteColorProfile(idStr(getObj.ID), 1)
+
+	var aerr *model.AppError
+	assert.ErrorAs(t, err, &aerr)
+	assert.Equal(t, http.StatusPreconditionFailed, aerr.Code)
+}
+
 func TestDeleteColorProfile_MissingDBProfile(t *testing.T) {
 	mocks := createCPHandlerMocks(t)
 	getObj := createDummyProfile()
 	mocks.expectDBProfileGet(nil, errors.New("not found"))
 	idS := idStr(getObj.ID)
 
-	err := mocks.cps.DeleteColorProfile(idS)
+	err := mocks.cps.DeleteColorProfile(idS, 0)
 
 	assert.Error(t, err)
 }
@@ -156,6 +194,7 @@ func TestDeleteColorProfile_DeleteError(t *testing.T) {
 	mocks := createCPHandlerMocks(t)
 	getObj := createDummyProfile()
 	mocks.expectDBProfileGet(getObj, nil)
+	mocks.expectDBStripGetAll([]model.LedStrip{}, nil)
 
 	mocks.cpDbh.
 		EXPECT().
@@ -163,8 +202,113 @@ func TestDeleteColorProfile_DeleteError(t *testing.T) {
 		Return(errors.New("delete error"))
 	idS := idStr(getObj.ID)
 
-	err := mocks.cps.DeleteColorProfile(idS)
+	err := mocks.cps.DeleteColorProfile(idS, 0)
+
+	assert.Error(t, err)
+}
+
+func TestDeleteColorProfile_Referenced(t *testing.T) {
+	mocks := createCPHandlerMocks(t)
+	getObj := createDummyProfile()
+	mocks.expectDBProfileGet(getObj, nil)
+	strips := []model.LedStrip{
+		{BaseModel: model.BaseModel{ID: 1}, ProfileID: null.IntFrom(getObj.ID)},
+		{BaseModel: model.BaseModel{ID: 2}, ProfileID: null.IntFrom(getObj.ID + 1)},
+		{BaseModel: model.BaseModel{ID: 3}, ProfileID: null.IntFrom(getObj.ID)},
+	}
+	mocks.expectDBStripGetAll(strips, nil)
+
+	err := mocks.cps.DeleteColorProfile(idStr(getObj.ID), 0)
+
+	var aerr *model.AppError
+	assert.ErrorAs(t, err, &aerr)
+	assert.Equal(t, 409, aerr.Code)
+	assert.Contains(t, aerr.Error(), "1, 3")
+}
+
+func TestDeleteColorProfile_ReferencedDetach(t *testing.T) {
+	mocks := createCPHandlerMocks(t)
+	mocks.cps.deletePolicy = config.ProfileDeleteDetach
+	getObj := createDummyProfile()
+	mocks.expectDBProfileGet(getObj, nil)
+	strips := []model.LedStrip{
+		{BaseModel: model.BaseModel{ID: 1}, ProfileID: null.IntFrom(getObj.ID)},
+		{BaseModel: model.BaseModel{ID: 2}, ProfileID: null.IntFrom(getObj.ID + 1)},
+	}
+	mocks.expectDBStripGetAll(strips, nil)
+	mocks.cpDbh.
+		EXPECT().
+		Delete(mock.Anything).
+		Return(nil)
+	// the strip is detached through the led service
+	mocks.lsDbh.EXPECT().Get("1").Return(&strips[0], nil).Once()
+	mocks.lsDbh.
+		EXPECT().
+		Save(mock.Anything).
+		Run(func(input *model.LedStrip) {
+			assert.Equal(t, int64(1), input.ID)
+			assert.False(t, input.ProfileID.Valid)
+		}).
+		Return(nil).
+		Once()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	mocks.mh.
+		EXPECT().
+		PublishStripEvent(mock.Anything).
+		Run(func(event *model.StripEvent) {
+			assert.Equal(t, model.Save, event.Type)
+			assert.Equal(t, int64(1), event.ID.Int64)
+			assert.False(t, event.Strip.Strip.Profile.Valid)
+			wg.Done()
+		}).
+		Return(nil).
+		Once()
+	profileWg := mocks.expectPublishProfileEvent(t, model.Delete, getObj.ID, nil)
+
+	err := mocks.cps.DeleteColorProfile(idStr(getObj.ID), 0)
+	wg.Wait()
+	profileWg.Wait()
+	assert.NoError(t, err)
+}
+
+func TestDeleteColorProfile_ReferencedDetachDeleteError(t *testing.T) {
+	mocks := createCPHandlerMocks(t)
+	mocks.cps.deletePolicy = config.ProfileDeleteDetach
+	getObj := createDummyProfile()
+	mocks.expectDBProfileGet(getObj, nil)
+	mocks.expectDBStripGetAll([]model.LedStrip{{BaseModel: model.BaseModel{ID: 1}, ProfileID: null.IntFrom(getObj.ID)}}, nil)
+	// the strip isn't detached from the remaining profile
+	mocks.cpDbh.
+		EXPECT().
+		Delete(mock.Anything).
+		Return(errors.New("delete error"))
+
+	err := mocks.cps.DeleteColorProfile(idStr(getObj.ID), 0)
+
+	assertAppErrCode(t, err, http.StatusBadRequest)
+}
+
+func TestDeleteColorProfile_StripLookupError(t *testing.T) {
+	mocks := createCPHandlerMocks(t)
+	getObj := createDummyProfile()
+	mocks.expectDBProfileGet(getObj, nil)
+	mocks.expectDBStripGetAll(nil, assert.AnError)
+
+	err := mocks.cps.DeleteColorProfile(idStr(getObj.ID), 0)
+
+	assert.Error(t, err)
+}
 
+func TestNewCPService_InvalidPolicy(t *testing.T) {
+	i := do.New()
+	createBaseMocks(i, t)
+	do.ProvideValue[LEDService](i, &ledSvc{})
+	do.OverrideValue(i, &config.Config{Database: config.DatabaseConfig{ProfileDeletePolicy: "unknown"}})
+
+	cps, err := NewCPService(i)
+
+	assert.Nil(t, cps)
 	assert.Error(t, err)
 }
 
@@ -173,17 +317,34 @@ func TestUpdateColorProfile(t *testing.T) {
 	inBody := createDummyProfile()
 	dbO := *createProfile(105, 100, 100, 100, 2)
 	mocks.expectDBProfileGet(&dbO, nil)
+	// the id of the stored profile is kept
+	inBody.ID = dbO.ID
 
 	mocks.cpDbh.
 		EXPECT().
 		Update(dbO, *inBody).
 		Return(nil)
-	wg := mocks.expectPublishProfileEvent(t, model.Save, inBody.ID, inBody)
+	// the event contains the version after the update
+	published := *inBody
+	published.Version = dbO.Version + 1
+	wg := mocks.expectPublishProfileEvent(t, model.Save, inBody.ID, &published)
 	idS := idStr(dbO.ID)
 
-	err := mocks.cps.UpdateColorProfile(idS, *inBody)
+	res, err := mocks.cps.UpdateColorProfile(idS, *inBody)
 	wg.Wait()
 	assert.NoError(t, err)
+	assert.Equal(t, &published, res)
+}
+
+func TestUpdateColorProfile_Invalid(t *testing.T) {
+	mocks := createCPHandlerMocks(t)
+	input := *createProfile(12, 0, 0, 0, 32)
+
+	_, err := mocks.cps.UpdateColorProfile("12", input)
+
+	var verr *model.ValidationError
+	assert.ErrorAs(t, err, &verr)
+	assert.Equal(t, "brightness", verr.Errors[0].Field)
 }
 
 func TestUpdateColorProfile_MissingDBProfile(t *testing.T) {
@@ -193,7 +354,7 @@ func TestUpdateColorProfile_MissingDBProfile(t *testing.T) {
 	mocks.expectDBProfileGet(nil, errors.New("not found"))
 	idS := idStr(dbO.ID)
 
-	err := mocks.cps.UpdateColorProfile(idS, *inBody)
+	_, err := mocks.cps.UpdateColorProfile(idS, *inBody)
 
 	assert.Error(t, err)
 }
@@ -203,6 +364,7 @@ func TestUpdateColorProfile_UpdateError(t *testing.T) {
 	inBody := createDummyProfile()
 	dbO := *createProfile(105, 100, 100, 100, 2)
 	mocks.expectDBProfileGet(&dbO, nil)
+	inBody.ID = dbO.ID
 
 	mocks.cpDbh.
 		EXPECT().
@@ -210,15 +372,88 @@ func TestUpdateColorProfile_UpdateError(t *testing.T) {
 		Return(errors.New("update error"))
 	idS := idStr(dbO.ID)
 
-	err := mocks.cps.UpdateColorProfile(idS, *inBody)
+	_, err := mocks.cps.UpdateColorProfile(idS, *inBody)
+
+	assert.Error(t, err)
+}
+
+func TestPatchColorProfile(t *testing.T) {
+	mocks := createCPHandlerMocks(t)
+	dbO := createProfile(105, 100, 100, 100, 2)
+	expected := *dbO
+	expected.Red = null.IntFrom(255)
+	expected.Blue = null.NewInt(0, false)
+	mocks.expectDBProfileGet(dbO, nil)
+	mocks.cpDbh.
+		EXPECT().
+		Update(*dbO, expected).
+		Return(nil)
+	// the result has the version after the update
+	published := expected
+	published.Version = dbO.Version + 1
+	wg := mocks.expectPublishProfileEvent(t, model.Save, dbO.ID, &published)
+
+	result, err := mocks.cps.PatchColorProfile(idStr(dbO.ID), 0, []byte(`{"red":255,"blue":null}`))
+	wg.Wait()
+
+	assert.NoError(t, err)
+	assert.Equal(t, published, *result)
+}
+
+func TestPatchColorProfile_VersionMismatch(t *testing.T) {
+	mocks := createCPHandlerMocks(t)
+	dbO := createProfile(105, 100, 100, 100, 2)
+	dbO.Version = 7
+	mocks.expectDBProfileGet(dbO, nil)
+
+	result, err := mocks.cps.PatchColorProfile("105", 6, []byte(`{"red":255}`))
+
+	assert.Nil(t, result)
+	var aerr *model.AppError
+	assert.ErrorAs(t, err, &aerr)
+	assert.Equal(t, http.StatusPreconditionFailed, aerr.Code)
+}
+
+func TestPatchColorProfile_MissingDBProfile(t *testing.T) {
+	mocks := createCPHandlerMocks(t)
+	mocks.expectDBProfileGet(nil, errors.New("not found"))
+
+	result, err := mocks.cps.PatchColorProfile("105", 0, []byte(`{"red":255}`))
 
+	assert.Nil(t, result)
 	assert.Error(t, err)
 }
 
-func (chm *cphMocks) expectPublishProfileEvent(t *testing.T, typ model.EventType, id int64, body *model.ColorProfile) *sync.WaitGroup {
+func TestPatchColorProfile_Invalid(t *testing.T) {
+	mocks := createCPHandlerMocks(t)
+	mocks.expectDBProfileGet(createProfile(105, 100, 100, 100, 2), nil)
+
+	result, err := mocks.cps.PatchColorProfile("105", 0, []byte(`{"brightness":40}`))
+
+	assert.Nil(t, result)
+	var verr *model.ValidationError
+	assert.ErrorAs(t, err, &verr)
+}
+
+func TestPatchColorProfile_UpdateError(t *testing.T) {
+	mocks := createCPHandlerMocks(t)
+	dbO := createProfile(105, 100, 100, 100, 2)
+	mocks.expectDBProfileGet(dbO, nil)
+	mocks.cpDbh.
+		EXPECT().
+		Update(*dbO, mock.Anything).
+		Return(errors.New("update failed"))
+
+	result, err := mocks.cps.PatchColorProfile("105", 0, []byte(`{"red":1}`))
+
+	assert.Nil(t, result)
+	assert.Error(t, err)
+}
+
+func (bm *baseMocks) expectPublishProfileEvent(t *testing.T, typ model.EventType, id int64, body *model.ColorProfile) *sync.WaitGroup {
 	var wg sync.WaitGroup
 	wg.Add(1)
-	chm.mh.
+	bm.mh.
 		EXPECT().
 		PublishProfileEvent(mock.Anything).
 		Run(func(event *model.ProfileEvent) {
@@ -251,6 +486,10 @@ func createProfile(id, red, green, blue, brightness int64) *model.ColorProfile {
 func createCPHandlerMocks(t *testing.T) *cphMocks {
 	i := do.New()
 	bm := createBaseMocks(i, t)
+	bm.expectDBTimerGetAll()
+	ls, err := NewLEDService(i)
+	assert.NoError(t, err)
+	do.ProvideValue(i, ls)
 	cps, err := NewCPService(i)
 	assert.NoError(t, err)
 	return &cphMocks{
@@ -262,17 +501,57 @@ func createCPHandlerMocks(t *testing.T) *cphMocks {
 func createBaseMocks(i *do.Injector, t *testing.T) *baseMocks {
 	cpDbh := dbm.NewDBHandler[model.ColorProfile](t)
 	lsDbh := dbm.NewDBHandler[model.LedStrip](t)
+	do.ProvideValue(i, &config.Config{})
 	do.ProvideValue[database.DBHandler[model.ColorProfile]](i, cpDbh)
 	do.ProvideValue[database.DBHandler[model.LedStrip]](i, lsDbh)
+	tDbh := dbm.NewDBHandler[model.Timer](t)
+	do.ProvideValue[database.DBHandler[model.Timer]](i, tDbh)
 	mh := mhm.NewEventHandler(t)
 	do.ProvideValue[messaging.EventHandler](i, mh)
+	sched := gocron.NewScheduler(time.UTC)
+	do.ProvideValue(i, sched)
 	return &baseMocks{
 		cpDbh: cpDbh,
 		lsDbh: lsDbh,
+		tDbh:  tDbh,
 		mh:    mh,
+		sched: sched,
 	}
 }
 
+// expectDBTimerGetAll expects the stored timers to be read by the led service on its creation
+func (bm *baseMocks) expectDBTimerGetAll(stored ...model.Timer) {
+	bm.tDbh.
+		EXPECT().
+		GetAll().
+		Return(stored, nil).
+		Once()
+}
+
+func (bm *baseMocks) expectDBProfileNextID(id int64) {
+	bm.cpDbh.
+		EXPECT().
+		NextID().
+		Return(id, nil).
+		Once()
+}
+
+func (bm *baseMocks) expectDBStripNextID(id int64) {
+	bm.lsDbh.
+		EXPECT().
+		NextID().
+		Return(id, nil).
+		Once()
+}
+
+func (bm *baseMocks) expectDBStripGetAll(strips []model.LedStrip, getError error) {
+	bm.lsDbh.
+		EXPECT().
+		GetAll().
+		Return(strips, getError).
+		Once()
+}
+
 func (bm *baseMocks) expectDBProfileGet(getStrip *model.ColorProfile, getError error) {
 	getStripIdStr := mock.Anything
 	if getStrip != nil {
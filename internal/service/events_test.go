@@ -0,0 +1,266 @@
+package service
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// eventRecorder records all published events
+type eventRecorder struct {
+	mu       sync.Mutex
+	strips   []*model.StripEvent
+	profiles []*model.ProfileEvent
+}
+
+type eventMocks struct {
+	*baseMocks
+	cps *cpService
+	ls  *ledSvc
+	rec *eventRecorder
+}
+
+// TestEventContract every create/update/delete of strips and profiles publishes exactly one event
+func TestEventContract(t *testing.T) {
+	stripWithProfile := func() *model.LedStrip {
+		s := createValidDummyStrip()
+		s.ProfileID = null.IntFrom(15)
+		return s
+	}
+	profile15 := createProfile(15, 1, 2, 3, 4)
+
+	tests := []struct {
+		name          string
+		run           func(m *eventMocks) error
+		stripEvents   []model.EventType
+		profileEvents []model.EventType
+		withProfile   bool
+	}{
+		{
+			name: "create strip",
+			run: func(m *eventMocks) error {
+				m.expectDBStripNextID(500)
+				m.lsDbh.EXPECT().Create(mock.Anything).Return(nil)
+				return m.ls.CreateLEDStrip(createValidDummyStrip())
+			},
+			stripEvents: []model.EventType{model.Save},
+		},
+		{
+			name: "create strip with profile",
+			run: func(m *eventMocks) error {
+				m.expectDBStripNextID(500)
+				m.lsDbh.EXPECT().Create(mock.Anything).Return(nil)
+				m.expectDBProfileGet(profile15, nil)
+				return m.ls.CreateLEDStrip(stripWithProfile())
+			},
+			stripEvents: []model.EventType{model.Save},
+			withProfile: true,
+		},
+		{
+			name: "update strip",
+			run: func(m *eventMocks) error {
+				m.expectDBStripGet(stripWithProfile())
+				m.lsDbh.EXPECT().Update(mock.Anything, mock.Anything).Return(nil)
+				m.expectDBProfileGet(profile15, nil)
+				_, err := m.ls.UpdateLEDStrip("185", *createValidDummyStrip())
+				return err
+			},
+			stripEvents: []model.EventType{model.Save},
+			withProfile: true,
+		},
+		{
+			name: "update strip without profile",
+			run: func(m *eventMocks) error {
+				m.expectDBStripGet(createValidDummyStrip())
+				m.lsDbh.EXPECT().Update(mock.Anything, mock.Anything).Return(nil)
+				_, err := m.ls.UpdateLEDStrip("185", *createValidDummyStrip())
+				return err
+			},
+			stripEvents: []model.EventType{model.Save},
+		},
+		{
+			name: "update strip with missing profile",
+			run: func(m *eventMocks) error {
+				m.expectDBStripGet(stripWithProfile())
+				m.lsDbh.EXPECT().Update(mock.Anything, mock.Anything).Return(nil)
+				m.expectDBProfileGet(nil, assert.AnError)
+				_, err := m.ls.UpdateLEDStrip("185", *createValidDummyStrip())
+				return err
+			},
+			stripEvents: []model.EventType{model.Save},
+		},
+		{
+			name: "patch strip",
+			run: func(m *eventMocks) error {
+				m.expectDBStripGet(createValidDummyStrip())
+				m.lsDbh.EXPECT().Update(mock.Anything, mock.Anything).Return(nil)
+				_, err := m.ls.PatchLEDStrip("185", 0, []byte(`{"enabled":true}`))
+				return err
+			},
+			stripEvents: []model.EventType{model.Save},
+		},
+		{
+			name: "delete strip",
+			run: func(m *eventMocks) error {
+				m.expectDBStripGet(createValidDummyStrip())
+				m.lsDbh.EXPECT().Delete(mock.Anything).Return(nil)
+				return m.ls.DeleteLEDStrip("185", 0)
+			},
+			stripEvents: []model.EventType{model.Delete},
+		},
+		{
+			name: "set profile of strip",
+			run: func(m *eventMocks) error {
+				m.expectDBStripGet(createValidDummyStrip())
+				m.expectDBProfileGet(profile15, nil)
+				m.lsDbh.EXPECT().Save(mock.Anything).Return(nil)
+				_, err := m.ls.UpdateProfileForStrip("185", *profile15)
+				return err
+			},
+			stripEvents: []model.EventType{model.Save},
+			withProfile: true,
+		},
+		{
+			name: "remove profile of strip",
+			run: func(m *eventMocks) error {
+				m.expectDBStripGet(stripWithProfile())
+				m.lsDbh.EXPECT().Save(mock.Anything).Return(nil)
+				return m.ls.RemoveProfileForStrip("185")
+			},
+			stripEvents: []model.EventType{model.Save},
+		},
+		{
+			name: "create profile",
+			run: func(m *eventMocks) error {
+				m.expectDBProfileNextID(500)
+				m.cpDbh.EXPECT().Create(mock.Anything).Return(nil)
+				return m.cps.CreateColorProfile(createProfile(0, 1, 2, 3, 4))
+			},
+			profileEvents: []model.EventType{model.Save},
+		},
+		{
+			name: "update profile",
+			run: func(m *eventMocks) error {
+				m.expectDBProfileGet(profile15, nil)
+				m.cpDbh.EXPECT().Update(mock.Anything, mock.Anything).Return(nil)
+				_, err := m.cps.UpdateColorProfile("15", *createProfile(15, 5, 6, 7, 8))
+				return err
+			},
+			profileEvents: []model.EventType{model.Save},
+		},
+		{
+			name: "patch profile",
+			run: func(m *eventMocks) error {
+				m.expectDBProfileGet(profile15, nil)
+				m.cpDbh.EXPECT().Update(mock.Anything, mock.Anything).Return(nil)
+				_, err := m.cps.PatchColorProfile("15", 0, []byte(`{"red":5}`))
+				return err
+			},
+			profileEvents: []model.EventType{model.Save},
+		},
+		{
+			name: "delete profile",
+			run: func(m *eventMocks) error {
+				m.expectDBProfileGet(profile15, nil)
+				m.expectDBStripGetAll([]model.LedStrip{}, nil)
+				m.cpDbh.EXPECT().Delete(mock.Anything).Return(nil)
+				return m.cps.DeleteColorProfile("15", 0)
+			},
+			profileEvents: []model.EventType{model.Delete},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := createEventMocks(t)
+
+			err := tt.run(m)
+
+			// no wait, the events are recorded before the write returns
+			assert.NoError(t, err)
+			m.rec.mu.Lock()
+			defer m.rec.mu.Unlock()
+			stripTypes := []model.EventType{}
+			for _, e := range m.rec.strips {
+				stripTypes = append(stripTypes, e.Type)
+				assert.True(t, e.ID.Valid, "strip events have to contain the id")
+				if e.Type == model.Save {
+					assert.Equal(t, tt.withProfile, e.Strip.Strip.Profile.Valid)
+				}
+			}
+			profileTypes := []model.EventType{}
+			for _, e := range m.rec.profiles {
+				profileTypes = append(profileTypes, e.Type)
+				assert.True(t, e.ID.Valid, "profile events have to contain the id")
+			}
+			assert.ElementsMatch(t, tt.stripEvents, stripTypes)
+			assert.ElementsMatch(t, tt.profileEvents, profileTypes)
+		})
+	}
+}
+
+// TestEventOrder the events of subsequent writes are recorded in the order of the writes
+func TestEventOrder(t *testing.T) {
+	m := createEventMocks(t)
+	m.lsDbh.EXPECT().Get("185").Return(createValidDummyStrip(), nil)
+	m.lsDbh.EXPECT().Update(mock.Anything, mock.Anything).Return(nil)
+
+	for _, enabled := range []bool{true, false, true} {
+		upd := *createValidDummyStrip()
+		upd.Enabled = enabled
+		_, err := m.ls.UpdateLEDStrip("185", upd)
+		assert.NoError(t, err)
+	}
+
+	m.rec.mu.Lock()
+	defer m.rec.mu.Unlock()
+	enabled := []bool{}
+	for _, e := range m.rec.strips {
+		enabled = append(enabled, e.Strip.Strip.Enabled)
+	}
+	assert.Equal(t, []bool{true, false, true}, enabled)
+}
+
+func createEventMocks(t *testing.T, timers ...model.Timer) *eventMocks {
+	i := do.New()
+	bm := createBaseMocks(i, t)
+	bm.expectDBTimerGetAll(timers...)
+	ls, err := NewLEDService(i)
+	assert.NoError(t, err)
+	do.ProvideValue(i, ls)
+	cps, err := NewCPService(i)
+	assert.NoError(t, err)
+	rec := &eventRecorder{}
+	bm.mh.EXPECT().
+		PublishStripEvent(mock.Anything).
+		Run(func(event *model.StripEvent) {
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			rec.strips = append(rec.strips, event)
+		}).
+		Return(nil).
+		Maybe()
+	bm.mh.EXPECT().
+		PublishProfileEvent(mock.Anything).
+		Run(func(event *model.ProfileEvent) {
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			rec.profiles = append(rec.profiles, event)
+		}).
+		Return(nil).
+		Maybe()
+	return &eventMocks{
+		baseMocks: bm,
+		cps:       cps.(*cpService),
+		ls:        ls.(*ledSvc),
+		rec:       rec,
+	}
+}
+
+func (m *eventMocks) expectDBStripGet(strip *model.LedStrip) {
+	m.lsDbh.EXPECT().Get(idStr(strip.ID)).Return(strip, nil).Once()
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/validation"
+	"github.com/samber/do"
+)
+
+//go:generate mockery --name=GroupService --with-expecter=true --outpkg=servicemocks
+type GroupService interface {
+	// WithActor returns the service, which publishes the events of the group actions as caused by the actor
+	WithActor(actor string) GroupService
+	GetAll() ([]model.Group, error)
+	GetGroup(id string) (*model.Group, error)
+	CreateGroup(mdl *model.Group) error
+	// UpdateGroup replaces the group and returns the stored group, a version other than 0 on the input has to
+	// match the current version
+	UpdateGroup(id string, updMdl model.Group) (*model.Group, error)
+	// DeleteGroup deletes the group, a version other than 0 has to match the current version. A group nested in
+	// another group can't be deleted.
+	DeleteGroup(id string, version int64) error
+	// StripIDs the ids of the strips of the group and its nested groups, each strip is only contained once
+	StripIDs(id string) ([]int64, error)
+	// EnableGroup enables the strips of the group, the result contains the strips it couldn't be applied to
+	EnableGroup(id string) (*model.GroupResult, error)
+	// DisableGroup disables the strips of the group, the result contains the strips it couldn't be applied to
+	DisableGroup(id string) (*model.GroupResult, error)
+	// SetProfileForGroup enables the strips of the group with the profile, the result contains the strips it
+	// couldn't be applied to
+	SetProfileForGroup(id string, profileID int64) (*model.GroupResult, error)
+}
+
+type groupSvc struct {
+	dbh   database.DBHandler[model.Group]
+	lsDbh database.DBHandler[model.LedStrip]
+	cpDbh database.DBHandler[model.ColorProfile]
+	lsvc  LEDService
+	actor string
+	l     alog.Logger
+}
+
+func NewGroupService(i *do.Injector) (GroupService, error) {
+	return &groupSvc{
+		dbh:   do.MustInvoke[database.DBHandler[model.Group]](i),
+		lsDbh: do.MustInvoke[database.DBHandler[model.LedStrip]](i),
+		cpDbh: do.MustInvoke[database.DBHandler[model.ColorProfile]](i),
+		lsvc:  do.MustInvoke[LEDService](i),
+		l:     alog.NewLogger("groupservice"),
+	}, nil
+}
+
+func (s *groupSvc) WithActor(actor string) GroupService {
+	svc := *s
+	svc.actor = actor
+	return &svc
+}
+
+func (s *groupSvc) GetAll() ([]model.Group, error) {
+	return s.dbh.GetAll()
+}
+
+func (s *groupSvc) GetGroup(id string) (*model.Group, error) {
+	return s.dbh.Get(id)
+}
+
+func (s *groupSvc) CreateGroup(mdl *model.Group) error {
+	if err := s.validate(*mdl); err != nil {
+		return err
+	}
+	if err := database.CreateWithNextID(s.dbh, mdl); err != nil {
+		return err
+	}
+	s.l.Debug("Created group with ID %d", mdl.ID)
+	return nil
+}
+
+func (s *groupSvc) UpdateGroup(id string, updMdl model.Group) (*model.Group, error) {
+	// Get model if exist
+	group, err := s.dbh.Get(id)
+	if err != nil {
+		return nil, model.NewAppErr(http.StatusNotFound, err)
+	}
+	if err := checkVersion(updMdl.Version, group); err != nil {
+		return nil, err
+	}
+	// the id is needed to detect a group nesting itself
+	updMdl.ID = group.ID
+	if err := s.validate(updMdl); err != nil {
+		return nil, err
+	}
+	updMdl.Version = group.Version
+	if err := s.dbh.Update(*group, updMdl); err != nil {
+		return nil, updateErr(err)
+	}
+	updMdl.Version++
+	return &updMdl, nil
+}
+
+func (s *groupSvc) DeleteGroup(id string, version int64) error {
+	// Get model if exist
+	group, err := s.dbh.Get(id)
+	if err != nil {
+		return model.NewAppErr(http.StatusNotFound, err)
+	}
+	if err := checkVersion(version, group); err != nil {
+		return err
+	}
+	groups, err := s.dbh.GetAll()
+	if err != nil {
+		return model.NewAppErr(http.StatusInternalServerError, err)
+	}
+	for _, parent := range groups {
+		if slices.Contains(parent.Groups, group.ID) {
+			return model.NewAppErr(http.StatusConflict, fmt.Errorf("group %d is nested in group %d", group.ID, parent.ID))
+		}
+	}
+	if err := s.dbh.Delete(group); err != nil {
+		return model.NewAppErr(http.StatusBadRequest, err)
+	}
+	return nil
+}
+
+func (s *groupSvc) StripIDs(id string) ([]int64, error) {
+	// Get model if exist
+	group, err := s.dbh.Get(id)
+	if err != nil {
+		return nil, model.NewAppErr(http.StatusNotFound, err)
+	}
+	return s.resolve(*group), nil
+}
+
+func (s *groupSvc) EnableGroup(id string) (*model.GroupResult, error) {
+	return s.apply(id, model.ActionOn, null.Int{})
+}
+
+func (s *groupSvc) DisableGroup(id string) (*model.GroupResult, error) {
+	return s.apply(id, model.ActionOff, null.Int{})
+}
+
+func (s *groupSvc) SetProfileForGroup(id string, profileID int64) (*model.GroupResult, error) {
+	return s.apply(id, model.ActionSetProfile, null.IntFrom(profileID))
+}
+
+// apply performs the action on each strip of the group separately, so a missing or failing strip doesn't prevent the
+// others from being changed
+func (s *groupSvc) apply(id string, action model.ScheduleAction, profileID null.Int) (*model.GroupResult, error) {
+	// Get model if exist
+	group, err := s.dbh.Get(id)
+	if err != nil {
+		return nil, model.NewAppErr(http.StatusNotFound, err)
+	}
+	if profileID.Valid {
+		if _, err := s.cpDbh.Get(strconv.FormatInt(profileID.Int64, 10)); err != nil {
+			return nil, notFoundErr("profileId", "profile", profileID.Int64)
+		}
+	}
+	lsvc := s.lsvc.WithActor(s.actor)
+	result := &model.GroupResult{Strips: []model.LedStrip{}}
+	for _, stripID := range s.resolve(*group) {
+		state := model.StripState{StripID: stripID, Enabled: action != model.ActionOff, ProfileID: profileID}
+		strips, err := lsvc.ApplyStates([]model.StripState{state})
+		if err != nil {
+			result.Failed = append(result.Failed, model.StripFailure{StripID: stripID, Error: err.Error()})
+			continue
+		}
+		result.Strips = append(result.Strips, strips...)
+	}
+	s.l.Info("Applied %s to group %d, %d strips failed", action, group.ID, len(result.Failed))
+	return result, nil
+}
+
+// resolve the ids of the strips of the group and its nested groups in breadth-first order, missing nested groups are
+// skipped
+func (s *groupSvc) resolve(group model.Group) []int64 {
+	ids := []int64{}
+	seenStrips := map[int64]bool{}
+	seenGroups := map[int64]bool{group.ID: true}
+	queue := []model.Group{group}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, id := range current.Strips {
+			if !seenStrips[id] {
+				seenStrips[id] = true
+				ids = append(ids, id)
+			}
+		}
+		for _, id := range current.Groups {
+			if seenGroups[id] {
+				continue
+			}
+			seenGroups[id] = true
+			nested, err := s.dbh.Get(strconv.FormatInt(id, 10))
+			if err != nil {
+				s.l.Warn("group %d nested in group %d not found: %s", id, current.ID, err.Error())
+				continue
+			}
+			queue = append(queue, *nested)
+		}
+	}
+	return ids
+}
+
+// validate checks the group, whether its members exist and that it isn't nested in itself
+func (s *groupSvc) validate(group model.Group) error {
+	if err := validation.ValidateGroup(group); err != nil {
+		return err
+	}
+	for i, id := range group.Strips {
+		if _, err := s.lsDbh.Get(strconv.FormatInt(id, 10)); err != nil {
+			return notFoundErr(fmt.Sprintf("strips[%d]", i), "strip", id)
+		}
+	}
+	nested := map[int64]*model.Group{}
+	for i, id := range group.Groups {
+		g, err := s.dbh.Get(strconv.FormatInt(id, 10))
+		if err != nil {
+			return notFoundErr(fmt.Sprintf("groups[%d]", i), "group", id)
+		}
+		nested[id] = g
+	}
+	// a new group can't be nested anywhere yet
+	if group.ID == 0 {
+		return nil
+	}
+	for i, id := range group.Groups {
+		if id == group.ID || s.nests(*nested[id], group.ID) {
+			return model.NewAppErr(http.StatusUnprocessableEntity, &model.ValidationError{
+				Errors: []model.FieldError{{Field: fmt.Sprintf("groups[%d]", i), Message: "must not contain the group itself"}},
+			})
+		}
+	}
+	return nil
+}
+
+// nests whether the group with the id is nested in the group, directly or through other groups
+func (s *groupSvc) nests(group model.Group, id int64) bool {
+	seen := map[int64]bool{group.ID: true}
+	queue := []model.Group{group}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, nestedID := range current.Groups {
+			if nestedID == id {
+				return true
+			}
+			if seen[nestedID] {
+				continue
+			}
+			seen[nestedID] = true
+			if nested, err := s.dbh.Get(strconv.FormatInt(nestedID, 10)); err == nil {
+				queue = append(queue, *nested)
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	dbm "github.com/pthum/stripcontrol-golang/internal/database/mocks"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type groupMocks struct {
+	*eventMocks
+	grDbh *dbm.DBHandler[model.Group]
+	gs    *groupSvc
+}
+
+func TestGetAllGroups(t *testing.T) {
+	mocks := createGroupMocks(t)
+	groups := []model.Group{*createDummyGroup()}
+	mocks.grDbh.EXPECT().GetAll().Return(groups, nil).Once()
+
+	res, err := mocks.gs.GetAll()
+
+	assert.NoError(t, err)
+	assert.Equal(t, groups, res)
+}
+
+func TestGetGroup(t *testing.T) {
+	mocks := createGroupMocks(t)
+	group := createDummyGroup()
+	mocks.expectDBGroupGet(group)
+
+	res, err := mocks.gs.GetGroup("4")
+
+	assert.NoError(t, err)
+	assert.Equal(t, group, res)
+}
+
+func TestCreateGroup(t *testing.T) {
+	mocks := createGroupMocks(t)
+	group := createDummyGroup()
+	group.ID = 0
+	group.Groups = model.IDs{2}
+	mocks.expectDBStripGet(createValidDummyStrip())
+	mocks.expectDBGroupGet(&model.Group{BaseModel: model.BaseModel{ID: 2}, Name: "Kitchen"})
+	mocks.grDbh.EXPECT().NextID().Return(5, nil).Once()
+	mocks.grDbh.EXPECT().Create(mock.Anything).Return(nil).Once()
+
+	err := mocks.gs.CreateGroup(group)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), group.ID)
+}
+
+func TestCreateGroup_Invalid(t *testing.T) {
+	mocks := createGroupMocks(t)
+
+	err := mocks.gs.CreateGroup(&model.Group{Name: "Ground floor", Strips: model.IDs{0}})
+
+	var verr *model.ValidationError
+	assert.ErrorAs(t, err, &verr)
+}
+
+func TestCreateGroup_MissingReferences(t *testing.T) {
+	mocks := createGroupMocks(t)
+	group := createDummyGroup()
+	group.ID = 0
+	group.Groups = model.IDs{2}
+	mocks.lsDbh.EXPECT().Get("185").Return(nil, errors.New("not found")).Once()
+
+	err := mocks.gs.CreateGroup(group)
+
+	var verr *model.ValidationError
+	if assert.ErrorAs(t, err, &verr) {
+		assert.Equal(t, "strips[0]", verr.Errors[0].Field)
+	}
+
+	mocks.expectDBStripGet(createValidDummyStrip())
+	mocks.grDbh.EXPECT().Get("2").Return(nil, errors.New("not found")).Once()
+
+	err = mocks.gs.CreateGroup(group)
+
+	assertAppErrCode(t, err, http.StatusUnprocessableEntity)
+	if assert.ErrorAs(t, err, &verr) {
+		assert.Equal(t, "groups[0]", verr.Errors[0].Field)
+	}
+}
+
+func TestUpdateGroup(t *testing.T) {
+	mocks := createGroupMocks(t)
+	dbGroup := createDummyGroup()
+	dbGroup.Version = 2
+	upd := *createDummyGroup()
+	upd.ID = 0
+	upd.Name = "Upstairs"
+	upd.Version = 2
+	mocks.expectDBGroupGet(dbGroup)
+	mocks.expectDBStripGet(createValidDummyStrip())
+	expected := upd
+	expected.ID = 4
+	mocks.grDbh.EXPECT().Update(*dbGroup, expected).Return(nil).Once()
+
+	res, err := mocks.gs.UpdateGroup("4", upd)
+
+	assert.NoError(t, err)
+	expected.Version = 3
+	assert.Equal(t, &expected, res)
+}
+
+func TestUpdateGroup_Errors(t *testing.T) {
+	mocks := createGroupMocks(t)
+	upd := model.Group{Name: "Upstairs"}
+	upd.Version = 1
+	mocks.grDbh.EXPECT().Get("4").Return(nil, errors.New("not found")).Once()
+	_, err := mocks.gs.UpdateGroup("4", upd)
+	assertAppErrCode(t, err, http.StatusNotFound)
+
+	dbGroup := createDummyGroup()
+	dbGroup.Version = 2
+	mocks.expectDBGroupGet(dbGroup)
+	_, err = mocks.gs.UpdateGroup("4", upd)
+	assertAppErrCode(t, err, http.StatusPreconditionFailed)
+
+	upd.Version = 2
+	mocks.expectDBGroupGet(dbGroup)
+	mocks.grDbh.EXPECT().Update(mock.Anything, mock.Anything).Return(database.ErrVersionConflict).Once()
+	_, err = mocks.gs.UpdateGroup("4", upd)
+	assertAppErrCode(t, err, http.StatusPreconditionFailed)
+}
+
+func TestUpdateGroup_Cycle(t *testing.T) {
+	mocks := createGroupMocks(t)
+	group := &model.Group{BaseModel: model.BaseModel{ID: 1}, Name: "House"}
+	floor := &model.Group{BaseModel: model.BaseModel{ID: 2}, Name: "Floor", Groups: model.IDs{3}}
+	room := &model.Group{BaseModel: model.BaseModel{ID: 3}, Name: "Room", Groups: model.IDs{1}}
+	mocks.grDbh.EXPECT().Get("1").Return(group, nil)
+	mocks.grDbh.EXPECT().Get("2").Return(floor, nil)
+	mocks.grDbh.EXPECT().Get("3").Return(room, nil)
+
+	for _, nested := range []model.IDs{{1}, {2}} {
+		_, err := mocks.gs.UpdateGroup("1", model.Group{Name: "House", Groups: nested})
+
+		var verr *model.ValidationError
+		if assert.ErrorAs(t, err, &verr) {
+			assert.Equal(t, "groups[0]", verr.Errors[0].Field)
+		}
+	}
+}
+
+func TestDeleteGroup(t *testing.T) {
+	mocks := createGroupMocks(t)
+	group := createDummyGroup()
+	mocks.grDbh.EXPECT().Get("4").Return(group, nil).Twice()
+	mocks.grDbh.EXPECT().GetAll().Return([]model.Group{*group}, nil).Once()
+	mocks.grDbh.EXPECT().Delete(group).Return(nil).Once()
+
+	assert.NoError(t, mocks.gs.DeleteGroup("4", 0))
+	assertAppErrCode(t, mocks.gs.DeleteGroup("4", 3), http.StatusPreconditionFailed)
+
+	mocks.grDbh.EXPECT().Get("8").Return(nil, errors.New("not found")).Once()
+	assertAppErrCode(t, mocks.gs.DeleteGroup("8", 0), http.StatusNotFound)
+}
+
+func TestDeleteGroup_Nested(t *testing.T) {
+	mocks := createGroupMocks(t)
+	group := createDummyGroup()
+	parent := model.Group{BaseModel: model.BaseModel{ID: 1}, Name: "House", Groups: model.IDs{4}}
+	mocks.expectDBGroupGet(group)
+	mocks.grDbh.EXPECT().GetAll().Return([]model.Group{parent, *group}, nil).Once()
+
+	assertAppErrCode(t, mocks.gs.DeleteGroup("4", 0), http.StatusConflict)
+}
+
+func TestGroupStripIDs(t *testing.T) {
+	mocks := createGroupMocks(t)
+	// the nested groups contain each other and a missing group, the strips are only contained once
+	house := &model.Group{BaseModel: model.BaseModel{ID: 1}, Strips: model.IDs{5}, Groups: model.IDs{2, 3}}
+	floor := &model.Group{BaseModel: model.BaseModel{ID: 2}, Strips: model.IDs{6, 5}, Groups: model.IDs{1, 9}}
+	room := &model.Group{BaseModel: model.BaseModel{ID: 3}, Strips: model.IDs{7}, Groups: model.IDs{2}}
+	mocks.expectDBGroupGet(house)
+	mocks.expectDBGroupGet(floor)
+	mocks.expectDBGroupGet(room)
+	mocks.grDbh.EXPECT().Get("9").Return(nil, errors.New("not found")).Once()
+
+	ids, err := mocks.gs.StripIDs("1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{5, 6, 7}, ids)
+
+	mocks.grDbh.EXPECT().Get("8").Return(nil, errors.New("not found")).Once()
+	_, err = mocks.gs.StripIDs("8")
+	assertAppErrCode(t, err, http.StatusNotFound)
+}
+
+func TestEnableGroup(t *testing.T) {
+	mocks := createGroupMocks(t)
+	group := createDummyGroup()
+	group.Strips = model.IDs{185, 186}
+	mocks.expectDBGroupGet(group)
+	strip := createValidDummyStrip()
+	mocks.expectDBStripGet(strip)
+	mocks.lsDbh.EXPECT().Get("186").Return(nil, errors.New("not found")).Once()
+	enabled := *strip
+	enabled.Enabled = true
+	mocks.lsDbh.EXPECT().Update(*strip, enabled).Return(nil).Once()
+
+	res, err := mocks.gs.WithActor("rest:192.0.2.1").EnableGroup("4")
+	time.Sleep(50 * time.Millisecond)
+
+	assert.NoError(t, err)
+	enabled.Version++
+	assert.Equal(t, []model.LedStrip{enabled}, res.Strips)
+	if assert.Len(t, res.Failed, 1) {
+		assert.Equal(t, int64(186), res.Failed[0].StripID)
+		assert.NotEmpty(t, res.Failed[0].Error)
+	}
+	mocks.rec.mu.Lock()
+	defer mocks.rec.mu.Unlock()
+	if assert.Len(t, mocks.rec.strips, 1) {
+		assert.Equal(t, "rest:192.0.2.1", mocks.rec.strips[0].Meta.Actor)
+	}
+}
+
+func TestDisableGroup(t *testing.T) {
+	mocks := createGroupMocks(t)
+	group := createDummyGroup()
+	mocks.expectDBGroupGet(group)
+	strip := createValidDummyStrip()
+	strip.Enabled = true
+	mocks.expectDBStripGet(strip)
+	disabled := *strip
+	disabled.Enabled = false
+	mocks.lsDbh.EXPECT().Update(*strip, disabled).Return(nil).Once()
+
+	res, err := mocks.gs.DisableGroup("4")
+
+	assert.NoError(t, err)
+	assert.Len(t, res.Strips, 1)
+	assert.Empty(t, res.Failed)
+}
+
+func TestSetProfileForGroup(t *testing.T) {
+	mocks := createGroupMocks(t)
+	group := createDummyGroup()
+	profile := createProfile(15, 1, 2, 3, 4)
+	mocks.grDbh.EXPECT().Get("4").Return(group, nil).Twice()
+	mocks.cpDbh.EXPECT().Get("15").Return(profile, nil).Twice()
+	strip := createValidDummyStrip()
+	mocks.expectDBStripGet(strip)
+	updated := *strip
+	updated.Enabled = true
+	updated.ProfileID = null.IntFrom(15)
+	mocks.lsDbh.EXPECT().Update(*strip, updated).Return(nil).Once()
+
+	res, err := mocks.gs.SetProfileForGroup("4", 15)
+
+	assert.NoError(t, err)
+	if assert.Len(t, res.Strips, 1) {
+		assert.Equal(t, null.IntFrom(15), res.Strips[0].ProfileID)
+	}
+
+	mocks.cpDbh.EXPECT().Get("16").Return(nil, errors.New("not found")).Once()
+	_, err = mocks.gs.SetProfileForGroup("4", 16)
+	assertAppErrCode(t, err, http.StatusUnprocessableEntity)
+}
+
+func (m *groupMocks) expectDBGroupGet(group *model.Group) {
+	m.grDbh.EXPECT().Get(idStr(group.ID)).Return(group, nil).Once()
+}
+
+func createDummyGroup() *model.Group {
+	return &model.Group{
+		BaseModel: model.BaseModel{ID: 4},
+		Name:      "Ground floor",
+		Strips:    model.IDs{185},
+	}
+}
+
+func createGroupMocks(t *testing.T) *groupMocks {
+	em := createEventMocks(t)
+	i := do.New()
+	grDbh := dbm.NewDBHandler[model.Group](t)
+	do.ProvideValue[database.DBHandler[model.Group]](i, grDbh)
+	do.ProvideValue[database.DBHandler[model.LedStrip]](i, em.lsDbh)
+	do.ProvideValue[database.DBHandler[model.ColorProfile]](i, em.cpDbh)
+	do.ProvideValue[LEDService](i, em.ls)
+	gs, err := NewGroupService(i)
+	assert.NoError(t, err)
+	return &groupMocks{
+		eventMocks: em,
+		grDbh:      grDbh,
+		gs:         gs.(*groupSvc),
+	}
+}
@@ -3,45 +3,95 @@ package service
 import (
 	"errors"
 	"strconv"
+	"time"
 
+	"github.com/go-co-op/gocron"
 	"github.com/pthum/null"
 	"github.com/pthum/stripcontrol-golang/internal/database"
 	alog "github.com/pthum/stripcontrol-golang/internal/log"
 	"github.com/pthum/stripcontrol-golang/internal/messaging"
 	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/validation"
 	"github.com/samber/do"
 )
 
 //go:generate mockery --name=LEDService --with-expecter=true --outpkg=servicemocks
 type LEDService interface {
+	// WithActor returns the service, which publishes its events as caused by the actor
+	WithActor(actor string) LEDService
 	GetAll() ([]model.LedStrip, error)
 	GetLEDStrip(id string) (*model.LedStrip, error)
 	CreateLEDStrip(mdl *model.LedStrip) error
-	UpdateLEDStrip(id string, updMdl model.LedStrip) error
-	DeleteLEDStrip(id string) error
+	// UpdateLEDStrip replaces the strip and returns the stored strip, a version other than 0 on the input has to
+	// match the current version
+	UpdateLEDStrip(id string, updMdl model.LedStrip) (*model.LedStrip, error)
+	// PatchLEDStrip applies a json merge patch, a version other than 0 has to match the current version
+	PatchLEDStrip(id string, version int64, patch []byte) (*model.LedStrip, error)
+	// DeleteLEDStrip deletes the strip, a version other than 0 has to match the current version
+	DeleteLEDStrip(id string, version int64) error
 	UpdateProfileForStrip(id string, updProf model.ColorProfile) (*model.ColorProfile, error)
 	GetProfileForStrip(id string) (*model.ColorProfile, error)
 	RemoveProfileForStrip(id string) error
+	// SetColorForStrip applies the valid values of color to the profile of the strip, which affects all strips
+	// using the profile. A new profile is created for a strip without one.
+	SetColorForStrip(id string, color model.ColorProfile) (*model.ColorProfile, error)
+	// PreviewColorForStrip publishes the strip with the valid values of color applied to its profile, without
+	// storing it. A color without values publishes the stored state.
+	PreviewColorForStrip(id string, color model.ColorProfile) error
+	// ApplyStates sets the enabled flag and the profile or color of several strips. All states are checked before
+	// the first change, the applied changes are reverted if one fails. The events are published once all are applied.
+	// States coloring the same profile have to apply the same color, as it is shared by the strips.
+	ApplyStates(states []model.StripState) ([]model.LedStrip, error)
+	// SetTimer starts the timer of the strip, which replaces a running one and performs its action once the duration
+	// has passed. Any other change of the strip stops the timer.
+	SetTimer(id string, req model.TimerRequest) (*model.TimerInfo, error)
+	// GetTimer the running timer of the strip, nil if it has none
+	GetTimer(id int64) *model.TimerInfo
+	// CancelTimer stops the running timer of the strip without performing its action
+	CancelTimer(id string) error
 }
 
 type ledSvc struct {
 	dbh   database.DBHandler[model.LedStrip]
 	cpDbh database.DBHandler[model.ColorProfile]
+	tDbh  database.DBHandler[model.Timer]
 	mh    messaging.EventHandler
-	l     alog.Logger
+	sched *gocron.Scheduler
+	// timers the running timers, shared with the copies of WithActor
+	timers *timers
+	now    func() time.Time
+	actor  string
+	l      alog.Logger
 }
 
+// NewLEDService creates the service and registers the jobs of the stored timers on the shared scheduler
 func NewLEDService(i *do.Injector) (LEDService, error) {
 	lsdb := do.MustInvoke[database.DBHandler[model.LedStrip]](i)
 	cpdb := do.MustInvoke[database.DBHandler[model.ColorProfile]](i)
+	tdb := do.MustInvoke[database.DBHandler[model.Timer]](i)
 	mh := do.MustInvoke[messaging.EventHandler](i)
+	sched := do.MustInvoke[*gocron.Scheduler](i)
 	l := alog.NewLogger("ledservice")
-	return &ledSvc{
-		dbh:   lsdb,
-		cpDbh: cpdb,
-		mh:    mh,
-		l:     l,
-	}, nil
+	svc := &ledSvc{
+		dbh:    lsdb,
+		cpDbh:  cpdb,
+		tDbh:   tdb,
+		mh:     mh,
+		sched:  sched,
+		timers: &timers{jobs: map[int64]*timerJob{}},
+		now:    time.Now,
+		l:      l,
+	}
+	if err := svc.restoreTimers(); err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+func (l *ledSvc) WithActor(actor string) LEDService {
+	svc := *l
+	svc.actor = actor
+	return &svc
 }
 
 func (l *ledSvc) GetAll() ([]model.LedStrip, error) {
@@ -51,51 +101,94 @@ func (l *ledSvc) GetLEDStrip(id string) (*model.LedStrip, error) {
 	return l.dbh.Get(id)
 }
 func (l *ledSvc) CreateLEDStrip(mdl *model.LedStrip) error {
-	// generate an id
-	mdl.GenerateID()
-	l.l.Debug("Generated ID %d", mdl.ID)
-
-	if err := l.dbh.Create(mdl); err != nil {
+	if err := validation.ValidateLedStrip(*mdl); err != nil {
 		return err
 	}
+	if err := database.CreateWithNextID(l.dbh, mdl); err != nil {
+		return err
+	}
+	l.l.Debug("Created strip with ID %d", mdl.ID)
 
-	go l.publishStripSaveEvent(null.NewInt(0, false), *mdl, nil)
+	l.publishStripSave(*mdl)
 	return nil
 }
 
-func (l *ledSvc) UpdateLEDStrip(id string, updMdl model.LedStrip) error {
+func (l *ledSvc) UpdateLEDStrip(id string, updMdl model.LedStrip) (*model.LedStrip, error) {
+	if err := validation.ValidateLedStrip(updMdl); err != nil {
+		return nil, err
+	}
 	// Get model if exist
 	strip, err := l.dbh.Get(id)
 	if err != nil {
-		return model.NewAppErr(404, err)
+		return nil, model.NewAppErr(404, err)
+	}
+	if err := checkVersion(updMdl.Version, strip); err != nil {
+		return nil, err
+	}
+	updMdl.ID = strip.ID
+	if err := l.update(*strip, &updMdl); err != nil {
+		return nil, err
+	}
+	return &updMdl, nil
+}
+
+// PatchLEDStrip applies the json merge patch to the strip and returns the patched strip
+func (l *ledSvc) PatchLEDStrip(id string, version int64, patch []byte) (*model.LedStrip, error) {
+	// Get model if exist
+	strip, err := l.dbh.Get(id)
+	if err != nil {
+		return nil, model.NewAppErr(404, err)
+	}
+	if err := checkVersion(version, strip); err != nil {
+		return nil, err
+	}
+	updMdl, err := model.ApplyMergePatch(*strip, patch)
+	if err != nil {
+		return nil, err
+	}
+	// id and profile can't be changed through the patch
+	updMdl.ID = strip.ID
+	updMdl.ProfileID = strip.ProfileID
+	if err := validation.ValidateLedStrip(updMdl); err != nil {
+		return nil, err
 	}
+	if err := l.update(*strip, &updMdl); err != nil {
+		return nil, err
+	}
+	return &updMdl, nil
+}
 
+// update writes the changes of the strip, the version of updMdl is set to the one after the write
+func (l *ledSvc) update(strip model.LedStrip, updMdl *model.LedStrip) error {
 	// profile shouldn't be updated through this endpoint
 	updMdl.ProfileID = strip.ProfileID
+	updMdl.Version = strip.Version
 
-	if err := l.dbh.Update(*strip, updMdl); err != nil {
-		return model.NewAppErr(400, err)
-	}
-	// load profile for event
-	profile, err := l.cpDbh.Get(strconv.FormatInt(updMdl.ProfileID.Int64, 10))
-	if err == nil {
-		go l.publishStripSaveEvent(updMdl.GetNullID(), updMdl, profile)
+	if err := l.dbh.Update(strip, *updMdl); err != nil {
+		return updateErr(err)
 	}
+	updMdl.Version++
+	l.stopTimer(strip.ID)
+	l.publishStripSave(*updMdl)
 	return nil
 }
 
-func (l *ledSvc) DeleteLEDStrip(id string) error {
+func (l *ledSvc) DeleteLEDStrip(id string, version int64) error {
 	// Get model if exist
 	strip, err := l.dbh.Get(id)
 	if err != nil {
 		return model.NewAppErr(404, err)
 	}
+	if err := checkVersion(version, strip); err != nil {
+		return err
+	}
 
 	if err := l.dbh.Delete(strip); err != nil {
 		return model.NewAppErr(400, err)
 	}
-	var event = model.NewStripEvent(strip.GetNullID(), model.Delete)
-	go l.mh.PublishStripEvent(event)
+	l.stopTimer(strip.ID)
+	var event = model.NewStripEvent(strip.GetNullID(), model.Delete).By(l.actor)
+	l.publishStripEvent(event)
 	return nil
 }
 
@@ -117,8 +210,9 @@ func (l *ledSvc) UpdateProfileForStrip(id string, updProf model.ColorProfile) (*
 		l.l.Error("Error: %s", err)
 		return nil, model.NewAppErr(500, err)
 	}
+	l.stopTimer(strip.ID)
 
-	go l.publishStripSaveEvent(strip.GetNullID(), *strip, profile)
+	l.publishStripSaveEvent(strip.GetNullID(), *strip, profile)
 	return profile, nil
 }
 
@@ -140,8 +234,23 @@ func (l *ledSvc) GetProfileForStrip(id string) (*model.ColorProfile, error) {
 	return profile, nil
 }
 
+// publishStripSave publishes the save event of the strip, including its profile if it references one
+func (l *ledSvc) publishStripSave(strip model.LedStrip) {
+	var profile *model.ColorProfile
+	if strip.ProfileID.Valid {
+		// load profile for event, the event is published without it if it is missing
+		p, err := l.cpDbh.Get(strconv.FormatInt(strip.ProfileID.Int64, 10))
+		if err != nil {
+			l.l.Warn("profile %d of strip %d not found: %s", strip.ProfileID.Int64, strip.ID, err.Error())
+		} else {
+			profile = p
+		}
+	}
+	l.publishStripSaveEvent(strip.GetNullID(), strip, profile)
+}
+
 func (l *ledSvc) publishStripSaveEvent(id null.Int, strip model.LedStrip, profile *model.ColorProfile) {
-	var event = model.NewStripEvent(id, model.Save).With(&strip)
+	var event = model.NewStripEvent(id, model.Save).With(&strip).By(l.actor)
 
 	if strip.ProfileID.Valid {
 		if profile != nil {
@@ -149,9 +258,21 @@ func (l *ledSvc) publishStripSaveEvent(id null.Int, strip model.LedStrip, profil
 		}
 	}
 
+	l.publishStripEvent(event)
+}
+
+// publishStripEvent records the strip event for delivery, it is called before returning from the write, so the
+// events are recorded in the order of the writes
+func (l *ledSvc) publishStripEvent(event *model.StripEvent) {
 	if err := l.mh.PublishStripEvent(event); err != nil {
-		l.l.Error("error: %s", err.Error())
-		return
+		l.l.Error("error publishing strip event: %s", err.Error())
+	}
+}
+
+// publishProfileEvent records the profile event for delivery, like publishStripEvent
+func (l *ledSvc) publishProfileEvent(event *model.ProfileEvent) {
+	if err := l.mh.PublishProfileEvent(event); err != nil {
+		l.l.Error("error publishing profile event: %s", err.Error())
 	}
 }
 
@@ -167,7 +288,93 @@ func (l *ledSvc) RemoveProfileForStrip(id string) error {
 	if err := l.dbh.Save(strip); err != nil {
 		return model.NewAppErr(500, err)
 	}
+	l.stopTimer(strip.ID)
+
+	l.publishStripSaveEvent(strip.GetNullID(), *strip, nil)
+	return nil
+}
+
+func (l *ledSvc) SetColorForStrip(id string, color model.ColorProfile) (*model.ColorProfile, error) {
+	// Get model if exist
+	strip, err := l.dbh.Get(id)
+	if err != nil {
+		return nil, model.NewAppErr(404, err)
+	}
+	if !strip.ProfileID.Valid {
+		return l.createProfileForStrip(strip, color)
+	}
+
+	profile, err := l.cpDbh.Get(strconv.FormatInt(strip.ProfileID.Int64, 10))
+	if err != nil {
+		return nil, model.NewAppErr(404, err)
+	}
+	updMdl := profile.WithColor(color)
+	if err := validation.ValidateColorProfile(updMdl); err != nil {
+		return nil, err
+	}
+	if err := l.cpDbh.Update(*profile, updMdl); err != nil {
+		return nil, updateErr(err)
+	}
+	updMdl.Version++
+	l.stopTimer(strip.ID)
+
+	var event = model.NewProfileEvent(updMdl.GetNullID(), model.Save).With(updMdl).By(l.actor)
+	l.publishProfileEvent(event)
+	return &updMdl, nil
+}
+
+// createProfileForStrip creates a profile with the color and assigns it to the strip
+func (l *ledSvc) createProfileForStrip(strip *model.LedStrip, color model.ColorProfile) (*model.ColorProfile, error) {
+	profile := model.ColorProfile{}.WithColor(color)
+	if err := validation.ValidateColorProfile(profile); err != nil {
+		return nil, err
+	}
+	if err := database.CreateWithNextID(l.cpDbh, &profile); err != nil {
+		return nil, err
+	}
+	var event = model.NewProfileEvent(profile.GetNullID(), model.Save).With(profile).By(l.actor)
+	l.publishProfileEvent(event)
 
-	go l.publishStripSaveEvent(strip.GetNullID(), *strip, nil)
+	strip.ProfileID = profile.GetNullID()
+	if err := l.dbh.Save(strip); err != nil {
+		l.l.Error("Error: %s", err)
+		return nil, model.NewAppErr(500, err)
+	}
+	l.stopTimer(strip.ID)
+	l.publishStripSaveEvent(strip.GetNullID(), *strip, &profile)
+	return &profile, nil
+}
+
+func (l *ledSvc) PreviewColorForStrip(id string, color model.ColorProfile) error {
+	previewer, ok := l.mh.(messaging.Previewer)
+	if !ok {
+		return model.NewAppErr(501, messaging.ErrPreviewsUnsupported)
+	}
+	// Get model if exist
+	strip, err := l.dbh.Get(id)
+	if err != nil {
+		return model.NewAppErr(404, err)
+	}
+	var profile model.ColorProfile
+	if strip.ProfileID.Valid {
+		p, err := l.cpDbh.Get(strconv.FormatInt(strip.ProfileID.Int64, 10))
+		if err != nil {
+			return model.NewAppErr(404, err)
+		}
+		profile = *p
+	}
+	profile = profile.WithColor(color)
+	if err := validation.ValidateColorProfile(profile); err != nil {
+		return err
+	}
+
+	var event = model.NewStripEvent(strip.GetNullID(), model.Save).With(strip).By(l.actor)
+	event.Strip.With(profile)
+	if err := previewer.PublishPreview(event); err != nil {
+		if errors.Is(err, messaging.ErrPreviewsUnsupported) {
+			return model.NewAppErr(501, err)
+		}
+		return model.NewAppErr(503, err)
+	}
 	return nil
 }
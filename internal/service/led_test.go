@@ -2,10 +2,14 @@ package service
 
 import (
 	"errors"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	"github.com/pthum/stripcontrol-golang/internal/messaging"
+	mhm "github.com/pthum/stripcontrol-golang/internal/messaging/mocks"
 	"github.com/pthum/stripcontrol-golang/internal/model"
 	"github.com/samber/do"
 	"github.com/stretchr/testify/assert"
@@ -71,6 +75,7 @@ func TestCreateLEDStrip(t *testing.T) {
 	reqObj := createValidDummyStrip()
 	origReqObj := *reqObj
 	var newId int64
+	mocks.expectDBStripNextID(500)
 	mocks.lsDbh.
 		EXPECT().
 		Create(mock.Anything).
@@ -81,19 +86,18 @@ func TestCreateLEDStrip(t *testing.T) {
 		}).
 		Return(nil).
 		Once()
-	mocks.expectPublishStripEvent(t, model.Save, newId, true, false, nil)
+	mocks.expectPublishStripEvent(t, model.Save, 500, true, false, nil)
 
 	err := mocks.lh.CreateLEDStrip(reqObj)
 
-	// small sleep to have the async routines run
-	time.Sleep(50 * time.Millisecond)
-
 	assert.NoError(t, err)
+	assert.Equal(t, newId, reqObj.ID)
 }
 
 func TestCreateLEDStrip_SaveError(t *testing.T) {
 	mocks := createLEDHandlerMocks(t)
 	reqObj := createValidDummyStrip()
+	mocks.expectDBStripNextID(500)
 	mocks.lsDbh.
 		EXPECT().
 		Create(mock.Anything).
@@ -103,11 +107,48 @@ func TestCreateLEDStrip_SaveError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestCreateLEDStrip_IDConflict(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	reqObj := createValidDummyStrip()
+	mocks.expectDBStripNextID(500)
+	mocks.expectDBStripNextID(501)
+	mocks.lsDbh.
+		EXPECT().
+		Create(mock.Anything).
+		Return(database.ErrConflict).
+		Once()
+	mocks.lsDbh.
+		EXPECT().
+		Create(mock.Anything).
+		Return(nil).
+		Once()
+	mocks.expectPublishStripEvent(t, model.Save, 501, true, false, nil)
+
+	err := mocks.lh.CreateLEDStrip(reqObj)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(501), reqObj.ID)
+}
+
+func TestCreateLEDStrip_Invalid(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	reqObj := createValidDummyStrip()
+	reqObj.NumLeds = null.IntFrom(0)
+
+	err := mocks.lh.CreateLEDStrip(reqObj)
+
+	// invalid strips must not reach the database
+	var aerr *model.AppError
+	assert.ErrorAs(t, err, &aerr)
+	assert.Equal(t, http.StatusUnprocessableEntity, aerr.Code)
+}
+
 func TestCreateLEDStrip_PublishError(t *testing.T) {
 	mocks := createLEDHandlerMocks(t)
 	reqObj := createValidDummyStrip()
 	origReqObj := *reqObj
 	var newId int64
+	mocks.expectDBStripNextID(500)
 	mocks.lsDbh.
 		EXPECT().
 		Create(mock.Anything).
@@ -118,13 +159,12 @@ func TestCreateLEDStrip_PublishError(t *testing.T) {
 		}).
 		Return(nil).
 		Once()
-	mocks.expectPublishStripEvent(t, model.Save, newId, true, false, errors.New("publish failed"))
+	mocks.expectPublishStripEvent(t, model.Save, 500, true, false, errors.New("publish failed"))
 
 	err := mocks.lh.CreateLEDStrip(reqObj)
 
-	// small sleep to have the async routines run
-	time.Sleep(50 * time.Millisecond)
 	assert.NoError(t, err)
+	assert.Equal(t, newId, reqObj.ID)
 }
 
 func TestDeleteLEDStrip(t *testing.T) {
@@ -137,18 +177,56 @@ func TestDeleteLEDStrip(t *testing.T) {
 		Return(nil)
 	mocks.expectPublishStripEvent(t, model.Delete, getObj.ID, false, false, nil)
 
-	err := mocks.lh.DeleteLEDStrip("185")
+	err := mocks.lh.DeleteLEDStrip("185", 0)
+
+	assert.NoError(t, err)
+}
+
+func TestDeleteLEDStrip_WithActor(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	getObj := createValidDummyStrip()
+	mocks.expectDBStripGet(getObj, nil)
+	mocks.lsDbh.
+		EXPECT().
+		Delete(mock.Anything).
+		Return(nil)
+	published := make(chan *model.StripEvent, 1)
+	mocks.mh.
+		EXPECT().
+		PublishStripEvent(mock.Anything).
+		Run(func(event *model.StripEvent) {
+			published <- event
+		}).
+		Return(nil)
+
+	err := mocks.lh.WithActor("telegram:jane").DeleteLEDStrip("185", 0)
 
-	// small sleep to have the async routines run
-	time.Sleep(50 * time.Millisecond)
 	assert.NoError(t, err)
+	event := <-published
+	assert.Equal(t, "telegram:jane", event.Meta.Actor)
+	assert.NotEmpty(t, event.Meta.ID)
+	// the actor is only set on the copy
+	assert.Empty(t, mocks.lh.actor)
+}
+
+func TestDeleteLEDStrip_VersionMismatch(t *testing.T) {
+	dbObj := createValidDummyStrip()
+	dbObj.Version = 3
+	mocks := createLEDHandlerMocks(t)
+	mocks.expectDBStripGet(dbObj, nil)
+
+	err := mocks.lh.DeleteLEDStrip("185", 2)
+
+	var aerr *model.AppError
+	assert.ErrorAs(t, err, &aerr)
+	assert.Equal(t, http.StatusPreconditionFailed, aerr.Code)
 }
 
 func TestDeleteLEDStrip_MissingDBStrip(t *testing.T) {
 	mocks := createLEDHandlerMocks(t)
 	mocks.expectDBStripGet(nil, errors.New("not found"))
 
-	err := mocks.lh.DeleteLEDStrip("185")
+	err := mocks.lh.DeleteLEDStrip("185", 0)
 	assert.Error(t, err)
 }
 
@@ -161,7 +239,7 @@ func TestDeleteLEDStrip_DeleteError(t *testing.T) {
 		Delete(mock.Anything).
 		Return(errors.New("delete error"))
 
-	err := mocks.lh.DeleteLEDStrip("185")
+	err := mocks.lh.DeleteLEDStrip("185", 0)
 	assert.Error(t, err)
 }
 
@@ -175,10 +253,8 @@ func TestDeleteLEDStrip_PublishError(t *testing.T) {
 		Return(nil)
 	mocks.expectPublishStripEvent(t, model.Delete, getObj.ID, false, false, errors.New("publish failed"))
 
-	err := mocks.lh.DeleteLEDStrip("185")
+	err := mocks.lh.DeleteLEDStrip("185", 0)
 
-	// small sleep to have the async routines run
-	time.Sleep(50 * time.Millisecond)
 	assert.NoError(t, err)
 }
 
@@ -208,11 +284,56 @@ func TestUpdateLEDStrip(t *testing.T) {
 	mocks.expectPublishStripEvent(t, model.Save, inputObj.ID, true, true, nil)
 	mocks.expectDBProfileGet(fakeProfile, nil)
 
-	err := mocks.lh.UpdateLEDStrip("185", *inputObj)
+	res, err := mocks.lh.UpdateLEDStrip("185", *inputObj)
 
-	// small sleep to have the async routines run
-	time.Sleep(50 * time.Millisecond)
 	assert.NoError(t, err)
+	// the stored strip has the version after the update
+	expected := *inputObj
+	expected.Version = dbObj.Version + 1
+	assert.Equal(t, &expected, res)
+}
+
+func TestUpdateLEDStrip_Invalid(t *testing.T) {
+	inputObj := createValidDummyStrip()
+	inputObj.SclkPin = inputObj.MisoPin
+	mocks := createLEDHandlerMocks(t)
+
+	_, err := mocks.lh.UpdateLEDStrip("185", *inputObj)
+
+	var verr *model.ValidationError
+	assert.ErrorAs(t, err, &verr)
+	assert.Equal(t, "sclkPin", verr.Errors[0].Field)
+}
+
+func TestUpdateLEDStrip_VersionMismatch(t *testing.T) {
+	dbObj := createValidDummyStrip()
+	dbObj.Version = 3
+	inputObj := createValidDummyStrip()
+	inputObj.Version = 2
+	mocks := createLEDHandlerMocks(t)
+	mocks.expectDBStripGet(dbObj, nil)
+
+	_, err := mocks.lh.UpdateLEDStrip("185", *inputObj)
+
+	var aerr *model.AppError
+	assert.ErrorAs(t, err, &aerr)
+	assert.Equal(t, http.StatusPreconditionFailed, aerr.Code)
+}
+
+func TestUpdateLEDStrip_VersionConflict(t *testing.T) {
+	dbObj := createValidDummyStrip()
+	mocks := createLEDHandlerMocks(t)
+	mocks.expectDBStripGet(dbObj, nil)
+	mocks.lsDbh.
+		EXPECT().
+		Update(*dbObj, mock.Anything).
+		Return(database.ErrVersionConflict)
+
+	_, err := mocks.lh.UpdateLEDStrip("185", *createValidDummyStrip())
+
+	var aerr *model.AppError
+	assert.ErrorAs(t, err, &aerr)
+	assert.Equal(t, http.StatusPreconditionFailed, aerr.Code)
 }
 
 func TestUpdateLEDStrip_MissingDBProfile(t *testing.T) {
@@ -221,7 +342,7 @@ func TestUpdateLEDStrip_MissingDBProfile(t *testing.T) {
 	mocks := createLEDHandlerMocks(t)
 	mocks.expectDBStripGet(nil, errors.New("not found"))
 
-	err := mocks.lh.UpdateLEDStrip("185", *inputObj)
+	_, err := mocks.lh.UpdateLEDStrip("185", *inputObj)
 	assert.Error(t, err)
 }
 
@@ -247,7 +368,7 @@ func TestUpdateLEDStrip_UpdateError(t *testing.T) {
 		Update(dbObj, *inputObj).
 		Return(errors.New("update failed"))
 
-	err := mocks.lh.UpdateLEDStrip("185", *inputObj)
+	_, err := mocks.lh.UpdateLEDStrip("185", *inputObj)
 	assert.Error(t, err)
 }
 
@@ -276,11 +397,71 @@ func TestUpdateLEDStrip_PublishError(t *testing.T) {
 	mocks.expectPublishStripEvent(t, model.Save, inputObj.ID, true, true, errors.New("publish error"))
 	mocks.expectDBProfileGet(fakeProfile, nil)
 
-	err := mocks.lh.UpdateLEDStrip("185", *inputObj)
+	_, err := mocks.lh.UpdateLEDStrip("185", *inputObj)
+
+	assert.NoError(t, err)
+}
+
+func TestPatchLEDStrip(t *testing.T) {
+	dbObj := createValidDummyStrip()
+	dbObj.ProfileID = null.IntFrom(15)
+	fakeProfile := createDummyProfile()
+	fakeProfile.ID = 15
+	expected := *dbObj
+	expected.Enabled = true
+	expected.SpeedHz = null.NewInt(0, false)
+
+	mocks := createLEDHandlerMocks(t)
+	mocks.expectDBStripGet(dbObj, nil)
+	mocks.lsDbh.
+		EXPECT().
+		Update(*dbObj, expected).
+		Return(nil)
+	mocks.expectDBProfileGet(fakeProfile, nil)
+	mocks.expectPublishStripEvent(t, model.Save, dbObj.ID, true, true, nil)
+
+	// id and profile can't be changed through the patch
+	result, err := mocks.lh.PatchLEDStrip("185", 0, []byte(`{"id":7,"enabled":true,"speedHz":null,"profileId":3}`))
 
-	// small sleep to have the async routines run
-	time.Sleep(50 * time.Millisecond)
 	assert.NoError(t, err)
+	// the result has the version after the update
+	expected.Version = dbObj.Version + 1
+	assert.Equal(t, expected, *result)
+}
+
+func TestPatchLEDStrip_MissingDBStrip(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	mocks.expectDBStripGet(nil, errors.New("not found"))
+
+	result, err := mocks.lh.PatchLEDStrip("185", 0, []byte(`{"enabled":true}`))
+
+	assert.Nil(t, result)
+	var aerr *model.AppError
+	assert.ErrorAs(t, err, &aerr)
+	assert.Equal(t, http.StatusNotFound, aerr.Code)
+}
+
+func TestPatchLEDStrip_InvalidPatch(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	mocks.expectDBStripGet(createValidDummyStrip(), nil)
+
+	result, err := mocks.lh.PatchLEDStrip("185", 0, []byte(`{"enabled":`))
+
+	assert.Nil(t, result)
+	var aerr *model.AppError
+	assert.ErrorAs(t, err, &aerr)
+	assert.Equal(t, http.StatusBadRequest, aerr.Code)
+}
+
+func TestPatchLEDStrip_Invalid(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	mocks.expectDBStripGet(createValidDummyStrip(), nil)
+
+	result, err := mocks.lh.PatchLEDStrip("185", 0, []byte(`{"numLeds":0}`))
+
+	assert.Nil(t, result)
+	var verr *model.ValidationError
+	assert.ErrorAs(t, err, &verr)
 }
 
 func TestGetProfileForLEDStrip(t *testing.T) {
@@ -549,6 +730,145 @@ func TestRemoveProfileForLEDStrip_PublishError(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestSetColorForLEDStrip(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	strip := createValidDummyStrip()
+	strip.ProfileID = null.IntFrom(15)
+	dbO := createProfile(15, 100, 100, 100, 2)
+	expected := *dbO
+	expected.Red = null.IntFrom(255)
+	expected.Brightness = null.IntFrom(31)
+	mocks.expectDBStripGet(strip, nil)
+	mocks.expectDBProfileGet(dbO, nil)
+	mocks.cpDbh.
+		EXPECT().
+		Update(*dbO, expected).
+		Return(nil)
+	published := expected
+	published.Version = dbO.Version + 1
+	wg := mocks.expectPublishProfileEvent(t, model.Save, dbO.ID, &published)
+
+	res, err := mocks.lh.SetColorForStrip(idStr(strip.ID), model.ColorProfile{Red: null.IntFrom(255), Brightness: null.IntFrom(31)})
+	wg.Wait()
+
+	assert.NoError(t, err)
+	assert.Equal(t, published, *res)
+}
+
+func TestSetColorForLEDStrip_NoProfile(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	strip := createValidDummyStrip()
+	expected := model.ColorProfile{BaseModel: model.BaseModel{ID: 16}, Green: null.IntFrom(20)}
+	mocks.expectDBStripGet(strip, nil)
+	mocks.expectDBProfileNextID(16)
+	mocks.cpDbh.
+		EXPECT().
+		Create(&expected).
+		Return(nil)
+	mocks.lsDbh.
+		EXPECT().
+		Save(mock.Anything).
+		Run(func(input *model.LedStrip) {
+			assert.Equal(t, null.IntFrom(16), input.ProfileID)
+		}).
+		Return(nil)
+	wg := mocks.expectPublishProfileEvent(t, model.Save, 16, &expected)
+	wg.Add(1)
+	mocks.mh.
+		EXPECT().
+		PublishStripEvent(mock.Anything).
+		Run(func(event *model.StripEvent) {
+			assert.Equal(t, expected, event.Strip.Strip.Profile.Profile)
+			wg.Done()
+		}).
+		Return(nil)
+
+	res, err := mocks.lh.SetColorForStrip(idStr(strip.ID), model.ColorProfile{Green: null.IntFrom(20)})
+	wg.Wait()
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, *res)
+}
+
+func TestSetColorForLEDStrip_Invalid(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	strip := createValidDummyStrip()
+	strip.ProfileID = null.IntFrom(15)
+	mocks.expectDBStripGet(strip, nil)
+	mocks.expectDBProfileGet(createProfile(15, 100, 100, 100, 2), nil)
+
+	res, err := mocks.lh.SetColorForStrip(idStr(strip.ID), model.ColorProfile{Brightness: null.IntFrom(32)})
+
+	assert.Nil(t, res)
+	var verr *model.ValidationError
+	assert.ErrorAs(t, err, &verr)
+}
+
+func TestSetColorForLEDStrip_MissingStrip(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	mocks.expectDBStripGet(nil, errors.New("not found"))
+
+	res, err := mocks.lh.SetColorForStrip("185", model.ColorProfile{Red: null.IntFrom(1)})
+
+	assert.Nil(t, res)
+	assertAppErrCode(t, err, http.StatusNotFound)
+}
+
+func TestPreviewColorForLEDStrip(t *testing.T) {
+	mocks := createLEDHandlerMocks(t)
+	pv := &previewerFake{EventHandler: mocks.mh}
+	mocks.lh.mh = pv
+	strip := createValidDummyStrip()
+	strip.ProfileID = null.IntFrom(15)
+	mocks.expectDBStripGet(strip, nil)
+	mocks.expectDBProfileGet(createProfile(15, 100, 100, 100, 2), nil)
+
+	err := mocks.lh.PreviewColorForStrip(idStr(strip.ID), model.ColorProfile{Blue: null.IntFrom(0)})
+
+	assert.NoError(t, err)
+	assert.Len(t, pv.previews, 1)
+	assert.Equal(t, strip.ID, pv.previews[0].ID.Int64)
+	assert.True(t, pv.previews[0].Strip.Strip.Profile.Valid)
+	assert.Equal(t, *createProfile(15, 100, 100, 0, 2), pv.previews[0].Strip.Strip.Profile.Profile)
+}
+
+func TestPreviewColorForLEDStrip_Errors(t *testing.T) {
+	// no handler supports previews
+	mocks := createLEDHandlerMocks(t)
+	assertAppErrCode(t, mocks.lh.PreviewColorForStrip("185", model.ColorProfile{}), http.StatusNotImplemented)
+
+	mocks.lh.mh = &previewerFake{EventHandler: mocks.mh}
+	mocks.expectDBStripGet(nil, errors.New("not found"))
+	assertAppErrCode(t, mocks.lh.PreviewColorForStrip("185", model.ColorProfile{}), http.StatusNotFound)
+
+	mocks.expectDBStripGet(createValidDummyStrip(), nil)
+	var verr *model.ValidationError
+	assert.ErrorAs(t, mocks.lh.PreviewColorForStrip("185", model.ColorProfile{Red: null.IntFrom(256)}), &verr)
+
+	mocks.lh.mh = &previewerFake{EventHandler: mocks.mh, err: messaging.ErrNotConnected}
+	mocks.expectDBStripGet(createValidDummyStrip(), nil)
+	assertAppErrCode(t, mocks.lh.PreviewColorForStrip("185", model.ColorProfile{}), http.StatusServiceUnavailable)
+}
+
+// previewerFake an event handler recording the previews
+type previewerFake struct {
+	*mhm.EventHandler
+	previews []*model.StripEvent
+	err      error
+}
+
+func (p *previewerFake) PublishPreview(event *model.StripEvent) error {
+	p.previews = append(p.previews, event)
+	return p.err
+}
+
+func assertAppErrCode(t *testing.T, err error, code int) {
+	var aerr *model.AppError
+	if assert.ErrorAs(t, err, &aerr) {
+		assert.Equal(t, code, aerr.Code)
+	}
+}
+
 func (lhm *lsMocks) expectDBStripGet(getStrip *model.LedStrip, getStripError error) {
 	getStripIdStr := mock.Anything
 	if getStrip != nil {
@@ -597,6 +917,7 @@ func createValidDummyStrip() *model.LedStrip {
 func createLEDHandlerMocks(t *testing.T) *lsMocks {
 	i := do.New()
 	bm := createBaseMocks(i, t)
+	bm.expectDBTimerGetAll()
 	lh, err := NewLEDService(i)
 	assert.NoError(t, err)
 	return &lsMocks{
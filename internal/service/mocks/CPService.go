@@ -1,9 +1,10 @@
-// Code generated by mockery v2.36.0. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package servicemocks
 
 import (
 	model "github.com/pthum/stripcontrol-golang/internal/model"
+	service "github.com/pthum/stripcontrol-golang/internal/service"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -24,6 +25,10 @@ func (_m *CPService) EXPECT() *CPService_Expecter {
 func (_m *CPService) CreateColorProfile(mdl *model.ColorProfile) error {
 	ret := _m.Called(mdl)
 
+	if len(ret) == 0 {
+		panic("no return value specified for CreateColorProfile")
+	}
+
 	var r0 error
 	if rf, ok := ret.Get(0).(func(*model.ColorProfile) error); ok {
 		r0 = rf(mdl)
@@ -62,13 +67,17 @@ func (_c *CPService_CreateColorProfile_Call) RunAndReturn(run func(*model.ColorP
 	return _c
 }
 
-// DeleteColorProfile provides a mock function with given fields: id
-func (_m *CPService) DeleteColorProfile(id string) error {
-	ret := _m.Called(id)
+// DeleteColorProfile provides a mock function with given fields: id, version
+func (_m *CPService) DeleteColorProfile(id string, version int64) error {
+	ret := _m.Called(id, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteColorProfile")
+	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(string, int64) error); ok {
+		r0 = rf(id, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -83,13 +92,14 @@ type CPService_DeleteColorProfile_Call struct {
 
 // DeleteColorProfile is a helper method to define mock.On call
 //   - id string
-func (_e *CPService_Expecter) DeleteColorProfile(id interface{}) *CPService_DeleteColorProfile_Call {
-	return &CPService_DeleteColorProfile_Call{Call: _e.mock.On("DeleteColorProfile", id)}
+//   - version int64
+func (_e *CPService_Expecter) DeleteColorProfile(id interface{}, version interface{}) *CPService_DeleteColorProfile_Call {
+	return &CPService_DeleteColorProfile_Call{Call: _e.mock.On("DeleteColorProfile", id, version)}
 }
 
-func (_c *CPService_DeleteColorProfile_Call) Run(run func(id string)) *CPService_DeleteColorProfile_Call {
+func (_c *CPService_DeleteColorProfile_Call) Run(run func(id string, version int64)) *CPService_DeleteColorProfile_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(string), args[1].(int64))
 	})
 	return _c
 }
@@ -99,15 +109,19 @@ func (_c *CPService_DeleteColorProfile_Call) Return(_a0 error) *CPService_Delete
 	return _c
 }
 
-func (_c *CPService_DeleteColorProfile_Call) RunAndReturn(run func(string) error) *CPService_DeleteColorProfile_Call {
+func (_c *CPService_DeleteColorProfile_Call) RunAndReturn(run func(string, int64) error) *CPService_DeleteColorProfile_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetAll provides a mock function with given fields:
+// GetAll provides a mock function with no fields
 func (_m *CPService) GetAll() ([]model.ColorProfile, error) {
 	ret := _m.Called()
 
+	if len(ret) == 0 {
+		panic("no return value specified for GetAll")
+	}
+
 	var r0 []model.ColorProfile
 	var r1 error
 	if rf, ok := ret.Get(0).(func() ([]model.ColorProfile, error)); ok {
@@ -161,6 +175,10 @@ func (_c *CPService_GetAll_Call) RunAndReturn(run func() ([]model.ColorProfile,
 func (_m *CPService) GetColorProfile(id string) (*model.ColorProfile, error) {
 	ret := _m.Called(id)
 
+	if len(ret) == 0 {
+		panic("no return value specified for GetColorProfile")
+	}
+
 	var r0 *model.ColorProfile
 	var r1 error
 	if rf, ok := ret.Get(0).(func(string) (*model.ColorProfile, error)); ok {
@@ -211,18 +229,94 @@ func (_c *CPService_GetColorProfile_Call) RunAndReturn(run func(string) (*model.
 	return _c
 }
 
+// PatchColorProfile provides a mock function with given fields: id, version, patch
+func (_m *CPService) PatchColorProfile(id string, version int64, patch []byte) (*model.ColorProfile, error) {
+	ret := _m.Called(id, version, patch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PatchColorProfile")
+	}
+
+	var r0 *model.ColorProfile
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int64, []byte) (*model.ColorProfile, error)); ok {
+		return rf(id, version, patch)
+	}
+	if rf, ok := ret.Get(0).(func(string, int64, []byte) *model.ColorProfile); ok {
+		r0 = rf(id, version, patch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ColorProfile)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int64, []byte) error); ok {
+		r1 = rf(id, version, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CPService_PatchColorProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PatchColorProfile'
+type CPService_PatchColorProfile_Call struct {
+	*mock.Call
+}
+
+// PatchColorProfile is a helper method to define mock.On call
+//   - id string
+//   - version int64
+//   - patch []byte
+func (_e *CPService_Expecter) PatchColorProfile(id interface{}, version interface{}, patch interface{}) *CPService_PatchColorProfile_Call {
+	return &CPService_PatchColorProfile_Call{Call: _e.mock.On("PatchColorProfile", id, version, patch)}
+}
+
+func (_c *CPService_PatchColorProfile_Call) Run(run func(id string, version int64, patch []byte)) *CPService_PatchColorProfile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int64), args[2].([]byte))
+	})
+	return _c
+}
+
+func (_c *CPService_PatchColorProfile_Call) Return(_a0 *model.ColorProfile, _a1 error) *CPService_PatchColorProfile_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CPService_PatchColorProfile_Call) RunAndReturn(run func(string, int64, []byte) (*model.ColorProfile, error)) *CPService_PatchColorProfile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateColorProfile provides a mock function with given fields: id, updMdl
-func (_m *CPService) UpdateColorProfile(id string, updMdl model.ColorProfile) error {
+func (_m *CPService) UpdateColorProfile(id string, updMdl model.ColorProfile) (*model.ColorProfile, error) {
 	ret := _m.Called(id, updMdl)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string, model.ColorProfile) error); ok {
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateColorProfile")
+	}
+
+	var r0 *model.ColorProfile
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, model.ColorProfile) (*model.ColorProfile, error)); ok {
+		return rf(id, updMdl)
+	}
+	if rf, ok := ret.Get(0).(func(string, model.ColorProfile) *model.ColorProfile); ok {
 		r0 = rf(id, updMdl)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ColorProfile)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(string, model.ColorProfile) error); ok {
+		r1 = rf(id, updMdl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // CPService_UpdateColorProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateColorProfile'
@@ -244,12 +338,60 @@ func (_c *CPService_UpdateColorProfile_Call) Run(run func(id string, updMdl mode
 	return _c
 }
 
-func (_c *CPService_UpdateColorProfile_Call) Return(_a0 error) *CPService_UpdateColorProfile_Call {
+func (_c *CPService_UpdateColorProfile_Call) Return(_a0 *model.ColorProfile, _a1 error) *CPService_UpdateColorProfile_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CPService_UpdateColorProfile_Call) RunAndReturn(run func(string, model.ColorProfile) (*model.ColorProfile, error)) *CPService_UpdateColorProfile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WithActor provides a mock function with given fields: actor
+func (_m *CPService) WithActor(actor string) service.CPService {
+	ret := _m.Called(actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WithActor")
+	}
+
+	var r0 service.CPService
+	if rf, ok := ret.Get(0).(func(string) service.CPService); ok {
+		r0 = rf(actor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(service.CPService)
+		}
+	}
+
+	return r0
+}
+
+// CPService_WithActor_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WithActor'
+type CPService_WithActor_Call struct {
+	*mock.Call
+}
+
+// WithActor is a helper method to define mock.On call
+//   - actor string
+func (_e *CPService_Expecter) WithActor(actor interface{}) *CPService_WithActor_Call {
+	return &CPService_WithActor_Call{Call: _e.mock.On("WithActor", actor)}
+}
+
+func (_c *CPService_WithActor_Call) Run(run func(actor string)) *CPService_WithActor_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *CPService_WithActor_Call) Return(_a0 service.CPService) *CPService_WithActor_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *CPService_UpdateColorProfile_Call) RunAndReturn(run func(string, model.ColorProfile) error) *CPService_UpdateColorProfile_Call {
+func (_c *CPService_WithActor_Call) RunAndReturn(run func(string) service.CPService) *CPService_WithActor_Call {
 	_c.Call.Return(run)
 	return _c
 }
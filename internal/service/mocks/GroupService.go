@@ -0,0 +1,584 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package servicemocks
+
+import (
+	model "github.com/pthum/stripcontrol-golang/internal/model"
+	service "github.com/pthum/stripcontrol-golang/internal/service"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// GroupService is an autogenerated mock type for the GroupService type
+type GroupService struct {
+	mock.Mock
+}
+
+type GroupService_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *GroupService) EXPECT() *GroupService_Expecter {
+	return &GroupService_Expecter{mock: &_m.Mock}
+}
+
+// CreateGroup provides a mock function with given fields: mdl
+func (_m *GroupService) CreateGroup(mdl *model.Group) error {
+	ret := _m.Called(mdl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateGroup")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Group) error); ok {
+		r0 = rf(mdl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GroupService_CreateGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateGroup'
+type GroupService_CreateGroup_Call struct {
+	*mock.Call
+}
+
+// CreateGroup is a helper method to define mock.On call
+//   - mdl *model.Group
+func (_e *GroupService_Expecter) CreateGroup(mdl interface{}) *GroupService_CreateGroup_Call {
+	return &GroupService_CreateGroup_Call{Call: _e.mock.On("CreateGroup", mdl)}
+}
+
+func (_c *GroupService_CreateGroup_Call) Run(run func(mdl *model.Group)) *GroupService_CreateGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.Group))
+	})
+	return _c
+}
+
+func (_c *GroupService_CreateGroup_Call) Return(_a0 error) *GroupService_CreateGroup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GroupService_CreateGroup_Call) RunAndReturn(run func(*model.Group) error) *GroupService_CreateGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteGroup provides a mock function with given fields: id, version
+func (_m *GroupService) DeleteGroup(id string, version int64) error {
+	ret := _m.Called(id, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteGroup")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int64) error); ok {
+		r0 = rf(id, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GroupService_DeleteGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteGroup'
+type GroupService_DeleteGroup_Call struct {
+	*mock.Call
+}
+
+// DeleteGroup is a helper method to define mock.On call
+//   - id string
+//   - version int64
+func (_e *GroupService_Expecter) DeleteGroup(id interface{}, version interface{}) *GroupService_DeleteGroup_Call {
+	return &GroupService_DeleteGroup_Call{Call: _e.mock.On("DeleteGroup", id, version)}
+}
+
+func (_c *GroupService_DeleteGroup_Call) Run(run func(id string, version int64)) *GroupService_DeleteGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *GroupService_DeleteGroup_Call) Return(_a0 error) *GroupService_DeleteGroup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GroupService_DeleteGroup_Call) RunAndReturn(run func(string, int64) error) *GroupService_DeleteGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DisableGroup provides a mock function with given fields: id
+func (_m *GroupService) DisableGroup(id string) (*model.GroupResult, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DisableGroup")
+	}
+
+	var r0 *model.GroupResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*model.GroupResult, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *model.GroupResult); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.GroupResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GroupService_DisableGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DisableGroup'
+type GroupService_DisableGroup_Call struct {
+	*mock.Call
+}
+
+// DisableGroup is a helper method to define mock.On call
+//   - id string
+func (_e *GroupService_Expecter) DisableGroup(id interface{}) *GroupService_DisableGroup_Call {
+	return &GroupService_DisableGroup_Call{Call: _e.mock.On("DisableGroup", id)}
+}
+
+func (_c *GroupService_DisableGroup_Call) Run(run func(id string)) *GroupService_DisableGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *GroupService_DisableGroup_Call) Return(_a0 *model.GroupResult, _a1 error) *GroupService_DisableGroup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GroupService_DisableGroup_Call) RunAndReturn(run func(string) (*model.GroupResult, error)) *GroupService_DisableGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// EnableGroup provides a mock function with given fields: id
+func (_m *GroupService) EnableGroup(id string) (*model.GroupResult, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnableGroup")
+	}
+
+	var r0 *model.GroupResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*model.GroupResult, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *model.GroupResult); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.GroupResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GroupService_EnableGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnableGroup'
+type GroupService_EnableGroup_Call struct {
+	*mock.Call
+}
+
+// EnableGroup is a helper method to define mock.On call
+//   - id string
+func (_e *GroupService_Expecter) EnableGroup(id interface{}) *GroupService_EnableGroup_Call {
+	return &GroupService_EnableGroup_Call{Call: _e.mock.On("EnableGroup", id)}
+}
+
+func (_c *GroupService_EnableGroup_Call) Run(run func(id string)) *GroupService_EnableGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *GroupService_EnableGroup_Call) Return(_a0 *model.GroupResult, _a1 error) *GroupService_EnableGroup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GroupService_EnableGroup_Call) RunAndReturn(run func(string) (*model.GroupResult, error)) *GroupService_EnableGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAll provides a mock function with no fields
+func (_m *GroupService) GetAll() ([]model.Group, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAll")
+	}
+
+	var r0 []model.Group
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]model.Group, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []model.Group); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Group)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GroupService_GetAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAll'
+type GroupService_GetAll_Call struct {
+	*mock.Call
+}
+
+// GetAll is a helper method to define mock.On call
+func (_e *GroupService_Expecter) GetAll() *GroupService_GetAll_Call {
+	return &GroupService_GetAll_Call{Call: _e.mock.On("GetAll")}
+}
+
+func (_c *GroupService_GetAll_Call) Run(run func()) *GroupService_GetAll_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *GroupService_GetAll_Call) Return(_a0 []model.Group, _a1 error) *GroupService_GetAll_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GroupService_GetAll_Call) RunAndReturn(run func() ([]model.Group, error)) *GroupService_GetAll_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetGroup provides a mock function with given fields: id
+func (_m *GroupService) GetGroup(id string) (*model.Group, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGroup")
+	}
+
+	var r0 *model.Group
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*model.Group, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *model.Group); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Group)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GroupService_GetGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGroup'
+type GroupService_GetGroup_Call struct {
+	*mock.Call
+}
+
+// GetGroup is a helper method to define mock.On call
+//   - id string
+func (_e *GroupService_Expecter) GetGroup(id interface{}) *GroupService_GetGroup_Call {
+	return &GroupService_GetGroup_Call{Call: _e.mock.On("GetGroup", id)}
+}
+
+func (_c *GroupService_GetGroup_Call) Run(run func(id string)) *GroupService_GetGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *GroupService_GetGroup_Call) Return(_a0 *model.Group, _a1 error) *GroupService_GetGroup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GroupService_GetGroup_Call) RunAndReturn(run func(string) (*model.Group, error)) *GroupService_GetGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetProfileForGroup provides a mock function with given fields: id, profileID
+func (_m *GroupService) SetProfileForGroup(id string, profileID int64) (*model.GroupResult, error) {
+	ret := _m.Called(id, profileID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetProfileForGroup")
+	}
+
+	var r0 *model.GroupResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int64) (*model.GroupResult, error)); ok {
+		return rf(id, profileID)
+	}
+	if rf, ok := ret.Get(0).(func(string, int64) *model.GroupResult); ok {
+		r0 = rf(id, profileID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.GroupResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int64) error); ok {
+		r1 = rf(id, profileID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GroupService_SetProfileForGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetProfileForGroup'
+type GroupService_SetProfileForGroup_Call struct {
+	*mock.Call
+}
+
+// SetProfileForGroup is a helper method to define mock.On call
+//   - id string
+//   - profileID int64
+func (_e *GroupService_Expecter) SetProfileForGroup(id interface{}, profileID interface{}) *GroupService_SetProfileForGroup_Call {
+	return &GroupService_SetProfileForGroup_Call{Call: _e.mock.On("SetProfileForGroup", id, profileID)}
+}
+
+func (_c *GroupService_SetProfileForGroup_Call) Run(run func(id string, profileID int64)) *GroupService_SetProfileForGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *GroupService_SetProfileForGroup_Call) Return(_a0 *model.GroupResult, _a1 error) *GroupService_SetProfileForGroup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GroupService_SetProfileForGroup_Call) RunAndReturn(run func(string, int64) (*model.GroupResult, error)) *GroupService_SetProfileForGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StripIDs provides a mock function with given fields: id
+func (_m *GroupService) StripIDs(id string) ([]int64, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StripIDs")
+	}
+
+	var r0 []int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]int64, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) []int64); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GroupService_StripIDs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StripIDs'
+type GroupService_StripIDs_Call struct {
+	*mock.Call
+}
+
+// StripIDs is a helper method to define mock.On call
+//   - id string
+func (_e *GroupService_Expecter) StripIDs(id interface{}) *GroupService_StripIDs_Call {
+	return &GroupService_StripIDs_Call{Call: _e.mock.On("StripIDs", id)}
+}
+
+func (_c *GroupService_StripIDs_Call) Run(run func(id string)) *GroupService_StripIDs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *GroupService_StripIDs_Call) Return(_a0 []int64, _a1 error) *GroupService_StripIDs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GroupService_StripIDs_Call) RunAndReturn(run func(string) ([]int64, error)) *GroupService_StripIDs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateGroup provides a mock function with given fields: id, updMdl
+func (_m *GroupService) UpdateGroup(id string, updMdl model.Group) (*model.Group, error) {
+	ret := _m.Called(id, updMdl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateGroup")
+	}
+
+	var r0 *model.Group
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, model.Group) (*model.Group, error)); ok {
+		return rf(id, updMdl)
+	}
+	if rf, ok := ret.Get(0).(func(string, model.Group) *model.Group); ok {
+		r0 = rf(id, updMdl)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Group)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, model.Group) error); ok {
+		r1 = rf(id, updMdl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GroupService_UpdateGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateGroup'
+type GroupService_UpdateGroup_Call struct {
+	*mock.Call
+}
+
+// UpdateGroup is a helper method to define mock.On call
+//   - id string
+//   - updMdl model.Group
+func (_e *GroupService_Expecter) UpdateGroup(id interface{}, updMdl interface{}) *GroupService_UpdateGroup_Call {
+	return &GroupService_UpdateGroup_Call{Call: _e.mock.On("UpdateGroup", id, updMdl)}
+}
+
+func (_c *GroupService_UpdateGroup_Call) Run(run func(id string, updMdl model.Group)) *GroupService_UpdateGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(model.Group))
+	})
+	return _c
+}
+
+func (_c *GroupService_UpdateGroup_Call) Return(_a0 *model.Group, _a1 error) *GroupService_UpdateGroup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GroupService_UpdateGroup_Call) RunAndReturn(run func(string, model.Group) (*model.Group, error)) *GroupService_UpdateGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WithActor provides a mock function with given fields: actor
+func (_m *GroupService) WithActor(actor string) service.GroupService {
+	ret := _m.Called(actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WithActor")
+	}
+
+	var r0 service.GroupService
+	if rf, ok := ret.Get(0).(func(string) service.GroupService); ok {
+		r0 = rf(actor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(service.GroupService)
+		}
+	}
+
+	return r0
+}
+
+// GroupService_WithActor_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WithActor'
+type GroupService_WithActor_Call struct {
+	*mock.Call
+}
+
+// WithActor is a helper method to define mock.On call
+//   - actor string
+func (_e *GroupService_Expecter) WithActor(actor interface{}) *GroupService_WithActor_Call {
+	return &GroupService_WithActor_Call{Call: _e.mock.On("WithActor", actor)}
+}
+
+func (_c *GroupService_WithActor_Call) Run(run func(actor string)) *GroupService_WithActor_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *GroupService_WithActor_Call) Return(_a0 service.GroupService) *GroupService_WithActor_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GroupService_WithActor_Call) RunAndReturn(run func(string) service.GroupService) *GroupService_WithActor_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewGroupService creates a new instance of GroupService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewGroupService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *GroupService {
+	mock := &GroupService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
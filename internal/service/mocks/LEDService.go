@@ -1,9 +1,10 @@
-// Code generated by mockery v2.36.0. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package servicemocks
 
 import (
 	model "github.com/pthum/stripcontrol-golang/internal/model"
+	service "github.com/pthum/stripcontrol-golang/internal/service"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -20,10 +21,118 @@ func (_m *LEDService) EXPECT() *LEDService_Expecter {
 	return &LEDService_Expecter{mock: &_m.Mock}
 }
 
+// ApplyStates provides a mock function with given fields: states
+func (_m *LEDService) ApplyStates(states []model.StripState) ([]model.LedStrip, error) {
+	ret := _m.Called(states)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ApplyStates")
+	}
+
+	var r0 []model.LedStrip
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]model.StripState) ([]model.LedStrip, error)); ok {
+		return rf(states)
+	}
+	if rf, ok := ret.Get(0).(func([]model.StripState) []model.LedStrip); ok {
+		r0 = rf(states)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.LedStrip)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]model.StripState) error); ok {
+		r1 = rf(states)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LEDService_ApplyStates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ApplyStates'
+type LEDService_ApplyStates_Call struct {
+	*mock.Call
+}
+
+// ApplyStates is a helper method to define mock.On call
+//   - states []model.StripState
+func (_e *LEDService_Expecter) ApplyStates(states interface{}) *LEDService_ApplyStates_Call {
+	return &LEDService_ApplyStates_Call{Call: _e.mock.On("ApplyStates", states)}
+}
+
+func (_c *LEDService_ApplyStates_Call) Run(run func(states []model.StripState)) *LEDService_ApplyStates_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]model.StripState))
+	})
+	return _c
+}
+
+func (_c *LEDService_ApplyStates_Call) Return(_a0 []model.LedStrip, _a1 error) *LEDService_ApplyStates_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LEDService_ApplyStates_Call) RunAndReturn(run func([]model.StripState) ([]model.LedStrip, error)) *LEDService_ApplyStates_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CancelTimer provides a mock function with given fields: id
+func (_m *LEDService) CancelTimer(id string) error {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelTimer")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LEDService_CancelTimer_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CancelTimer'
+type LEDService_CancelTimer_Call struct {
+	*mock.Call
+}
+
+// CancelTimer is a helper method to define mock.On call
+//   - id string
+func (_e *LEDService_Expecter) CancelTimer(id interface{}) *LEDService_CancelTimer_Call {
+	return &LEDService_CancelTimer_Call{Call: _e.mock.On("CancelTimer", id)}
+}
+
+func (_c *LEDService_CancelTimer_Call) Run(run func(id string)) *LEDService_CancelTimer_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *LEDService_CancelTimer_Call) Return(_a0 error) *LEDService_CancelTimer_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *LEDService_CancelTimer_Call) RunAndReturn(run func(string) error) *LEDService_CancelTimer_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateLEDStrip provides a mock function with given fields: mdl
 func (_m *LEDService) CreateLEDStrip(mdl *model.LedStrip) error {
 	ret := _m.Called(mdl)
 
+	if len(ret) == 0 {
+		panic("no return value specified for CreateLEDStrip")
+	}
+
 	var r0 error
 	if rf, ok := ret.Get(0).(func(*model.LedStrip) error); ok {
 		r0 = rf(mdl)
@@ -62,13 +171,17 @@ func (_c *LEDService_CreateLEDStrip_Call) RunAndReturn(run func(*model.LedStrip)
 	return _c
 }
 
-// DeleteLEDStrip provides a mock function with given fields: id
-func (_m *LEDService) DeleteLEDStrip(id string) error {
-	ret := _m.Called(id)
+// DeleteLEDStrip provides a mock function with given fields: id, version
+func (_m *LEDService) DeleteLEDStrip(id string, version int64) error {
+	ret := _m.Called(id, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteLEDStrip")
+	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(string, int64) error); ok {
+		r0 = rf(id, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -83,13 +196,14 @@ type LEDService_DeleteLEDStrip_Call struct {
 
 // DeleteLEDStrip is a helper method to define mock.On call
 //   - id string
-func (_e *LEDService_Expecter) DeleteLEDStrip(id interface{}) *LEDService_DeleteLEDStrip_Call {
-	return &LEDService_DeleteLEDStrip_Call{Call: _e.mock.On("DeleteLEDStrip", id)}
+//   - version int64
+func (_e *LEDService_Expecter) DeleteLEDStrip(id interface{}, version interface{}) *LEDService_DeleteLEDStrip_Call {
+	return &LEDService_DeleteLEDStrip_Call{Call: _e.mock.On("DeleteLEDStrip", id, version)}
 }
 
-func (_c *LEDService_DeleteLEDStrip_Call) Run(run func(id string)) *LEDService_DeleteLEDStrip_Call {
+func (_c *LEDService_DeleteLEDStrip_Call) Run(run func(id string, version int64)) *LEDService_DeleteLEDStrip_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(string), args[1].(int64))
 	})
 	return _c
 }
@@ -99,15 +213,19 @@ func (_c *LEDService_DeleteLEDStrip_Call) Return(_a0 error) *LEDService_DeleteLE
 	return _c
 }
 
-func (_c *LEDService_DeleteLEDStrip_Call) RunAndReturn(run func(string) error) *LEDService_DeleteLEDStrip_Call {
+func (_c *LEDService_DeleteLEDStrip_Call) RunAndReturn(run func(string, int64) error) *LEDService_DeleteLEDStrip_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetAll provides a mock function with given fields:
+// GetAll provides a mock function with no fields
 func (_m *LEDService) GetAll() ([]model.LedStrip, error) {
 	ret := _m.Called()
 
+	if len(ret) == 0 {
+		panic("no return value specified for GetAll")
+	}
+
 	var r0 []model.LedStrip
 	var r1 error
 	if rf, ok := ret.Get(0).(func() ([]model.LedStrip, error)); ok {
@@ -161,6 +279,10 @@ func (_c *LEDService_GetAll_Call) RunAndReturn(run func() ([]model.LedStrip, err
 func (_m *LEDService) GetLEDStrip(id string) (*model.LedStrip, error) {
 	ret := _m.Called(id)
 
+	if len(ret) == 0 {
+		panic("no return value specified for GetLEDStrip")
+	}
+
 	var r0 *model.LedStrip
 	var r1 error
 	if rf, ok := ret.Get(0).(func(string) (*model.LedStrip, error)); ok {
@@ -215,6 +337,10 @@ func (_c *LEDService_GetLEDStrip_Call) RunAndReturn(run func(string) (*model.Led
 func (_m *LEDService) GetProfileForStrip(id string) (*model.ColorProfile, error) {
 	ret := _m.Called(id)
 
+	if len(ret) == 0 {
+		panic("no return value specified for GetProfileForStrip")
+	}
+
 	var r0 *model.ColorProfile
 	var r1 error
 	if rf, ok := ret.Get(0).(func(string) (*model.ColorProfile, error)); ok {
@@ -265,10 +391,169 @@ func (_c *LEDService_GetProfileForStrip_Call) RunAndReturn(run func(string) (*mo
 	return _c
 }
 
+// GetTimer provides a mock function with given fields: id
+func (_m *LEDService) GetTimer(id int64) *model.TimerInfo {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTimer")
+	}
+
+	var r0 *model.TimerInfo
+	if rf, ok := ret.Get(0).(func(int64) *model.TimerInfo); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.TimerInfo)
+		}
+	}
+
+	return r0
+}
+
+// LEDService_GetTimer_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTimer'
+type LEDService_GetTimer_Call struct {
+	*mock.Call
+}
+
+// GetTimer is a helper method to define mock.On call
+//   - id int64
+func (_e *LEDService_Expecter) GetTimer(id interface{}) *LEDService_GetTimer_Call {
+	return &LEDService_GetTimer_Call{Call: _e.mock.On("GetTimer", id)}
+}
+
+func (_c *LEDService_GetTimer_Call) Run(run func(id int64)) *LEDService_GetTimer_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *LEDService_GetTimer_Call) Return(_a0 *model.TimerInfo) *LEDService_GetTimer_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *LEDService_GetTimer_Call) RunAndReturn(run func(int64) *model.TimerInfo) *LEDService_GetTimer_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PatchLEDStrip provides a mock function with given fields: id, version, patch
+func (_m *LEDService) PatchLEDStrip(id string, version int64, patch []byte) (*model.LedStrip, error) {
+	ret := _m.Called(id, version, patch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PatchLEDStrip")
+	}
+
+	var r0 *model.LedStrip
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int64, []byte) (*model.LedStrip, error)); ok {
+		return rf(id, version, patch)
+	}
+	if rf, ok := ret.Get(0).(func(string, int64, []byte) *model.LedStrip); ok {
+		r0 = rf(id, version, patch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.LedStrip)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int64, []byte) error); ok {
+		r1 = rf(id, version, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LEDService_PatchLEDStrip_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PatchLEDStrip'
+type LEDService_PatchLEDStrip_Call struct {
+	*mock.Call
+}
+
+// PatchLEDStrip is a helper method to define mock.On call
+//   - id string
+//   - version int64
+//   - patch []byte
+func (_e *LEDService_Expecter) PatchLEDStrip(id interface{}, version interface{}, patch interface{}) *LEDService_PatchLEDStrip_Call {
+	return &LEDService_PatchLEDStrip_Call{Call: _e.mock.On("PatchLEDStrip", id, version, patch)}
+}
+
+func (_c *LEDService_PatchLEDStrip_Call) Run(run func(id string, version int64, patch []byte)) *LEDService_PatchLEDStrip_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int64), args[2].([]byte))
+	})
+	return _c
+}
+
+func (_c *LEDService_PatchLEDStrip_Call) Return(_a0 *model.LedStrip, _a1 error) *LEDService_PatchLEDStrip_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LEDService_PatchLEDStrip_Call) RunAndReturn(run func(string, int64, []byte) (*model.LedStrip, error)) *LEDService_PatchLEDStrip_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PreviewColorForStrip provides a mock function with given fields: id, color
+func (_m *LEDService) PreviewColorForStrip(id string, color model.ColorProfile) error {
+	ret := _m.Called(id, color)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PreviewColorForStrip")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, model.ColorProfile) error); ok {
+		r0 = rf(id, color)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LEDService_PreviewColorForStrip_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PreviewColorForStrip'
+type LEDService_PreviewColorForStrip_Call struct {
+	*mock.Call
+}
+
+// PreviewColorForStrip is a helper method to define mock.On call
+//   - id string
+//   - color model.ColorProfile
+func (_e *LEDService_Expecter) PreviewColorForStrip(id interface{}, color interface{}) *LEDService_PreviewColorForStrip_Call {
+	return &LEDService_PreviewColorForStrip_Call{Call: _e.mock.On("PreviewColorForStrip", id, color)}
+}
+
+func (_c *LEDService_PreviewColorForStrip_Call) Run(run func(id string, color model.ColorProfile)) *LEDService_PreviewColorForStrip_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(model.ColorProfile))
+	})
+	return _c
+}
+
+func (_c *LEDService_PreviewColorForStrip_Call) Return(_a0 error) *LEDService_PreviewColorForStrip_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *LEDService_PreviewColorForStrip_Call) RunAndReturn(run func(string, model.ColorProfile) error) *LEDService_PreviewColorForStrip_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // RemoveProfileForStrip provides a mock function with given fields: id
 func (_m *LEDService) RemoveProfileForStrip(id string) error {
 	ret := _m.Called(id)
 
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveProfileForStrip")
+	}
+
 	var r0 error
 	if rf, ok := ret.Get(0).(func(string) error); ok {
 		r0 = rf(id)
@@ -307,18 +592,152 @@ func (_c *LEDService_RemoveProfileForStrip_Call) RunAndReturn(run func(string) e
 	return _c
 }
 
+// SetColorForStrip provides a mock function with given fields: id, color
+func (_m *LEDService) SetColorForStrip(id string, color model.ColorProfile) (*model.ColorProfile, error) {
+	ret := _m.Called(id, color)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetColorForStrip")
+	}
+
+	var r0 *model.ColorProfile
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, model.ColorProfile) (*model.ColorProfile, error)); ok {
+		return rf(id, color)
+	}
+	if rf, ok := ret.Get(0).(func(string, model.ColorProfile) *model.ColorProfile); ok {
+		r0 = rf(id, color)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ColorProfile)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, model.ColorProfile) error); ok {
+		r1 = rf(id, color)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LEDService_SetColorForStrip_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetColorForStrip'
+type LEDService_SetColorForStrip_Call struct {
+	*mock.Call
+}
+
+// SetColorForStrip is a helper method to define mock.On call
+//   - id string
+//   - color model.ColorProfile
+func (_e *LEDService_Expecter) SetColorForStrip(id interface{}, color interface{}) *LEDService_SetColorForStrip_Call {
+	return &LEDService_SetColorForStrip_Call{Call: _e.mock.On("SetColorForStrip", id, color)}
+}
+
+func (_c *LEDService_SetColorForStrip_Call) Run(run func(id string, color model.ColorProfile)) *LEDService_SetColorForStrip_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(model.ColorProfile))
+	})
+	return _c
+}
+
+func (_c *LEDService_SetColorForStrip_Call) Return(_a0 *model.ColorProfile, _a1 error) *LEDService_SetColorForStrip_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LEDService_SetColorForStrip_Call) RunAndReturn(run func(string, model.ColorProfile) (*model.ColorProfile, error)) *LEDService_SetColorForStrip_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetTimer provides a mock function with given fields: id, req
+func (_m *LEDService) SetTimer(id string, req model.TimerRequest) (*model.TimerInfo, error) {
+	ret := _m.Called(id, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetTimer")
+	}
+
+	var r0 *model.TimerInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, model.TimerRequest) (*model.TimerInfo, error)); ok {
+		return rf(id, req)
+	}
+	if rf, ok := ret.Get(0).(func(string, model.TimerRequest) *model.TimerInfo); ok {
+		r0 = rf(id, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.TimerInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, model.TimerRequest) error); ok {
+		r1 = rf(id, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LEDService_SetTimer_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetTimer'
+type LEDService_SetTimer_Call struct {
+	*mock.Call
+}
+
+// SetTimer is a helper method to define mock.On call
+//   - id string
+//   - req model.TimerRequest
+func (_e *LEDService_Expecter) SetTimer(id interface{}, req interface{}) *LEDService_SetTimer_Call {
+	return &LEDService_SetTimer_Call{Call: _e.mock.On("SetTimer", id, req)}
+}
+
+func (_c *LEDService_SetTimer_Call) Run(run func(id string, req model.TimerRequest)) *LEDService_SetTimer_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(model.TimerRequest))
+	})
+	return _c
+}
+
+func (_c *LEDService_SetTimer_Call) Return(_a0 *model.TimerInfo, _a1 error) *LEDService_SetTimer_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LEDService_SetTimer_Call) RunAndReturn(run func(string, model.TimerRequest) (*model.TimerInfo, error)) *LEDService_SetTimer_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateLEDStrip provides a mock function with given fields: id, updMdl
-func (_m *LEDService) UpdateLEDStrip(id string, updMdl model.LedStrip) error {
+func (_m *LEDService) UpdateLEDStrip(id string, updMdl model.LedStrip) (*model.LedStrip, error) {
 	ret := _m.Called(id, updMdl)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string, model.LedStrip) error); ok {
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateLEDStrip")
+	}
+
+	var r0 *model.LedStrip
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, model.LedStrip) (*model.LedStrip, error)); ok {
+		return rf(id, updMdl)
+	}
+	if rf, ok := ret.Get(0).(func(string, model.LedStrip) *model.LedStrip); ok {
 		r0 = rf(id, updMdl)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.LedStrip)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(string, model.LedStrip) error); ok {
+		r1 = rf(id, updMdl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // LEDService_UpdateLEDStrip_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateLEDStrip'
@@ -340,12 +759,12 @@ func (_c *LEDService_UpdateLEDStrip_Call) Run(run func(id string, updMdl model.L
 	return _c
 }
 
-func (_c *LEDService_UpdateLEDStrip_Call) Return(_a0 error) *LEDService_UpdateLEDStrip_Call {
-	_c.Call.Return(_a0)
+func (_c *LEDService_UpdateLEDStrip_Call) Return(_a0 *model.LedStrip, _a1 error) *LEDService_UpdateLEDStrip_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *LEDService_UpdateLEDStrip_Call) RunAndReturn(run func(string, model.LedStrip) error) *LEDService_UpdateLEDStrip_Call {
+func (_c *LEDService_UpdateLEDStrip_Call) RunAndReturn(run func(string, model.LedStrip) (*model.LedStrip, error)) *LEDService_UpdateLEDStrip_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -354,6 +773,10 @@ func (_c *LEDService_UpdateLEDStrip_Call) RunAndReturn(run func(string, model.Le
 func (_m *LEDService) UpdateProfileForStrip(id string, updProf model.ColorProfile) (*model.ColorProfile, error) {
 	ret := _m.Called(id, updProf)
 
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateProfileForStrip")
+	}
+
 	var r0 *model.ColorProfile
 	var r1 error
 	if rf, ok := ret.Get(0).(func(string, model.ColorProfile) (*model.ColorProfile, error)); ok {
@@ -405,6 +828,54 @@ func (_c *LEDService_UpdateProfileForStrip_Call) RunAndReturn(run func(string, m
 	return _c
 }
 
+// WithActor provides a mock function with given fields: actor
+func (_m *LEDService) WithActor(actor string) service.LEDService {
+	ret := _m.Called(actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WithActor")
+	}
+
+	var r0 service.LEDService
+	if rf, ok := ret.Get(0).(func(string) service.LEDService); ok {
+		r0 = rf(actor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(service.LEDService)
+		}
+	}
+
+	return r0
+}
+
+// LEDService_WithActor_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WithActor'
+type LEDService_WithActor_Call struct {
+	*mock.Call
+}
+
+// WithActor is a helper method to define mock.On call
+//   - actor string
+func (_e *LEDService_Expecter) WithActor(actor interface{}) *LEDService_WithActor_Call {
+	return &LEDService_WithActor_Call{Call: _e.mock.On("WithActor", actor)}
+}
+
+func (_c *LEDService_WithActor_Call) Run(run func(actor string)) *LEDService_WithActor_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *LEDService_WithActor_Call) Return(_a0 service.LEDService) *LEDService_WithActor_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *LEDService_WithActor_Call) RunAndReturn(run func(string) service.LEDService) *LEDService_WithActor_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewLEDService creates a new instance of LEDService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewLEDService(t interface {
@@ -0,0 +1,455 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package servicemocks
+
+import (
+	model "github.com/pthum/stripcontrol-golang/internal/model"
+	service "github.com/pthum/stripcontrol-golang/internal/service"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SceneService is an autogenerated mock type for the SceneService type
+type SceneService struct {
+	mock.Mock
+}
+
+type SceneService_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *SceneService) EXPECT() *SceneService_Expecter {
+	return &SceneService_Expecter{mock: &_m.Mock}
+}
+
+// ActivateScene provides a mock function with given fields: id
+func (_m *SceneService) ActivateScene(id string) ([]model.LedStrip, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ActivateScene")
+	}
+
+	var r0 []model.LedStrip
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]model.LedStrip, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) []model.LedStrip); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.LedStrip)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SceneService_ActivateScene_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ActivateScene'
+type SceneService_ActivateScene_Call struct {
+	*mock.Call
+}
+
+// ActivateScene is a helper method to define mock.On call
+//   - id string
+func (_e *SceneService_Expecter) ActivateScene(id interface{}) *SceneService_ActivateScene_Call {
+	return &SceneService_ActivateScene_Call{Call: _e.mock.On("ActivateScene", id)}
+}
+
+func (_c *SceneService_ActivateScene_Call) Run(run func(id string)) *SceneService_ActivateScene_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *SceneService_ActivateScene_Call) Return(_a0 []model.LedStrip, _a1 error) *SceneService_ActivateScene_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SceneService_ActivateScene_Call) RunAndReturn(run func(string) ([]model.LedStrip, error)) *SceneService_ActivateScene_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CaptureScene provides a mock function with given fields: mdl
+func (_m *SceneService) CaptureScene(mdl *model.Scene) error {
+	ret := _m.Called(mdl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CaptureScene")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Scene) error); ok {
+		r0 = rf(mdl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SceneService_CaptureScene_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CaptureScene'
+type SceneService_CaptureScene_Call struct {
+	*mock.Call
+}
+
+// CaptureScene is a helper method to define mock.On call
+//   - mdl *model.Scene
+func (_e *SceneService_Expecter) CaptureScene(mdl interface{}) *SceneService_CaptureScene_Call {
+	return &SceneService_CaptureScene_Call{Call: _e.mock.On("CaptureScene", mdl)}
+}
+
+func (_c *SceneService_CaptureScene_Call) Run(run func(mdl *model.Scene)) *SceneService_CaptureScene_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.Scene))
+	})
+	return _c
+}
+
+func (_c *SceneService_CaptureScene_Call) Return(_a0 error) *SceneService_CaptureScene_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *SceneService_CaptureScene_Call) RunAndReturn(run func(*model.Scene) error) *SceneService_CaptureScene_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateScene provides a mock function with given fields: mdl
+func (_m *SceneService) CreateScene(mdl *model.Scene) error {
+	ret := _m.Called(mdl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateScene")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Scene) error); ok {
+		r0 = rf(mdl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SceneService_CreateScene_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateScene'
+type SceneService_CreateScene_Call struct {
+	*mock.Call
+}
+
+// CreateScene is a helper method to define mock.On call
+//   - mdl *model.Scene
+func (_e *SceneService_Expecter) CreateScene(mdl interface{}) *SceneService_CreateScene_Call {
+	return &SceneService_CreateScene_Call{Call: _e.mock.On("CreateScene", mdl)}
+}
+
+func (_c *SceneService_CreateScene_Call) Run(run func(mdl *model.Scene)) *SceneService_CreateScene_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.Scene))
+	})
+	return _c
+}
+
+func (_c *SceneService_CreateScene_Call) Return(_a0 error) *SceneService_CreateScene_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *SceneService_CreateScene_Call) RunAndReturn(run func(*model.Scene) error) *SceneService_CreateScene_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteScene provides a mock function with given fields: id, version
+func (_m *SceneService) DeleteScene(id string, version int64) error {
+	ret := _m.Called(id, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteScene")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int64) error); ok {
+		r0 = rf(id, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SceneService_DeleteScene_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteScene'
+type SceneService_DeleteScene_Call struct {
+	*mock.Call
+}
+
+// DeleteScene is a helper method to define mock.On call
+//   - id string
+//   - version int64
+func (_e *SceneService_Expecter) DeleteScene(id interface{}, version interface{}) *SceneService_DeleteScene_Call {
+	return &SceneService_DeleteScene_Call{Call: _e.mock.On("DeleteScene", id, version)}
+}
+
+func (_c *SceneService_DeleteScene_Call) Run(run func(id string, version int64)) *SceneService_DeleteScene_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *SceneService_DeleteScene_Call) Return(_a0 error) *SceneService_DeleteScene_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *SceneService_DeleteScene_Call) RunAndReturn(run func(string, int64) error) *SceneService_DeleteScene_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAll provides a mock function with no fields
+func (_m *SceneService) GetAll() ([]model.Scene, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAll")
+	}
+
+	var r0 []model.Scene
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]model.Scene, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []model.Scene); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Scene)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SceneService_GetAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAll'
+type SceneService_GetAll_Call struct {
+	*mock.Call
+}
+
+// GetAll is a helper method to define mock.On call
+func (_e *SceneService_Expecter) GetAll() *SceneService_GetAll_Call {
+	return &SceneService_GetAll_Call{Call: _e.mock.On("GetAll")}
+}
+
+func (_c *SceneService_GetAll_Call) Run(run func()) *SceneService_GetAll_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *SceneService_GetAll_Call) Return(_a0 []model.Scene, _a1 error) *SceneService_GetAll_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SceneService_GetAll_Call) RunAndReturn(run func() ([]model.Scene, error)) *SceneService_GetAll_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetScene provides a mock function with given fields: id
+func (_m *SceneService) GetScene(id string) (*model.Scene, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetScene")
+	}
+
+	var r0 *model.Scene
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*model.Scene, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *model.Scene); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Scene)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SceneService_GetScene_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetScene'
+type SceneService_GetScene_Call struct {
+	*mock.Call
+}
+
+// GetScene is a helper method to define mock.On call
+//   - id string
+func (_e *SceneService_Expecter) GetScene(id interface{}) *SceneService_GetScene_Call {
+	return &SceneService_GetScene_Call{Call: _e.mock.On("GetScene", id)}
+}
+
+func (_c *SceneService_GetScene_Call) Run(run func(id string)) *SceneService_GetScene_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *SceneService_GetScene_Call) Return(_a0 *model.Scene, _a1 error) *SceneService_GetScene_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SceneService_GetScene_Call) RunAndReturn(run func(string) (*model.Scene, error)) *SceneService_GetScene_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateScene provides a mock function with given fields: id, updMdl
+func (_m *SceneService) UpdateScene(id string, updMdl model.Scene) (*model.Scene, error) {
+	ret := _m.Called(id, updMdl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateScene")
+	}
+
+	var r0 *model.Scene
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, model.Scene) (*model.Scene, error)); ok {
+		return rf(id, updMdl)
+	}
+	if rf, ok := ret.Get(0).(func(string, model.Scene) *model.Scene); ok {
+		r0 = rf(id, updMdl)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Scene)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, model.Scene) error); ok {
+		r1 = rf(id, updMdl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SceneService_UpdateScene_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateScene'
+type SceneService_UpdateScene_Call struct {
+	*mock.Call
+}
+
+// UpdateScene is a helper method to define mock.On call
+//   - id string
+//   - updMdl model.Scene
+func (_e *SceneService_Expecter) UpdateScene(id interface{}, updMdl interface{}) *SceneService_UpdateScene_Call {
+	return &SceneService_UpdateScene_Call{Call: _e.mock.On("UpdateScene", id, updMdl)}
+}
+
+func (_c *SceneService_UpdateScene_Call) Run(run func(id string, updMdl model.Scene)) *SceneService_UpdateScene_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(model.Scene))
+	})
+	return _c
+}
+
+func (_c *SceneService_UpdateScene_Call) Return(_a0 *model.Scene, _a1 error) *SceneService_UpdateScene_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SceneService_UpdateScene_Call) RunAndReturn(run func(string, model.Scene) (*model.Scene, error)) *SceneService_UpdateScene_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WithActor provides a mock function with given fields: actor
+func (_m *SceneService) WithActor(actor string) service.SceneService {
+	ret := _m.Called(actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WithActor")
+	}
+
+	var r0 service.SceneService
+	if rf, ok := ret.Get(0).(func(string) service.SceneService); ok {
+		r0 = rf(actor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(service.SceneService)
+		}
+	}
+
+	return r0
+}
+
+// SceneService_WithActor_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WithActor'
+type SceneService_WithActor_Call struct {
+	*mock.Call
+}
+
+// WithActor is a helper method to define mock.On call
+//   - actor string
+func (_e *SceneService_Expecter) WithActor(actor interface{}) *SceneService_WithActor_Call {
+	return &SceneService_WithActor_Call{Call: _e.mock.On("WithActor", actor)}
+}
+
+func (_c *SceneService_WithActor_Call) Run(run func(actor string)) *SceneService_WithActor_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *SceneService_WithActor_Call) Return(_a0 service.SceneService) *SceneService_WithActor_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *SceneService_WithActor_Call) RunAndReturn(run func(string) service.SceneService) *SceneService_WithActor_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewSceneService creates a new instance of SceneService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSceneService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SceneService {
+	mock := &SceneService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,340 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package servicemocks
+
+import (
+	model "github.com/pthum/stripcontrol-golang/internal/model"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// ScheduleService is an autogenerated mock type for the ScheduleService type
+type ScheduleService struct {
+	mock.Mock
+}
+
+type ScheduleService_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *ScheduleService) EXPECT() *ScheduleService_Expecter {
+	return &ScheduleService_Expecter{mock: &_m.Mock}
+}
+
+// CreateSchedule provides a mock function with given fields: mdl
+func (_m *ScheduleService) CreateSchedule(mdl *model.Schedule) error {
+	ret := _m.Called(mdl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateSchedule")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Schedule) error); ok {
+		r0 = rf(mdl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ScheduleService_CreateSchedule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateSchedule'
+type ScheduleService_CreateSchedule_Call struct {
+	*mock.Call
+}
+
+// CreateSchedule is a helper method to define mock.On call
+//   - mdl *model.Schedule
+func (_e *ScheduleService_Expecter) CreateSchedule(mdl interface{}) *ScheduleService_CreateSchedule_Call {
+	return &ScheduleService_CreateSchedule_Call{Call: _e.mock.On("CreateSchedule", mdl)}
+}
+
+func (_c *ScheduleService_CreateSchedule_Call) Run(run func(mdl *model.Schedule)) *ScheduleService_CreateSchedule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.Schedule))
+	})
+	return _c
+}
+
+func (_c *ScheduleService_CreateSchedule_Call) Return(_a0 error) *ScheduleService_CreateSchedule_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ScheduleService_CreateSchedule_Call) RunAndReturn(run func(*model.Schedule) error) *ScheduleService_CreateSchedule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteSchedule provides a mock function with given fields: id, version
+func (_m *ScheduleService) DeleteSchedule(id string, version int64) error {
+	ret := _m.Called(id, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSchedule")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int64) error); ok {
+		r0 = rf(id, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ScheduleService_DeleteSchedule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteSchedule'
+type ScheduleService_DeleteSchedule_Call struct {
+	*mock.Call
+}
+
+// DeleteSchedule is a helper method to define mock.On call
+//   - id string
+//   - version int64
+func (_e *ScheduleService_Expecter) DeleteSchedule(id interface{}, version interface{}) *ScheduleService_DeleteSchedule_Call {
+	return &ScheduleService_DeleteSchedule_Call{Call: _e.mock.On("DeleteSchedule", id, version)}
+}
+
+func (_c *ScheduleService_DeleteSchedule_Call) Run(run func(id string, version int64)) *ScheduleService_DeleteSchedule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *ScheduleService_DeleteSchedule_Call) Return(_a0 error) *ScheduleService_DeleteSchedule_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ScheduleService_DeleteSchedule_Call) RunAndReturn(run func(string, int64) error) *ScheduleService_DeleteSchedule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAll provides a mock function with no fields
+func (_m *ScheduleService) GetAll() ([]model.ScheduleInfo, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAll")
+	}
+
+	var r0 []model.ScheduleInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]model.ScheduleInfo, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []model.ScheduleInfo); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.ScheduleInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ScheduleService_GetAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAll'
+type ScheduleService_GetAll_Call struct {
+	*mock.Call
+}
+
+// GetAll is a helper method to define mock.On call
+func (_e *ScheduleService_Expecter) GetAll() *ScheduleService_GetAll_Call {
+	return &ScheduleService_GetAll_Call{Call: _e.mock.On("GetAll")}
+}
+
+func (_c *ScheduleService_GetAll_Call) Run(run func()) *ScheduleService_GetAll_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *ScheduleService_GetAll_Call) Return(_a0 []model.ScheduleInfo, _a1 error) *ScheduleService_GetAll_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *ScheduleService_GetAll_Call) RunAndReturn(run func() ([]model.ScheduleInfo, error)) *ScheduleService_GetAll_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSchedule provides a mock function with given fields: id
+func (_m *ScheduleService) GetSchedule(id string) (*model.ScheduleInfo, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSchedule")
+	}
+
+	var r0 *model.ScheduleInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*model.ScheduleInfo, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *model.ScheduleInfo); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ScheduleInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ScheduleService_GetSchedule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSchedule'
+type ScheduleService_GetSchedule_Call struct {
+	*mock.Call
+}
+
+// GetSchedule is a helper method to define mock.On call
+//   - id string
+func (_e *ScheduleService_Expecter) GetSchedule(id interface{}) *ScheduleService_GetSchedule_Call {
+	return &ScheduleService_GetSchedule_Call{Call: _e.mock.On("GetSchedule", id)}
+}
+
+func (_c *ScheduleService_GetSchedule_Call) Run(run func(id string)) *ScheduleService_GetSchedule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *ScheduleService_GetSchedule_Call) Return(_a0 *model.ScheduleInfo, _a1 error) *ScheduleService_GetSchedule_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *ScheduleService_GetSchedule_Call) RunAndReturn(run func(string) (*model.ScheduleInfo, error)) *ScheduleService_GetSchedule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NextRun provides a mock function with given fields: id
+func (_m *ScheduleService) NextRun(id int64) *time.Time {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NextRun")
+	}
+
+	var r0 *time.Time
+	if rf, ok := ret.Get(0).(func(int64) *time.Time); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*time.Time)
+		}
+	}
+
+	return r0
+}
+
+// ScheduleService_NextRun_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NextRun'
+type ScheduleService_NextRun_Call struct {
+	*mock.Call
+}
+
+// NextRun is a helper method to define mock.On call
+//   - id int64
+func (_e *ScheduleService_Expecter) NextRun(id interface{}) *ScheduleService_NextRun_Call {
+	return &ScheduleService_NextRun_Call{Call: _e.mock.On("NextRun", id)}
+}
+
+func (_c *ScheduleService_NextRun_Call) Run(run func(id int64)) *ScheduleService_NextRun_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *ScheduleService_NextRun_Call) Return(_a0 *time.Time) *ScheduleService_NextRun_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ScheduleService_NextRun_Call) RunAndReturn(run func(int64) *time.Time) *ScheduleService_NextRun_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateSchedule provides a mock function with given fields: id, updMdl
+func (_m *ScheduleService) UpdateSchedule(id string, updMdl model.Schedule) error {
+	ret := _m.Called(id, updMdl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateSchedule")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, model.Schedule) error); ok {
+		r0 = rf(id, updMdl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ScheduleService_UpdateSchedule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateSchedule'
+type ScheduleService_UpdateSchedule_Call struct {
+	*mock.Call
+}
+
+// UpdateSchedule is a helper method to define mock.On call
+//   - id string
+//   - updMdl model.Schedule
+func (_e *ScheduleService_Expecter) UpdateSchedule(id interface{}, updMdl interface{}) *ScheduleService_UpdateSchedule_Call {
+	return &ScheduleService_UpdateSchedule_Call{Call: _e.mock.On("UpdateSchedule", id, updMdl)}
+}
+
+func (_c *ScheduleService_UpdateSchedule_Call) Run(run func(id string, updMdl model.Schedule)) *ScheduleService_UpdateSchedule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(model.Schedule))
+	})
+	return _c
+}
+
+func (_c *ScheduleService_UpdateSchedule_Call) Return(_a0 error) *ScheduleService_UpdateSchedule_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ScheduleService_UpdateSchedule_Call) RunAndReturn(run func(string, model.Schedule) error) *ScheduleService_UpdateSchedule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewScheduleService creates a new instance of ScheduleService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewScheduleService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ScheduleService {
+	mock := &ScheduleService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/validation"
+	"github.com/samber/do"
+)
+
+//go:generate mockery --name=SceneService --with-expecter=true --outpkg=servicemocks
+type SceneService interface {
+	// WithActor returns the service, which publishes the events of the activations as caused by the actor
+	WithActor(actor string) SceneService
+	GetAll() ([]model.Scene, error)
+	GetScene(id string) (*model.Scene, error)
+	CreateScene(mdl *model.Scene) error
+	// UpdateScene replaces the scene and returns the stored scene, a version other than 0 on the input has to
+	// match the current version
+	UpdateScene(id string, updMdl model.Scene) (*model.Scene, error)
+	// DeleteScene deletes the scene, a version other than 0 has to match the current version
+	DeleteScene(id string, version int64) error
+	// ActivateScene applies the states of the scene to its strips, either all of them or none are applied
+	ActivateScene(id string) ([]model.LedStrip, error)
+	// CaptureScene fills the states of the scene with the current state of its strips and creates it, all strips
+	// are captured if it doesn't contain any
+	CaptureScene(mdl *model.Scene) error
+}
+
+type sceneSvc struct {
+	dbh   database.DBHandler[model.Scene]
+	lsDbh database.DBHandler[model.LedStrip]
+	cpDbh database.DBHandler[model.ColorProfile]
+	lsvc  LEDService
+	actor string
+	l     alog.Logger
+}
+
+func NewSceneService(i *do.Injector) (SceneService, error) {
+	return &sceneSvc{
+		dbh:   do.MustInvoke[database.DBHandler[model.Scene]](i),
+		lsDbh: do.MustInvoke[database.DBHandler[model.LedStrip]](i),
+		cpDbh: do.MustInvoke[database.DBHandler[model.ColorProfile]](i),
+		lsvc:  do.MustInvoke[LEDService](i),
+		l:     alog.NewLogger("sceneservice"),
+	}, nil
+}
+
+func (s *sceneSvc) WithActor(actor string) SceneService {
+	svc := *s
+	svc.actor = actor
+	return &svc
+}
+
+func (s *sceneSvc) GetAll() ([]model.Scene, error) {
+	return s.dbh.GetAll()
+}
+
+func (s *sceneSvc) GetScene(id string) (*model.Scene, error) {
+	return s.dbh.Get(id)
+}
+
+func (s *sceneSvc) CreateScene(mdl *model.Scene) error {
+	if err := s.validate(*mdl); err != nil {
+		return err
+	}
+	if err := database.CreateWithNextID(s.dbh, mdl); err != nil {
+		return err
+	}
+	s.l.Debug("Created scene with ID %d", mdl.ID)
+	return nil
+}
+
+func (s *sceneSvc) UpdateScene(id string, updMdl model.Scene) (*model.Scene, error) {
+	if err := s.validate(updMdl); err != nil {
+		return nil, err
+	}
+	// Get model if exist
+	scene, err := s.dbh.Get(id)
+	if err != nil {
+		return nil, model.NewAppErr(404, err)
+	}
+	if err := checkVersion(updMdl.Version, scene); err != nil {
+		return nil, err
+	}
+	updMdl.ID = scene.ID
+	updMdl.Version = scene.Version
+	if err := s.dbh.Update(*scene, updMdl); err != nil {
+		return nil, updateErr(err)
+	}
+	updMdl.Version++
+	return &updMdl, nil
+}
+
+func (s *sceneSvc) DeleteScene(id string, version int64) error {
+	// Get model if exist
+	scene, err := s.dbh.Get(id)
+	if err != nil {
+		return model.NewAppErr(404, err)
+	}
+	if err := checkVersion(version, scene); err != nil {
+		return err
+	}
+	if err := s.dbh.Delete(scene); err != nil {
+		return model.NewAppErr(400, err)
+	}
+	return nil
+}
+
+func (s *sceneSvc) ActivateScene(id string) ([]model.LedStrip, error) {
+	// Get model if exist
+	scene, err := s.dbh.Get(id)
+	if err != nil {
+		return nil, model.NewAppErr(404, err)
+	}
+	strips, err := s.lsvc.WithActor(s.actor).ApplyStates(scene.Strips)
+	if err != nil {
+		return nil, err
+	}
+	s.l.Info("Activated scene %d", scene.ID)
+	return strips, nil
+}
+
+func (s *sceneSvc) CaptureScene(mdl *model.Scene) error {
+	var strips []model.LedStrip
+	if len(mdl.Strips) == 0 {
+		all, err := s.lsDbh.GetAll()
+		if err != nil {
+			return model.NewAppErr(500, err)
+		}
+		strips = all
+	} else {
+		for i, state := range mdl.Strips {
+			strip, err := s.lsDbh.Get(strconv.FormatInt(state.StripID, 10))
+			if err != nil {
+				return notFoundErr(fmt.Sprintf("strips[%d].stripId", i), "strip", state.StripID)
+			}
+			strips = append(strips, *strip)
+		}
+	}
+	states := make(model.StripStates, len(strips))
+	for i, strip := range strips {
+		states[i] = model.StripState{StripID: strip.ID, Enabled: strip.Enabled, ProfileID: strip.ProfileID}
+	}
+	mdl.Strips = states
+	return s.CreateScene(mdl)
+}
+
+// validate checks the scene and whether the referenced strips and profiles exist
+func (s *sceneSvc) validate(scene model.Scene) error {
+	if err := validation.ValidateScene(scene); err != nil {
+		return err
+	}
+	for i, state := range scene.Strips {
+		if _, err := s.lsDbh.Get(strconv.FormatInt(state.StripID, 10)); err != nil {
+			return notFoundErr(fmt.Sprintf("strips[%d].stripId", i), "strip", state.StripID)
+		}
+		if !state.ProfileID.Valid {
+			continue
+		}
+		if _, err := s.cpDbh.Get(strconv.FormatInt(state.ProfileID.Int64, 10)); err != nil {
+			return notFoundErr(fmt.Sprintf("strips[%d].profileId", i), "profile", state.ProfileID.Int64)
+		}
+	}
+	return nil
+}
+
+// notFoundErr the validation error of a reference to a missing object
+func notFoundErr(field, kind string, id int64) error {
+	return model.NewAppErr(http.StatusUnprocessableEntity, &model.ValidationError{
+		Errors: []model.FieldError{{Field: field, Message: fmt.Sprintf("%s %d not found", kind, id)}},
+	})
+}
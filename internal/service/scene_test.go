@@ -0,0 +1,242 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	dbm "github.com/pthum/stripcontrol-golang/internal/database/mocks"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type sceneMocks struct {
+	*eventMocks
+	dbh *dbm.DBHandler[model.Scene]
+	ss  *sceneSvc
+}
+
+func TestGetAllScenes(t *testing.T) {
+	mocks := createSceneMocks(t)
+	scenes := []model.Scene{*createDummyScene()}
+	mocks.dbh.EXPECT().GetAll().Return(scenes, nil).Once()
+
+	res, err := mocks.ss.GetAll()
+
+	assert.NoError(t, err)
+	assert.Equal(t, scenes, res)
+}
+
+func TestGetScene(t *testing.T) {
+	mocks := createSceneMocks(t)
+	scene := createDummyScene()
+	mocks.dbh.EXPECT().Get("7").Return(scene, nil).Once()
+
+	res, err := mocks.ss.GetScene("7")
+
+	assert.NoError(t, err)
+	assert.Equal(t, scene, res)
+}
+
+func TestCreateScene(t *testing.T) {
+	mocks := createSceneMocks(t)
+	scene := createDummyScene()
+	scene.ID = 0
+	mocks.expectDBStripGet(createValidDummyStrip())
+	mocks.expectDBProfileGet(createProfile(15, 1, 2, 3, 4), nil)
+	mocks.dbh.EXPECT().NextID().Return(8, nil).Once()
+	mocks.dbh.EXPECT().Create(mock.Anything).Return(nil).Once()
+
+	err := mocks.ss.CreateScene(scene)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8), scene.ID)
+}
+
+func TestCreateScene_Invalid(t *testing.T) {
+	mocks := createSceneMocks(t)
+
+	err := mocks.ss.CreateScene(&model.Scene{Name: " "})
+
+	var verr *model.ValidationError
+	assert.ErrorAs(t, err, &verr)
+}
+
+func TestCreateScene_MissingReferences(t *testing.T) {
+	mocks := createSceneMocks(t)
+	scene := createDummyScene()
+	mocks.lsDbh.EXPECT().Get("185").Return(nil, errors.New("not found")).Once()
+
+	err := mocks.ss.CreateScene(scene)
+
+	assertAppErrCode(t, err, http.StatusUnprocessableEntity)
+	var verr *model.ValidationError
+	if assert.ErrorAs(t, err, &verr) {
+		assert.Equal(t, "strips[0].stripId", verr.Errors[0].Field)
+	}
+
+	mocks.expectDBStripGet(createValidDummyStrip())
+	mocks.expectDBProfileGet(nil, errors.New("not found"))
+
+	err = mocks.ss.CreateScene(scene)
+
+	assertAppErrCode(t, err, http.StatusUnprocessableEntity)
+	if assert.ErrorAs(t, err, &verr) {
+		assert.Equal(t, "strips[0].profileId", verr.Errors[0].Field)
+	}
+}
+
+func TestUpdateScene(t *testing.T) {
+	mocks := createSceneMocks(t)
+	dbScene := createDummyScene()
+	dbScene.Version = 2
+	upd := *createDummyScene()
+	upd.ID = 0
+	upd.Name = "Evening"
+	upd.Version = 2
+	mocks.expectDBStripGet(createValidDummyStrip())
+	mocks.expectDBProfileGet(createProfile(15, 1, 2, 3, 4), nil)
+	mocks.dbh.EXPECT().Get("7").Return(dbScene, nil).Once()
+	expected := upd
+	expected.ID = 7
+	mocks.dbh.EXPECT().Update(*dbScene, expected).Return(nil).Once()
+
+	res, err := mocks.ss.UpdateScene("7", upd)
+
+	assert.NoError(t, err)
+	expected.Version = 3
+	assert.Equal(t, &expected, res)
+}
+
+func TestUpdateScene_Errors(t *testing.T) {
+	mocks := createSceneMocks(t)
+	upd := model.Scene{Name: "Evening"}
+	upd.Version = 1
+	mocks.dbh.EXPECT().Get("7").Return(nil, errors.New("not found")).Once()
+	_, err := mocks.ss.UpdateScene("7", upd)
+	assertAppErrCode(t, err, http.StatusNotFound)
+
+	dbScene := createDummyScene()
+	dbScene.Version = 2
+	mocks.dbh.EXPECT().Get("7").Return(dbScene, nil).Once()
+	_, err = mocks.ss.UpdateScene("7", upd)
+	assertAppErrCode(t, err, http.StatusPreconditionFailed)
+
+	upd.Version = 2
+	mocks.dbh.EXPECT().Get("7").Return(dbScene, nil).Once()
+	mocks.dbh.EXPECT().Update(mock.Anything, mock.Anything).Return(database.ErrVersionConflict).Once()
+	_, err = mocks.ss.UpdateScene("7", upd)
+	assertAppErrCode(t, err, http.StatusPreconditionFailed)
+}
+
+func TestDeleteScene(t *testing.T) {
+	mocks := createSceneMocks(t)
+	scene := createDummyScene()
+	mocks.dbh.EXPECT().Get("7").Return(scene, nil).Twice()
+	mocks.dbh.EXPECT().Delete(scene).Return(nil).Once()
+
+	assert.NoError(t, mocks.ss.DeleteScene("7", 0))
+	assertAppErrCode(t, mocks.ss.DeleteScene("7", 3), http.StatusPreconditionFailed)
+
+	mocks.dbh.EXPECT().Get("8").Return(nil, errors.New("not found")).Once()
+	assertAppErrCode(t, mocks.ss.DeleteScene("8", 0), http.StatusNotFound)
+}
+
+func TestActivateScene(t *testing.T) {
+	mocks := createSceneMocks(t)
+	scene := createDummyScene()
+	strip := createValidDummyStrip()
+	profile := createProfile(15, 1, 2, 3, 4)
+	mocks.dbh.EXPECT().Get("7").Return(scene, nil).Once()
+	mocks.expectDBStripGet(strip)
+	mocks.expectDBProfileGet(profile, nil)
+	updated := *strip
+	updated.Enabled = true
+	updated.ProfileID = null.IntFrom(15)
+	mocks.lsDbh.EXPECT().Update(*strip, updated).Return(nil).Once()
+
+	strips, err := mocks.ss.WithActor("test").ActivateScene("7")
+
+	assert.NoError(t, err)
+	updated.Version++
+	assert.Equal(t, []model.LedStrip{updated}, strips)
+}
+
+func TestActivateScene_Errors(t *testing.T) {
+	mocks := createSceneMocks(t)
+	mocks.dbh.EXPECT().Get("7").Return(nil, errors.New("not found")).Once()
+
+	_, err := mocks.ss.ActivateScene("7")
+	assertAppErrCode(t, err, http.StatusNotFound)
+
+	mocks.dbh.EXPECT().Get("7").Return(createDummyScene(), nil).Once()
+	mocks.lsDbh.EXPECT().Get("185").Return(nil, errors.New("not found")).Once()
+
+	_, err = mocks.ss.ActivateScene("7")
+	assertAppErrCode(t, err, http.StatusNotFound)
+}
+
+func TestCaptureScene(t *testing.T) {
+	mocks := createSceneMocks(t)
+	first := createValidDummyStrip()
+	first.Enabled = true
+	first.ProfileID = null.IntFrom(15)
+	second := createValidDummyStrip()
+	second.ID = 186
+	mocks.lsDbh.EXPECT().GetAll().Return([]model.LedStrip{*first, *second}, nil).Once()
+	mocks.expectDBStripGet(first)
+	mocks.expectDBStripGet(second)
+	mocks.expectDBProfileGet(createProfile(15, 1, 2, 3, 4), nil)
+	mocks.dbh.EXPECT().NextID().Return(8, nil).Once()
+	mocks.dbh.EXPECT().Create(mock.Anything).Return(nil).Once()
+	scene := &model.Scene{Name: "Now"}
+
+	err := mocks.ss.CaptureScene(scene)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8), scene.ID)
+	assert.Equal(t, model.StripStates{
+		{StripID: 185, Enabled: true, ProfileID: null.IntFrom(15)},
+		{StripID: 186},
+	}, scene.Strips)
+}
+
+func TestCaptureScene_SelectedStrips(t *testing.T) {
+	mocks := createSceneMocks(t)
+	mocks.lsDbh.EXPECT().Get("186").Return(nil, errors.New("not found")).Once()
+
+	err := mocks.ss.CaptureScene(&model.Scene{Name: "Now", Strips: model.StripStates{{StripID: 186}}})
+
+	assertAppErrCode(t, err, http.StatusUnprocessableEntity)
+}
+
+func createDummyScene() *model.Scene {
+	return &model.Scene{
+		BaseModel: model.BaseModel{ID: 7},
+		Name:      "Movie",
+		Strips: model.StripStates{
+			{StripID: 185, Enabled: true, ProfileID: null.IntFrom(15)},
+		},
+	}
+}
+
+func createSceneMocks(t *testing.T) *sceneMocks {
+	em := createEventMocks(t)
+	i := do.New()
+	dbh := dbm.NewDBHandler[model.Scene](t)
+	do.ProvideValue[database.DBHandler[model.Scene]](i, dbh)
+	do.ProvideValue[database.DBHandler[model.LedStrip]](i, em.lsDbh)
+	do.ProvideValue[database.DBHandler[model.ColorProfile]](i, em.cpDbh)
+	do.ProvideValue[LEDService](i, em.ls)
+	ss, err := NewSceneService(i)
+	assert.NoError(t, err)
+	return &sceneMocks{
+		eventMocks: em,
+		dbh:        dbh,
+		ss:         ss.(*sceneSvc),
+	}
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-co-op/gocron"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/solar"
+	"github.com/pthum/stripcontrol-golang/internal/validation"
+	"github.com/samber/do"
+)
+
+//go:generate mockery --name=ScheduleService --with-expecter=true --outpkg=servicemocks
+type ScheduleService interface {
+	GetAll() ([]model.ScheduleInfo, error)
+	GetSchedule(id string) (*model.ScheduleInfo, error)
+	// CreateSchedule creates the schedule and registers its job
+	CreateSchedule(mdl *model.Schedule) error
+	// UpdateSchedule replaces the schedule and its job, a version other than 0 on the input has to match the current
+	// version
+	UpdateSchedule(id string, updMdl model.Schedule) error
+	// DeleteSchedule deletes the schedule and unregisters its job, a version other than 0 has to match the current
+	// version
+	DeleteSchedule(id string, version int64) error
+	// NextRun the time of the next run of the schedule, nil if it has no job or the scheduler isn't running
+	NextRun(id int64) *time.Time
+}
+
+type scheduleSvc struct {
+	dbh   database.DBHandler[model.Schedule]
+	lsDbh database.DBHandler[model.LedStrip]
+	cpDbh database.DBHandler[model.ColorProfile]
+	scDbh database.DBHandler[model.Scene]
+	grDbh database.DBHandler[model.Group]
+	lsvc  LEDService
+	ss    SceneService
+	gs    GroupService
+	sched *gocron.Scheduler
+	loc   config.LocationConfig
+	now   func() time.Time
+	// mu guards the jobs of the schedules by their id
+	mu   sync.Mutex
+	jobs map[int64]*gocron.Job
+	l    alog.Logger
+}
+
+// NewScheduleService creates the service and registers the jobs of the stored schedules on the shared scheduler, the
+// next runs of the solar schedules are recomputed daily
+func NewScheduleService(i *do.Injector) (ScheduleService, error) {
+	s := &scheduleSvc{
+		dbh:   do.MustInvoke[database.DBHandler[model.Schedule]](i),
+		lsDbh: do.MustInvoke[database.DBHandler[model.LedStrip]](i),
+		cpDbh: do.MustInvoke[database.DBHandler[model.ColorProfile]](i),
+		scDbh: do.MustInvoke[database.DBHandler[model.Scene]](i),
+		grDbh: do.MustInvoke[database.DBHandler[model.Group]](i),
+		lsvc:  do.MustInvoke[LEDService](i),
+		ss:    do.MustInvoke[SceneService](i),
+		gs:    do.MustInvoke[GroupService](i),
+		sched: do.MustInvoke[*gocron.Scheduler](i),
+		loc:   do.MustInvoke[*config.Config](i).Location,
+		now:   time.Now,
+		jobs:  map[int64]*gocron.Job{},
+		l:     alog.NewLogger("scheduleservice"),
+	}
+	schedules, err := s.dbh.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, schedule := range schedules {
+		// a broken schedule must not prevent the others from running
+		if err := s.register(schedule); err != nil {
+			s.l.Error("error registering schedule %d: %s", schedule.ID, err.Error())
+		}
+	}
+	if _, err := s.sched.Every(1).Day().At("00:00").Tag("solar-schedules").Do(s.recomputeSolar); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *scheduleSvc) GetAll() ([]model.ScheduleInfo, error) {
+	schedules, err := s.dbh.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]model.ScheduleInfo, len(schedules))
+	for i, schedule := range schedules {
+		infos[i] = model.ScheduleInfo{Schedule: schedule, NextRun: s.NextRun(schedule.ID)}
+	}
+	return infos, nil
+}
+
+func (s *scheduleSvc) GetSchedule(id string) (*model.ScheduleInfo, error) {
+	schedule, err := s.dbh.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ScheduleInfo{Schedule: *schedule, NextRun: s.NextRun(schedule.ID)}, nil
+}
+
+func (s *scheduleSvc) CreateSchedule(mdl *model.Schedule) error {
+	if err := s.validate(*mdl); err != nil {
+		return err
+	}
+	if err := database.CreateWithNextID(s.dbh, mdl); err != nil {
+		return err
+	}
+	if err := s.register(*mdl); err != nil {
+		return model.NewAppErr(http.StatusInternalServerError, err)
+	}
+	s.l.Debug("Created schedule with ID %d", mdl.ID)
+	return nil
+}
+
+func (s *scheduleSvc) UpdateSchedule(id string, updMdl model.Schedule) error {
+	if err := s.validate(updMdl); err != nil {
+		return err
+	}
+	// Get model if exist
+	schedule, err := s.dbh.Get(id)
+	if err != nil {
+		return model.NewAppErr(http.StatusNotFound, err)
+	}
+	if err := checkVersion(updMdl.Version, schedule); err != nil {
+		return err
+	}
+	updMdl.ID = schedule.ID
+	updMdl.Version = schedule.Version
+	if err := s.dbh.Update(*schedule, updMdl); err != nil {
+		return updateErr(err)
+	}
+	if err := s.register(updMdl); err != nil {
+		return model.NewAppErr(http.StatusInternalServerError, err)
+	}
+	return nil
+}
+
+func (s *scheduleSvc) DeleteSchedule(id string, version int64) error {
+	// Get model if exist
+	schedule, err := s.dbh.Get(id)
+	if err != nil {
+		return model.NewAppErr(http.StatusNotFound, err)
+	}
+	if err := checkVersion(version, schedule); err != nil {
+		return err
+	}
+	if err := s.dbh.Delete(schedule); err != nil {
+		return model.NewAppErr(http.StatusBadRequest, err)
+	}
+	s.unregister(schedule.ID)
+	return nil
+}
+
+func (s *scheduleSvc) NextRun(id int64) *time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok || !s.sched.IsRunning() {
+		return nil
+	}
+	next := job.NextRun()
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// register replaces the job of the schedule
+func (s *scheduleSvc) register(schedule model.Schedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[schedule.ID]; ok {
+		s.sched.RemoveByReference(job)
+		delete(s.jobs, schedule.ID)
+	}
+	var spec string
+	if schedule.Solar == "" {
+		spec = cronSpec(schedule)
+	} else {
+		next, ok := s.nextSolarRun(schedule, s.now())
+		if !ok {
+			s.l.Info("Schedule %d has no %s within the next week", schedule.ID, schedule.Solar)
+			return nil
+		}
+		spec = solarSpec(next)
+	}
+	job, err := s.sched.Cron(spec).Tag(scheduleTag(schedule.ID)).Do(s.run, schedule.ID)
+	if err != nil {
+		return err
+	}
+	s.jobs[schedule.ID] = job
+	s.l.Info("Registered schedule %d with %q", schedule.ID, spec)
+	return nil
+}
+
+func (s *scheduleSvc) unregister(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		s.sched.RemoveByReference(job)
+		delete(s.jobs, id)
+	}
+}
+
+// run executes the current state of the schedule, the errors are only logged as nobody waits for them
+func (s *scheduleSvc) run(id int64) {
+	schedule, err := s.dbh.Get(strconv.FormatInt(id, 10))
+	if err != nil {
+		s.l.Warn("schedule %d not found: %s", id, err.Error())
+		return
+	}
+	if schedule.Solar != "" {
+		// the job of a solar schedule only covers a single run
+		defer s.reregister(*schedule)
+	}
+	if err := s.execute(*schedule); err != nil {
+		s.l.Error("error running schedule %d: %s", id, err.Error())
+		return
+	}
+	s.l.Info("Ran schedule %d", id)
+}
+
+// recomputeSolar registers the next runs of the solar schedules, which covers the days without a solar event
+func (s *scheduleSvc) recomputeSolar() {
+	schedules, err := s.dbh.GetAll()
+	if err != nil {
+		s.l.Error("error reading the schedules: %s", err.Error())
+		return
+	}
+	for _, schedule := range schedules {
+		if schedule.Solar != "" {
+			s.reregister(schedule)
+		}
+	}
+}
+
+func (s *scheduleSvc) reregister(schedule model.Schedule) {
+	if err := s.register(schedule); err != nil {
+		s.l.Error("error registering schedule %d: %s", schedule.ID, err.Error())
+	}
+}
+
+// nextSolarRun the next run of the solar schedule after now, false if there is none within the next week. The weekdays
+// refer to the day of the solar event in the timezone of the schedule, the offset may move the run to another day.
+func (s *scheduleSvc) nextSolarRun(schedule model.Schedule, now time.Time) (time.Time, bool) {
+	tz, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	days := map[string]bool{}
+	for _, day := range schedule.Weekdays {
+		days[day] = true
+	}
+	today := now.In(tz)
+	// a positive offset may move the run of yesterday's event to today
+	for d := -1; d <= 7; d++ {
+		date := time.Date(today.Year(), today.Month(), today.Day()+d, 0, 0, 0, 0, tz)
+		if len(days) > 0 && !days[strings.ToLower(date.Weekday().String()[:3])] {
+			continue
+		}
+		event, ok := solar.Time(schedule.Solar, date, s.loc.Latitude, s.loc.Longitude)
+		if !ok {
+			continue
+		}
+		next := event.Add(time.Duration(schedule.Offset) * time.Minute).Round(time.Minute)
+		if next.After(now) {
+			return next.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// execute applies the action of the schedule to the strips of its target, the on action of a scene activates it and
+// a group is changed strip by strip through the group service
+func (s *scheduleSvc) execute(schedule model.Schedule) error {
+	actor := fmt.Sprintf("schedule:%d", schedule.ID)
+	targetID := strconv.FormatInt(schedule.TargetID, 10)
+	var stripIDs []int64
+	switch schedule.TargetType {
+	case model.TargetStrip:
+		stripIDs = []int64{schedule.TargetID}
+	case model.TargetScene:
+		if schedule.Action == model.ActionOn {
+			_, err := s.ss.WithActor(actor).ActivateScene(targetID)
+			return err
+		}
+		scene, err := s.scDbh.Get(targetID)
+		if err != nil {
+			return model.NewAppErr(http.StatusNotFound, err)
+		}
+		for _, state := range scene.Strips {
+			stripIDs = append(stripIDs, state.StripID)
+		}
+	case model.TargetGroup:
+		return s.executeGroup(actor, targetID, schedule)
+	default:
+		return fmt.Errorf("unsupported target type %q", schedule.TargetType)
+	}
+
+	states := make([]model.StripState, len(stripIDs))
+	for i, stripID := range stripIDs {
+		states[i] = model.StripState{StripID: stripID, Enabled: schedule.Action != model.ActionOff}
+		if schedule.Action == model.ActionSetProfile {
+			states[i].ProfileID = schedule.ProfileID
+		}
+	}
+	_, err := s.lsvc.WithActor(actor).ApplyStates(states)
+	return err
+}
+
+// executeGroup applies the action to the strips of the group and its nested groups, a missing or failing strip is
+// only logged and doesn't prevent the others from being changed
+func (s *scheduleSvc) executeGroup(actor string, id string, schedule model.Schedule) error {
+	gs := s.gs.WithActor(actor)
+	var result *model.GroupResult
+	var err error
+	switch schedule.Action {
+	case model.ActionOn:
+		result, err = gs.EnableGroup(id)
+	case model.ActionOff:
+		result, err = gs.DisableGroup(id)
+	case model.ActionSetProfile:
+		result, err = gs.SetProfileForGroup(id, schedule.ProfileID.Int64)
+	default:
+		return fmt.Errorf("unsupported action %q", schedule.Action)
+	}
+	if err != nil {
+		return err
+	}
+	for _, failure := range result.Failed {
+		s.l.Warn("schedule %d couldn't be applied to strip %d: %s", schedule.ID, failure.StripID, failure.Error)
+	}
+	return nil
+}
+
+// validate checks the schedule and whether its target and profile exist
+func (s *scheduleSvc) validate(schedule model.Schedule) error {
+	if err := validation.ValidateSchedule(schedule); err != nil {
+		return err
+	}
+	if schedule.Solar != "" && !s.loc.IsSet() {
+		return model.NewAppErr(http.StatusUnprocessableEntity, &model.ValidationError{
+			Errors: []model.FieldError{{Field: "solar", Message: "requires a configured location"}},
+		})
+	}
+	targetID := strconv.FormatInt(schedule.TargetID, 10)
+	switch schedule.TargetType {
+	case model.TargetStrip:
+		if _, err := s.lsDbh.Get(targetID); err != nil {
+			return notFoundErr("targetId", "strip", schedule.TargetID)
+		}
+	case model.TargetScene:
+		if _, err := s.scDbh.Get(targetID); err != nil {
+			return notFoundErr("targetId", "scene", schedule.TargetID)
+		}
+	case model.TargetGroup:
+		if _, err := s.grDbh.Get(targetID); err != nil {
+			return notFoundErr("targetId", "group", schedule.TargetID)
+		}
+	}
+	if schedule.ProfileID.Valid {
+		if _, err := s.cpDbh.Get(strconv.FormatInt(schedule.ProfileID.Int64, 10)); err != nil {
+			return notFoundErr("profileId", "profile", schedule.ProfileID.Int64)
+		}
+	}
+	return nil
+}
+
+// cronSpec the cron expression of the schedule in its timezone, the weekdays and time are converted to one
+func cronSpec(schedule model.Schedule) string {
+	tz := schedule.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	if schedule.Cron != "" {
+		return fmt.Sprintf("CRON_TZ=%s %s", tz, schedule.Cron)
+	}
+	// the time is already validated
+	at, _ := time.Parse("15:04", schedule.Time)
+	days := "*"
+	if len(schedule.Weekdays) > 0 {
+		days = strings.Join(schedule.Weekdays, ",")
+	}
+	return fmt.Sprintf("CRON_TZ=%s %d %d * * %s", tz, at.Minute(), at.Hour(), days)
+}
+
+// solarSpec the cron expression of the single run of a solar schedule, the job is replaced before it repeats a year
+// later
+func solarSpec(next time.Time) string {
+	return fmt.Sprintf("CRON_TZ=UTC %d %d %d %d *", next.Minute(), next.Hour(), next.Day(), next.Month())
+}
+
+func scheduleTag(id int64) string {
+	return "schedule-" + strconv.FormatInt(id, 10)
+}
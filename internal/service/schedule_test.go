@@ -0,0 +1,498 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-co-op/gocron"
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	dbm "github.com/pthum/stripcontrol-golang/internal/database/mocks"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type scheduleMocks struct {
+	*sceneMocks
+	schDbh *dbm.DBHandler[model.Schedule]
+	grDbh  *dbm.DBHandler[model.Group]
+	sched  *gocron.Scheduler
+	schs   *scheduleSvc
+}
+
+func TestCronSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule model.Schedule
+		want     string
+	}{
+		{"cron", model.Schedule{Cron: "*/5 * * * *", Timezone: "Europe/Berlin"}, "CRON_TZ=Europe/Berlin */5 * * * *"},
+		{"daily", model.Schedule{Time: "07:05"}, "CRON_TZ=UTC 5 7 * * *"},
+		{"midnight", model.Schedule{Time: "00:00"}, "CRON_TZ=UTC 0 0 * * *"},
+		{"weekdays", model.Schedule{Time: "22:30", Weekdays: model.Weekdays{"sat", "sun"}, Timezone: "America/New_York"},
+			"CRON_TZ=America/New_York 30 22 * * sat,sun"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cronSpec(tt.schedule))
+		})
+	}
+}
+
+func TestNewScheduleService_RegistersStored(t *testing.T) {
+	broken := *createDummySchedule()
+	broken.ID = 4
+	broken.Cron = "not a cron"
+	mocks := createScheduleMocks(t, *createDummySchedule(), broken)
+
+	assert.Contains(t, mocks.schs.jobs, int64(3))
+	mocks.assertJobs(t, 1)
+}
+
+func TestGetAllSchedules(t *testing.T) {
+	mocks := createScheduleMocks(t, *createDummySchedule())
+	mocks.sched.StartAsync()
+	defer mocks.sched.Stop()
+	mocks.schDbh.EXPECT().GetAll().Return([]model.Schedule{*createDummySchedule()}, nil).Once()
+
+	res, err := mocks.schs.GetAll()
+
+	assert.NoError(t, err)
+	if assert.Len(t, res, 1) && assert.NotNil(t, res[0].NextRun) {
+		berlin, _ := time.LoadLocation("Europe/Berlin")
+		next := res[0].NextRun.In(berlin)
+		assert.True(t, next.After(time.Now()))
+		assert.Equal(t, 7, next.Hour())
+		assert.Equal(t, 30, next.Minute())
+	}
+}
+
+func TestGetSchedule_NotRunning(t *testing.T) {
+	mocks := createScheduleMocks(t, *createDummySchedule())
+	mocks.schDbh.EXPECT().Get("3").Return(createDummySchedule(), nil).Once()
+
+	res, err := mocks.schs.GetSchedule("3")
+
+	assert.NoError(t, err)
+	assert.Equal(t, *createDummySchedule(), res.Schedule)
+	assert.Nil(t, res.NextRun)
+}
+
+func TestCreateSchedule(t *testing.T) {
+	mocks := createScheduleMocks(t)
+	mocks.sched.StartAsync()
+	defer mocks.sched.Stop()
+	schedule := createDummySchedule()
+	schedule.ID = 0
+	mocks.expectDBStripGet(createValidDummyStrip())
+	mocks.expectDBProfileGet(createProfile(15, 1, 2, 3, 4), nil)
+	mocks.schDbh.EXPECT().NextID().Return(5, nil).Once()
+	mocks.schDbh.EXPECT().Create(mock.Anything).Return(nil).Once()
+
+	err := mocks.schs.CreateSchedule(schedule)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), schedule.ID)
+	mocks.assertJobs(t, 1)
+	assert.NotNil(t, mocks.schs.NextRun(5))
+}
+
+func TestCreateSchedule_Invalid(t *testing.T) {
+	mocks := createScheduleMocks(t)
+
+	err := mocks.schs.CreateSchedule(&model.Schedule{Name: "Broken"})
+
+	var verr *model.ValidationError
+	assert.ErrorAs(t, err, &verr)
+	mocks.assertJobs(t, 0)
+}
+
+func TestCreateSchedule_MissingReferences(t *testing.T) {
+	mocks := createScheduleMocks(t)
+	schedule := createDummySchedule()
+	mocks.lsDbh.EXPECT().Get("185").Return(nil, errors.New("not found")).Once()
+	assertAppErrCode(t, mocks.schs.CreateSchedule(schedule), http.StatusUnprocessableEntity)
+
+	mocks.expectDBStripGet(createValidDummyStrip())
+	mocks.expectDBProfileGet(nil, errors.New("not found"))
+	assertAppErrCode(t, mocks.schs.CreateSchedule(schedule), http.StatusUnprocessableEntity)
+
+	schedule.TargetType = model.TargetScene
+	schedule.TargetID = 7
+	mocks.dbh.EXPECT().Get("7").Return(nil, errors.New("not found")).Once()
+	assertAppErrCode(t, mocks.schs.CreateSchedule(schedule), http.StatusUnprocessableEntity)
+
+	schedule.TargetType = model.TargetGroup
+	schedule.TargetID = 4
+	mocks.grDbh.EXPECT().Get("4").Return(nil, errors.New("not found")).Once()
+	assertAppErrCode(t, mocks.schs.CreateSchedule(schedule), http.StatusUnprocessableEntity)
+	mocks.assertJobs(t, 0)
+}
+
+func TestUpdateSchedule(t *testing.T) {
+	dbSchedule := createDummySchedule()
+	mocks := createScheduleMocks(t, *dbSchedule)
+	oldJob := mocks.schs.jobs[3]
+	upd := *createDummySchedule()
+	upd.ID = 0
+	upd.Time = ""
+	upd.Weekdays = nil
+	upd.Cron = "0 22 * * *"
+	mocks.expectDBStripGet(createValidDummyStrip())
+	mocks.expectDBProfileGet(createProfile(15, 1, 2, 3, 4), nil)
+	mocks.schDbh.EXPECT().Get("3").Return(dbSchedule, nil).Once()
+	expected := upd
+	expected.ID = 3
+	mocks.schDbh.EXPECT().Update(*dbSchedule, expected).Return(nil).Once()
+
+	err := mocks.schs.UpdateSchedule("3", upd)
+
+	assert.NoError(t, err)
+	mocks.assertJobs(t, 1)
+	assert.NotSame(t, oldJob, mocks.schs.jobs[3])
+}
+
+func TestUpdateSchedule_Errors(t *testing.T) {
+	dbSchedule := createDummySchedule()
+	dbSchedule.Version = 2
+	mocks := createScheduleMocks(t, *dbSchedule)
+	oldJob := mocks.schs.jobs[3]
+	upd := *createDummySchedule()
+	upd.Version = 1
+	mocks.expectDBStripGet(createValidDummyStrip())
+	mocks.expectDBProfileGet(createProfile(15, 1, 2, 3, 4), nil)
+	mocks.schDbh.EXPECT().Get("3").Return(dbSchedule, nil).Once()
+	assertAppErrCode(t, mocks.schs.UpdateSchedule("3", upd), http.StatusPreconditionFailed)
+
+	upd.Version = 2
+	mocks.expectDBStripGet(createValidDummyStrip())
+	mocks.expectDBProfileGet(createProfile(15, 1, 2, 3, 4), nil)
+	mocks.schDbh.EXPECT().Get("3").Return(dbSchedule, nil).Once()
+	mocks.schDbh.EXPECT().Update(mock.Anything, mock.Anything).Return(database.ErrVersionConflict).Once()
+	assertAppErrCode(t, mocks.schs.UpdateSchedule("3", upd), http.StatusPreconditionFailed)
+
+	mocks.expectDBStripGet(createValidDummyStrip())
+	mocks.expectDBProfileGet(createProfile(15, 1, 2, 3, 4), nil)
+	mocks.schDbh.EXPECT().Get("4").Return(nil, errors.New("not found")).Once()
+	assertAppErrCode(t, mocks.schs.UpdateSchedule("4", upd), http.StatusNotFound)
+
+	// the job is kept if the update fails
+	assert.Same(t, oldJob, mocks.schs.jobs[3])
+}
+
+func TestDeleteSchedule(t *testing.T) {
+	dbSchedule := createDummySchedule()
+	mocks := createScheduleMocks(t, *dbSchedule)
+	mocks.schDbh.EXPECT().Get("3").Return(dbSchedule, nil).Twice()
+	assertAppErrCode(t, mocks.schs.DeleteSchedule("3", 2), http.StatusPreconditionFailed)
+	mocks.schDbh.EXPECT().Delete(dbSchedule).Return(nil).Once()
+
+	assert.NoError(t, mocks.schs.DeleteSchedule("3", 0))
+
+	mocks.assertJobs(t, 0)
+	mocks.schDbh.EXPECT().Get("3").Return(nil, errors.New("not found")).Once()
+	assertAppErrCode(t, mocks.schs.DeleteSchedule("3", 0), http.StatusNotFound)
+}
+
+func TestRunSchedule_Strip(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  model.ScheduleAction
+		enabled bool
+		profile null.Int
+	}{
+		{"on", model.ActionOn, true, null.Int{}},
+		{"off", model.ActionOff, false, null.Int{}},
+		{"set-profile", model.ActionSetProfile, true, null.IntFrom(15)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mocks := createScheduleMocks(t)
+			schedule := createDummySchedule()
+			schedule.Action = tt.action
+			schedule.ProfileID = tt.profile
+			mocks.schDbh.EXPECT().Get("3").Return(schedule, nil).Once()
+			strip := createValidDummyStrip()
+			strip.Enabled = !tt.enabled
+			mocks.expectDBStripGet(strip)
+			if tt.profile.Valid {
+				mocks.expectDBProfileGet(createProfile(15, 1, 2, 3, 4), nil)
+			}
+			updated := *strip
+			updated.Enabled = tt.enabled
+			updated.ProfileID = tt.profile
+			mocks.lsDbh.EXPECT().Update(*strip, updated).Return(nil).Once()
+
+			mocks.schs.run(3)
+
+			time.Sleep(50 * time.Millisecond)
+			mocks.rec.mu.Lock()
+			defer mocks.rec.mu.Unlock()
+			if assert.Len(t, mocks.rec.strips, 1) {
+				assert.Equal(t, "schedule:3", mocks.rec.strips[0].Meta.Actor)
+			}
+		})
+	}
+}
+
+func TestRunSchedule_Scene(t *testing.T) {
+	mocks := createScheduleMocks(t)
+	scene := createDummyScene()
+	schedule := createDummySchedule()
+	schedule.TargetType = model.TargetScene
+	schedule.TargetID = scene.ID
+	schedule.Action = model.ActionOn
+	schedule.ProfileID = null.Int{}
+	// on activates the scene
+	mocks.dbh.EXPECT().Get("7").Return(scene, nil).Twice()
+	strip := createValidDummyStrip()
+	mocks.expectDBStripGet(strip)
+	mocks.expectDBProfileGet(createProfile(15, 1, 2, 3, 4), nil)
+	activated := *strip
+	activated.Enabled = true
+	activated.ProfileID = null.IntFrom(15)
+	mocks.lsDbh.EXPECT().Update(*strip, activated).Return(nil).Once()
+
+	assert.NoError(t, mocks.schs.execute(*schedule))
+
+	// off disables the strips of the scene, their profile is kept
+	schedule.Action = model.ActionOff
+	activated.Version++
+	mocks.expectDBStripGet(&activated)
+	mocks.expectDBProfileGet(createProfile(15, 1, 2, 3, 4), nil)
+	disabled := activated
+	disabled.Enabled = false
+	mocks.lsDbh.EXPECT().Update(activated, disabled).Return(nil).Once()
+
+	assert.NoError(t, mocks.schs.execute(*schedule))
+}
+
+func TestRunSchedule_Group(t *testing.T) {
+	mocks := createScheduleMocks(t)
+	schedule := createDummySchedule()
+	schedule.TargetType = model.TargetGroup
+	schedule.TargetID = 4
+	schedule.Action = model.ActionOff
+	schedule.ProfileID = null.Int{}
+	// the strips of the nested group are switched as well
+	mocks.grDbh.EXPECT().Get("4").Return(&model.Group{BaseModel: model.BaseModel{ID: 4}, Groups: model.IDs{5}}, nil).Once()
+	mocks.grDbh.EXPECT().Get("5").Return(&model.Group{BaseModel: model.BaseModel{ID: 5}, Strips: model.IDs{185}}, nil).Once()
+	strip := createValidDummyStrip()
+	strip.Enabled = true
+	mocks.expectDBStripGet(strip)
+	disabled := *strip
+	disabled.Enabled = false
+	mocks.lsDbh.EXPECT().Update(*strip, disabled).Return(nil).Once()
+
+	assert.NoError(t, mocks.schs.execute(*schedule))
+
+	mocks.grDbh.EXPECT().Get("4").Return(nil, errors.New("not found")).Once()
+	assertAppErrCode(t, mocks.schs.execute(*schedule), http.StatusNotFound)
+}
+
+func TestRunSchedule_GroupMissingStrip(t *testing.T) {
+	mocks := createScheduleMocks(t)
+	schedule := createDummySchedule()
+	schedule.TargetType = model.TargetGroup
+	schedule.TargetID = 4
+	schedule.Action = model.ActionOff
+	schedule.ProfileID = null.Int{}
+	// the deleted strip 186 doesn't prevent the other strip from being switched
+	mocks.grDbh.EXPECT().Get("4").Return(&model.Group{BaseModel: model.BaseModel{ID: 4}, Strips: model.IDs{186, 185}}, nil).Once()
+	mocks.lsDbh.EXPECT().Get("186").Return(nil, errors.New("not found")).Once()
+	strip := createValidDummyStrip()
+	strip.Enabled = true
+	mocks.expectDBStripGet(strip)
+	disabled := *strip
+	disabled.Enabled = false
+	mocks.lsDbh.EXPECT().Update(*strip, disabled).Return(nil).Once()
+
+	assert.NoError(t, mocks.schs.execute(*schedule))
+}
+
+func TestRunSchedule_MissingTarget(t *testing.T) {
+	mocks := createScheduleMocks(t)
+	schedule := createDummySchedule()
+	schedule.TargetType = model.TargetScene
+	schedule.Action = model.ActionOff
+	mocks.dbh.EXPECT().Get("185").Return(nil, errors.New("not found")).Once()
+	assertAppErrCode(t, mocks.schs.execute(*schedule), http.StatusNotFound)
+
+	schedule.TargetType = model.TargetStrip
+	mocks.lsDbh.EXPECT().Get("185").Return(nil, errors.New("not found")).Once()
+	assertAppErrCode(t, mocks.schs.execute(*schedule), http.StatusNotFound)
+
+	// a deleted schedule is skipped
+	mocks.schDbh.EXPECT().Get("3").Return(nil, errors.New("not found")).Once()
+	mocks.schs.run(3)
+}
+
+func TestNextSolarRun(t *testing.T) {
+	mocks := createScheduleMocks(t)
+	schedule := createDummySchedule()
+	schedule.Time = ""
+	schedule.Weekdays = nil
+	schedule.Solar = model.Sunset
+	schedule.Offset = 20
+	// 2024-06-21 is a friday, the sunset in berlin is at 21:33 CEST
+	noon := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+
+	next, ok := mocks.schs.nextSolarRun(*schedule, noon)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 6, 21, 19, 53, 0, 0, time.UTC), next)
+
+	// after today's run the one of tomorrow follows
+	next, ok = mocks.schs.nextSolarRun(*schedule, next)
+	assert.True(t, ok)
+	assert.Equal(t, 22, next.Day())
+
+	// the weekdays refer to the day of the event
+	schedule.Weekdays = model.Weekdays{"mon"}
+	next, ok = mocks.schs.nextSolarRun(*schedule, noon)
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Date(2024, 6, 24, 19, 54, 0, 0, time.UTC), next, 2*time.Minute)
+	assert.Equal(t, time.Monday, next.In(mustLoadLocation(t, "Europe/Berlin")).Weekday())
+
+	// a large offset moves the run of yesterday's event past midnight
+	schedule.Weekdays = nil
+	schedule.Offset = 300
+	afterMidnight := time.Date(2024, 6, 21, 1, 0, 0, 0, mustLoadLocation(t, "Europe/Berlin"))
+	next, ok = mocks.schs.nextSolarRun(*schedule, afterMidnight)
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Date(2024, 6, 21, 0, 33, 0, 0, time.UTC), next, 2*time.Minute)
+}
+
+func TestNextSolarRun_Polar(t *testing.T) {
+	mocks := createScheduleMocks(t)
+	mocks.schs.loc = config.LocationConfig{Latitude: 78.22, Longitude: 15.65}
+	schedule := createDummySchedule()
+	schedule.Time = ""
+	schedule.Solar = model.Sunrise
+	schedule.Timezone = "Arctic/Longyearbyen"
+
+	_, ok := mocks.schs.nextSolarRun(*schedule, time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC))
+
+	assert.False(t, ok)
+}
+
+func TestCreateSchedule_Solar(t *testing.T) {
+	mocks := createScheduleMocks(t)
+	mocks.schs.now = func() time.Time { return time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC) }
+	schedule := createDummySchedule()
+	schedule.ID = 0
+	schedule.Time = ""
+	schedule.Weekdays = nil
+	schedule.Solar = model.Sunset
+	mocks.expectDBStripGet(createValidDummyStrip())
+	mocks.expectDBProfileGet(createProfile(15, 1, 2, 3, 4), nil)
+	mocks.schDbh.EXPECT().NextID().Return(5, nil).Once()
+	mocks.schDbh.EXPECT().Create(mock.Anything).Return(nil).Once()
+
+	assert.NoError(t, mocks.schs.CreateSchedule(schedule))
+
+	mocks.assertJobs(t, 1)
+	assert.Contains(t, mocks.schs.jobs[5].Tags(), "schedule-5")
+
+	// the location is required for solar schedules
+	mocks.schs.loc = config.LocationConfig{}
+	assertAppErrCode(t, mocks.schs.CreateSchedule(schedule), http.StatusUnprocessableEntity)
+}
+
+func TestRunSchedule_SolarReregisters(t *testing.T) {
+	schedule := createDummySchedule()
+	schedule.Time = ""
+	schedule.Solar = model.Sunrise
+	schedule.Action = model.ActionOff
+	schedule.ProfileID = null.Int{}
+	mocks := createScheduleMocks(t, *schedule)
+	oldJob := mocks.schs.jobs[3]
+	mocks.schDbh.EXPECT().Get("3").Return(schedule, nil).Once()
+	// the execution fails, the next run is registered anyway
+	mocks.lsDbh.EXPECT().Get("185").Return(nil, errors.New("not found")).Once()
+
+	mocks.schs.run(3)
+
+	mocks.assertJobs(t, 1)
+	assert.NotSame(t, oldJob, mocks.schs.jobs[3])
+
+	// the daily recompute replaces the jobs of the solar schedules only
+	timed := *createDummySchedule()
+	timed.ID = 4
+	assert.NoError(t, mocks.schs.register(timed))
+	timedJob := mocks.schs.jobs[4]
+	solarJob := mocks.schs.jobs[3]
+	mocks.schDbh.EXPECT().GetAll().Return([]model.Schedule{*schedule, timed}, nil).Once()
+
+	mocks.schs.recomputeSolar()
+
+	mocks.assertJobs(t, 2)
+	assert.Same(t, timedJob, mocks.schs.jobs[4])
+	assert.NotSame(t, solarJob, mocks.schs.jobs[3])
+}
+
+func TestSolarSpec(t *testing.T) {
+	assert.Equal(t, "CRON_TZ=UTC 53 19 21 6 *", solarSpec(time.Date(2024, 6, 21, 19, 53, 0, 0, time.UTC)))
+}
+
+// assertJobs checks the number of registered schedules, the daily recompute of the solar schedules is always
+// registered in addition
+func (m *scheduleMocks) assertJobs(t *testing.T, count int) {
+	assert.Len(t, m.schs.jobs, count)
+	assert.Equal(t, count+1, m.sched.Len())
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	assert.NoError(t, err)
+	return loc
+}
+
+func createDummySchedule() *model.Schedule {
+	return &model.Schedule{
+		BaseModel:  model.BaseModel{ID: 3},
+		Name:       "Wake up",
+		Weekdays:   model.Weekdays{"mon", "tue", "wed", "thu", "fri"},
+		Time:       "07:30",
+		Timezone:   "Europe/Berlin",
+		TargetType: model.TargetStrip,
+		TargetID:   185,
+		Action:     model.ActionSetProfile,
+		ProfileID:  null.IntFrom(15),
+	}
+}
+
+func createScheduleMocks(t *testing.T, stored ...model.Schedule) *scheduleMocks {
+	sm := createSceneMocks(t)
+	i := do.New()
+	schDbh := dbm.NewDBHandler[model.Schedule](t)
+	schDbh.EXPECT().GetAll().Return(stored, nil).Once()
+	sched := gocron.NewScheduler(time.UTC)
+	// berlin, matching the timezone of the dummy schedule
+	do.ProvideValue(i, &config.Config{Location: config.LocationConfig{Latitude: 52.52, Longitude: 13.405}})
+	do.ProvideValue[database.DBHandler[model.Schedule]](i, schDbh)
+	do.ProvideValue[database.DBHandler[model.LedStrip]](i, sm.lsDbh)
+	do.ProvideValue[database.DBHandler[model.ColorProfile]](i, sm.cpDbh)
+	do.ProvideValue[database.DBHandler[model.Scene]](i, sm.dbh)
+	do.ProvideValue[LEDService](i, sm.ls)
+	do.ProvideValue[SceneService](i, sm.ss)
+	grDbh := dbm.NewDBHandler[model.Group](t)
+	do.ProvideValue[database.DBHandler[model.Group]](i, grDbh)
+	gs, err := NewGroupService(i)
+	assert.NoError(t, err)
+	do.ProvideValue(i, gs)
+	do.ProvideValue(i, sched)
+	schs, err := NewScheduleService(i)
+	assert.NoError(t, err)
+	return &scheduleMocks{
+		sceneMocks: sm,
+		schDbh:     schDbh,
+		grDbh:      grDbh,
+		sched:      sched,
+		schs:       schs.(*scheduleSvc),
+	}
+}
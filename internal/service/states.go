@@ -0,0 +1,187 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/validation"
+)
+
+// stripChange the change of a strip and its profile by a state
+type stripChange struct {
+	strip   model.LedStrip
+	updated model.LedStrip
+	// profile the profile of the strip after the change, nil if it has none or it is missing
+	profile *model.ColorProfile
+	// colored the profile before the color of the state was applied, nil if it isn't changed
+	colored *model.ColorProfile
+	// created the profile is created for the color of the state
+	created bool
+}
+
+func (l *ledSvc) ApplyStates(states []model.StripState) ([]model.LedStrip, error) {
+	changes := make([]*stripChange, 0, len(states))
+	for _, state := range states {
+		c, err := l.prepareChange(state)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	if err := mergeColors(changes); err != nil {
+		return nil, err
+	}
+	for i, c := range changes {
+		if err := l.applyChange(c); err != nil {
+			l.revertChanges(changes[:i])
+			return nil, err
+		}
+	}
+
+	strips := make([]model.LedStrip, len(changes))
+	for i, c := range changes {
+		l.stopTimer(c.strip.ID)
+		if c.created || c.colored != nil {
+			var event = model.NewProfileEvent(c.profile.GetNullID(), model.Save).With(*c.profile).By(l.actor)
+			l.publishProfileEvent(event)
+		}
+		l.publishStripSaveEvent(c.updated.GetNullID(), c.updated, c.profile)
+		strips[i] = c.updated
+	}
+	return strips, nil
+}
+
+// prepareChange reads the strip and its profile and determines the changes of the state, nothing is written yet
+func (l *ledSvc) prepareChange(state model.StripState) (*stripChange, error) {
+	strip, err := l.dbh.Get(strconv.FormatInt(state.StripID, 10))
+	if err != nil {
+		return nil, model.NewAppErr(404, fmt.Errorf("strip %d not found: %w", state.StripID, err))
+	}
+	c := &stripChange{strip: *strip, updated: *strip}
+	c.updated.Enabled = state.Enabled
+	if state.ProfileID.Valid {
+		c.updated.ProfileID = state.ProfileID
+	}
+	if c.updated.ProfileID.Valid {
+		profile, err := l.cpDbh.Get(strconv.FormatInt(c.updated.ProfileID.Int64, 10))
+		switch {
+		case err == nil:
+			c.profile = profile
+		case state.ProfileID.Valid || state.Color != nil:
+			return nil, model.NewAppErr(404, fmt.Errorf("profile %d not found: %w", c.updated.ProfileID.Int64, err))
+		default:
+			// the unchanged strip is published without its missing profile
+			l.l.Warn("profile %d of strip %d not found: %s", strip.ProfileID.Int64, strip.ID, err.Error())
+		}
+	}
+	if state.Color == nil {
+		return c, nil
+	}
+
+	// the color is applied to the profile of the strip, a new one is created for a strip without a profile
+	var colored model.ColorProfile
+	if c.profile == nil {
+		c.created = true
+		colored = model.ColorProfile{}.WithColor(*state.Color)
+	} else {
+		colored = c.profile.WithColor(*state.Color)
+		if len(database.ChangedFields(*c.profile, colored)) > 0 {
+			c.colored = c.profile
+		}
+	}
+	if err := validation.ValidateColorProfile(colored); err != nil {
+		return nil, err
+	}
+	c.profile = &colored
+	return c, nil
+}
+
+// mergeColors ensures each profile is colored only once, as its color is shared by all strips using it. States
+// applying the same color to a profile share the change, different colors for the same profile are rejected. The
+// strips using a colored profile are published with its new color.
+func mergeColors(changes []*stripChange) error {
+	coloredBy := map[int64]*stripChange{}
+	for i, c := range changes {
+		if c.colored == nil {
+			continue
+		}
+		first, ok := coloredBy[c.colored.ID]
+		if !ok {
+			coloredBy[c.colored.ID] = c
+			continue
+		}
+		if len(database.ChangedFields(*first.profile, *c.profile)) > 0 {
+			return model.NewAppErr(http.StatusUnprocessableEntity, &model.ValidationError{
+				Errors: []model.FieldError{{
+					Field:   fmt.Sprintf("strips[%d].color", i),
+					Message: fmt.Sprintf("must match the other colors of profile %d, which is shared by the strips", c.colored.ID),
+				}},
+			})
+		}
+		// the profile is only written once
+		c.colored = nil
+	}
+	for _, c := range changes {
+		if c.profile == nil || c.created {
+			continue
+		}
+		if first, ok := coloredBy[c.profile.ID]; ok {
+			c.profile = first.profile
+		}
+	}
+	return nil
+}
+
+// applyChange writes the profile and the strip of the change, the profile is reverted if the strip can't be written
+func (l *ledSvc) applyChange(c *stripChange) error {
+	switch {
+	case c.created:
+		if err := database.CreateWithNextID(l.cpDbh, c.profile); err != nil {
+			return model.NewAppErr(500, err)
+		}
+		c.updated.ProfileID = c.profile.GetNullID()
+	case c.colored != nil:
+		if err := l.cpDbh.Update(*c.colored, *c.profile); err != nil {
+			return updateErr(err)
+		}
+		c.profile.Version++
+	}
+	if len(database.ChangedFields(c.strip, c.updated)) == 0 {
+		return nil
+	}
+	if err := l.dbh.Update(c.strip, c.updated); err != nil {
+		l.revertProfile(c)
+		return updateErr(err)
+	}
+	c.updated.Version++
+	return nil
+}
+
+// revertChanges restores the strips and profiles of the applied changes, failures are only logged
+func (l *ledSvc) revertChanges(changes []*stripChange) {
+	for i := len(changes) - 1; i >= 0; i-- {
+		c := changes[i]
+		if c.updated.Version != c.strip.Version {
+			if err := l.dbh.Update(c.updated, c.strip); err != nil {
+				l.l.Error("error reverting strip %d: %s", c.strip.ID, err.Error())
+			}
+		}
+		l.revertProfile(c)
+	}
+}
+
+func (l *ledSvc) revertProfile(c *stripChange) {
+	var err error
+	switch {
+	case c.created:
+		err = l.cpDbh.Delete(c.profile)
+	case c.colored != nil:
+		err = l.cpDbh.Update(*c.profile, *c.colored)
+	}
+	if err != nil {
+		l.l.Error("error reverting profile %d: %s", c.profile.ID, err.Error())
+	}
+}
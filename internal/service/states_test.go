@@ -0,0 +1,214 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyStates(t *testing.T) {
+	m := createEventMocks(t)
+	first := createValidDummyStrip()
+	second := createValidDummyStrip()
+	second.ID = 186
+	second.Enabled = true
+	second.ProfileID = null.IntFrom(16)
+	profile15 := createProfile(15, 1, 2, 3, 4)
+	profile16 := createProfile(16, 10, 20, 30, 4)
+	m.expectDBStripGet(first)
+	m.expectDBStripGet(second)
+	m.expectDBProfileGet(profile15, nil)
+	m.expectDBProfileGet(profile16, nil)
+	updFirst := *first
+	updFirst.Enabled = true
+	updFirst.ProfileID = null.IntFrom(15)
+	m.lsDbh.EXPECT().Update(*first, updFirst).Return(nil).Once()
+	colored := *createProfile(16, 255, 20, 30, 4)
+	m.cpDbh.EXPECT().Update(*profile16, colored).Return(nil).Once()
+
+	strips, err := m.ls.ApplyStates([]model.StripState{
+		{StripID: 185, Enabled: true, ProfileID: null.IntFrom(15)},
+		{StripID: 186, Enabled: true, Color: &model.ColorProfile{Red: null.IntFrom(255)}},
+	})
+	time.Sleep(50 * time.Millisecond)
+
+	assert.NoError(t, err)
+	updFirst.Version++
+	assert.Equal(t, []model.LedStrip{updFirst, *second}, strips)
+	m.rec.mu.Lock()
+	defer m.rec.mu.Unlock()
+	assert.Len(t, m.rec.strips, 2)
+	if assert.Len(t, m.rec.profiles, 1) {
+		assert.Equal(t, int64(16), m.rec.profiles[0].ID.Int64)
+		assert.Equal(t, int64(1), m.rec.profiles[0].State.Profile.Version)
+	}
+}
+
+func TestApplyStates_CreateProfile(t *testing.T) {
+	m := createEventMocks(t)
+	strip := createValidDummyStrip()
+	m.expectDBStripGet(strip)
+	m.expectDBProfileNextID(16)
+	created := model.ColorProfile{BaseModel: model.BaseModel{ID: 16}, Green: null.IntFrom(20)}
+	m.cpDbh.EXPECT().Create(&created).Return(nil).Once()
+	updated := *strip
+	updated.ProfileID = null.IntFrom(16)
+	m.lsDbh.EXPECT().Update(*strip, updated).Return(nil).Once()
+
+	strips, err := m.ls.ApplyStates([]model.StripState{
+		{StripID: 185, Color: &model.ColorProfile{Green: null.IntFrom(20)}},
+	})
+	time.Sleep(50 * time.Millisecond)
+
+	assert.NoError(t, err)
+	if assert.Len(t, strips, 1) {
+		assert.Equal(t, null.IntFrom(16), strips[0].ProfileID)
+	}
+	m.rec.mu.Lock()
+	defer m.rec.mu.Unlock()
+	assert.Len(t, m.rec.profiles, 1)
+	if assert.Len(t, m.rec.strips, 1) {
+		assert.Equal(t, created, m.rec.strips[0].Strip.Strip.Profile.Profile)
+	}
+}
+
+func TestApplyStates_SharedProfile(t *testing.T) {
+	m := createEventMocks(t)
+	first := createValidDummyStrip()
+	first.ProfileID = null.IntFrom(16)
+	second := createValidDummyStrip()
+	second.ID = 186
+	second.ProfileID = null.IntFrom(16)
+	profile16 := createProfile(16, 10, 20, 30, 4)
+	m.expectDBStripGet(first)
+	m.expectDBStripGet(second)
+	m.cpDbh.EXPECT().Get("16").Return(profile16, nil).Twice()
+	colored := *createProfile(16, 255, 20, 30, 4)
+	// the profile is colored once for both strips
+	m.cpDbh.EXPECT().Update(*profile16, colored).Return(nil).Once()
+	updFirst := *first
+	updFirst.Enabled = true
+	updSecond := *second
+	updSecond.Enabled = true
+	m.lsDbh.EXPECT().Update(*first, updFirst).Return(nil).Once()
+	m.lsDbh.EXPECT().Update(*second, updSecond).Return(nil).Once()
+
+	strips, err := m.ls.ApplyStates([]model.StripState{
+		{StripID: 185, Enabled: true, Color: &model.ColorProfile{Red: null.IntFrom(255)}},
+		{StripID: 186, Enabled: true, Color: &model.ColorProfile{Red: null.IntFrom(255)}},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, strips, 2)
+	m.rec.mu.Lock()
+	defer m.rec.mu.Unlock()
+	assert.Len(t, m.rec.profiles, 1)
+	if assert.Len(t, m.rec.strips, 2) {
+		for _, e := range m.rec.strips {
+			assert.Equal(t, null.IntFrom(255), e.Strip.Strip.Profile.Profile.Red)
+		}
+	}
+}
+
+func TestApplyStates_SharedProfileConflict(t *testing.T) {
+	m := createEventMocks(t)
+	first := createValidDummyStrip()
+	first.ProfileID = null.IntFrom(16)
+	second := createValidDummyStrip()
+	second.ID = 186
+	second.ProfileID = null.IntFrom(16)
+	m.expectDBStripGet(first)
+	m.expectDBStripGet(second)
+	m.cpDbh.EXPECT().Get("16").Return(createProfile(16, 10, 20, 30, 4), nil).Twice()
+
+	strips, err := m.ls.ApplyStates([]model.StripState{
+		{StripID: 185, Enabled: true, Color: &model.ColorProfile{Red: null.IntFrom(255)}},
+		{StripID: 186, Enabled: true, Color: &model.ColorProfile{Red: null.IntFrom(0)}},
+	})
+
+	assert.Nil(t, strips)
+	assertAppErrCode(t, err, http.StatusUnprocessableEntity)
+	var verr *model.ValidationError
+	if assert.ErrorAs(t, err, &verr) {
+		assert.Equal(t, "strips[1].color", verr.Errors[0].Field)
+	}
+	// nothing is written or published, the mocks fail on any unexpected call
+	assert.Empty(t, m.rec.strips)
+}
+
+func TestApplyStates_MissingStrip(t *testing.T) {
+	m := createEventMocks(t)
+	m.expectDBStripGet(createValidDummyStrip())
+	m.lsDbh.EXPECT().Get("186").Return(nil, errors.New("not found")).Once()
+
+	strips, err := m.ls.ApplyStates([]model.StripState{
+		{StripID: 185, Enabled: true},
+		{StripID: 186, Enabled: true},
+	})
+
+	assert.Nil(t, strips)
+	assertAppErrCode(t, err, http.StatusNotFound)
+	// nothing is written or published, the mocks fail on any unexpected call
+	assert.Empty(t, m.rec.strips)
+}
+
+func TestApplyStates_MissingProfile(t *testing.T) {
+	m := createEventMocks(t)
+	m.expectDBStripGet(createValidDummyStrip())
+	m.expectDBProfileGet(nil, errors.New("not found"))
+
+	strips, err := m.ls.ApplyStates([]model.StripState{
+		{StripID: 185, Enabled: true, ProfileID: null.IntFrom(15)},
+	})
+
+	assert.Nil(t, strips)
+	assertAppErrCode(t, err, http.StatusNotFound)
+}
+
+func TestApplyStates_InvalidColor(t *testing.T) {
+	m := createEventMocks(t)
+	m.expectDBStripGet(createValidDummyStrip())
+
+	strips, err := m.ls.ApplyStates([]model.StripState{
+		{StripID: 185, Color: &model.ColorProfile{Red: null.IntFrom(256)}},
+	})
+
+	assert.Nil(t, strips)
+	var verr *model.ValidationError
+	assert.ErrorAs(t, err, &verr)
+}
+
+func TestApplyStates_RevertOnError(t *testing.T) {
+	m := createEventMocks(t)
+	first := createValidDummyStrip()
+	second := createValidDummyStrip()
+	second.ID = 186
+	m.expectDBStripGet(first)
+	m.expectDBStripGet(second)
+	updFirst := *first
+	updFirst.Enabled = true
+	updSecond := *second
+	updSecond.Enabled = true
+	m.lsDbh.EXPECT().Update(*first, updFirst).Return(nil).Once()
+	m.lsDbh.EXPECT().Update(*second, updSecond).Return(errors.New("failed")).Once()
+	reverted := updFirst
+	reverted.Version++
+	m.lsDbh.EXPECT().Update(reverted, *first).Return(nil).Once()
+
+	strips, err := m.ls.ApplyStates([]model.StripState{
+		{StripID: 185, Enabled: true},
+		{StripID: 186, Enabled: true},
+	})
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Nil(t, strips)
+	assert.Error(t, err)
+	m.rec.mu.Lock()
+	defer m.rec.mu.Unlock()
+	assert.Empty(t, m.rec.strips)
+}
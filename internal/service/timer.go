@@ -0,0 +1,181 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-co-op/gocron"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/pthum/stripcontrol-golang/internal/validation"
+)
+
+// timers the running timers by the id of their strip, shared by all copies of the service
+type timers struct {
+	mu   sync.Mutex
+	jobs map[int64]*timerJob
+}
+
+type timerJob struct {
+	timer model.Timer
+	job   *gocron.Job
+}
+
+func (l *ledSvc) SetTimer(id string, req model.TimerRequest) (*model.TimerInfo, error) {
+	if err := validation.ValidateTimerRequest(req); err != nil {
+		return nil, err
+	}
+	// Get model if exist
+	strip, err := l.dbh.Get(id)
+	if err != nil {
+		return nil, model.NewAppErr(http.StatusNotFound, err)
+	}
+	if req.ProfileID.Valid {
+		if _, err := l.cpDbh.Get(strconv.FormatInt(req.ProfileID.Int64, 10)); err != nil {
+			return nil, notFoundErr("profileId", "profile", req.ProfileID.Int64)
+		}
+	}
+	// the duration is already validated
+	d, _ := time.ParseDuration(req.Duration)
+	timer := model.Timer{
+		BaseModel: model.BaseModel{ID: strip.ID},
+		Deadline:  l.now().UTC().Add(d).Round(time.Second),
+		Action:    req.Action,
+		ProfileID: req.ProfileID,
+	}
+	if timer.Action == "" {
+		timer.Action = model.ActionOff
+	}
+
+	l.timers.mu.Lock()
+	defer l.timers.mu.Unlock()
+	if err := l.tDbh.Save(&timer); err != nil {
+		return nil, model.NewAppErr(http.StatusInternalServerError, err)
+	}
+	if err := l.registerTimer(timer); err != nil {
+		return nil, model.NewAppErr(http.StatusInternalServerError, err)
+	}
+	l.l.Info("Started timer of strip %d until %s", strip.ID, timer.Deadline.Format(time.RFC3339))
+	return l.timerInfo(timer), nil
+}
+
+func (l *ledSvc) GetTimer(id int64) *model.TimerInfo {
+	l.timers.mu.Lock()
+	defer l.timers.mu.Unlock()
+	tj, ok := l.timers.jobs[id]
+	if !ok {
+		return nil
+	}
+	return l.timerInfo(tj.timer)
+}
+
+func (l *ledSvc) CancelTimer(id string) error {
+	// Get model if exist
+	strip, err := l.dbh.Get(id)
+	if err != nil {
+		return model.NewAppErr(http.StatusNotFound, err)
+	}
+	if !l.stopTimer(strip.ID) {
+		return model.NewAppErr(http.StatusNotFound, errors.New("no timer running"))
+	}
+	return nil
+}
+
+// restoreTimers registers the jobs of the stored timers, the expired ones run as soon as the scheduler is started
+func (l *ledSvc) restoreTimers() error {
+	stored, err := l.tDbh.GetAll()
+	if err != nil {
+		return err
+	}
+	l.timers.mu.Lock()
+	defer l.timers.mu.Unlock()
+	for _, timer := range stored {
+		// a broken timer must not prevent the others from running
+		if err := l.registerTimer(timer); err != nil {
+			l.l.Error("error registering timer of strip %d: %s", timer.ID, err.Error())
+		}
+	}
+	return nil
+}
+
+// registerTimer replaces the job of the strip with the one of the timer, the lock of the timers has to be held
+func (l *ledSvc) registerTimer(timer model.Timer) error {
+	if tj, ok := l.timers.jobs[timer.ID]; ok {
+		l.sched.RemoveByReference(tj.job)
+		delete(l.timers.jobs, timer.ID)
+	}
+	var sched *gocron.Scheduler
+	if remaining := timer.Deadline.Sub(l.now()); remaining > 0 {
+		sched = l.sched.Every(remaining).StartAt(timer.Deadline)
+	} else {
+		sched = l.sched.Every(1).Second().StartImmediately()
+	}
+	tj := &timerJob{timer: timer}
+	job, err := sched.LimitRunsTo(1).Tag(timerTag(timer.ID)).Do(l.expireTimer, tj)
+	if err != nil {
+		return err
+	}
+	tj.job = job
+	l.timers.jobs[timer.ID] = tj
+	return nil
+}
+
+// stopTimer removes the running timer of the strip, false if it has none. It is called on every change of the strip,
+// so the timer only performs its action on the state it was started for.
+func (l *ledSvc) stopTimer(id int64) bool {
+	l.timers.mu.Lock()
+	defer l.timers.mu.Unlock()
+	tj, ok := l.timers.jobs[id]
+	if !ok {
+		return false
+	}
+	l.sched.RemoveByReference(tj.job)
+	delete(l.timers.jobs, id)
+	if err := l.tDbh.Delete(&tj.timer); err != nil {
+		l.l.Error("error deleting timer of strip %d: %s", id, err.Error())
+	}
+	l.l.Info("Stopped timer of strip %d", id)
+	return true
+}
+
+// expireTimer performs the action of the timer, the errors are only logged as nobody waits for them
+func (l *ledSvc) expireTimer(tj *timerJob) {
+	id := tj.timer.ID
+	l.timers.mu.Lock()
+	// the job may have fired while the timer was stopped or replaced, the new timer must not be affected
+	running := l.timers.jobs[id] == tj
+	if running {
+		delete(l.timers.jobs, id)
+		if err := l.tDbh.Delete(&tj.timer); err != nil {
+			l.l.Error("error deleting timer of strip %d: %s", id, err.Error())
+		}
+	}
+	l.timers.mu.Unlock()
+	if !running {
+		return
+	}
+
+	state := model.StripState{StripID: id, Enabled: tj.timer.Action != model.ActionOff}
+	if tj.timer.Action == model.ActionSetProfile {
+		state.ProfileID = tj.timer.ProfileID
+	}
+	if _, err := l.WithActor(fmt.Sprintf("timer:%d", id)).ApplyStates([]model.StripState{state}); err != nil {
+		l.l.Error("error running timer of strip %d: %s", id, err.Error())
+		return
+	}
+	l.l.Info("Ran timer of strip %d", id)
+}
+
+// timerInfo the timer with the remaining time rounded up to the second
+func (l *ledSvc) timerInfo(timer model.Timer) *model.TimerInfo {
+	remaining := math.Ceil(timer.Deadline.Sub(l.now()).Seconds())
+	return &model.TimerInfo{Timer: timer, Remaining: int64(max(remaining, 0))}
+}
+
+func timerTag(id int64) string {
+	return "timer-" + strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/config"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSetTimer(t *testing.T) {
+	m := createEventMocks(t)
+	now := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+	m.ls.now = func() time.Time { return now }
+	strip := createValidDummyStrip()
+	m.expectDBStripGet(strip)
+	timer := model.Timer{BaseModel: model.BaseModel{ID: 185}, Deadline: now.Add(30 * time.Minute), Action: model.ActionOff}
+	m.tDbh.EXPECT().Save(&timer).Return(nil).Once()
+
+	res, err := m.ls.SetTimer("185", model.TimerRequest{Duration: "30m"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &model.TimerInfo{Timer: timer, Remaining: 1800}, res)
+	assert.Equal(t, res, m.ls.GetTimer(185))
+	assert.Equal(t, 1, m.sched.Len())
+	now = now.Add(10*time.Minute + 500*time.Millisecond)
+	assert.Equal(t, int64(1200), m.ls.GetTimer(185).Remaining)
+}
+
+func TestSetTimer_Replace(t *testing.T) {
+	m := createEventMocks(t)
+	m.ls.now = func() time.Time { return time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC) }
+	strip := createValidDummyStrip()
+	m.lsDbh.EXPECT().Get("185").Return(strip, nil).Twice()
+	m.expectDBProfileGet(createProfile(15, 1, 2, 3, 4), nil)
+	m.tDbh.EXPECT().Save(mock.Anything).Return(nil).Twice()
+
+	_, err := m.ls.SetTimer("185", model.TimerRequest{Duration: "30m"})
+	assert.NoError(t, err)
+	_, err = m.ls.SetTimer("185", model.TimerRequest{Duration: "1h", Action: model.ActionSetProfile, ProfileID: null.IntFrom(15)})
+	assert.NoError(t, err)
+
+	res := m.ls.GetTimer(185)
+	if assert.NotNil(t, res) {
+		assert.Equal(t, model.ActionSetProfile, res.Action)
+		assert.Equal(t, int64(3600), res.Remaining)
+	}
+	assert.Equal(t, 1, m.sched.Len())
+}
+
+func TestSetTimer_Invalid(t *testing.T) {
+	m := createEventMocks(t)
+
+	res, err := m.ls.SetTimer("185", model.TimerRequest{Duration: "forever"})
+
+	assert.Nil(t, res)
+	assertAppErrCode(t, err, http.StatusUnprocessableEntity)
+	assert.Equal(t, 0, m.sched.Len())
+}
+
+func TestSetTimer_StripNotFound(t *testing.T) {
+	m := createEventMocks(t)
+	m.lsDbh.EXPECT().Get("185").Return(nil, assert.AnError).Once()
+
+	res, err := m.ls.SetTimer("185", model.TimerRequest{Duration: "30m"})
+
+	assert.Nil(t, res)
+	assertAppErrCode(t, err, http.StatusNotFound)
+}
+
+func TestSetTimer_MissingProfile(t *testing.T) {
+	m := createEventMocks(t)
+	m.expectDBStripGet(createValidDummyStrip())
+	m.expectDBProfileGet(&model.ColorProfile{BaseModel: model.BaseModel{ID: 15}}, assert.AnError)
+
+	res, err := m.ls.SetTimer("185", model.TimerRequest{Duration: "30m", Action: model.ActionSetProfile, ProfileID: null.IntFrom(15)})
+
+	assert.Nil(t, res)
+	assertAppErrCode(t, err, http.StatusUnprocessableEntity)
+	assert.Equal(t, 0, m.sched.Len())
+}
+
+func TestCancelTimer(t *testing.T) {
+	m := createEventMocks(t)
+	strip := createValidDummyStrip()
+	m.lsDbh.EXPECT().Get("185").Return(strip, nil).Twice()
+	m.tDbh.EXPECT().Save(mock.Anything).Return(nil).Once()
+	_, err := m.ls.SetTimer("185", model.TimerRequest{Duration: "30m"})
+	assert.NoError(t, err)
+	m.tDbh.EXPECT().Delete(mock.Anything).Return(nil).Once()
+
+	err = m.ls.CancelTimer("185")
+
+	assert.NoError(t, err)
+	assert.Nil(t, m.ls.GetTimer(185))
+	assert.Equal(t, 0, m.sched.Len())
+}
+
+func TestCancelTimer_NoTimer(t *testing.T) {
+	m := createEventMocks(t)
+	m.expectDBStripGet(createValidDummyStrip())
+
+	err := m.ls.CancelTimer("185")
+
+	assertAppErrCode(t, err, http.StatusNotFound)
+}
+
+func TestTimerStoppedByChange(t *testing.T) {
+	m := createEventMocks(t)
+	strip := createValidDummyStrip()
+	m.lsDbh.EXPECT().Get("185").Return(strip, nil).Twice()
+	m.tDbh.EXPECT().Save(mock.Anything).Return(nil).Once()
+	_, err := m.ls.SetTimer("185", model.TimerRequest{Duration: "30m"})
+	assert.NoError(t, err)
+	m.lsDbh.EXPECT().Save(mock.Anything).Return(nil).Once()
+	m.tDbh.EXPECT().Delete(mock.Anything).Return(nil).Once()
+
+	err = m.ls.RemoveProfileForStrip("185")
+	time.Sleep(50 * time.Millisecond)
+
+	assert.NoError(t, err)
+	assert.Nil(t, m.ls.GetTimer(185))
+	assert.Equal(t, 0, m.sched.Len())
+}
+
+func TestTimerStoppedByProfileDelete(t *testing.T) {
+	m := createEventMocks(t)
+	m.cps.deletePolicy = config.ProfileDeleteDetach
+	strip := createValidDummyStrip()
+	strip.ProfileID = null.IntFrom(15)
+	m.lsDbh.EXPECT().Get("185").Return(strip, nil).Twice()
+	m.tDbh.EXPECT().Save(mock.Anything).Return(nil).Once()
+	_, err := m.ls.SetTimer("185", model.TimerRequest{Duration: "30m"})
+	assert.NoError(t, err)
+	m.expectDBProfileGet(createProfile(15, 1, 2, 3, 4), nil)
+	m.lsDbh.EXPECT().GetAll().Return([]model.LedStrip{*strip}, nil).Once()
+	m.cpDbh.EXPECT().Delete(mock.Anything).Return(nil).Once()
+	m.lsDbh.EXPECT().Save(mock.Anything).Return(nil).Once()
+	m.tDbh.EXPECT().Delete(mock.Anything).Return(nil).Once()
+
+	// the strip detached from the deleted profile is changed like a manual change
+	assert.NoError(t, m.cps.DeleteColorProfile("15", 0))
+
+	assert.Nil(t, m.ls.GetTimer(185))
+	m.rec.mu.Lock()
+	defer m.rec.mu.Unlock()
+	if assert.Len(t, m.rec.strips, 1) {
+		assert.False(t, m.rec.strips[0].Strip.Strip.Profile.Valid)
+	}
+}
+
+func TestExpireTimer(t *testing.T) {
+	m := createEventMocks(t)
+	strip := createValidDummyStrip()
+	profile := createProfile(15, 1, 2, 3, 4)
+	m.lsDbh.EXPECT().Get("185").Return(strip, nil).Twice()
+	m.expectDBProfileGet(profile, nil)
+	m.tDbh.EXPECT().Save(mock.Anything).Return(nil).Once()
+	_, err := m.ls.SetTimer("185", model.TimerRequest{Duration: "30m", Action: model.ActionSetProfile, ProfileID: null.IntFrom(15)})
+	assert.NoError(t, err)
+	m.tDbh.EXPECT().Delete(mock.Anything).Return(nil).Once()
+	m.expectDBProfileGet(profile, nil)
+	updated := *strip
+	updated.Enabled = true
+	updated.ProfileID = null.IntFrom(15)
+	m.lsDbh.EXPECT().Update(*strip, updated).Return(nil).Once()
+
+	m.ls.expireTimer(m.ls.timers.jobs[185])
+
+	assert.Nil(t, m.ls.GetTimer(185))
+	m.rec.mu.Lock()
+	defer m.rec.mu.Unlock()
+	if assert.Len(t, m.rec.strips, 1) {
+		assert.Equal(t, "timer:185", m.rec.strips[0].Meta.Actor)
+		assert.True(t, m.rec.strips[0].Strip.Strip.Enabled)
+	}
+}
+
+func TestExpireTimer_Stopped(t *testing.T) {
+	m := createEventMocks(t)
+
+	m.ls.expireTimer(&timerJob{timer: model.Timer{BaseModel: model.BaseModel{ID: 185}}})
+
+	m.rec.mu.Lock()
+	defer m.rec.mu.Unlock()
+	assert.Empty(t, m.rec.strips)
+}
+
+func TestExpireTimer_Replaced(t *testing.T) {
+	m := createEventMocks(t)
+	m.lsDbh.EXPECT().Get("185").Return(createValidDummyStrip(), nil).Twice()
+	m.tDbh.EXPECT().Save(mock.Anything).Return(nil).Twice()
+	_, err := m.ls.SetTimer("185", model.TimerRequest{Duration: "30m"})
+	assert.NoError(t, err)
+	replaced := m.ls.timers.jobs[185]
+	_, err = m.ls.SetTimer("185", model.TimerRequest{Duration: "1h", Action: model.ActionOn})
+	assert.NoError(t, err)
+
+	// the job of the replaced timer fired before it was removed
+	m.ls.expireTimer(replaced)
+
+	res := m.ls.GetTimer(185)
+	if assert.NotNil(t, res) {
+		assert.Equal(t, model.ActionOn, res.Action)
+	}
+	m.rec.mu.Lock()
+	defer m.rec.mu.Unlock()
+	assert.Empty(t, m.rec.strips)
+}
+
+func TestRestoreTimers(t *testing.T) {
+	future := model.Timer{BaseModel: model.BaseModel{ID: 185}, Deadline: time.Now().Add(time.Hour), Action: model.ActionOff}
+	expired := model.Timer{BaseModel: model.BaseModel{ID: 186}, Deadline: time.Now().Add(-time.Minute), Action: model.ActionOff}
+	m := createEventMocks(t, future, expired)
+	assert.Equal(t, 2, m.sched.Len())
+	assert.NotNil(t, m.ls.GetTimer(185))
+	assert.Equal(t, int64(0), m.ls.GetTimer(186).Remaining)
+
+	strip := createValidDummyStrip()
+	strip.ID = 186
+	strip.Enabled = true
+	m.expectDBStripGet(strip)
+	updated := *strip
+	updated.Enabled = false
+	m.lsDbh.EXPECT().Update(*strip, updated).Return(nil).Once()
+	m.tDbh.EXPECT().Delete(&expired).Return(nil).Once()
+	m.sched.StartAsync()
+	t.Cleanup(m.sched.Stop)
+
+	assert.Eventually(t, func() bool {
+		m.rec.mu.Lock()
+		defer m.rec.mu.Unlock()
+		return len(m.rec.strips) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Nil(t, m.ls.GetTimer(186))
+	assert.NotNil(t, m.ls.GetTimer(185))
+}
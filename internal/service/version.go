@@ -0,0 +1,27 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/pthum/stripcontrol-golang/internal/database"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+)
+
+// checkVersion fails if a version is expected and the object has a different one, 0 expects no version
+func checkVersion(expected int64, obj model.Versioned) error {
+	if expected != 0 && expected != obj.GetVersion() {
+		return model.NewAppErr(http.StatusPreconditionFailed,
+			fmt.Errorf("version %d doesn't match the current version %d", expected, obj.GetVersion()))
+	}
+	return nil
+}
+
+// updateErr maps the error of a database update to an app error
+func updateErr(err error) error {
+	if errors.Is(err, database.ErrVersionConflict) {
+		return model.NewAppErr(http.StatusPreconditionFailed, err)
+	}
+	return model.NewAppErr(http.StatusBadRequest, err)
+}
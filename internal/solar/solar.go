@@ -0,0 +1,75 @@
+// Package solar computes the times of the solar events locally with the sunrise equation, the results are accurate
+// to about a minute away from the polar regions
+package solar
+
+import (
+	"math"
+	"time"
+
+	"github.com/pthum/stripcontrol-golang/internal/model"
+)
+
+const (
+	// julian2000 the julian day of 2000-01-01 12:00 UTC
+	julian2000 = 2451545.0
+	// julianUnix the julian day of the unix epoch
+	julianUnix = 2440587.5
+	// obliquity the axial tilt of the earth in degrees
+	obliquity = 23.4397
+)
+
+// elevations the elevation of the center of the sun at the events in degrees, sunrise and sunset account for the
+// refraction and the radius of the sun
+var elevations = map[model.SolarEvent]float64{
+	model.Sunrise:   -0.833,
+	model.Sunset:    -0.833,
+	model.CivilDawn: -6,
+	model.CivilDusk: -6,
+}
+
+// Time the time of the event on the date at the position, the date is the calendar day at the position. False is
+// returned for unknown events and if the sun doesn't reach the elevation of the event on that day, like during the
+// polar night.
+func Time(event model.SolarEvent, date time.Time, latitude, longitude float64) (time.Time, bool) {
+	elevation, ok := elevations[event]
+	if !ok {
+		return time.Time{}, false
+	}
+	// the day since 2000-01-01 and the mean solar noon at the longitude
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	n := math.Ceil(toJulian(midnight) - julian2000 + 0.0008)
+	meanNoon := n - longitude/360
+
+	anomaly := math.Mod(357.5291+0.98560028*meanNoon, 360)
+	center := 1.9148*sin(anomaly) + 0.02*sin(2*anomaly) + 0.0003*sin(3*anomaly)
+	eclipticLongitude := math.Mod(anomaly+center+180+102.9372, 360)
+	transit := julian2000 + meanNoon + 0.0053*sin(anomaly) - 0.0069*sin(2*eclipticLongitude)
+	declination := math.Asin(sin(eclipticLongitude) * sin(obliquity))
+
+	cosHourAngle := (sin(elevation) - sin(latitude)*math.Sin(declination)) / (cos(latitude) * math.Cos(declination))
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		return time.Time{}, false
+	}
+	hourAngle := math.Acos(cosHourAngle) * 180 / math.Pi
+	if event == model.Sunrise || event == model.CivilDawn {
+		return fromJulian(transit - hourAngle/360), true
+	}
+	return fromJulian(transit + hourAngle/360), true
+}
+
+func toJulian(t time.Time) float64 {
+	return float64(t.Unix())/86400 + julianUnix
+}
+
+// fromJulian the time of the julian day, rounded to the second
+func fromJulian(j float64) time.Time {
+	return time.Unix(int64(math.Round((j-julianUnix)*86400)), 0).UTC()
+}
+
+func sin(deg float64) float64 {
+	return math.Sin(deg * math.Pi / 180)
+}
+
+func cos(deg float64) float64 {
+	return math.Cos(deg * math.Pi / 180)
+}
@@ -0,0 +1,61 @@
+package solar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTime(t *testing.T) {
+	berlin := [2]float64{52.52, 13.405}
+	newYork := [2]float64{40.7128, -74.006}
+	sydney := [2]float64{-33.8688, 151.2093}
+	tests := []struct {
+		name     string
+		event    model.SolarEvent
+		date     time.Time
+		position [2]float64
+		want     time.Time
+	}{
+		{"berlin sunrise", model.Sunrise, date(2024, 6, 21), berlin, time.Date(2024, 6, 21, 2, 43, 0, 0, time.UTC)},
+		{"berlin sunset", model.Sunset, date(2024, 6, 21), berlin, time.Date(2024, 6, 21, 19, 33, 0, 0, time.UTC)},
+		{"berlin civil dawn", model.CivilDawn, date(2024, 12, 21), berlin, time.Date(2024, 12, 21, 6, 33, 0, 0, time.UTC)},
+		{"berlin civil dusk", model.CivilDusk, date(2024, 12, 21), berlin, time.Date(2024, 12, 21, 15, 36, 0, 0, time.UTC)},
+		{"new york sunrise", model.Sunrise, date(2024, 3, 20), newYork, time.Date(2024, 3, 20, 10, 58, 0, 0, time.UTC)},
+		{"new york sunset", model.Sunset, date(2024, 3, 20), newYork, time.Date(2024, 3, 20, 23, 8, 0, 0, time.UTC)},
+		// the local morning is on the previous day in UTC
+		{"sydney sunrise", model.Sunrise, date(2024, 12, 21), sydney, time.Date(2024, 12, 20, 18, 41, 0, 0, time.UTC)},
+		{"sydney sunset", model.Sunset, date(2024, 12, 21), sydney, time.Date(2024, 12, 21, 9, 5, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Time(tt.event, tt.date, tt.position[0], tt.position[1])
+
+			assert.True(t, ok)
+			assert.WithinDuration(t, tt.want, got, 2*time.Minute)
+		})
+	}
+}
+
+func TestTime_Polar(t *testing.T) {
+	tromso := [2]float64{69.6496, 18.956}
+
+	_, ok := Time(model.Sunset, date(2024, 6, 21), tromso[0], tromso[1])
+	assert.False(t, ok, "midnight sun")
+	_, ok = Time(model.Sunrise, date(2024, 12, 21), tromso[0], tromso[1])
+	assert.False(t, ok, "polar night")
+	_, ok = Time(model.CivilDawn, date(2024, 12, 21), tromso[0], tromso[1])
+	assert.True(t, ok, "civil twilight during the polar night")
+}
+
+func TestTime_UnknownEvent(t *testing.T) {
+	_, ok := Time("noon", date(2024, 6, 21), 52.52, 13.405)
+
+	assert.False(t, ok)
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
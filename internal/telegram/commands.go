@@ -4,14 +4,17 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	alog "github.com/pthum/stripcontrol-golang/internal/log"
+	"github.com/pthum/stripcontrol-golang/internal/model"
 	"github.com/pthum/stripcontrol-golang/internal/service"
 	"github.com/samber/do"
 )
 
 var rx_digits, _ = regexp.Compile(`\d+`)
+var rx_id, _ = regexp.Compile(`^\d+$`)
 
 type botCommand struct {
 	Cmd         string
@@ -43,6 +46,11 @@ func (c *cmdHandler) GetCommands() []botCommand {
 			Description: "Set an LED Strip to off, use: /ledoff <id>",
 			Action:      c.actionLedOff,
 		},
+		{
+			Cmd:         "/timer",
+			Description: "Turn an LED Strip off after a duration like 30m, use: /timer <id> <duration|cancel>",
+			Action:      c.actionTimer,
+		},
 		{
 			Cmd:         "/getstrips",
 			Description: "Returns all LED Strips, use: /getstrips",
@@ -64,6 +72,28 @@ func (c *cmdHandler) actionLedOff(inp *tgbotapi.Message) string {
 	return c.setLEDState(false, inp)
 }
 
+func (c *cmdHandler) actionTimer(inp *tgbotapi.Message) string {
+	args := strings.Fields(inp.Text)
+	if len(args) != 3 || !rx_id.MatchString(args[1]) {
+		return "Usage: /timer <id> <duration|cancel>"
+	}
+	id := args[1]
+	lsvc := c.lsvc.WithActor(telegramActor(inp))
+	if args[2] == "cancel" {
+		if err := lsvc.CancelTimer(id); err != nil {
+			c.l.Error("Error cancelling the timer of ID %s: %v", id, err)
+			return fmt.Sprintf("Error cancelling the timer of ID %s", id)
+		}
+		return fmt.Sprintf("Cancelled the timer of ID %s", id)
+	}
+	timer, err := lsvc.SetTimer(id, model.TimerRequest{Duration: args[2]})
+	if err != nil {
+		c.l.Error("Error setting the timer of ID %s: %v", id, err)
+		return fmt.Sprintf("Error setting the timer of ID %s", id)
+	}
+	return fmt.Sprintf("Turning off ID %s in %s", id, time.Duration(timer.Remaining)*time.Second)
+}
+
 func (c *cmdHandler) actionGetAll(inp *tgbotapi.Message) string {
 	strips, err := c.lsvc.GetAll()
 	if err != nil {
@@ -100,14 +130,10 @@ func (c *cmdHandler) setLEDState(enable bool, inp *tgbotapi.Message) string {
 		state = "Enabled"
 	}
 	msg := fmt.Sprintf("Turning %s LED(s) with id %s\n", action, procid)
+	patch := fmt.Sprintf(`{"enabled":%t}`, enable)
+	lsvc := c.lsvc.WithActor(telegramActor(inp))
 	for _, id := range procid {
-		s, err := c.lsvc.GetLEDStrip(id)
-		if err != nil {
-			msg += fmt.Sprintf("Error getting ID %v\n", id)
-			continue
-		}
-		s.Enabled = enable
-		err = c.lsvc.UpdateLEDStrip(id, *s)
+		_, err := lsvc.PatchLEDStrip(id, 0, []byte(patch))
 		if err != nil {
 			msg += fmt.Sprintf("Error updating ID %v\n", id)
 		} else {
@@ -117,6 +143,17 @@ func (c *cmdHandler) setLEDState(enable bool, inp *tgbotapi.Message) string {
 	return msg
 }
 
+// telegramActor identifies the sender of the message as the actor of the caused events
+func telegramActor(msg *tgbotapi.Message) string {
+	if msg.From == nil {
+		return "telegram"
+	}
+	if msg.From.UserName != "" {
+		return "telegram:" + msg.From.UserName
+	}
+	return fmt.Sprintf("telegram:%d", msg.From.ID)
+}
+
 func (c *cmdHandler) stripIdForMsg(msg string) []string {
 	procId := strings.TrimSpace(msg)
 	if procId == "" {
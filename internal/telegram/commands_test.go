@@ -18,7 +18,7 @@ type cmdMocks struct {
 	ch   *cmdHandler
 }
 
-const expectedCommandCount = 4
+const expectedCommandCount = 5
 
 func TestGetCommands(t *testing.T) {
 	mocks := createCmdHandlerMocks(t)
@@ -76,15 +76,11 @@ func TestSetLed_Disable(t *testing.T) {
 	mocks := createCmdHandlerMocks(t)
 	mocks.lsvc.
 		EXPECT().
-		GetLEDStrip(id).
-		Return(&model.LedStrip{BaseModel: model.BaseModel{ID: 12}, Name: "test"}, nil)
-	mocks.lsvc.
-		EXPECT().
-		UpdateLEDStrip(id, mock.Anything).
-		Run(func(id string, updMdl model.LedStrip) {
-			assert.False(t, updMdl.Enabled)
+		PatchLEDStrip(id, int64(0), mock.Anything).
+		Run(func(id string, version int64, patch []byte) {
+			assert.JSONEq(t, `{"enabled":false}`, string(patch))
 		}).
-		Return(nil)
+		Return(&model.LedStrip{BaseModel: model.BaseModel{ID: 12}, Name: "test"}, nil)
 	msg := createTestMessage("/ledoff " + id)
 
 	res := mocks.ch.setLEDState(false, msg)
@@ -99,15 +95,11 @@ func TestSetLed_Enable(t *testing.T) {
 	mocks := createCmdHandlerMocks(t)
 	mocks.lsvc.
 		EXPECT().
-		GetLEDStrip(id).
-		Return(&model.LedStrip{BaseModel: model.BaseModel{ID: 12}, Name: "test"}, nil)
-	mocks.lsvc.
-		EXPECT().
-		UpdateLEDStrip(id, mock.Anything).
-		Run(func(id string, updMdl model.LedStrip) {
-			assert.True(t, updMdl.Enabled)
+		PatchLEDStrip(id, int64(0), mock.Anything).
+		Run(func(id string, version int64, patch []byte) {
+			assert.JSONEq(t, `{"enabled":true}`, string(patch))
 		}).
-		Return(nil)
+		Return(&model.LedStrip{BaseModel: model.BaseModel{ID: 12}, Name: "test", Enabled: true}, nil)
 	msg := createTestMessage("/ledon " + id)
 
 	res := mocks.ch.setLEDState(true, msg)
@@ -123,20 +115,12 @@ func TestSetLed_EnableMulti(t *testing.T) {
 	mocks := createCmdHandlerMocks(t)
 	mocks.lsvc.
 		EXPECT().
-		GetLEDStrip(id1).
-		Return(&model.LedStrip{BaseModel: model.BaseModel{ID: 12}, Name: "test"}, nil)
-	mocks.lsvc.
-		EXPECT().
-		UpdateLEDStrip(id1, mock.Anything).
-		Return(nil)
-	mocks.lsvc.
-		EXPECT().
-		GetLEDStrip(id2).
-		Return(&model.LedStrip{BaseModel: model.BaseModel{ID: 23}, Name: "test"}, nil)
+		PatchLEDStrip(id1, int64(0), mock.Anything).
+		Return(&model.LedStrip{BaseModel: model.BaseModel{ID: 12}, Name: "test", Enabled: true}, nil)
 	mocks.lsvc.
 		EXPECT().
-		UpdateLEDStrip(id2, mock.Anything).
-		Return(nil)
+		PatchLEDStrip(id2, int64(0), mock.Anything).
+		Return(&model.LedStrip{BaseModel: model.BaseModel{ID: 23}, Name: "test", Enabled: true}, nil)
 	msg := createTestMessage("/ledon " + id1 + " " + id2)
 
 	res := mocks.ch.setLEDState(true, msg)
@@ -144,6 +128,7 @@ func TestSetLed_EnableMulti(t *testing.T) {
 	assert.NotEmpty(t, res)
 	assert.Contains(t, res, "Turning on ")
 	assert.Contains(t, res, "Enabled ID "+id1)
+	assert.Contains(t, res, "Enabled ID "+id2)
 }
 
 func TestSetLed_EnableNoIDs(t *testing.T) {
@@ -156,12 +141,12 @@ func TestSetLed_EnableNoIDs(t *testing.T) {
 	assert.Contains(t, res, "Turning on ")
 }
 
-func TestSetLed_EnableGetError(t *testing.T) {
+func TestSetLed_EnableUpdateError(t *testing.T) {
 	id := "12"
 	mocks := createCmdHandlerMocks(t)
 	mocks.lsvc.
 		EXPECT().
-		GetLEDStrip(id).
+		PatchLEDStrip(id, int64(0), mock.Anything).
 		Return(nil, assert.AnError)
 	msg := createTestMessage("/ledon " + id)
 
@@ -169,27 +154,70 @@ func TestSetLed_EnableGetError(t *testing.T) {
 
 	assert.NotEmpty(t, res)
 	assert.Contains(t, res, "Turning on ")
-	assert.Contains(t, res, "Error getting ID "+id)
+	assert.Contains(t, res, "Error updating ID "+id)
 }
 
-func TestSetLed_EnableUpdateError(t *testing.T) {
-	id := "12"
+func TestActionTimer(t *testing.T) {
 	mocks := createCmdHandlerMocks(t)
 	mocks.lsvc.
 		EXPECT().
-		GetLEDStrip(id).
-		Return(&model.LedStrip{BaseModel: model.BaseModel{ID: 12}, Name: "test"}, nil)
+		SetTimer("3", model.TimerRequest{Duration: "30m"}).
+		Return(&model.TimerInfo{Remaining: 1800}, nil).
+		Once()
+	msg := createTestMessage("/timer 3 30m")
+
+	res := mocks.ch.actionTimer(msg)
+
+	assert.Equal(t, "Turning off ID 3 in 30m0s", res)
+}
+
+func TestActionTimer_Error(t *testing.T) {
+	mocks := createCmdHandlerMocks(t)
 	mocks.lsvc.
 		EXPECT().
-		UpdateLEDStrip(id, mock.Anything).
-		Return(assert.AnError)
-	msg := createTestMessage("/ledon " + id)
+		SetTimer("3", model.TimerRequest{Duration: "soon"}).
+		Return(nil, assert.AnError).
+		Once()
+	msg := createTestMessage("/timer 3 soon")
 
-	res := mocks.ch.setLEDState(true, msg)
+	res := mocks.ch.actionTimer(msg)
 
-	assert.NotEmpty(t, res)
-	assert.Contains(t, res, "Turning on ")
-	assert.Contains(t, res, "Error updating ID "+id)
+	assert.Equal(t, "Error setting the timer of ID 3", res)
+}
+
+func TestActionTimer_Cancel(t *testing.T) {
+	mocks := createCmdHandlerMocks(t)
+	mocks.lsvc.
+		EXPECT().
+		CancelTimer("3").
+		Return(nil).
+		Once()
+	msg := createTestMessage("/timer 3 cancel")
+
+	res := mocks.ch.actionTimer(msg)
+
+	assert.Equal(t, "Cancelled the timer of ID 3", res)
+}
+
+func TestActionTimer_Usage(t *testing.T) {
+	mocks := createCmdHandlerMocks(t)
+
+	for _, text := range []string{"/timer", "/timer 3", "/timer x 30m", "/timer 12abc 30m", "/timer a1 cancel", "/timer 3 30m now"} {
+		res := mocks.ch.actionTimer(createTestMessage(text))
+
+		assert.Contains(t, res, "Usage: ")
+	}
+}
+
+func TestTelegramActor(t *testing.T) {
+	msg := createTestMessage("/ledon 1")
+	assert.Equal(t, "telegram", telegramActor(msg))
+
+	msg.From = &tgbotapi.User{ID: 42}
+	assert.Equal(t, "telegram:42", telegramActor(msg))
+
+	msg.From.UserName = "jane"
+	assert.Equal(t, "telegram:jane", telegramActor(msg))
 }
 
 func TestCommandForMsg(t *testing.T) {
@@ -237,6 +265,7 @@ func createTestMessage(text string) *tgbotapi.Message {
 func createCmdHandlerMocks(t *testing.T) *cmdMocks {
 	i := do.New()
 	lsvc := servicemocks.NewLEDService(t)
+	lsvc.EXPECT().WithActor(mock.Anything).Return(lsvc).Maybe()
 	do.ProvideValue[service.LEDService](i, lsvc)
 	ch := NewCmdHandler(i)
 	return &cmdMocks{
@@ -0,0 +1,240 @@
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	MaxColor = 255
+	// MaxBrightness the APA102 supports 5 bit of global brightness
+	MaxBrightness = 31
+	// MaxSpeedHz the upper limit for the SPI clock of the strips
+	MaxSpeedHz = 32000000
+	// MaxPin the highest GPIO number (BCM) available on the header of the Raspberry Pi
+	MaxPin = 27
+	// MaxSolarOffset the maximum offset of a schedule from its solar event in minutes
+	MaxSolarOffset = 720
+	// MaxTimerDuration the longest duration of a timer
+	MaxTimerDuration = 24 * time.Hour
+)
+
+// ValidateColorProfile checks the colors and brightness of the profile, unset values are allowed
+func ValidateColorProfile(profile model.ColorProfile) error {
+	v := &validator{}
+	v.color("", profile)
+	return v.result()
+}
+
+// ValidateLedStrip checks the hardware configuration of the strip, unset values are allowed
+func ValidateLedStrip(strip model.LedStrip) error {
+	v := &validator{}
+	v.inRange("misoPin", strip.MisoPin, 0, MaxPin)
+	v.inRange("sclkPin", strip.SclkPin, 0, MaxPin)
+	v.inRange("speedHz", strip.SpeedHz, 1, MaxSpeedHz)
+	if strip.NumLeds.Valid && strip.NumLeds.Int64 <= 0 {
+		v.add("numLeds", "must be greater than 0")
+	}
+	if strip.MisoPin.Valid && strip.SclkPin.Valid && strip.MisoPin.Int64 == strip.SclkPin.Int64 {
+		v.add("sclkPin", "must differ from misoPin")
+	}
+	return v.result()
+}
+
+// ValidateScene checks the name and the states of the scene, each strip may only be contained once
+func ValidateScene(scene model.Scene) error {
+	v := &validator{}
+	if strings.TrimSpace(scene.Name) == "" {
+		v.add("name", "must not be empty")
+	}
+	seen := map[int64]bool{}
+	for i, state := range scene.Strips {
+		prefix := fmt.Sprintf("strips[%d].", i)
+		if state.StripID <= 0 {
+			v.add(prefix+"stripId", "must be greater than 0")
+		} else if seen[state.StripID] {
+			v.add(prefix+"stripId", "must be unique")
+		}
+		seen[state.StripID] = true
+		if state.ProfileID.Valid && state.Color != nil {
+			v.add(prefix+"color", "must not be set together with profileId")
+		}
+		if state.Color != nil {
+			v.color(prefix+"color.", *state.Color)
+		}
+	}
+	return v.result()
+}
+
+// ValidateGroup checks the name and the members of the group, each strip and nested group may only be contained once
+func ValidateGroup(group model.Group) error {
+	v := &validator{}
+	if strings.TrimSpace(group.Name) == "" {
+		v.add("name", "must not be empty")
+	}
+	v.ids("strips", group.Strips)
+	v.ids("groups", group.Groups)
+	return v.result()
+}
+
+// weekdays the names of the days as used by cron expressions
+var weekdays = map[string]bool{"sun": true, "mon": true, "tue": true, "wed": true, "thu": true, "fri": true, "sat": true}
+
+// ValidateSchedule checks that the schedule has either a cron expression, a time or a solar event, its timezone,
+// target and action
+func ValidateSchedule(schedule model.Schedule) error {
+	v := &validator{}
+	if strings.TrimSpace(schedule.Name) == "" {
+		v.add("name", "must not be empty")
+	}
+	switch {
+	case schedule.Cron != "" && (schedule.Time != "" || schedule.Solar != "" || len(schedule.Weekdays) > 0):
+		v.add("cron", "must not be set together with time, solar and weekdays")
+	case schedule.Cron != "":
+		v.cron("cron", schedule.Cron)
+	case schedule.Time != "" && schedule.Solar != "":
+		v.add("solar", "must not be set together with time")
+	case schedule.Solar != "":
+		v.solar(schedule.Solar, schedule.Offset)
+	case schedule.Time == "":
+		v.add("time", "must be set if neither a cron expression nor a solar event is given")
+	default:
+		if _, err := time.Parse("15:04", schedule.Time); err != nil {
+			v.add("time", "must be formatted as HH:MM")
+		}
+	}
+	if schedule.Solar == "" && schedule.Offset != 0 {
+		v.add("offset", "must only be set for a solar event")
+	}
+	seen := map[string]bool{}
+	for i, day := range schedule.Weekdays {
+		field := fmt.Sprintf("weekdays[%d]", i)
+		if !weekdays[day] {
+			v.add(field, "must be one of sun, mon, tue, wed, thu, fri, sat")
+		} else if seen[day] {
+			v.add(field, "must be unique")
+		}
+		seen[day] = true
+	}
+	if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+		v.add("timezone", "must be a known timezone")
+	}
+	switch schedule.TargetType {
+	case model.TargetStrip, model.TargetScene, model.TargetGroup:
+	default:
+		v.add("targetType", "must be one of strip, scene, group")
+	}
+	if schedule.TargetID <= 0 {
+		v.add("targetId", "must be greater than 0")
+	}
+	v.action(schedule.Action, schedule.ProfileID)
+	return v.result()
+}
+
+// ValidateTimerRequest checks the duration and the end action of the timer, no action stands for off
+func ValidateTimerRequest(req model.TimerRequest) error {
+	v := &validator{}
+	d, err := time.ParseDuration(req.Duration)
+	switch {
+	case err != nil:
+		v.add("duration", "must be a duration like 30m or 1h30m")
+	case d <= 0 || d > MaxTimerDuration:
+		v.add("duration", fmt.Sprintf("must be greater than 0 and at most %s", MaxTimerDuration))
+	}
+	action := req.Action
+	if action == "" {
+		action = model.ActionOff
+	}
+	v.action(action, req.ProfileID)
+	return v.result()
+}
+
+type validator struct {
+	errs []model.FieldError
+}
+
+// color checks the color and brightness values, the fields are prefixed for nested profiles
+func (v *validator) color(prefix string, profile model.ColorProfile) {
+	v.inRange(prefix+"red", profile.Red, 0, MaxColor)
+	v.inRange(prefix+"green", profile.Green, 0, MaxColor)
+	v.inRange(prefix+"blue", profile.Blue, 0, MaxColor)
+	v.inRange(prefix+"brightness", profile.Brightness, 0, MaxBrightness)
+}
+
+// cron checks the standard cron expression, the timezone is given separately
+func (v *validator) cron(field string, expr string) {
+	if strings.HasPrefix(expr, "TZ=") || strings.HasPrefix(expr, "CRON_TZ=") {
+		v.add(field, "must not contain a timezone")
+		return
+	}
+	if _, err := cron.ParseStandard(expr); err != nil {
+		v.add(field, "must be a valid cron expression: "+err.Error())
+	}
+}
+
+// solar checks the event and that the offset stays within half a day
+func (v *validator) solar(event model.SolarEvent, offset int) {
+	switch event {
+	case model.Sunrise, model.Sunset, model.CivilDawn, model.CivilDusk:
+	default:
+		v.add("solar", "must be one of sunrise, sunset, civil-dawn, civil-dusk")
+	}
+	if offset < -MaxSolarOffset || offset > MaxSolarOffset {
+		v.add("offset", fmt.Sprintf("must be between %d and %d", -MaxSolarOffset, MaxSolarOffset))
+	}
+}
+
+// ids checks that the referenced ids are valid and unique
+func (v *validator) ids(field string, ids model.IDs) {
+	seen := map[int64]bool{}
+	for i, id := range ids {
+		if id <= 0 {
+			v.add(fmt.Sprintf("%s[%d]", field, i), "must be greater than 0")
+		} else if seen[id] {
+			v.add(fmt.Sprintf("%s[%d]", field, i), "must be unique")
+		}
+		seen[id] = true
+	}
+}
+
+// action checks the action and that the profile is only given for the set-profile action
+func (v *validator) action(action model.ScheduleAction, profileID null.Int) {
+	switch action {
+	case model.ActionOn, model.ActionOff:
+		if profileID.Valid {
+			v.add("profileId", "must only be set for the set-profile action")
+		}
+	case model.ActionSetProfile:
+		if !profileID.Valid {
+			v.add("profileId", "must be set for the set-profile action")
+		}
+	default:
+		v.add("action", "must be one of on, off, set-profile")
+	}
+}
+
+func (v *validator) inRange(field string, value null.Int, min, max int64) {
+	if !value.Valid {
+		return
+	}
+	if value.Int64 < min || value.Int64 > max {
+		v.add(field, fmt.Sprintf("must be between %d and %d", min, max))
+	}
+}
+
+func (v *validator) add(field string, message string) {
+	v.errs = append(v.errs, model.FieldError{Field: field, Message: message})
+}
+
+func (v *validator) result() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return model.NewAppErr(http.StatusUnprocessableEntity, &model.ValidationError{Errors: v.errs})
+}
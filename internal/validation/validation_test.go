@@ -0,0 +1,181 @@
+package validation
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pthum/null"
+	"github.com/pthum/stripcontrol-golang/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateColorProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile model.ColorProfile
+		fields  []string
+	}{
+		{"valid", model.ColorProfile{Red: null.IntFrom(255), Green: null.IntFrom(0), Blue: null.IntFrom(10), Brightness: null.IntFrom(31)}, nil},
+		{"unset values", model.ColorProfile{}, nil},
+		{"color too high", model.ColorProfile{Red: null.IntFrom(256)}, []string{"red"}},
+		{"negative color", model.ColorProfile{Green: null.IntFrom(-1)}, []string{"green"}},
+		{"brightness too high", model.ColorProfile{Brightness: null.IntFrom(32)}, []string{"brightness"}},
+		{"multiple", model.ColorProfile{Blue: null.IntFrom(300), Brightness: null.IntFrom(-2)}, []string{"blue", "brightness"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFields(t, ValidateColorProfile(tt.profile), tt.fields)
+		})
+	}
+}
+
+func TestValidateLedStrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		strip  model.LedStrip
+		fields []string
+	}{
+		{"valid", model.LedStrip{MisoPin: null.IntFrom(10), SclkPin: null.IntFrom(11), NumLeds: null.IntFrom(60), SpeedHz: null.IntFrom(800000)}, nil},
+		{"unset values", model.LedStrip{}, nil},
+		{"pin out of range", model.LedStrip{MisoPin: null.IntFrom(28), SclkPin: null.IntFrom(-1)}, []string{"misoPin", "sclkPin"}},
+		{"same pins", model.LedStrip{MisoPin: null.IntFrom(10), SclkPin: null.IntFrom(10)}, []string{"sclkPin"}},
+		{"no leds", model.LedStrip{NumLeds: null.IntFrom(0)}, []string{"numLeds"}},
+		{"speed zero", model.LedStrip{SpeedHz: null.IntFrom(0)}, []string{"speedHz"}},
+		{"speed too high", model.LedStrip{SpeedHz: null.IntFrom(MaxSpeedHz + 1)}, []string{"speedHz"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFields(t, ValidateLedStrip(tt.strip), tt.fields)
+		})
+	}
+}
+
+func TestValidateScene(t *testing.T) {
+	color := &model.ColorProfile{Red: null.IntFrom(300), Brightness: null.IntFrom(31)}
+	tests := []struct {
+		name   string
+		scene  model.Scene
+		fields []string
+	}{
+		{"valid", model.Scene{Name: "Evening", Strips: model.StripStates{{StripID: 1, ProfileID: null.IntFrom(2)}, {StripID: 2, Color: &model.ColorProfile{Red: null.IntFrom(10)}}}}, nil},
+		{"no strips", model.Scene{Name: "Empty"}, nil},
+		{"no name", model.Scene{Name: " "}, []string{"name"}},
+		{"invalid strip", model.Scene{Name: "a", Strips: model.StripStates{{StripID: 0}}}, []string{"strips[0].stripId"}},
+		{"duplicate strip", model.Scene{Name: "a", Strips: model.StripStates{{StripID: 1}, {StripID: 1}}}, []string{"strips[1].stripId"}},
+		{"profile and color", model.Scene{Name: "a", Strips: model.StripStates{{StripID: 1, ProfileID: null.IntFrom(2), Color: &model.ColorProfile{}}}}, []string{"strips[0].color"}},
+		{"invalid color", model.Scene{Name: "a", Strips: model.StripStates{{StripID: 1, Color: color}}}, []string{"strips[0].color.red"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFields(t, ValidateScene(tt.scene), tt.fields)
+		})
+	}
+}
+
+func TestValidateGroup(t *testing.T) {
+	tests := []struct {
+		name   string
+		group  model.Group
+		fields []string
+	}{
+		{"valid", model.Group{Name: "Kitchen", Strips: model.IDs{1, 2}, Groups: model.IDs{3}}, nil},
+		{"no members", model.Group{Name: "Empty"}, nil},
+		{"no name", model.Group{Name: " "}, []string{"name"}},
+		{"invalid strip", model.Group{Name: "a", Strips: model.IDs{0, 1, 1}}, []string{"strips[0]", "strips[2]"}},
+		{"invalid group", model.Group{Name: "a", Groups: model.IDs{-1, 2, 2}}, []string{"groups[0]", "groups[2]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFields(t, ValidateGroup(tt.group), tt.fields)
+		})
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	valid := func(mod func(s *model.Schedule)) model.Schedule {
+		s := model.Schedule{Name: "Night", Time: "22:30", TargetType: model.TargetStrip, TargetID: 1, Action: model.ActionOff}
+		mod(&s)
+		return s
+	}
+	tests := []struct {
+		name     string
+		schedule model.Schedule
+		fields   []string
+	}{
+		{"valid time", valid(func(s *model.Schedule) {}), nil},
+		{"valid weekdays", valid(func(s *model.Schedule) { s.Weekdays = model.Weekdays{"mon", "sat"}; s.Timezone = "Europe/Berlin" }), nil},
+		{"valid cron", valid(func(s *model.Schedule) { s.Time = ""; s.Cron = "*/15 6-8 * * 1-5" }), nil},
+		{"valid profile", valid(func(s *model.Schedule) { s.Action = model.ActionSetProfile; s.ProfileID = null.IntFrom(2) }), nil},
+		{"valid scene", valid(func(s *model.Schedule) { s.TargetType = model.TargetScene; s.Action = model.ActionOn }), nil},
+		{"valid group", valid(func(s *model.Schedule) { s.TargetType = model.TargetGroup }), nil},
+		{"valid solar", valid(func(s *model.Schedule) { s.Time = ""; s.Solar = model.Sunset; s.Offset = -30 }), nil},
+		{"valid solar weekdays", valid(func(s *model.Schedule) { s.Time = ""; s.Solar = model.CivilDawn; s.Weekdays = model.Weekdays{"sun"} }), nil},
+		{"no name", valid(func(s *model.Schedule) { s.Name = "" }), []string{"name"}},
+		{"no time", valid(func(s *model.Schedule) { s.Time = "" }), []string{"time"}},
+		{"invalid time", valid(func(s *model.Schedule) { s.Time = "24:00" }), []string{"time"}},
+		{"cron and time", valid(func(s *model.Schedule) { s.Cron = "0 1 * * *" }), []string{"cron"}},
+		{"invalid cron", valid(func(s *model.Schedule) { s.Time = ""; s.Cron = "0 25 * * *" }), []string{"cron"}},
+		{"cron timezone", valid(func(s *model.Schedule) { s.Time = ""; s.Cron = "CRON_TZ=UTC 0 1 * * *" }), []string{"cron"}},
+		{"cron and solar", valid(func(s *model.Schedule) { s.Time = ""; s.Cron = "0 1 * * *"; s.Solar = model.Sunrise }), []string{"cron"}},
+		{"time and solar", valid(func(s *model.Schedule) { s.Solar = model.Sunrise }), []string{"solar"}},
+		{"invalid solar", valid(func(s *model.Schedule) { s.Time = ""; s.Solar = "noon"; s.Offset = 721 }), []string{"solar", "offset"}},
+		{"offset without solar", valid(func(s *model.Schedule) { s.Offset = 10 }), []string{"offset"}},
+		{"invalid weekdays", valid(func(s *model.Schedule) { s.Weekdays = model.Weekdays{"mon", "Monday", "mon"} }), []string{"weekdays[1]", "weekdays[2]"}},
+		{"invalid timezone", valid(func(s *model.Schedule) { s.Timezone = "Mars/Base" }), []string{"timezone"}},
+		{"invalid target", valid(func(s *model.Schedule) { s.TargetType = "room"; s.TargetID = 0 }), []string{"targetType", "targetId"}},
+		{"invalid action", valid(func(s *model.Schedule) { s.Action = "toggle" }), []string{"action"}},
+		{"missing profile", valid(func(s *model.Schedule) { s.Action = model.ActionSetProfile }), []string{"profileId"}},
+		{"unexpected profile", valid(func(s *model.Schedule) { s.ProfileID = null.IntFrom(2) }), []string{"profileId"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFields(t, ValidateSchedule(tt.schedule), tt.fields)
+		})
+	}
+}
+
+func TestValidateTimerRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    model.TimerRequest
+		fields []string
+	}{
+		{"valid", model.TimerRequest{Duration: "30m"}, nil},
+		{"valid profile", model.TimerRequest{Duration: "1h30m", Action: model.ActionSetProfile, ProfileID: null.IntFrom(2)}, nil},
+		{"valid max", model.TimerRequest{Duration: "24h", Action: model.ActionOn}, nil},
+		{"no duration", model.TimerRequest{}, []string{"duration"}},
+		{"invalid duration", model.TimerRequest{Duration: "30"}, []string{"duration"}},
+		{"negative duration", model.TimerRequest{Duration: "-5m"}, []string{"duration"}},
+		{"too long", model.TimerRequest{Duration: "24h1m"}, []string{"duration"}},
+		{"invalid action", model.TimerRequest{Duration: "5m", Action: "toggle"}, []string{"action"}},
+		{"missing profile", model.TimerRequest{Duration: "5m", Action: model.ActionSetProfile}, []string{"profileId"}},
+		{"unexpected profile", model.TimerRequest{Duration: "5m", ProfileID: null.IntFrom(2)}, []string{"profileId"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFields(t, ValidateTimerRequest(tt.req), tt.fields)
+		})
+	}
+}
+
+func assertFields(t *testing.T, err error, fields []string) {
+	if len(fields) == 0 {
+		assert.NoError(t, err)
+		return
+	}
+	var aerr *model.AppError
+	if !assert.ErrorAs(t, err, &aerr) {
+		return
+	}
+	assert.Equal(t, http.StatusUnprocessableEntity, aerr.Code)
+	var verr *model.ValidationError
+	if !assert.ErrorAs(t, err, &verr) {
+		return
+	}
+	actual := []string{}
+	for _, fe := range verr.Errors {
+		actual = append(actual, fe.Field)
+		assert.NotEmpty(t, fe.Message)
+	}
+	assert.Equal(t, fields, actual)
+}